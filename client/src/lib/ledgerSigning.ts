@@ -0,0 +1,177 @@
+import { openDB, DBSchema, IDBPDatabase } from 'idb';
+
+/**
+ * Signs the ledger events the user performs, on this device, so the ledger holds proof
+ * of who acted that the server cannot forge. The private key is generated here as a
+ * non-extractable WebCrypto key and never leaves the browser; only its public half is
+ * enrolled with the server (POST /api/signing-keys).
+ *
+ * The bytes signed must match ledger.CanonicalEventBytes on the server exactly, so the
+ * builders below mirror the server's ledger.*Event functions field for field, in the
+ * same order.
+ */
+
+export interface LedgerSignature {
+  eventId: string;
+  keyId: string;
+  value: string; // Base64 Ed25519 signature
+}
+
+interface DeviceKey {
+  id: 'ledger-signing-key';
+  keyId: string;
+  keyPair: CryptoKeyPair;
+}
+
+interface SigningKeyDB extends DBSchema {
+  keys: {
+    key: string;
+    value: DeviceKey;
+  };
+}
+
+let dbPromise: Promise<IDBPDatabase<SigningKeyDB>> | null = null;
+
+function getDb(): Promise<IDBPDatabase<SigningKeyDB>> {
+  if (!dbPromise) {
+    dbPromise = openDB<SigningKeyDB>('HandReceiptSigningKeys', 1, {
+      upgrade(db) {
+        db.createObjectStore('keys', { keyPath: 'id' });
+      },
+    });
+  }
+  return dbPromise;
+}
+
+function toBase64(bytes: ArrayBuffer): string {
+  return btoa(String.fromCharCode(...new Uint8Array(bytes)));
+}
+
+/**
+ * Returns this device's signing key, generating and enrolling one the first time.
+ */
+export async function getDeviceKey(): Promise<DeviceKey> {
+  const db = await getDb();
+  const existing = await db.get('keys', 'ledger-signing-key');
+  if (existing) {
+    return existing;
+  }
+
+  const keyPair = (await crypto.subtle.generateKey({ name: 'Ed25519' }, false, ['sign', 'verify'])) as CryptoKeyPair;
+  const publicKey = toBase64(await crypto.subtle.exportKey('raw', keyPair.publicKey));
+  const response = await fetch('/api/signing-keys', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ publicKey }),
+  });
+  if (!response.ok) {
+    throw new Error('Failed to enroll this device\'s signing key');
+  }
+  const enrolled: { keyId: string } = await response.json();
+
+  const key: DeviceKey = { id: 'ledger-signing-key', keyId: enrolled.keyId, keyPair };
+  await db.put('keys', key);
+  return key;
+}
+
+/**
+ * Returns a new UUIDv7, the form of ID the server requires for signed events. The server
+ * refuses IDs issued more than a few minutes before the request.
+ */
+export function newEventId(): string {
+  const bytes = crypto.getRandomValues(new Uint8Array(16));
+  let ms = Date.now();
+  for (let i = 5; i >= 0; i--) {
+    bytes[i] = ms % 256;
+    ms = Math.floor(ms / 256);
+  }
+  bytes[6] = (bytes[6] & 0x0f) | 0x70;
+  bytes[8] = (bytes[8] & 0x3f) | 0x80;
+  const hex = Array.from(bytes, (b) => b.toString(16).padStart(2, '0')).join('');
+  return `${hex.slice(0, 8)}-${hex.slice(8, 12)}-${hex.slice(12, 16)}-${hex.slice(16, 20)}-${hex.slice(20)}`;
+}
+
+// Canonical events. Property order is significant; optional properties are left out
+// when empty, as the server's JSON encoding does.
+
+type Details = Record<string, unknown>;
+
+interface CanonicalEvent {
+  v: 2;
+  eventId: string;
+  eventType: string;
+  actorUserId: number;
+  itemId: number | null;
+  serialNumber: string;
+  [details: string]: unknown;
+}
+
+function event(eventId: string, eventType: string, actorUserId: number, itemId: number | null, serialNumber: string, field: string, details: Details): CanonicalEvent {
+  return { v: 2, eventId, eventType, actorUserId, itemId, serialNumber, [field]: details };
+}
+
+function optional(details: Details, field: string, value: unknown): void {
+  if (value !== undefined && value !== null && value !== '') {
+    details[field] = value;
+  }
+}
+
+/** The event of creating an item. The server assigns the item's ID, so it is not signed. */
+export function itemCreationEvent(eventId: string, item: { name: string; serialNumber: string; description?: string | null; currentStatus: string; assignedToUserId?: number | null }, userId: number): CanonicalEvent {
+  const details: Details = { action: 'Created' };
+  optional(details, 'name', item.name);
+  optional(details, 'description', item.description);
+  details.stateRecorded = true;
+  optional(details, 'status', item.currentStatus);
+  optional(details, 'assignedToUserId', item.assignedToUserId);
+  return event(eventId, 'EquipmentEvent', userId, null, item.serialNumber, 'itemCreation', details);
+}
+
+/** The event of moving a transfer to status. The transfer's ID is not signed. */
+export function transferEvent(eventId: string, transfer: { propertyId: number; fromUserId: number; toUserId: number; status: string; notes?: string | null }, serialNumber: string, actorUserId: number): CanonicalEvent {
+  const details: Details = {
+    transferId: '',
+    status: transfer.status,
+    fromUserId: transfer.fromUserId,
+    toUserId: transfer.toUserId,
+  };
+  optional(details, 'notes', transfer.notes);
+  return event(eventId, 'TransferEvent', actorUserId, transfer.propertyId, serialNumber, 'transfer', details);
+}
+
+/** The event of changing an item's status. */
+export function statusChangeEvent(eventId: string, itemId: number, serialNumber: string, oldStatus: string, newStatus: string, userId: number): CanonicalEvent {
+  const details: Details = {};
+  optional(details, 'previousStatus', oldStatus);
+  details.newStatus = newStatus;
+  return event(eventId, 'StatusChangeEvent', userId, itemId, serialNumber, 'statusChange', details);
+}
+
+/** The event of checking an item. */
+export function verificationEvent(eventId: string, itemId: number, serialNumber: string, userId: number, verificationType: string): CanonicalEvent {
+  return event(eventId, 'VerificationEvent', userId, itemId, serialNumber, 'verification', { result: verificationType });
+}
+
+/** The event of correcting an earlier ledger event. */
+export function correctionEvent(eventId: string, originalEventId: string, originalEventType: string, reason: string, userId: number): CanonicalEvent {
+  return event(eventId, 'CorrectionEvent', userId, null, '', 'correction', { originalEventId, originalEventType, reason });
+}
+
+/**
+ * Encodes a canonical event as the server does. Go escapes U+2028 and U+2029 even with
+ * HTML escaping off; JSON.stringify does not.
+ */
+export function canonicalEventBytes(canonical: CanonicalEvent): Uint8Array {
+  const json = JSON.stringify(canonical).replace(/\u2028/g, '\\u2028').replace(/\u2029/g, '\\u2029');
+  return new TextEncoder().encode(json);
+}
+
+/**
+ * Signs an event with this device's key, for the ledgerSignature field of the request
+ * that performs it.
+ */
+export async function signLedgerEvent(canonical: CanonicalEvent): Promise<LedgerSignature> {
+  const key = await getDeviceKey();
+  const signature = await crypto.subtle.sign('Ed25519', key.keyPair.privateKey, canonicalEventBytes(canonical));
+  return { eventId: canonical.eventId, keyId: key.keyId, value: toBase64(signature) };
+}
@@ -127,22 +127,39 @@ const LedgerStatusIndicator: React.FC = () => {
 
 // --- Ledger History Explorer --- //
 
-// Define the expected structure for general ledger events
-// NOTE: This is a hypothetical structure. The backend endpoint /api/ledger/history needs to be implemented to return this.
-interface GeneralLedgerEvent {
-  eventId: string; // Assuming a unique ID for each event
-  eventType: string; // e.g., 'TRANSFER_REQUEST', 'TRANSFER_APPROVE', 'ITEM_VERIFY', 'STATUS_CHANGE'
+// Ledger event as returned by /api/ledger/history. The envelope is the same for every
+// ledger backend; exactly one of the detail objects, matching eventType, is present.
+interface LedgerEvent {
+  eventId: string;
+  eventType: 'EquipmentEvent' | 'TransferEvent' | 'StatusChangeEvent' | 'VerificationEvent' | 'MaintenanceEvent' | 'CorrectionEvent';
   timestamp: string; // ISO date string
-  userId?: number; // User initiating the event (if applicable)
+  actorUserId?: number; // User who performed or initiated the event
   itemId?: number; // Property/Item ID related to the event
-  details: Record<string, any>; // Flexible object for event-specific details (e.g., { fromUserId: 1, toUserId: 2 }, { newStatus: 'Operational' })
-  // Optional Azure Ledger metadata
-  ledgerTransactionId?: number;
-  ledgerSequenceNumber?: number;
+  serialNumber?: string;
+  ledger: {
+    backend: 'immudb' | 'azure_sql' | 'local';
+    transactionId?: number;
+    sequenceNumber?: number;
+    key?: string;
+    hash?: string;
+  };
+  itemCreation?: { action: string; name?: string; description?: string; notes?: string; stateRecorded: boolean; status?: string; assignedToUserId?: number };
+  transfer?: { transferId: string; status: string; fromUserId: number; toUserId: number; approvingUserId?: number; notes?: string };
+  statusChange?: { previousStatus?: string; newStatus: string; reason?: string };
+  verification?: { result: string; notes?: string };
+  maintenance?: { maintenanceRecordId: string; stage: string; maintenanceType?: string; description?: string; performingUserId?: number };
+  correction?: { originalEventId: string; originalEventType: string; reason: string };
+  // Ed25519 signature by the acting user, or by the server for system events
+  signature?: { algorithm: string; signer: 'user' | 'server'; userId?: number; keyId: string; publicKey: string; value: string };
+  signatureStatus?: 'verified' | 'invalid' | 'unknown_key' | 'unsigned';
 }
 
-// Define columns for the GeneralLedgerEvent table
-const columns: ColumnDef<GeneralLedgerEvent>[] = [
+// eventDetails returns the detail object of whichever type the event is
+const eventDetails = (event: LedgerEvent) =>
+  event.itemCreation ?? event.transfer ?? event.statusChange ?? event.verification ?? event.maintenance ?? event.correction;
+
+// Define columns for the ledger event table
+const columns: ColumnDef<LedgerEvent>[] = [
   {
     accessorKey: "timestamp",
     header: "Timestamp",
@@ -165,7 +182,7 @@ const columns: ColumnDef<GeneralLedgerEvent>[] = [
     cell: ({ row }) => <span className="font-mono text-xs">{row.getValue("eventId")}</span>,
   },
   {
-    accessorKey: "userId",
+    accessorKey: "actorUserId",
     header: "User ID",
   },
   {
@@ -173,18 +190,31 @@ const columns: ColumnDef<GeneralLedgerEvent>[] = [
     header: "Item ID",
   },
   {
-    accessorKey: "details",
+    accessorKey: "signatureStatus",
+    header: "Signature",
+    cell: ({ row }) => {
+      const { signature, signatureStatus } = row.original;
+      const signer = signature?.signer === 'user' ? `user ${signature.userId}` : signature?.signer;
+      return (
+        <span className={signatureStatus === 'verified' ? '' : 'text-destructive'} title={signature ? `Key ${signature.keyId}` : undefined}>
+          {signatureStatus ?? 'unsigned'}{signer ? ` (${signer})` : ''}
+        </span>
+      );
+    },
+  },
+  {
+    id: "details",
     header: "Details",
     cell: ({ row }) => (
       <pre className="text-xs bg-muted p-1 rounded overflow-x-auto">
-        {JSON.stringify(row.getValue("details"), null, 2)}
+        {JSON.stringify(eventDetails(row.original), null, 2)}
       </pre>
     ),
   },
 ];
 
 const LedgerHistoryExplorer: React.FC = () => {
-  const [data, setData] = useState<GeneralLedgerEvent[]>([]);
+  const [data, setData] = useState<LedgerEvent[]>([]);
   const [isLoading, setIsLoading] = useState<boolean>(true);
   const [error, setError] = useState<string | null>(null);
   const { authedFetch } = useAuth();
@@ -203,10 +233,10 @@ const LedgerHistoryExplorer: React.FC = () => {
       try {
         // ***** IMPORTANT *****
         // This endpoint `/api/ledger/history` needs to be implemented on the backend
-        // to return data matching the `GeneralLedgerEvent` interface.
+        // to return data matching the `LedgerEvent` interface.
         // Currently, this will likely fail or return unexpected data.
         // *********************
-        const { data: fetchedData } = await authedFetch<GeneralLedgerEvent[]>('/api/ledger/history');
+        const { data: fetchedData } = await authedFetch<LedgerEvent[]>('/api/ledger/history');
         setData(fetchedData || []);
       } catch (err: any) {
         console.error("Failed to fetch general ledger history:", err);
@@ -220,6 +250,17 @@ const LedgerHistoryExplorer: React.FC = () => {
   // eslint-disable-next-line react-hooks/exhaustive-deps
   }, []);
 
+  // Prepend events as they are committed. EventSource reconnects on its own and resumes
+  // from the last event it received.
+  useEffect(() => {
+    const source = new EventSource('/api/ledger/stream', { withCredentials: true });
+    source.addEventListener('ledger-event', (message) => {
+      const event: LedgerEvent = JSON.parse((message as MessageEvent).data);
+      setData((current) => current.some((e) => e.eventId === event.eventId) ? current : [event, ...current]);
+    });
+    return () => source.close();
+  }, []);
+
   const table = useReactTable({
     data,
     columns,
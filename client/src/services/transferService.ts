@@ -33,24 +33,34 @@ export async function createTransfer(newTransferData: Omit<Transfer, 'id' | 'dat
 }
 
 /**
- * Update the status of a transfer (approve/reject)
+ * Update the status of a transfer (approve/reject).
+ * Pass the version of the transfer the user was looking at; if someone else has
+ * changed it since, the server refuses the update with 409 Conflict.
  */
 export async function updateTransferStatus(params: { 
   id: string; 
   status: 'approved' | 'rejected'; 
   reason?: string;
+  version?: number;
 }): Promise<Transfer> {
-  const { id, status, reason } = params;
+  const { id, status, reason, version } = params;
   
   // TODO: Add authentication headers if required
+  const headers: Record<string, string> = {
+    'Content-Type': 'application/json',
+  };
+  if (version !== undefined) {
+    headers['If-Match'] = `"${version}"`;
+  }
   const response = await fetch(`/api/transfers/${id}/status`, {
     method: 'PATCH',
-    headers: {
-      'Content-Type': 'application/json',
-    },
+    headers,
     body: JSON.stringify({ status, reason }),
   });
   
+  if (response.status === 409) {
+    throw new Error('This transfer was changed by someone else. Reload it and try again.');
+  }
   if (!response.ok) {
     throw new Error(`Failed to ${status} transfer`);
   }
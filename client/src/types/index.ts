@@ -91,6 +91,7 @@ export interface Transfer {
   approvedDate?: string; // Optional: ISO 8601 date string for approval
   rejectedDate?: string; // Optional: ISO 8601 date string for rejection
   rejectionReason?: string; // Optional: Reason for rejection
+  version?: number; // Server version of the transfer; send it back when updating the status
 }
 
 // Activity Types
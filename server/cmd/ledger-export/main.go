@@ -0,0 +1,190 @@
+// Command ledger-export writes an auditor export of the ledger, or verifies one offline.
+//
+// Usage:
+//
+//	ledger-export [-config ./configs] [-from time] [-to time] [-unit name] -out dir
+//	ledger-export -verify dir [-pubkey server.pem]
+//
+// An export holds the matching events as events.jsonl and events.csv, a manifest.json
+// listing the SHA-256 hash of each file and the ledger digest at export time, and a
+// detached signature over the manifest in manifest.sig. -from and -to are RFC 3339
+// timestamps (from inclusive, to exclusive); -unit keeps the events involving the unit's
+// current members. The same export is served as a zip archive at /api/admin/ledger/export.
+//
+// The ledger is read from the primary backend in the configuration (ledger.backends, as
+// the worker selects it), and the manifest is signed with the receipt signing key
+// (receipts.signing_key_path). -unit also needs the database, to look up the unit's members.
+//
+// -verify checks the manifest signature and every file hash. The server's public key is
+// served at /api/ledger/receipt-key; without -pubkey the signature is checked against the
+// key embedded in the export, which proves integrity but not origin.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/toole-brendan/handreceipt-go/internal/config"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/export"
+	"github.com/toole-brendan/handreceipt-go/internal/repository"
+	"github.com/toole-brendan/handreceipt-go/internal/services/signing"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func main() {
+	configPath := flag.String("config", "./configs", "directory containing config.yaml")
+	from := flag.String("from", "", "RFC 3339 lower bound on the event time (inclusive)")
+	to := flag.String("to", "", "RFC 3339 upper bound on the event time (exclusive)")
+	unit := flag.String("unit", "", "only export events involving a current member of this unit")
+	out := flag.String("out", "", "directory to write the export to")
+	verifyDir := flag.String("verify", "", "verify the export in this directory instead of writing one")
+	serverKeyPath := flag.String("pubkey", "", "PEM file with the HandReceipt server's public key, for -verify")
+	flag.Parse()
+
+	if *verifyDir != "" {
+		verify(*verifyDir, *serverKeyPath)
+		return
+	}
+	if *out == "" || flag.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: ledger-export [-config dir] [-from time] [-to time] [-unit name] -out dir")
+		fmt.Fprintln(os.Stderr, "       ledger-export -verify dir [-pubkey file]")
+		os.Exit(2)
+	}
+
+	filter := export.Filter{Unit: *unit}
+	var err error
+	if filter.From, err = parseTime("from", *from); err != nil {
+		log.Fatal(err)
+	}
+	if filter.To, err = parseTime("to", *to); err != nil {
+		log.Fatal(err)
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ledgerService, err := openLedger(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to open ledger: %v", err)
+	}
+	defer ledgerService.Close()
+
+	signer, err := signing.LoadSigner(cfg.Receipts.SigningKeyPath)
+	if err != nil {
+		log.Fatalf("Failed to load receipt signing key: %v", err)
+	}
+
+	var units export.UnitDirectory
+	if filter.Unit != "" {
+		db, err := gorm.Open(postgres.Open(cfg.Database.GetDSN()), &gorm.Config{})
+		if err != nil {
+			log.Fatalf("Failed to connect to database: %v", err)
+		}
+		units = repository.NewPostgresRepository(db)
+	}
+
+	dst, err := export.NewDirDestination(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	manifest, err := export.NewExporter(ledgerService, units, signer).Export(ctx, filter, dst)
+	if err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+
+	fmt.Printf("Exported %d events to %s\n", manifest.EventCount, *out)
+	fmt.Printf("  ledger digest: %s at %d (%s)\n", manifest.LedgerDigest.Hash, manifest.LedgerDigest.Position, manifest.LedgerDigest.Backend)
+	fmt.Printf("  signed by key: %s\n", signer.KeyID())
+}
+
+// verify checks the export in dir and exits non-zero if it does not verify.
+func verify(dir, serverKeyPath string) {
+	var trusted []byte
+	if serverKeyPath != "" {
+		data, err := os.ReadFile(serverKeyPath)
+		if err != nil {
+			log.Fatalf("Failed to read server public key: %v", err)
+		}
+		if trusted, err = signing.ParsePublicKeyPEM(data); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	result, err := export.Verify(os.DirFS(dir), trusted)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Export is NOT valid: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Export is valid\n")
+	fmt.Printf("  events:        %d, exported %s\n", result.Manifest.EventCount, result.Manifest.ExportedAt.Format(time.RFC3339))
+	fmt.Printf("  ledger digest: %s at %d (%s)\n", result.Manifest.LedgerDigest.Hash, result.Manifest.LedgerDigest.Position, result.Manifest.LedgerDigest.Backend)
+	fmt.Printf("  signed by key: %s (trusted: %t)\n", result.ServerKeyID, result.ServerTrusted)
+	for _, note := range result.Notes {
+		fmt.Printf("  note: %s\n", note)
+	}
+}
+
+// parseTime parses an optional RFC 3339 flag value.
+func parseTime(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -%s: expected an RFC 3339 timestamp", name)
+	}
+	return &t, nil
+}
+
+// openLedger opens the primary ledger backend, selected the same way the worker does.
+func openLedger(ctx context.Context, cfg *config.Config) (ledger.LedgerService, error) {
+	name := ""
+	switch {
+	case len(cfg.Ledger.Backends) > 0:
+		name = cfg.Ledger.Backends[0]
+	case cfg.Server.IsProduction() || cfg.ImmuDB.Enabled:
+		name = "immudb"
+	case cfg.Ledger.AzureConnectionString != "":
+		name = "azure_sql"
+	default:
+		name = "local"
+	}
+
+	var (
+		service ledger.LedgerService
+		err     error
+	)
+	switch name {
+	case "immudb":
+		service, err = ledger.NewImmuDBLedgerService(cfg.ImmuDB.Host, cfg.ImmuDB.Port, cfg.ImmuDB.Username, cfg.ImmuDB.Password, cfg.ImmuDB.Database)
+	case "azure_sql":
+		// The export only reads, so the schema is verified rather than applied
+		service, err = ledger.NewAzureSqlLedgerService(cfg.Ledger.AzureConnectionString, ledger.AzureSchemaVerify)
+	case "local":
+		service, err = ledger.NewLocalLedgerService(cfg.Ledger.LocalPath)
+	default:
+		return nil, fmt.Errorf("unknown ledger backend %q: must be immudb, azure_sql or local", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := service.Initialize(ctx); err != nil {
+		service.Close()
+		return nil, err
+	}
+	return service, nil
+}
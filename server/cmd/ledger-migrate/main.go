@@ -0,0 +1,101 @@
+// Command ledger-migrate copies every event in the Azure SQL Ledger history tables to ImmuDB.
+//
+// Usage:
+//
+//	ledger-migrate [-config ./configs] [-azure connection-string] [-checkpoint file] [-batch n] [-compare-only]
+//
+// Events are read in commit order and written with their original event IDs and timestamps.
+// Progress is saved to the checkpoint file after every batch, so an interrupted run resumes
+// where it stopped; events ImmuDB already holds are skipped. The run ends by comparing the
+// event count and a content hash of both ledgers, and exits non-zero if they differ.
+//
+// ImmuDB connection settings are read from the configuration (immudb.*). The Azure SQL
+// connection string defaults to ledger.azure_connection_string, then to the
+// AZURE_SQL_LEDGER_CONNECTION_STRING environment variable.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/toole-brendan/handreceipt-go/internal/config"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/migrate"
+)
+
+func main() {
+	configPath := flag.String("config", "./configs", "directory containing config.yaml")
+	azureConn := flag.String("azure", "", "Azure SQL Ledger connection string")
+	checkpoint := flag.String("checkpoint", "data/ledger-migrate-checkpoint.json", "file recording migration progress")
+	batchSize := flag.Int("batch", migrate.DefaultBatchSize, "events read per batch")
+	compareOnly := flag.Bool("compare-only", false, "only compare the ledgers, without importing")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	connectionString := *azureConn
+	if connectionString == "" {
+		connectionString = cfg.Ledger.AzureConnectionString
+	}
+	if connectionString == "" {
+		connectionString = os.Getenv("AZURE_SQL_LEDGER_CONNECTION_STRING")
+	}
+	if connectionString == "" {
+		log.Fatal("No Azure SQL Ledger connection string: pass -azure or set ledger.azure_connection_string")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The source is only read, and may predate versioned schemas, so its schema is left alone
+	source, err := ledger.NewAzureSqlLedgerService(connectionString, ledger.AzureSchemaOff)
+	if err != nil {
+		log.Fatalf("Failed to connect to Azure SQL Ledger: %v", err)
+	}
+	defer source.Close()
+
+	target, err := ledger.NewImmuDBLedgerService(cfg.ImmuDB.Host, cfg.ImmuDB.Port, cfg.ImmuDB.Username, cfg.ImmuDB.Password, cfg.ImmuDB.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to ImmuDB: %v", err)
+	}
+	defer target.Close()
+	if err := target.Initialize(ctx); err != nil {
+		log.Fatalf("Failed to initialize ImmuDB ledger: %v", err)
+	}
+
+	migrator := migrate.NewMigrator(source, target, migrate.Options{BatchSize: *batchSize, Checkpoint: *checkpoint})
+
+	if !*compareOnly {
+		progress, err := migrator.Run(ctx)
+		if err != nil {
+			log.Fatalf("Migration stopped: %v (rerun to resume from %s)", err, *checkpoint)
+		}
+		fmt.Printf("Migration finished: %d events read, %d imported, %d already present\n", progress.Read, progress.Imported, progress.Skipped)
+	}
+
+	comparison, err := migrator.Compare(ctx)
+	if err != nil {
+		log.Fatalf("Comparison failed: %v", err)
+	}
+	fmt.Printf("  Azure SQL events: %d  hash %s\n", comparison.SourceCount, comparison.SourceHash)
+	fmt.Printf("  ImmuDB events:    %d  hash %s\n", comparison.TargetCount, comparison.TargetHash)
+	for _, id := range comparison.Missing {
+		fmt.Printf("  missing:  %s\n", id)
+	}
+	for _, id := range comparison.Modified {
+		fmt.Printf("  modified: %s\n", id)
+	}
+	if !comparison.Complete() {
+		fmt.Println("Ledgers do NOT match")
+		os.Exit(1)
+	}
+	fmt.Println("Ledgers match: every Azure SQL Ledger event is in ImmuDB unchanged")
+}
@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"github.com/toole-brendan/handreceipt-go/internal/api/routes"
 	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/digest"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/outbox"
 	"github.com/toole-brendan/handreceipt-go/internal/platform/database"
 	"github.com/toole-brendan/handreceipt-go/internal/repository"
+	"github.com/toole-brendan/handreceipt-go/internal/services/signing"
+	"github.com/toole-brendan/handreceipt-go/internal/services/storage"
 )
 
 func main() {
@@ -51,45 +58,62 @@ func main() {
 	// Initialize Repository
 	repo := repository.NewPostgresRepository(db)
 
+	// Load the keys ledger events are signed with
+	eventSigner, err := newEventSigner(repo)
+	if err != nil {
+		log.Fatalf("Failed to load ledger signing keys: %v", err)
+	}
+
 	// Initialize Ledger Service based on configuration
-	var ledgerService ledger.LedgerService
-
-	if environment == "production" || viper.GetBool("immudb.enabled") {
-		// Use ImmuDB for ledger service
-		immuHost := viper.GetString("immudb.host")
-		immuPort := viper.GetInt("immudb.port")
-		immuUsername := viper.GetString("immudb.username")
-		immuPassword := viper.GetString("immudb.password")
-		immuDatabase := viper.GetString("immudb.database")
-
-		var err error
-		ledgerService, err = ledger.NewImmuDBLedgerService(immuHost, immuPort, immuUsername, immuPassword, immuDatabase)
-		if err != nil {
-			log.Fatalf("Failed to initialize ImmuDB Ledger service: %v", err)
-		}
-		log.Println("Using ImmuDB Ledger")
-	} else {
-		// Fallback to Azure SQL for development/testing if needed
-		connectionString := os.Getenv("AZURE_SQL_LEDGER_CONNECTION_STRING")
-		if connectionString != "" {
-			var err error
-			ledgerService, err = ledger.NewAzureSqlLedgerService(connectionString)
-			if err != nil {
-				log.Fatalf("Failed to initialize Azure SQL Ledger service: %v", err)
-			}
-			log.Println("Using Azure SQL Ledger (fallback)")
-		} else {
-			log.Println("No ledger service configured - using mock/disabled mode")
-			// You might want to create a mock ledger service here
-		}
+	ledgerService, err := newLedgerService(environment, repo, eventSigner)
+	if err != nil {
+		log.Fatalf("Failed to initialize Ledger service: %v", err)
 	}
 
-	if err := ledgerService.Initialize(); err != nil {
+	if err := ledgerService.Initialize(context.Background()); err != nil {
 		log.Fatalf("Failed to initialize Ledger service: %v", err)
 	}
 	// Ensure Close is called on shutdown (using defer in main is tricky, consider signal handling)
 	// defer ledgerService.Close()
 
+	relayConfig := outbox.RelayConfig{
+		BatchSize:   viper.GetInt("ledger.outbox.batch_size"),
+		MaxAttempts: viper.GetInt("ledger.outbox.max_attempts"),
+		BaseBackoff: viper.GetDuration("ledger.outbox.base_backoff"),
+		MaxBackoff:  viper.GetDuration("ledger.outbox.max_backoff"),
+		Lease:       viper.GetDuration("ledger.outbox.lease"),
+	}
+	relayPollInterval := viper.GetDuration("ledger.outbox.poll_interval")
+	if relayPollInterval <= 0 {
+		relayPollInterval = 10 * time.Second
+	}
+	// Ledger streams re-read far enough back to send events the outbox delivers late
+	streamOptions := ledger.StreamOptions{LateLookback: relayConfig.MaxDeliveryDelay(relayPollInterval)}
+
+	// Drain the ledger outbox in-process when configured (required for the single-writer local ledger)
+	if viper.GetBool("ledger.outbox.run_in_server") {
+		relay := outbox.NewRelay(db, ledgerService, relayConfig, logrus.StandardLogger())
+		go outbox.Run(context.Background(), relay, relayPollInterval, logrus.StandardLogger())
+		log.Printf("Draining ledger outbox in-process every %s", relayPollInterval)
+	}
+
+	// Load the key ledger event receipts are signed with, creating one on first start
+	signingKeyPath := viper.GetString("receipts.signing_key_path")
+	if signingKeyPath == "" {
+		signingKeyPath = filepath.Join("data", "receipt_signing_key.pem")
+	}
+	receiptSigner, err := signing.LoadOrCreateSigner(signingKeyPath)
+	if err != nil {
+		log.Fatalf("Failed to load receipt signing key: %v", err)
+	}
+	log.Printf("Signing ledger receipts with key %s from %s", receiptSigner.KeyID(), signingKeyPath)
+
+	// Open the store of ledger digests captured by the worker, checked by /api/verification/database
+	digests, err := digestStore()
+	if err != nil {
+		log.Fatalf("Failed to open ledger digest store: %v", err)
+	}
+
 	// Create Gin router
 	router := gin.Default()
 
@@ -97,7 +121,7 @@ func main() {
 	router.Use(corsMiddleware())
 
 	// Setup routes, passing the LedgerService interface and Repository
-	routes.SetupRoutes(router, ledgerService, repo)
+	routes.SetupRoutes(router, ledgerService, repo, receiptSigner, eventSigner, digests, streamOptions)
 
 	// Get server port, prioritizing environment variable, then config, then default
 	var port int
@@ -171,7 +195,7 @@ func corsMiddleware() gin.HandlerFunc {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", c.Request.Header.Get("Origin"))
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, X-Next-Cursor")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		if c.Request.Method == "OPTIONS" {
@@ -182,3 +206,188 @@ func corsMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// newLedgerService opens the ledger backends listed in ledger.backends, wrapping them in a
+// MultiLedgerService when there is more than one. Without ledger.backends a single backend
+// is chosen: ImmuDB in production or when enabled, then Azure SQL when
+// AZURE_SQL_LEDGER_CONNECTION_STRING is set, then the local file ledger.
+func newLedgerService(environment string, recorder ledger.DivergenceRecorder, signer *ledger.EventSigner) (ledger.LedgerService, error) {
+	names := viper.GetStringSlice("ledger.backends")
+	if len(names) == 0 {
+		switch {
+		case environment == "production" || viper.GetBool("immudb.enabled"):
+			names = []string{"immudb"}
+		case os.Getenv("AZURE_SQL_LEDGER_CONNECTION_STRING") != "":
+			names = []string{"azure_sql"}
+		default:
+			names = []string{"local"}
+		}
+	}
+
+	// Bound every ledger call so a slow ledger cannot hold requests open indefinitely.
+	// Each backend gets its own deadlines, so a slow secondary cannot use up the primary's.
+	timeouts := ledgerTimeouts()
+	backends := make([]ledger.Backend, 0, len(names))
+	closeAll := func() {
+		for _, b := range backends {
+			b.Service.Close()
+		}
+	}
+	for _, name := range names {
+		service, err := openLedgerBackend(name)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("ledger backend %s: %w", name, err)
+		}
+		if backend, ok := service.(ledger.SigningBackend); ok {
+			backend.SetEventSigner(signer)
+		}
+		backends = append(backends, ledger.Backend{Name: name, Service: ledger.WithTimeouts(service, timeouts)})
+	}
+	if len(backends) == 1 {
+		return backends[0].Service, nil
+	}
+
+	policy, err := ledger.ParseWritePolicy(viper.GetString("ledger.write_policy"))
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	multi, err := ledger.NewMultiLedgerService(backends[0], backends[1:], policy, recorder)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	log.Printf("Writing to ledgers %v (primary %s, write policy %q)", names, names[0], policy)
+	return multi, nil
+}
+
+// newEventSigner loads the key system events are signed with, creating it if missing, and
+// enrolls it in keys; or returns nil when ledger.signatures.enabled is false. Users sign
+// their own events with keys they enroll. Keys the server once held for them, in
+// ledger.signatures.user_key_dir, are enrolled as revoked, so the events signed with them
+// still verify but no new ones can be.
+func newEventSigner(keys ledger.KeyDirectory) (*ledger.EventSigner, error) {
+	if viper.IsSet("ledger.signatures.enabled") && !viper.GetBool("ledger.signatures.enabled") {
+		log.Println("Ledger event signing is disabled")
+		return nil, nil
+	}
+
+	serverKeyPath := viper.GetString("ledger.signatures.server_key_path")
+	if serverKeyPath == "" {
+		serverKeyPath = filepath.Join("data", "ledger_server_key.pem")
+	}
+	userKeyDir := viper.GetString("ledger.signatures.user_key_dir")
+	if userKeyDir == "" {
+		userKeyDir = filepath.Join("data", "user-signing-keys")
+	}
+
+	serverKey, err := signing.LoadOrCreateSigner(serverKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	// The server key has signed system events since it was created, which is not recorded
+	if _, err := ledger.EnrollKey(keys, nil, serverKey.PublicKey(), time.Time{}); err != nil {
+		return nil, err
+	}
+	imported, err := ledger.ImportServerHeldKeys(keys, userKeyDir, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to import user signing keys from %s: %w", userKeyDir, err)
+	}
+	if imported > 0 {
+		log.Printf("WARNING: Imported %d user signing keys from %s as revoked; delete the directory, users must enroll their own keys", imported, userKeyDir)
+	}
+
+	signer := ledger.NewEventSigner(serverKey, keys)
+	if viper.GetBool("ledger.signatures.require_user_signatures") {
+		signer.RequireUserSignatures()
+	}
+	log.Printf("Signing system ledger events with server key %s; user signatures required: %t", serverKey.KeyID(), signer.UserSignaturesRequired())
+	return signer, nil
+}
+
+// openLedgerBackend connects to the ledger backend called name: "immudb", "azure_sql" or "local".
+func openLedgerBackend(name string) (ledger.LedgerService, error) {
+	switch name {
+	case "immudb":
+		log.Println("Using ImmuDB Ledger")
+		return ledger.NewImmuDBLedgerService(
+			viper.GetString("immudb.host"),
+			viper.GetInt("immudb.port"),
+			viper.GetString("immudb.username"),
+			viper.GetString("immudb.password"),
+			viper.GetString("immudb.database"),
+		)
+	case "azure_sql":
+		connectionString := os.Getenv("AZURE_SQL_LEDGER_CONNECTION_STRING")
+		if connectionString == "" {
+			return nil, fmt.Errorf("AZURE_SQL_LEDGER_CONNECTION_STRING is not set")
+		}
+		log.Println("Using Azure SQL Ledger")
+		return ledger.NewAzureSqlLedgerService(connectionString, ledger.AzureSchemaMode(viper.GetString("ledger.azure_schema")))
+	case "local":
+		// No external ledger configured: use the local hash-chained file ledger
+		localPath := viper.GetString("ledger.local_path")
+		if localPath == "" {
+			localPath = filepath.Join("data", "ledger.jsonl")
+		}
+		log.Printf("Using local hash-chained ledger at %s", localPath)
+		return ledger.NewLocalLedgerService(localPath)
+	default:
+		return nil, fmt.Errorf("unknown ledger backend %q: must be immudb, azure_sql or local", name)
+	}
+}
+
+// ledgerTimeouts reads per-operation ledger deadlines from configuration,
+// falling back to ledger.DefaultTimeouts for any that are not set.
+func ledgerTimeouts() ledger.Timeouts {
+	timeouts := ledger.DefaultTimeouts()
+	if viper.IsSet("ledger.timeouts.write") {
+		timeouts.Write = viper.GetDuration("ledger.timeouts.write")
+	}
+	if viper.IsSet("ledger.timeouts.read") {
+		timeouts.Read = viper.GetDuration("ledger.timeouts.read")
+	}
+	if viper.IsSet("ledger.timeouts.verify") {
+		timeouts.Verify = viper.GetDuration("ledger.timeouts.verify")
+	}
+	if viper.IsSet("ledger.timeouts.initialize") {
+		timeouts.Initialize = viper.GetDuration("ledger.timeouts.initialize")
+	}
+	return timeouts
+}
+
+// digestStore opens the ledger digest store named by ledger.digests.store ("local" or "minio"),
+// or returns nil when digest anchoring is disabled.
+func digestStore() (digest.Store, error) {
+	if viper.IsSet("ledger.digests.enabled") && !viper.GetBool("ledger.digests.enabled") {
+		return nil, nil
+	}
+
+	switch kind := viper.GetString("ledger.digests.store"); kind {
+	case "minio":
+		minioService, err := storage.NewMinIOService(
+			viper.GetString("minio.endpoint"),
+			viper.GetString("minio.access_key_id"),
+			viper.GetString("minio.secret_access_key"),
+			viper.GetString("minio.bucket_name"),
+			viper.GetBool("minio.use_ssl"),
+		)
+		if err != nil {
+			return nil, err
+		}
+		prefix := viper.GetString("ledger.digests.minio_prefix")
+		if prefix == "" {
+			prefix = "ledger-digests/"
+		}
+		return digest.NewMinIOStore(minioService, prefix), nil
+	case "", "local":
+		dir := viper.GetString("ledger.digests.local_dir")
+		if dir == "" {
+			dir = filepath.Join("data", "ledger-digests")
+		}
+		return digest.NewDirStore(dir)
+	default:
+		return nil, fmt.Errorf("unknown ledger digest store %q", kind)
+	}
+}
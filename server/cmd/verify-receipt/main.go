@@ -0,0 +1,109 @@
+// Command verify-receipt checks a HandReceipt ledger event receipt offline.
+//
+// Usage:
+//
+//	verify-receipt [-pubkey server.pem] [-immudb-pubkey immudb.pem] [receipt.json]
+//
+// The receipt is read from the named file, or from stdin when none is given. The server's
+// public key is served at /api/ledger/receipt-key. Without -pubkey the signature is checked
+// against the key embedded in the receipt, which proves integrity but not origin.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/receipt"
+	"github.com/toole-brendan/handreceipt-go/internal/services/signing"
+)
+
+func main() {
+	serverKeyPath := flag.String("pubkey", "", "PEM file with the HandReceipt server's receipt signing public key")
+	immudbKeyPath := flag.String("immudb-pubkey", "", "PEM file with the immudb server's state signing public key")
+	flag.Parse()
+
+	if flag.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "usage: verify-receipt [-pubkey file] [-immudb-pubkey file] [receipt.json]")
+		os.Exit(2)
+	}
+
+	opts, err := loadOptions(*serverKeyPath, *immudbKeyPath)
+	if err != nil {
+		fail(err)
+	}
+
+	rcpt, err := readReceipt(flag.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+
+	result, err := receipt.Verify(rcpt, opts)
+	if err != nil {
+		fail(fmt.Errorf("receipt is NOT valid: %w", err))
+	}
+
+	fmt.Printf("Receipt is valid\n")
+	fmt.Printf("  event:           %s (%s)\n", result.EventID, rcpt.EventType)
+	fmt.Printf("  backend:         %s\n", result.Backend)
+	fmt.Printf("  signed by key:   %s (trusted: %t)\n", result.ServerKeyID, result.ServerTrusted)
+	fmt.Printf("  ledger verified: %t\n", result.LedgerVerified)
+	for _, note := range result.Notes {
+		fmt.Printf("  note: %s\n", note)
+	}
+}
+
+// loadOptions reads the trusted keys named on the command line.
+func loadOptions(serverKeyPath, immudbKeyPath string) (receipt.VerifyOptions, error) {
+	var opts receipt.VerifyOptions
+
+	if serverKeyPath != "" {
+		data, err := os.ReadFile(serverKeyPath)
+		if err != nil {
+			return opts, fmt.Errorf("failed to read server public key: %w", err)
+		}
+		if opts.ServerKey, err = signing.ParsePublicKeyPEM(data); err != nil {
+			return opts, err
+		}
+	}
+
+	if immudbKeyPath != "" {
+		key, err := signer.ParsePublicKeyFile(immudbKeyPath)
+		if err != nil {
+			return opts, fmt.Errorf("failed to read immudb public key: %w", err)
+		}
+		opts.ImmuDBServerKey = key
+	}
+
+	return opts, nil
+}
+
+// readReceipt decodes a receipt from path, or from stdin when path is empty.
+func readReceipt(path string) (*receipt.Receipt, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt: %w", err)
+	}
+
+	var rcpt receipt.Receipt
+	if err := json.Unmarshal(data, &rcpt); err != nil {
+		return nil, fmt.Errorf("failed to parse receipt: %w", err)
+	}
+	return &rcpt, nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -11,8 +12,15 @@ import (
 	"github.com/robfig/cron/v3"
 	"github.com/sirupsen/logrus"
 	"github.com/toole-brendan/handreceipt-go/internal/config"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/digest"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/outbox"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/reconcile"
 	"github.com/toole-brendan/handreceipt-go/internal/repositories/immudb"
+	"github.com/toole-brendan/handreceipt-go/internal/repository"
 	"github.com/toole-brendan/handreceipt-go/internal/services/nsn"
+	"github.com/toole-brendan/handreceipt-go/internal/services/signing"
+	"github.com/toole-brendan/handreceipt-go/internal/services/storage"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -47,10 +55,38 @@ func main() {
 			auditRepo = immudb.NewAuditRepository(immuClient, &cfg.ImmuDB, logger)
 		}
 	}
+	archiveAudit := false
+	if auditRepo != nil && cfg.ImmuDB.Archive.Enabled {
+		minioService, err := storage.NewMinIOService(cfg.MinIO.Endpoint, cfg.MinIO.AccessKeyID, cfg.MinIO.SecretAccessKey, cfg.MinIO.BucketName, cfg.MinIO.UseSSL)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to initialize MinIO, audit log archiving will be disabled")
+		} else {
+			auditRepo.SetArchiveStorage(minioService, cfg.ImmuDB.Archive.MinIOPrefix)
+			archiveAudit = true
+		}
+	}
 
 	// Initialize NSN service
 	nsnService := nsn.NewNSNService(&cfg.NSN, db, logger)
 
+	// Initialize Ledger service for draining the ledger outbox, unless the API server does it
+	var relay *outbox.Relay
+	if !cfg.Ledger.Outbox.RunInServer {
+		ledgerService, err := initLedger(cfg, repository.NewPostgresRepository(db), logger)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to initialize Ledger service")
+		}
+		defer ledgerService.Close()
+
+		relay = outbox.NewRelay(db, ledgerService, outbox.RelayConfig{
+			BatchSize:   cfg.Ledger.Outbox.BatchSize,
+			MaxAttempts: cfg.Ledger.Outbox.MaxAttempts,
+			BaseBackoff: cfg.Ledger.Outbox.BaseBackoff,
+			MaxBackoff:  cfg.Ledger.Outbox.MaxBackoff,
+			Lease:       cfg.Ledger.Outbox.Lease,
+		}, logger)
+	}
+
 	// Create cron scheduler
 	c := cron.New(cron.WithLogger(cron.VerbosePrintfLogger(logger)))
 
@@ -70,15 +106,15 @@ func main() {
 		logger.WithError(err).Error("Failed to schedule NSN data refresh")
 	}
 
-	// Schedule audit log compression - weekly on Sunday at 3 AM
-	if auditRepo != nil {
+	// Schedule audit log archiving - weekly on Sunday at 3 AM
+	if archiveAudit {
 		_, err = c.AddFunc("0 3 * * 0", func() {
 			logger.Info("Starting scheduled audit log compression")
 			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Hour)
 			defer cancel()
 
-			// Compress logs older than 90 days
-			olderThan := time.Now().AddDate(0, 0, -90)
+			// Archive logs older than the configured age
+			olderThan := time.Now().AddDate(0, 0, -cfg.ImmuDB.Archive.AfterDays)
 			if err := auditRepo.CompressOldLogs(ctx, olderThan); err != nil {
 				logger.WithError(err).Error("Audit log compression failed")
 			} else {
@@ -116,6 +152,57 @@ func main() {
 		logger.WithError(err).Error("Failed to schedule cache cleanup")
 	}
 
+	// Schedule ledger outbox relay - every poll interval, skipped while a previous run is still draining
+	if relay != nil {
+		relayJob := cron.NewChain(cron.SkipIfStillRunning(cron.VerbosePrintfLogger(logger))).Then(cron.FuncJob(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+
+			outbox.Drain(ctx, relay, logger)
+		}))
+		_, err = c.AddJob(fmt.Sprintf("@every %s", cfg.Ledger.Outbox.PollInterval), relayJob)
+		if err != nil {
+			logger.WithError(err).Error("Failed to schedule ledger outbox relay")
+		}
+	}
+
+	// Schedule ledger reconciliation - daily at 4 AM by default
+	if cfg.Ledger.Reconciliation.Enabled {
+		repo := repository.NewPostgresRepository(db)
+		_, err = c.AddFunc(cfg.Ledger.Reconciliation.Schedule, func() {
+			logger.Info("Starting scheduled ledger reconciliation")
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.Ledger.Reconciliation.Timeout)
+			defer cancel()
+
+			if err := reconcileLedger(ctx, cfg, repo, logger); err != nil {
+				logger.WithError(err).Error("Ledger reconciliation failed")
+			}
+		})
+		if err != nil {
+			logger.WithError(err).Error("Failed to schedule ledger reconciliation")
+		}
+	}
+
+	// Schedule ledger digest capture - hourly by default
+	if cfg.Ledger.Digests.Enabled {
+		digests, err := initDigestStore(cfg)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to open ledger digest store")
+		}
+
+		_, err = c.AddFunc(cfg.Ledger.Digests.Schedule, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.Ledger.Digests.Timeout)
+			defer cancel()
+
+			if err := captureLedgerDigest(ctx, cfg, digests, logger); err != nil {
+				logger.WithError(err).Error("Ledger digest capture failed")
+			}
+		})
+		if err != nil {
+			logger.WithError(err).Error("Failed to schedule ledger digest capture")
+		}
+	}
+
 	// Schedule health checks - every 5 minutes
 	_, err = c.AddFunc("*/5 * * * *", func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -194,6 +281,166 @@ func initImmuDB(cfg config.ImmuDBConfig, logger *logrus.Logger) (immuclient.Immu
 	return client, nil
 }
 
+// initLedger connects to the configured ledger backends. repo records divergences and
+// enrolls the server's signing key; it may be nil for short-lived connections.
+func initLedger(cfg *config.Config, repo repository.Repository, logger *logrus.Logger) (ledger.LedgerService, error) {
+	timeouts := ledger.Timeouts{
+		Write:      cfg.Ledger.Timeouts.Write,
+		Read:       cfg.Ledger.Timeouts.Read,
+		Verify:     cfg.Ledger.Timeouts.Verify,
+		Initialize: cfg.Ledger.Timeouts.Initialize,
+	}
+
+	var keys ledger.KeyDirectory
+	var recorder ledger.DivergenceRecorder
+	if repo != nil {
+		keys, recorder = repo, repo
+	}
+	signer, err := newEventSigner(cfg.Ledger.Signatures, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	names := cfg.Ledger.Backends
+	if len(names) == 0 {
+		// Select the backend the same way the API server does
+		switch {
+		case cfg.Server.IsProduction() || cfg.ImmuDB.Enabled:
+			names = []string{"immudb"}
+		case cfg.Ledger.AzureConnectionString != "":
+			names = []string{"azure_sql"}
+		default:
+			names = []string{"local"}
+		}
+	}
+
+	// Each backend gets its own deadlines, so a slow secondary cannot use up the primary's
+	backends := make([]ledger.Backend, 0, len(names))
+	closeAll := func() {
+		for _, b := range backends {
+			b.Service.Close()
+		}
+	}
+	for _, name := range names {
+		service, err := openLedgerBackend(cfg, name, logger)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("ledger backend %s: %w", name, err)
+		}
+		if backend, ok := service.(ledger.SigningBackend); ok {
+			backend.SetEventSigner(signer)
+		}
+		backends = append(backends, ledger.Backend{Name: name, Service: ledger.WithTimeouts(service, timeouts)})
+	}
+
+	ledgerService := backends[0].Service
+	if len(backends) > 1 {
+		policy, err := ledger.ParseWritePolicy(cfg.Ledger.WritePolicy)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		ledgerService, err = ledger.NewMultiLedgerService(backends[0], backends[1:], policy, recorder)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		logger.WithFields(logrus.Fields{"backends": names, "write_policy": policy}).Info("Writing to multiple ledgers")
+	}
+
+	if err := ledgerService.Initialize(context.Background()); err != nil {
+		ledgerService.Close()
+		return nil, err
+	}
+	return ledgerService, nil
+}
+
+// newEventSigner loads the key system events are signed with and, given keys, enrolls it;
+// or returns nil when signing is disabled. The worker only delivers the signatures users
+// made; it never signs for them.
+func newEventSigner(cfg config.SignaturesConfig, keys ledger.KeyDirectory) (*ledger.EventSigner, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	serverKey, err := signing.LoadOrCreateSigner(cfg.ServerKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ledger server signing key: %w", err)
+	}
+	if keys != nil {
+		if _, err := ledger.EnrollKey(keys, nil, serverKey.PublicKey(), time.Time{}); err != nil {
+			return nil, err
+		}
+	}
+	return ledger.NewEventSigner(serverKey, keys), nil
+}
+
+// openLedgerBackend connects to the ledger backend called name: "immudb", "azure_sql" or "local".
+func openLedgerBackend(cfg *config.Config, name string, logger *logrus.Logger) (ledger.LedgerService, error) {
+	switch name {
+	case "immudb":
+		logger.Info("Using ImmuDB Ledger")
+		return ledger.NewImmuDBLedgerService(cfg.ImmuDB.Host, cfg.ImmuDB.Port, cfg.ImmuDB.Username, cfg.ImmuDB.Password, cfg.ImmuDB.Database)
+	case "azure_sql":
+		logger.Info("Using Azure SQL Ledger")
+		return ledger.NewAzureSqlLedgerService(cfg.Ledger.AzureConnectionString, ledger.AzureSchemaMode(cfg.Ledger.AzureSchema))
+	case "local":
+		logger.WithField("path", cfg.Ledger.LocalPath).Info("Using local hash-chained ledger")
+		return ledger.NewLocalLedgerService(cfg.Ledger.LocalPath)
+	default:
+		return nil, fmt.Errorf("unknown ledger backend %q: must be immudb, azure_sql or local", name)
+	}
+}
+
+// reconcileLedger opens a fresh ledger connection for each run, so a local ledger written
+// by the API server is re-read from disk, and stores the drift report.
+func reconcileLedger(ctx context.Context, cfg *config.Config, repo repository.Repository, logger *logrus.Logger) error {
+	ledgerService, err := initLedger(cfg, nil, logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize Ledger service: %w", err)
+	}
+	defer ledgerService.Close()
+
+	_, err = reconcile.NewReconciler(repo, ledgerService, logger).Run(ctx)
+	return err
+}
+
+func initDigestStore(cfg *config.Config) (digest.Store, error) {
+	switch cfg.Ledger.Digests.Store {
+	case "minio":
+		minioService, err := storage.NewMinIOService(cfg.MinIO.Endpoint, cfg.MinIO.AccessKeyID, cfg.MinIO.SecretAccessKey, cfg.MinIO.BucketName, cfg.MinIO.UseSSL)
+		if err != nil {
+			return nil, err
+		}
+		return digest.NewMinIOStore(minioService, cfg.Ledger.Digests.MinIOPrefix), nil
+	case "", "local":
+		return digest.NewDirStore(cfg.Ledger.Digests.LocalDir)
+	default:
+		return nil, fmt.Errorf("unknown ledger digest store %q", cfg.Ledger.Digests.Store)
+	}
+}
+
+// captureLedgerDigest opens a fresh ledger connection, so a local ledger written by the API
+// server is re-read from disk, and stores a digest of it.
+func captureLedgerDigest(ctx context.Context, cfg *config.Config, digests digest.Store, logger *logrus.Logger) error {
+	ledgerService, err := initLedger(cfg, nil, logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize Ledger service: %w", err)
+	}
+	defer ledgerService.Close()
+
+	captured, err := digest.Capture(ctx, ledgerService, digests)
+	if err != nil {
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"backend":  captured.Backend,
+		"position": captured.Position,
+		"hash":     captured.Hash,
+	}).Info("Ledger digest captured")
+	return nil
+}
+
 func performDatabaseMaintenance(ctx context.Context, db *gorm.DB, logger *logrus.Logger) error {
 	// Analyze tables for better query performance
 	tables := []string{"users", "equipment", "hand_receipts", "maintenance_records", "audit_logs", "nsn_data"}
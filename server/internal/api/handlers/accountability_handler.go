@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/accountability"
+)
+
+// AccountabilityHandler serves point-in-time hand receipts reconstructed from the ledger
+type AccountabilityHandler struct {
+	Service *accountability.Service
+}
+
+// NewAccountabilityHandler creates a new accountability handler
+func NewAccountabilityHandler(service *accountability.Service) *AccountabilityHandler {
+	return &AccountabilityHandler{Service: service}
+}
+
+// GetUserAccountability godoc
+// @Summary Reconstruct a user's hand receipt at a point in time
+// @Description Replays the ledger, with corrections applied, to list the items the user held and their status.
+// @Description Only the user, the users above them in their chain of command, and investigators may view it.
+// @Description at and since accept an RFC 3339 timestamp or a date (YYYY-MM-DD), which means the end of that day in UTC.
+// @Tags Ledger
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param at query string false "Point in time (default now)"
+// @Param since query string false "Earlier point in time to report changes from"
+// @Success 200 {object} accountability.Report
+// @Failure 400 {object} map[string]string "error: Invalid parameters"
+// @Failure 403 {object} map[string]string "error: Not permitted"
+// @Failure 500 {object} map[string]string "error: Failed to reconstruct accountability"
+// @Router /ledger/accountability/users/{userId} [get]
+// @Security BearerAuth
+func (h *AccountabilityHandler) GetUserAccountability(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+	viewerID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	at, since, ok := parseAccountabilityTimes(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.Service.ForUser(c.Request.Context(), uint64(viewerID), userID, at, since)
+	h.respond(c, report, err)
+}
+
+// GetUnitAccountability godoc
+// @Summary Reconstruct a unit's hand receipts at a point in time
+// @Description Lists the items held by the unit's current members at the given time, replayed from the ledger.
+// @Description Investigators, and users in the chain of command of every member, may view it.
+// @Tags Ledger
+// @Produce json
+// @Param unit path string true "Unit name"
+// @Param at query string false "Point in time (default now)"
+// @Param since query string false "Earlier point in time to report changes from"
+// @Success 200 {object} accountability.Report
+// @Failure 400 {object} map[string]string "error: Invalid parameters"
+// @Failure 403 {object} map[string]string "error: Not permitted"
+// @Failure 404 {object} map[string]string "error: Unit not found"
+// @Failure 500 {object} map[string]string "error: Failed to reconstruct accountability"
+// @Router /ledger/accountability/units/{unit} [get]
+// @Security BearerAuth
+func (h *AccountabilityHandler) GetUnitAccountability(c *gin.Context) {
+	viewerID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	at, since, ok := parseAccountabilityTimes(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.Service.ForUnit(c.Request.Context(), uint64(viewerID), c.Param("unit"), at, since)
+	h.respond(c, report, err)
+}
+
+// GetItemAccountability godoc
+// @Summary Reconstruct an item's custody and status at a point in time
+// @Description Replays the item's ledger history, with corrections applied.
+// @Description The viewer must be permitted to view the accountability of everyone who held the item over the period.
+// @Description Only investigators may view an item whose holder is not known, or learn that an item does not exist.
+// @Tags Ledger
+// @Produce json
+// @Param itemId path int true "Item ID"
+// @Param at query string false "Point in time (default now)"
+// @Param since query string false "Earlier point in time to report changes from"
+// @Success 200 {object} accountability.Report
+// @Failure 400 {object} map[string]string "error: Invalid parameters"
+// @Failure 403 {object} map[string]string "error: Not permitted"
+// @Failure 404 {object} map[string]string "error: Item not found in ledger"
+// @Failure 500 {object} map[string]string "error: Failed to reconstruct accountability"
+// @Router /ledger/accountability/items/{itemId} [get]
+// @Security BearerAuth
+func (h *AccountabilityHandler) GetItemAccountability(c *gin.Context) {
+	itemID, err := strconv.ParseUint(c.Param("itemId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID format"})
+		return
+	}
+	viewerID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	at, since, ok := parseAccountabilityTimes(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.Service.ForItem(c.Request.Context(), uint64(viewerID), itemID, at, since)
+	h.respond(c, report, err)
+}
+
+// respond writes a report, or the response for the error that prevented it.
+func (h *AccountabilityHandler) respond(c *gin.Context, report *accountability.Report, err error) {
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, report)
+	case errors.Is(err, accountability.ErrInvalidRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, accountability.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, accountability.ErrItemNotFound), errors.Is(err, accountability.ErrUnitNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Error reconstructing accountability: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconstruct accountability"})
+	}
+}
+
+// parseAccountabilityTimes reads the at (default now) and since query parameters. It writes
+// a 400 response and returns false if either is malformed.
+func parseAccountabilityTimes(c *gin.Context) (time.Time, *time.Time, bool) {
+	at := time.Now().UTC()
+	if v := c.Query("at"); v != "" {
+		t, err := parsePointInTime(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid at: %v", err)})
+			return at, nil, false
+		}
+		at = t
+	}
+
+	var since *time.Time
+	if v := c.Query("since"); v != "" {
+		t, err := parsePointInTime(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid since: %v", err)})
+			return at, nil, false
+		}
+		since = &t
+	}
+	return at, since, true
+}
+
+// parsePointInTime parses an RFC 3339 timestamp, or a date meaning the end of that day in UTC.
+func parsePointInTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 timestamp or a YYYY-MM-DD date")
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
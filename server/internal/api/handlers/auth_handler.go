@@ -104,6 +104,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Password: string(hashedPassword),
 		Name:     createUserInput.Name,
 		Rank:     createUserInput.Rank,
+		Unit:     createUserInput.Unit,
 	}
 
 	if err := h.repo.CreateUser(user); err != nil {
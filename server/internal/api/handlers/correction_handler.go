@@ -1,15 +1,18 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin" // Assuming user ID comes from context
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
 	"github.com/toole-brendan/handreceipt-go/internal/ledger"
 )
 
 // CorrectionHandler handles correction-related API requests
 type CorrectionHandler struct {
-	Ledger ledger.LedgerService
+	Ledger      ledger.LedgerService
+	EventSigner *ledger.EventSigner // Verifies users' ledger event signatures; may be nil
 }
 
 // CorrectionInput defines the expected JSON input for creating a correction
@@ -17,11 +20,13 @@ type CorrectionInput struct {
 	OriginalEventID   string `json:"originalEventId" binding:"required"`   // UUID string of the event to correct
 	OriginalEventType string `json:"originalEventType" binding:"required"` // Type hint (e.g., "TransferEvent")
 	Reason            string `json:"reason" binding:"required"`            // Explanation for the correction
+
+	LedgerSignature *domain.LedgerSignatureInput `json:"ledgerSignature"` // The correcting user's signature, if they signed
 }
 
 // NewCorrectionHandler creates a new CorrectionHandler
-func NewCorrectionHandler(ledgerService ledger.LedgerService) *CorrectionHandler {
-	return &CorrectionHandler{Ledger: ledgerService}
+func NewCorrectionHandler(ledgerService ledger.LedgerService, eventSigner *ledger.EventSigner) *CorrectionHandler {
+	return &CorrectionHandler{Ledger: ledgerService, EventSigner: eventSigner}
 }
 
 // CreateCorrection godoc
@@ -31,9 +36,10 @@ func NewCorrectionHandler(ledgerService ledger.LedgerService) *CorrectionHandler
 // @Accept json
 // @Produce json
 // @Param correction body CorrectionInput true "Correction Details"
-// @Success 201 {object} map[string]string "message: Correction logged successfully"
-// @Failure 400 {object} map[string]string "error: Invalid input data"
+// @Success 201 {object} map[string]interface{} "message, ledgerEventId and ledger location of the correction"
+// @Failure 400 {object} map[string]string "error: Invalid input data or ledger signature, or originalEventType does not match the original event"
 // @Failure 401 {object} map[string]string "error: Unauthorized"
+// @Failure 404 {object} map[string]string "error: Original event not found"
 // @Failure 500 {object} map[string]string "error: Failed to log correction"
 // @Router /corrections [post]
 // @Security BearerAuth
@@ -56,13 +62,29 @@ func (h *CorrectionHandler) CreateCorrection(c *gin.Context) {
 		return
 	}
 
-	err := h.Ledger.LogCorrectionEvent(input.OriginalEventID, input.OriginalEventType, input.Reason, userID)
+	opts, err := userSignedWrite(h.EventSigner, input.LedgerSignature, func(eventID string) domain.LedgerEvent {
+		return ledger.CorrectionEvent(eventID, input.OriginalEventID, input.OriginalEventType, input.Reason, userID)
+	})
+	if err != nil {
+		respondLedgerSignatureError(c, err)
+		return
+	}
+
+	result, err := h.Ledger.LogCorrectionEvent(c.Request.Context(), input.OriginalEventID, input.OriginalEventType, input.Reason, userID, opts)
+	if errors.Is(err, ledger.ErrEventNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Original event not found: " + input.OriginalEventID})
+		return
+	}
+	if errors.Is(err, ledger.ErrInvalidCorrection) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log correction: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Correction logged successfully"})
+	c.JSON(http.StatusCreated, gin.H{"message": "Correction logged successfully", "ledgerEventId": result.EventID, "ledger": result.Ledger})
 }
 
 // GetAllCorrections godoc
@@ -75,7 +97,7 @@ func (h *CorrectionHandler) CreateCorrection(c *gin.Context) {
 // @Router /corrections [get]
 // @Security BearerAuth
 func (h *CorrectionHandler) GetAllCorrections(c *gin.Context) {
-	events, err := h.Ledger.GetAllCorrectionEvents()
+	events, err := h.Ledger.GetAllCorrectionEvents(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve correction events: " + err.Error()})
 		return
@@ -103,7 +125,7 @@ func (h *CorrectionHandler) GetCorrectionEventByID(c *gin.Context) {
 		return
 	}
 
-	event, err := h.Ledger.GetCorrectionEventByID(eventID)
+	event, err := h.Ledger.GetCorrectionEventByID(c.Request.Context(), eventID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve correction event: " + err.Error()})
 		return
@@ -134,7 +156,7 @@ func (h *CorrectionHandler) GetCorrectionsByOriginalID(c *gin.Context) {
 		return
 	}
 
-	events, err := h.Ledger.GetCorrectionEventsByOriginalID(originalEventID)
+	events, err := h.Ledger.GetCorrectionEventsByOriginalID(c.Request.Context(), originalEventID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve correction events: " + err.Error()})
 		return
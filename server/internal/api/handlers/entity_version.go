@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+)
+
+// expectedVersion returns the version of a record that the client based its update on. It
+// comes from the If-Match header (3, "3" or W/"3", as sent in ETag) or else from the version
+// field of the request body. It is nil if the client sent neither, or sent If-Match: *.
+func expectedVersion(c *gin.Context, bodyVersion *uint) (*uint, error) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" {
+		return bodyVersion, nil
+	}
+	if ifMatch == "*" {
+		return nil, nil
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+	parsed, err := strconv.ParseUint(tag, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid If-Match header %q: expected a version such as \"3\"", ifMatch)
+	}
+	version := uint(parsed)
+	if bodyVersion != nil && *bodyVersion != version {
+		return nil, fmt.Errorf("If-Match version %d does not match body version %d", version, *bodyVersion)
+	}
+	return &version, nil
+}
+
+// setVersionETag sets the ETag header to a record's version, for the client to send back
+// in If-Match when it updates the record.
+func setVersionETag(c *gin.Context, version uint) {
+	c.Header("ETag", strconv.Quote(strconv.FormatUint(uint64(version), 10)))
+}
+
+// respondPropertyConflict answers an update rejected with a *domain.VersionConflictError
+// with 409 Conflict and the item's current state, if it could be loaded, so the client can
+// reapply its change to it.
+func respondPropertyConflict(c *gin.Context, err error, current *domain.Property) {
+	body := gin.H{"error": err.Error()}
+	if current != nil {
+		setVersionETag(c, current.Version)
+		body["item"] = current
+	}
+	c.JSON(http.StatusConflict, body)
+}
+
+// respondTransferConflict is respondPropertyConflict for transfers.
+func respondTransferConflict(c *gin.Context, err error, current *domain.Transfer) {
+	body := gin.H{"error": err.Error()}
+	if current != nil {
+		setVersionETag(c, current.Version)
+		body["transfer"] = current
+	}
+	c.JSON(http.StatusConflict, body)
+}
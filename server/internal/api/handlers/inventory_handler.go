@@ -5,49 +5,115 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"log"
 
 	"github.com/gin-gonic/gin"
 	"github.com/toole-brendan/handreceipt-go/internal/domain"
 	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/outbox"
 	"github.com/toole-brendan/handreceipt-go/internal/repository"
 	"gorm.io/gorm"
 )
 
 // InventoryHandler handles inventory operations
 type InventoryHandler struct {
-	Ledger ledger.LedgerService
-	Repo   repository.Repository
+	Ledger      ledger.LedgerService
+	Repo        repository.Repository
+	EventSigner *ledger.EventSigner // Verifies ledger event signatures; may be nil
 }
 
 // NewInventoryHandler creates a new inventory handler
-func NewInventoryHandler(ledgerService ledger.LedgerService, repo repository.Repository) *InventoryHandler {
-	return &InventoryHandler{Ledger: ledgerService, Repo: repo}
+func NewInventoryHandler(ledgerService ledger.LedgerService, repo repository.Repository, eventSigner *ledger.EventSigner) *InventoryHandler {
+	return &InventoryHandler{Ledger: ledgerService, Repo: repo, EventSigner: eventSigner}
 }
 
-// GetAllInventoryItems returns all inventory items
+// GetAllInventoryItems returns one page of inventory items, filtered and sorted by the
+// query parameters (see parsePropertyQuery), with the total number of matching items.
 func (h *InventoryHandler) GetAllInventoryItems(c *gin.Context) {
-	// Check if filtering by assigned user ID
-	var userID *uint
-	userIDStr := c.Query("assignedToUserId")
-	if userIDStr != "" {
-		uID, err := strconv.ParseUint(userIDStr, 10, 32)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignedToUserId format"})
-			return
-		}
-		tempID := uint(uID)
-		userID = &tempID
+	query, err := parsePropertyQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	items, err := h.Repo.ListProperties(userID)
+	page, err := h.Repo.SearchProperties(query)
+	if errors.Is(err, domain.ErrInvalidPropertyQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inventory items"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"items": items})
+	c.JSON(http.StatusOK, page)
+}
+
+// parsePropertyQuery builds a domain.PropertyQuery from the request's query parameters:
+// status (repeated or comma-separated), propertyModelId, propertyTypeId, assignedToUserId,
+// unit, verificationOverdueDays (items not verified within that many days), q (free text),
+// sort, order, page and limit.
+func parsePropertyQuery(c *gin.Context) (domain.PropertyQuery, error) {
+	query := domain.PropertyQuery{
+		Unit:   c.Query("unit"),
+		Search: c.Query("q"),
+		Sort:   c.Query("sort"),
+		Order:  strings.ToLower(c.Query("order")),
+	}
+
+	for _, v := range c.QueryArray("status") {
+		for _, status := range strings.Split(v, ",") {
+			if strings.TrimSpace(status) == "" {
+				continue
+			}
+			canonical, err := domain.ParsePropertyStatus(status)
+			if err != nil {
+				return query, err
+			}
+			query.Statuses = append(query.Statuses, canonical)
+		}
+	}
+
+	for _, id := range []struct {
+		name string
+		dst  **uint
+	}{{"propertyModelId", &query.PropertyModelID}, {"propertyTypeId", &query.PropertyTypeID}, {"assignedToUserId", &query.AssignedToUserID}} {
+		if v := c.Query(id.name); v != "" {
+			n, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s format", id.name)
+			}
+			u := uint(n)
+			*id.dst = &u
+		}
+	}
+
+	if v := c.Query("verificationOverdueDays"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			return query, fmt.Errorf("invalid verificationOverdueDays")
+		}
+		cutoff := time.Now().UTC().AddDate(0, 0, -days)
+		query.VerifiedBefore = &cutoff
+	}
+
+	for _, n := range []struct {
+		name string
+		dst  *int
+	}{{"page", &query.Page}, {"limit", &query.Limit}} {
+		if v := c.Query(n.name); v != "" {
+			value, err := strconv.Atoi(v)
+			if err != nil || value < 1 {
+				return query, fmt.Errorf("invalid %s", n.name)
+			}
+			*n.dst = value
+		}
+	}
+
+	return query, nil
 }
 
 // GetInventoryItem returns a specific inventory item
@@ -69,7 +135,12 @@ func (h *InventoryHandler) GetInventoryItem(c *gin.Context) {
 		}
 		return
 	}
+	if item == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Inventory item not found"})
+		return
+	}
 
+	setVersionETag(c, item.Version)
 	c.JSON(http.StatusOK, gin.H{"item": item})
 }
 
@@ -95,33 +166,57 @@ func (h *InventoryHandler) CreateInventoryItem(c *gin.Context) {
 		return
 	}
 
+	// Validate the status before anything is persisted
+	status, err := domain.ParsePropertyStatus(input.CurrentStatus)
+	if err == nil {
+		err = domain.ValidateInitialPropertyStatus(status)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Prepare the inventory item for database insertion
 	item := &domain.Property{ // Changed to pointer
 		Name:             input.Name,
 		SerialNumber:     input.SerialNumber,
 		Description:      input.Description,
-		CurrentStatus:    input.CurrentStatus,
+		CurrentStatus:    status,
 		PropertyModelID:  input.PropertyModelID,
 		AssignedToUserID: input.AssignedToUserID,
 	}
 
-	// Insert into database using repository
-	if err := h.Repo.CreateProperty(item); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create inventory item: " + err.Error()})
+	opts, err := userSignedWrite(h.EventSigner, input.LedgerSignature, func(eventID string) domain.LedgerEvent {
+		return ledger.ItemCreationEvent(eventID, *item, userID)
+	})
+	if err != nil {
+		respondLedgerSignatureError(c, err)
 		return
 	}
 
-	// Log to Ledger Service (use the item *after* creation to ensure ID is populated)
-	errLedger := h.Ledger.LogItemCreation(*item, userID)
-	if errLedger != nil {
-		// Log the error but don't fail the primary operation
-		log.Printf("WARNING: Failed to log item creation (ID: %d, SN: %s) to Ledger: %v", item.ID, item.SerialNumber, errLedger)
+	// Insert into database together with its ledger outbox entry; the worker delivers it to the ledger.
+	var ledgerEventID string
+	err = h.Repo.WithTx(func(tx repository.Repository) error {
+		if err := tx.CreateProperty(item); err != nil {
+			return err
+		}
+		ledgerEventID, err = enqueueLedgerEvent(tx, func() (*domain.LedgerOutboxEntry, error) {
+			return outbox.NewItemCreationEntry(*item, userID, opts)
+		})
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create inventory item: " + err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusCreated, item)
+	c.JSON(http.StatusCreated, propertyResponse{Property: item, LedgerEventID: ledgerEventID})
 }
 
-// UpdateInventoryItemStatus updates the status of an inventory item
+// UpdateInventoryItemStatus updates the status of an inventory item. The new status must be
+// one the item's current status may change to (see domain.ValidatePropertyStatusTransition).
+// Clients should send the item version they last saw in If-Match or the version field; if
+// the item has changed since, the update is refused with 409 and the current item.
 func (h *InventoryHandler) UpdateInventoryItemStatus(c *gin.Context) {
 	// Parse ID from URL parameter
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -132,55 +227,98 @@ func (h *InventoryHandler) UpdateInventoryItemStatus(c *gin.Context) {
 
 	// Parse status from request body
 	var updateData struct {
-		Status string `json:"status" binding:"required"`
+		Status          string                       `json:"status" binding:"required"`
+		Version         *uint                        `json:"version"`
+		LedgerSignature *domain.LedgerSignatureInput `json:"ledgerSignature"`
 	}
 
 	if err := c.ShouldBindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input format"})
 		return
 	}
-
-	// Fetch item from repository
-	item, err := h.Repo.GetPropertyByID(uint(id))
+	expected, err := expectedVersion(c, updateData.Version)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Inventory item not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inventory item"})
-		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Store old status for logging
-	oldStatus := item.CurrentStatus
-
-	// Update status
-	item.CurrentStatus = updateData.Status
-	if err := h.Repo.UpdateProperty(item); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update inventory item status"})
+	newStatus, err := domain.ParsePropertyStatus(updateData.Status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get user ID from context
+	// Get user ID from context (recorded in the ledger as the user reporting the change)
 	userIDVal, exists := c.Get("userID")
-	if exists {
-		userID, ok := userIDVal.(uint)
-		if ok {
-			// Log to Ledger Service
-			errLedger := h.Ledger.LogStatusChange(item.ID, item.SerialNumber, oldStatus, updateData.Status, userID)
-			if errLedger != nil {
-				// Log error but don't fail the request
-				log.Printf("WARNING: Failed to log status change (ItemID: %d, SN: %s) to Ledger: %v", item.ID, item.SerialNumber, errLedger)
-				c.Writer.Write([]byte("\nWarning: Failed to log status update to immutable ledger")) // Optionally notify client
-			}
-		} else {
-			log.Printf("WARNING: Could not assert userID to uint for ledger logging in UpdateInventoryItemStatus")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	userID, ok := userIDVal.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format in context"})
+		return
+	}
+
+	// Lock the item so that the transition is checked against the status it is applied to
+	var (
+		item          *domain.Property
+		ledgerEventID string
+	)
+	err = h.Repo.WithTx(func(tx repository.Repository) error {
+		item, err = tx.GetPropertyByIDForUpdate(uint(id))
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			return errInventoryItemNotFound
+		}
+		if err := domain.CheckVersion("property", item.ID, item.Version, expected); err != nil {
+			return err
 		}
-	} else {
-		log.Printf("WARNING: UserID not found in context for ledger logging in UpdateInventoryItemStatus")
+		if err := domain.ValidatePropertyStatusTransition(item.CurrentStatus, newStatus); err != nil {
+			return err
+		}
+
+		oldStatus := item.CurrentStatus
+		opts, err := userSignedWrite(h.EventSigner, updateData.LedgerSignature, func(eventID string) domain.LedgerEvent {
+			return ledger.StatusChangeEvent(eventID, item.ID, item.SerialNumber, oldStatus, newStatus, userID)
+		})
+		if err != nil {
+			return err
+		}
+
+		// Update status together with its ledger outbox entry
+		item.CurrentStatus = newStatus
+		if err := tx.UpdateProperty(item); err != nil {
+			return err
+		}
+		ledgerEventID, err = enqueueLedgerEvent(tx, func() (*domain.LedgerOutboxEntry, error) {
+			return outbox.NewStatusChangeEntry(item.ID, item.SerialNumber, oldStatus, newStatus, userID, opts)
+		})
+		return err
+	})
+	if err != nil {
+		var conflict *domain.VersionConflictError
+		switch {
+		case errors.As(err, &conflict):
+			// Someone else updated the item after the client read it; the conflict is the
+			// answer even if the current item cannot be loaded
+			current, _ := h.Repo.GetPropertyByID(uint(id))
+			respondPropertyConflict(c, err, current)
+		case errors.Is(err, errInventoryItemNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Inventory item not found"})
+		case errors.Is(err, domain.ErrInvalidStatusTransition), errors.Is(err, ledger.ErrInvalidEventSignature):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			log.Printf("Error updating status of inventory item %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update inventory item status"})
+		}
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"item": item})
+	setVersionETag(c, item.Version)
+	c.JSON(http.StatusOK, gin.H{"item": item, "ledgerEventId": ledgerEventID})
 }
 
 // GetInventoryItemsByUser returns inventory items assigned to a specific user
@@ -203,7 +341,9 @@ func (h *InventoryHandler) GetInventoryItemsByUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"items": items})
 }
 
-// GetInventoryItemHistory returns the history of an inventory item from the Ledger
+// GetInventoryItemHistory returns the history of an inventory item from the Ledger, with
+// each event's corrections and signature status. The view query parameter selects "raw" (default) or
+// "effective" history, which leaves out events voided by a correction.
 func (h *InventoryHandler) GetInventoryItemHistory(c *gin.Context) {
 	// Parse serial number from URL parameter
 	serialNumber := c.Param("serialNumber")
@@ -211,6 +351,11 @@ func (h *InventoryHandler) GetInventoryItemHistory(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Serial number parameter is required"})
 		return
 	}
+	view, err := ledger.ParseHistoryView(c.Query("view"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Fetch the item by serial number to get its ID
 	item, err := h.Repo.GetPropertyBySerialNumber(serialNumber)
@@ -224,11 +369,17 @@ func (h *InventoryHandler) GetInventoryItemHistory(c *gin.Context) {
 	}
 
 	// Get item history from Ledger Service using ItemID
-	history, err := h.Ledger.GetItemHistory(item.ID)
+	history, err := h.Ledger.GetItemHistory(c.Request.Context(), item.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch item history from ledger: " + err.Error()})
 		return
 	}
+	history, err = ledger.ApplyCorrections(c.Request.Context(), h.Ledger, history, view)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch item corrections from ledger: " + err.Error()})
+		return
+	}
+	h.EventSigner.VerifySignatures(history)
 
 	c.JSON(http.StatusOK, gin.H{"history": history})
 }
@@ -246,6 +397,7 @@ func (h *InventoryHandler) VerifyInventoryItem(c *gin.Context) {
 	var verificationInput struct {
 		VerificationType string `json:"verificationType" binding:"required"`
 		// Add other relevant fields if needed, e.g., location, condition
+		LedgerSignature *domain.LedgerSignatureInput `json:"ledgerSignature"`
 	}
 
 	if err := c.ShouldBindJSON(&verificationInput); err != nil {
@@ -276,8 +428,16 @@ func (h *InventoryHandler) VerifyInventoryItem(c *gin.Context) {
 		return
 	}
 
+	opts, err := userSignedWrite(h.EventSigner, verificationInput.LedgerSignature, func(eventID string) domain.LedgerEvent {
+		return ledger.VerificationEvent(eventID, item.ID, item.SerialNumber, userID, verificationInput.VerificationType)
+	})
+	if err != nil {
+		respondLedgerSignatureError(c, err)
+		return
+	}
+
 	// Log verification event to Ledger Service
-	errLedger := h.Ledger.LogVerificationEvent(item.ID, item.SerialNumber, userID, verificationInput.VerificationType)
+	result, errLedger := h.Ledger.LogVerificationEvent(c.Request.Context(), item.ID, item.SerialNumber, userID, verificationInput.VerificationType, opts)
 	if errLedger != nil {
 		// Log error but don't necessarily fail the request, depending on requirements
 		log.Printf("WARNING: Failed to log verification event (ItemID: %d, SN: %s, Type: %s) to Ledger: %v", item.ID, item.SerialNumber, verificationInput.VerificationType, errLedger)
@@ -286,7 +446,7 @@ func (h *InventoryHandler) VerifyInventoryItem(c *gin.Context) {
 	}
 
 	log.Printf("Successfully logged verification event for ItemID: %d, SN: %s", item.ID, item.SerialNumber)
-	c.JSON(http.StatusOK, gin.H{"message": "Verification event logged successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Verification event logged successfully", "ledgerEventId": result.EventID, "ledger": result.Ledger})
 }
 
 // GetPropertyBySerialNumber godoc
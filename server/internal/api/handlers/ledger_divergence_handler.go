@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/repository"
+)
+
+// defaultDivergenceListLimit is the number of divergences returned when no limit is given
+const defaultDivergenceListLimit = 50
+
+// LedgerDivergenceHandler exposes writes that reached some ledger backends but not others
+type LedgerDivergenceHandler struct {
+	Repo repository.Repository
+}
+
+// NewLedgerDivergenceHandler creates a new ledger divergence handler
+func NewLedgerDivergenceHandler(repo repository.Repository) *LedgerDivergenceHandler {
+	return &LedgerDivergenceHandler{Repo: repo}
+}
+
+// ListDivergences godoc
+// @Summary List ledger divergences
+// @Description Returns the oldest ledger writes with the requested status that a backend missed while another accepted them, with the arguments needed to repeat them.
+// @Tags Admin
+// @Produce json
+// @Param status query string false "Divergence status to list: open or repaired (default open)"
+// @Param limit query int false "Maximum divergences to list (default 50)"
+// @Success 200 {object} map[string]interface{} "divergences"
+// @Failure 400 {object} map[string]string "error: Invalid query parameter"
+// @Failure 500 {object} map[string]string "error: Failed to read ledger divergences"
+// @Router /admin/ledger/divergences [get]
+// @Security BearerAuth
+func (h *LedgerDivergenceHandler) ListDivergences(c *gin.Context) {
+	status := c.DefaultQuery("status", domain.DivergenceStatusOpen)
+	if status != domain.DivergenceStatusOpen && status != domain.DivergenceStatusRepaired {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status: must be open or repaired"})
+		return
+	}
+
+	limit := defaultDivergenceListLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit: must be a positive integer"})
+			return
+		}
+		limit = parsed
+	}
+
+	divergences, err := h.Repo.ListLedgerDivergences(status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read ledger divergences: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"divergences": divergences})
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/repository"
+)
+
+// propertyResponse is a property together with the ID of the ledger event recording the
+// change just made to it. Clients can cite the ID in a correction.
+type propertyResponse struct {
+	*domain.Property
+	LedgerEventID string `json:"ledgerEventId"`
+}
+
+// transferResponse is a transfer together with the ID of the ledger event recording the
+// change just made to it.
+type transferResponse struct {
+	*domain.Transfer
+	LedgerEventID string `json:"ledgerEventId"`
+}
+
+// enqueueLedgerEvent writes the outbox entry built by build through tx and returns the ID of
+// the ledger event it will deliver. Call it inside WithTx after the state change it records,
+// so that generated IDs are populated and the entry commits or rolls back with the change.
+func enqueueLedgerEvent(tx repository.Repository, build func() (*domain.LedgerOutboxEntry, error)) (string, error) {
+	entry, err := build()
+	if err != nil {
+		return "", fmt.Errorf("failed to build ledger outbox entry: %w", err)
+	}
+	if err := tx.CreateLedgerOutboxEntry(entry); err != nil {
+		return "", err
+	}
+	return entry.EventID, nil
+}
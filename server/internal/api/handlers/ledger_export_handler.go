@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/export"
+)
+
+// maxExportRange caps the time range of an export served over HTTP. Longer exports are
+// written with the ledger-export command.
+const maxExportRange = 366 * 24 * time.Hour
+
+// LedgerExportHandler serves auditor exports of the ledger
+type LedgerExportHandler struct {
+	Exporter *export.Exporter
+}
+
+// NewLedgerExportHandler creates a new ledger export handler
+func NewLedgerExportHandler(exporter *export.Exporter) *LedgerExportHandler {
+	return &LedgerExportHandler{Exporter: exporter}
+}
+
+// ExportLedger godoc
+// @Summary Export the ledger for auditors
+// @Description Returns a zip archive of the matching events as JSON Lines and CSV, with a manifest of file hashes and the ledger digest, and a detached server signature over the manifest. Check it offline with ledger-export -verify. The time range is required and may span at most 366 days.
+// @Tags Ledger
+// @Produce application/zip
+// @Param from query string true "RFC 3339 lower bound on the event time (inclusive)"
+// @Param to query string true "RFC 3339 upper bound on the event time (exclusive)"
+// @Param unit query string false "Only events involving a current member of the unit"
+// @Success 200 {file} file "Export archive"
+// @Failure 400 {object} map[string]string "error: Invalid parameters"
+// @Failure 404 {object} map[string]string "error: Unit not found"
+// @Failure 500 {object} map[string]string "error: Failed to export ledger"
+// @Router /admin/ledger/export [get]
+// @Security BearerAuth
+func (h *LedgerExportHandler) ExportLedger(c *gin.Context) {
+	filter := export.Filter{Unit: c.Query("unit")}
+	for _, bound := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		v := c.Query(bound.name)
+		if v == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s is required", bound.name)})
+			return
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s: expected an RFC 3339 timestamp", bound.name)})
+			return
+		}
+		*bound.dst = &t
+	}
+	if filter.To.Sub(*filter.From) > maxExportRange {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("the export may span at most %d days", maxExportRange/(24*time.Hour))})
+		return
+	}
+
+	// Build the archive in a temporary file before responding, so a failure can still be
+	// reported as an error without holding the archive in memory
+	archive, err := os.CreateTemp("", "ledger-export-*.zip")
+	if err != nil {
+		log.Printf("Error creating ledger export file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export ledger"})
+		return
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	zw := zip.NewWriter(archive)
+	manifest, err := h.Exporter.Export(c.Request.Context(), filter, export.NewZipDestination(zw))
+	if err == nil {
+		err = zw.Close()
+	}
+	var size int64
+	if err == nil {
+		size, err = archive.Seek(0, io.SeekCurrent)
+	}
+	if err == nil {
+		_, err = archive.Seek(0, io.SeekStart)
+	}
+	switch {
+	case errors.Is(err, export.ErrInvalidFilter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, export.ErrUnitNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("Error exporting ledger: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export ledger"})
+		return
+	}
+
+	filename := fmt.Sprintf("ledger-export-%s.zip", manifest.ExportedAt.Format("20060102T150405Z"))
+	c.DataFromReader(http.StatusOK, size, "application/zip", archive, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", filename),
+	})
+}
@@ -1,41 +1,280 @@
 package handlers
 
 import (
-	"net/http"
-
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/toole-brendan/handreceipt-go/internal/domain"
 	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"github.com/toole-brendan/handreceipt-go/internal/services/signing"
 )
 
 // LedgerHandler holds dependencies for ledger-related handlers.
 type LedgerHandler struct {
 	LedgerService ledger.LedgerService
+	Signer        *signing.Signer      // Signs event receipts
+	EventSigner   *ledger.EventSigner  // Verifies event signatures; may be nil
+	StreamOptions ledger.StreamOptions // Tunes event streams; zero values select the defaults
 }
 
 // NewLedgerHandler creates a new LedgerHandler.
-func NewLedgerHandler(ledgerService ledger.LedgerService) *LedgerHandler {
-	return &LedgerHandler{LedgerService: ledgerService}
+func NewLedgerHandler(ledgerService ledger.LedgerService, signer *signing.Signer, eventSigner *ledger.EventSigner, streamOptions ledger.StreamOptions) *LedgerHandler {
+	return &LedgerHandler{LedgerService: ledgerService, Signer: signer, EventSigner: eventSigner, StreamOptions: streamOptions}
 }
 
 // GetLedgerHistoryHandler handles requests to retrieve the general ledger history.
+// Optional query parameters:
+//   - from, to: RFC 3339 timestamps bounding the event time (from inclusive, to exclusive)
+//   - eventType: record type to include, repeatable or comma-separated (e.g. TransferEvent)
+//   - userId, itemId, transferId: numeric IDs
+//   - serialNumber: item serial number
+//   - limit: page size (default 100, max 1000)
+//   - order: "desc" (default) or "asc"
+//   - cursor: value of the X-Next-Cursor header from the previous page
+//   - view: "raw" (default) or "effective", which leaves out corrections and the events they void
+//
+// Each event lists the corrections logged against it and reports in signatureStatus whether
+// its signature verifies ("verified", "invalid", "unknown_key" or "unsigned"). The response body is the array of
+// events; X-Next-Cursor is set when more events follow. An effective page may hold fewer
+// events than the limit even when more follow.
 func (h *LedgerHandler) GetLedgerHistoryHandler(c *gin.Context) {
 	log.Println("Handler: GetLedgerHistoryHandler invoked")
-	history, err := h.LedgerService.GetGeneralHistory()
+
+	query, err := parseHistoryQuery(c)
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	view, err := ledger.ParseHistoryView(c.Query("view"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.LedgerService.GetGeneralHistory(c.Request.Context(), query)
+	if err != nil {
+		if errors.Is(err, ledger.ErrInvalidHistoryQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		log.Printf("Error getting general history from service: %v", err)
-		// Consider mapping specific service errors to HTTP statuses if needed
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve ledger history"})
 		return
 	}
 
+	history, err := ledger.ApplyCorrections(c.Request.Context(), h.LedgerService, page.Events, view)
+	if err != nil {
+		log.Printf("Error reading corrections for general history: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve ledger corrections"})
+		return
+	}
+	h.EventSigner.VerifySignatures(history)
+
 	// Return empty array instead of null if history is empty
 	if history == nil {
-		history = []domain.GeneralLedgerEvent{}
+		history = []domain.LedgerEvent{}
+	}
+	if page.NextCursor != "" {
+		c.Header("X-Next-Cursor", page.NextCursor)
 	}
 
 	log.Printf("Handler: Returning %d general history events", len(history))
 	c.JSON(http.StatusOK, history)
 }
+
+// streamHeartbeatInterval is how often an idle ledger stream sends a comment, so proxies
+// do not close the connection.
+const streamHeartbeatInterval = 15 * time.Second
+
+// StreamLedgerHandler pushes new ledger events to the client as Server-Sent Events.
+// It accepts the same filters as GetLedgerHistoryHandler, except order, cursor and limit.
+// Each event is sent as a "ledger-event" message whose ID resumes the stream: browsers
+// send it back in the Last-Event-ID header when they reconnect, and clients that cannot
+// set headers may pass it as the lastEventId query parameter. Without either, the stream
+// starts at from, or at the time of the request.
+func (h *LedgerHandler) StreamLedgerHandler(c *gin.Context) {
+	query, err := parseHistoryQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.Limit = 0
+
+	resumeFrom := c.GetHeader("Last-Event-ID")
+	if resumeFrom == "" {
+		resumeFrom = c.Query("lastEventId")
+	}
+
+	follower, err := ledger.NewFollower(h.LedgerService, query, resumeFrom, h.StreamOptions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering
+	c.Status(http.StatusOK)
+
+	// The follower and the heartbeat both write to the response
+	var mu sync.Mutex
+	write := func(message string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err := io.WriteString(c.Writer, message); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	// The heartbeat must stop before the handler returns: gin reuses the response writer
+	// for the next request once it does
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	var heartbeat sync.WaitGroup
+	defer func() {
+		cancel()
+		heartbeat.Wait()
+	}()
+	if err := write(": connected\n\n"); err != nil {
+		return
+	}
+
+	heartbeat.Add(1)
+	go func() {
+		defer heartbeat.Done()
+		ticker := time.NewTicker(streamHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := write(": heartbeat\n\n"); err != nil {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	err = follower.Run(ctx, func(event domain.LedgerEvent, cursor string) error {
+		event.SignatureStatus = h.EventSigner.Verify(event)
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		return write(fmt.Sprintf("id: %s\nevent: ledger-event\ndata: %s\n\n", cursor, data))
+	})
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Error streaming ledger events: %v", err)
+		write("event: error\ndata: {\"error\":\"Failed to read ledger events\"}\n\n")
+	}
+}
+
+// GetEventReceiptHandler returns a signed receipt for a single ledger event, served as a
+// download. The receipt can be checked offline with the verify-receipt command.
+func (h *LedgerHandler) GetEventReceiptHandler(c *gin.Context) {
+	eventID := c.Param("eventId")
+
+	rcpt, err := h.LedgerService.GetEventReceipt(c.Request.Context(), eventID)
+	if err != nil {
+		if errors.Is(err, ledger.ErrEventNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ledger event not found"})
+			return
+		}
+		log.Printf("Error building receipt for ledger event %s: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build ledger event receipt"})
+		return
+	}
+
+	if err := rcpt.Sign(h.Signer); err != nil {
+		log.Printf("Error signing receipt for ledger event %s: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign ledger event receipt"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "receipt-"+rcpt.EventID+".json"))
+	c.JSON(http.StatusOK, rcpt)
+}
+
+// GetReceiptKeyHandler returns the public key receipts are signed with, for use with the
+// verifier's -pubkey flag.
+func (h *LedgerHandler) GetReceiptKeyHandler(c *gin.Context) {
+	publicKeyPEM, err := h.Signer.PublicKeyPEM()
+	if err != nil {
+		log.Printf("Error encoding receipt signing key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode receipt signing key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keyId":     h.Signer.KeyID(),
+		"algorithm": "Ed25519",
+		"publicKey": string(publicKeyPEM),
+	})
+}
+
+// parseHistoryQuery builds a ledger.HistoryQuery from the request's query parameters.
+func parseHistoryQuery(c *gin.Context) (ledger.HistoryQuery, error) {
+	query := ledger.HistoryQuery{
+		SerialNumber: c.Query("serialNumber"),
+		Cursor:       c.Query("cursor"),
+		Order:        ledger.SortOrder(strings.ToLower(c.Query("order"))),
+	}
+
+	for _, bound := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &query.From}, {"to", &query.To}} {
+		if v := c.Query(bound.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s: expected an RFC 3339 timestamp", bound.name)
+			}
+			*bound.dst = &t
+		}
+	}
+
+	for _, id := range []struct {
+		name string
+		dst  **uint64
+	}{{"userId", &query.UserID}, {"itemId", &query.ItemID}, {"transferId", &query.TransferID}} {
+		if v := c.Query(id.name); v != "" {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s", id.name)
+			}
+			*id.dst = &n
+		}
+	}
+
+	for _, v := range c.QueryArray("eventType") {
+		for _, eventType := range strings.Split(v, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				query.EventTypes = append(query.EventTypes, eventType)
+			}
+		}
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return query, fmt.Errorf("invalid limit")
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/repository"
+)
+
+// defaultOutboxListLimit is the number of entries returned when no limit is given
+const defaultOutboxListLimit = 50
+
+// LedgerOutboxHandler exposes the ledger outbox backlog for administrators
+type LedgerOutboxHandler struct {
+	Repo repository.Repository
+}
+
+// NewLedgerOutboxHandler creates a new ledger outbox handler
+func NewLedgerOutboxHandler(repo repository.Repository) *LedgerOutboxHandler {
+	return &LedgerOutboxHandler{Repo: repo}
+}
+
+// GetOutboxBacklog godoc
+// @Summary Show the ledger outbox backlog
+// @Description Returns outbox counts by status, the age of the oldest pending entry, and the oldest entries with the requested status.
+// @Tags Admin
+// @Produce json
+// @Param status query string false "Entry status to list: pending or dead (default dead)"
+// @Param limit query int false "Maximum entries to list (default 50)"
+// @Success 200 {object} map[string]interface{} "stats, entries"
+// @Failure 400 {object} map[string]string "error: Invalid query parameter"
+// @Failure 500 {object} map[string]string "error: Failed to read ledger outbox"
+// @Router /admin/ledger/outbox [get]
+// @Security BearerAuth
+func (h *LedgerOutboxHandler) GetOutboxBacklog(c *gin.Context) {
+	status := c.DefaultQuery("status", domain.OutboxStatusDead)
+	if status != domain.OutboxStatusPending && status != domain.OutboxStatusDead {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status: must be pending or dead"})
+		return
+	}
+
+	limit := defaultOutboxListLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit: must be a positive integer"})
+			return
+		}
+		limit = parsed
+	}
+
+	stats, err := h.Repo.GetLedgerOutboxStats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read ledger outbox: " + err.Error()})
+		return
+	}
+
+	entries, err := h.Repo.ListLedgerOutboxEntries(status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read ledger outbox: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stats": stats, "entries": entries})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+)
+
+// maxSignatureAge is how far the time in a signed event's UUIDv7 may be from the time the
+// request arrives. It keeps a captured signed request from being replayed later.
+const maxSignatureAge = 5 * time.Minute
+
+// userSignedWrite returns the options to record a user's action with: timestamped now and,
+// when the request carries the user's signature, under the signed event ID with the
+// signature, once it is checked against the event built by event. Without a signature the
+// event is recorded unsigned under a new ID, unless signatures are required. Errors that
+// wrap ledger.ErrInvalidEventSignature are the client's fault.
+func userSignedWrite(signer *ledger.EventSigner, input *domain.LedgerSignatureInput, event func(eventID string) domain.LedgerEvent) (ledger.WriteOptions, error) {
+	opts := ledger.WriteOptions{OccurredAt: time.Now().UTC()}
+	if input == nil {
+		if signer.UserSignaturesRequired() {
+			return opts, fmt.Errorf("%w: the request must carry the user's ledger signature", ledger.ErrInvalidEventSignature)
+		}
+		return opts, nil
+	}
+	if signer == nil {
+		return opts, fmt.Errorf("%w: ledger event signing is disabled", ledger.ErrInvalidEventSignature)
+	}
+
+	id, err := uuid.Parse(input.EventID)
+	if err != nil || id.Version() != 7 {
+		return opts, fmt.Errorf("%w: event ID %q is not a UUIDv7", ledger.ErrInvalidEventSignature, input.EventID)
+	}
+	sec, nsec := id.Time().UnixTime()
+	if age := opts.OccurredAt.Sub(time.Unix(sec, nsec)); age > maxSignatureAge || age < -maxSignatureAge {
+		return opts, fmt.Errorf("%w: event ID %s was not issued within %s of the request", ledger.ErrInvalidEventSignature, input.EventID, maxSignatureAge)
+	}
+
+	signature, err := signer.VerifyUserSignature(event(input.EventID), input.KeyID, input.Value, opts.OccurredAt)
+	if err != nil {
+		return opts, err
+	}
+	opts.EventID = input.EventID
+	opts.Signature = signature
+	return opts, nil
+}
+
+// respondLedgerSignatureError reports an error from userSignedWrite.
+func respondLedgerSignatureError(c *gin.Context, err error) {
+	if errors.Is(err, ledger.ErrInvalidEventSignature) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify ledger signature: " + err.Error()})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/toole-brendan/handreceipt-go/internal/repository"
+)
+
+// defaultReconciliationListLimit is the number of reports returned when no limit is given
+const defaultReconciliationListLimit = 20
+
+// ReconciliationHandler serves ledger-vs-database reconciliation reports
+type ReconciliationHandler struct {
+	Repo repository.Repository
+}
+
+// NewReconciliationHandler creates a new reconciliation handler
+func NewReconciliationHandler(repo repository.Repository) *ReconciliationHandler {
+	return &ReconciliationHandler{Repo: repo}
+}
+
+// ListReports godoc
+// @Summary List reconciliation reports
+// @Description Returns report summaries (without findings), newest first.
+// @Tags Admin
+// @Produce json
+// @Param limit query int false "Maximum reports to return (default 20)"
+// @Success 200 {object} map[string]interface{} "reports"
+// @Failure 400 {object} map[string]string "error: Invalid limit"
+// @Failure 500 {object} map[string]string "error: Failed to list reconciliation reports"
+// @Router /admin/ledger/reconciliation [get]
+// @Security BearerAuth
+func (h *ReconciliationHandler) ListReports(c *gin.Context) {
+	limit := defaultReconciliationListLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit: must be a positive integer"})
+			return
+		}
+		limit = parsed
+	}
+
+	reports, err := h.Repo.ListReconciliationReports(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list reconciliation reports: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"reports": reports})
+}
+
+// GetLatestReport godoc
+// @Summary Get the latest reconciliation report
+// @Description Returns the most recent reconciliation report with its drift findings.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} domain.ReconciliationReport
+// @Failure 404 {object} map[string]string "error: No reconciliation report found"
+// @Failure 500 {object} map[string]string "error: Failed to fetch reconciliation report"
+// @Router /admin/ledger/reconciliation/latest [get]
+// @Security BearerAuth
+func (h *ReconciliationHandler) GetLatestReport(c *gin.Context) {
+	report, err := h.Repo.GetLatestReconciliationReport()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reconciliation report: " + err.Error()})
+		return
+	}
+	if report == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No reconciliation report found"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetReportByID godoc
+// @Summary Get a reconciliation report
+// @Description Returns one reconciliation report with its drift findings.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Report ID"
+// @Success 200 {object} domain.ReconciliationReport
+// @Failure 400 {object} map[string]string "error: Invalid ID format"
+// @Failure 404 {object} map[string]string "error: Reconciliation report not found"
+// @Failure 500 {object} map[string]string "error: Failed to fetch reconciliation report"
+// @Router /admin/ledger/reconciliation/{id} [get]
+// @Security BearerAuth
+func (h *ReconciliationHandler) GetReportByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	report, err := h.Repo.GetReconciliationReportByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reconciliation report: " + err.Error()})
+		return
+	}
+	if report == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reconciliation report not found"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"github.com/toole-brendan/handreceipt-go/internal/repository"
+	"github.com/toole-brendan/handreceipt-go/internal/services/signing"
+)
+
+// SigningKeyHandler lets users enroll the keys they sign ledger events with. Users
+// generate their keys on their own devices and enroll only the public half.
+type SigningKeyHandler struct {
+	Repo repository.Repository
+}
+
+// NewSigningKeyHandler creates a new signing key handler
+func NewSigningKeyHandler(repo repository.Repository) *SigningKeyHandler {
+	return &SigningKeyHandler{Repo: repo}
+}
+
+// currentUserID returns the authenticated user's ID, or responds and returns false.
+func currentUserID(c *gin.Context) (uint, bool) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return 0, false
+	}
+	userID, ok := userIDVal.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format in context"})
+		return 0, false
+	}
+	return userID, true
+}
+
+// ListSigningKeys godoc
+// @Summary List the current user's signing keys
+// @Description Returns every key the user has enrolled, including revoked keys, which still verify the events signed before their revocation.
+// @Tags Signing Keys
+// @Produce json
+// @Success 200 {object} map[string]interface{} "keys"
+// @Failure 401 {object} map[string]string "error: User not authenticated"
+// @Failure 500 {object} map[string]string "error: Failed to fetch signing keys"
+// @Router /signing-keys [get]
+// @Security BearerAuth
+func (h *SigningKeyHandler) ListSigningKeys(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	keys, err := h.Repo.ListSigningKeys(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch signing keys"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+// EnrollSigningKey godoc
+// @Summary Enroll a signing key
+// @Description Enrolls the public half of an Ed25519 key the user generated on their device. Events the user signs with it from now on verify against it. Enrolling a key again returns it unchanged.
+// @Tags Signing Keys
+// @Accept json
+// @Produce json
+// @Param key body object true "publicKey: base64 Ed25519 public key"
+// @Success 201 {object} domain.SigningKey
+// @Failure 400 {object} map[string]string "error: Invalid public key"
+// @Failure 401 {object} map[string]string "error: User not authenticated"
+// @Failure 409 {object} map[string]string "error: Key enrolled by another user"
+// @Failure 500 {object} map[string]string "error: Failed to enroll signing key"
+// @Router /signing-keys [post]
+// @Security BearerAuth
+func (h *SigningKeyHandler) EnrollSigningKey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		PublicKey string `json:"publicKey" binding:"required"` // Base64
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input format: " + err.Error()})
+		return
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(input.PublicKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid public key: not base64"})
+		return
+	}
+
+	key, err := ledger.EnrollKey(h.Repo, &userID, publicKey, time.Now())
+	switch {
+	case errors.Is(err, signing.ErrInvalidKey):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid public key: " + err.Error()})
+	case errors.Is(err, ledger.ErrSigningKeyTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll signing key"})
+	default:
+		c.JSON(http.StatusCreated, key)
+	}
+}
+
+// RevokeSigningKey godoc
+// @Summary Revoke a signing key
+// @Description Stops the key from signing new events, for example when the device holding it is lost. Events it signed before now still verify.
+// @Tags Signing Keys
+// @Param keyId path string true "Key ID"
+// @Success 204
+// @Failure 401 {object} map[string]string "error: User not authenticated"
+// @Failure 404 {object} map[string]string "error: No such active key"
+// @Failure 500 {object} map[string]string "error: Failed to revoke signing key"
+// @Router /signing-keys/{keyId} [delete]
+// @Security BearerAuth
+func (h *SigningKeyHandler) RevokeSigningKey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	revoked, err := h.Repo.RevokeSigningKey(userID, c.Param("keyId"), time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke signing key"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You have no active signing key " + c.Param("keyId")})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
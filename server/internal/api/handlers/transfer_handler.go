@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
@@ -10,19 +11,43 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/toole-brendan/handreceipt-go/internal/domain"
 	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/outbox"
 	"github.com/toole-brendan/handreceipt-go/internal/repository"
 	"gorm.io/gorm"
 )
 
+var (
+	errInventoryItemNotFound = errors.New("inventory item not found")
+	errTransferNotFound      = errors.New("transfer not found")
+)
+
+// transferErrorStatus maps an error from a transfer change to its HTTP status code.
+func transferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errInventoryItemNotFound), errors.Is(err, errTransferNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ledger.ErrInvalidEventSignature):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrNotPropertyHolder), errors.Is(err, domain.ErrNotTransferParty):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrOpenTransferExists), errors.Is(err, domain.ErrTransferClosed), errors.Is(err, domain.ErrInvalidTransferTransition),
+		errors.Is(err, domain.ErrHolderChanged):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // TransferHandler handles transfer operations
 type TransferHandler struct {
-	Ledger ledger.LedgerService
-	Repo   repository.Repository
+	Ledger      ledger.LedgerService
+	Repo        repository.Repository
+	EventSigner *ledger.EventSigner // Verifies users' ledger event signatures; may be nil
 }
 
 // NewTransferHandler creates a new transfer handler
-func NewTransferHandler(ledgerService ledger.LedgerService, repo repository.Repository) *TransferHandler {
-	return &TransferHandler{Ledger: ledgerService, Repo: repo}
+func NewTransferHandler(ledgerService ledger.LedgerService, repo repository.Repository, eventSigner *ledger.EventSigner) *TransferHandler {
+	return &TransferHandler{Ledger: ledgerService, Repo: repo, EventSigner: eventSigner}
 }
 
 // CreateTransfer creates a new transfer record
@@ -48,47 +73,71 @@ func (h *TransferHandler) CreateTransfer(c *gin.Context) {
 		return
 	}
 
-	// Fetch the inventory item using repository to get the serial number for Ledger logging
-	item, err := h.Repo.GetPropertyByID(input.PropertyID)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Inventory item not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inventory item: " + err.Error()})
-		}
-		return
-	}
-
 	// Prepare the transfer for database insertion
 	transfer := &domain.Transfer{ // Changed to pointer
 		PropertyID: input.PropertyID,
 		FromUserID: requestingUserID, // Set FromUserID to the authenticated user
 		ToUserID:   input.ToUserID,
-		Status:     "Requested", // Set initial status
+		Status:     domain.TransferStatusRequested, // Set initial status
 		Notes:      input.Notes,
 		// RequestDate defaults to CURRENT_TIMESTAMP in DB
 		// ResolvedDate is null initially
 	}
 
-	// Insert into database using repository
-	if err := h.Repo.CreateTransfer(transfer); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transfer: " + err.Error()})
-		return
-	}
+	// Insert into database together with its ledger outbox entry; the worker delivers it to the ledger.
+	// The item stays locked until commit, so two requests for it cannot both pass the checks.
+	var ledgerEventID string
+	err := h.Repo.WithTx(func(tx repository.Repository) error {
+		item, err := tx.GetPropertyByIDForUpdate(input.PropertyID)
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			return errInventoryItemNotFound
+		}
+		if err := domain.ValidateTransferRequest(*item, requestingUserID); err != nil {
+			return err
+		}
+		open, err := tx.GetOpenTransferByPropertyID(item.ID)
+		if err != nil {
+			return err
+		}
+		if open != nil {
+			return fmt.Errorf("%w: transfer %d is %s", domain.ErrOpenTransferExists, open.ID, open.Status)
+		}
+
+		opts, err := userSignedWrite(h.EventSigner, input.LedgerSignature, func(eventID string) domain.LedgerEvent {
+			return ledger.TransferEvent(eventID, *transfer, item.SerialNumber, requestingUserID)
+		})
+		if err != nil {
+			return err
+		}
 
-	// Log to Ledger Service (use transfer *after* creation)
-	errLedger := h.Ledger.LogTransferEvent(*transfer, item.SerialNumber)
-	if errLedger != nil {
-		log.Printf("WARNING: Failed to log transfer creation (ID: %d, ItemID: %d, SN: %s) to Ledger after DB creation: %v", transfer.ID, transfer.PropertyID, item.SerialNumber, errLedger)
-		// Consider compensation logic here if ledger write fails, or at least alert
-	} else {
-		log.Printf("Successfully logged transfer creation (ID: %d, ItemID: %d) to Ledger", transfer.ID, transfer.PropertyID)
+		// The entry is built after the insert so the transfer ID and request date are populated.
+		if err := tx.CreateTransfer(transfer); err != nil {
+			return err
+		}
+		ledgerEventID, err = enqueueLedgerEvent(tx, func() (*domain.LedgerOutboxEntry, error) {
+			return outbox.NewTransferEntry(*transfer, item.SerialNumber, requestingUserID, opts)
+		})
+		return err
+	})
+	if err != nil {
+		c.JSON(transferErrorStatus(err), gin.H{"error": "Failed to create transfer: " + err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusCreated, transfer)
+	c.JSON(http.StatusCreated, transferResponse{Transfer: transfer, LedgerEventID: ledgerEventID})
 }
 
-// UpdateTransferStatus updates the status of a transfer
+// UpdateTransferStatus updates the status of a transfer. Completing a transfer assigns the
+// item to the recipient in the same transaction as the status change and its ledger event.
+// Clients should send the transfer version they last saw in If-Match or the version field;
+// if the transfer has changed since, the update is refused with 409 and the current transfer.
+// A requested transfer may be approved, rejected or cancelled, and an approved one completed
+// or cancelled; other changes are refused with 409.
+// Only the recipient may approve, reject or complete a transfer, and only the sender may
+// cancel it; anyone else is refused with 403.
 func (h *TransferHandler) UpdateTransferStatus(c *gin.Context) {
 	// Parse ID from URL parameter
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -105,72 +154,113 @@ func (h *TransferHandler) UpdateTransferStatus(c *gin.Context) {
 		return
 	}
 
-	// Validate status value (adjust allowed statuses as needed)
-	allowedStatuses := map[string]bool{"Requested": true, "Approved": true, "Rejected": true, "Completed": true, "Cancelled": true} // Added more statuses
-	if !allowedStatuses[updateData.Status] {
+	// Validate status value
+	if !domain.IsTransferStatus(updateData.Status) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status value"})
 		return
 	}
+	expected, err := expectedVersion(c, updateData.Version)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Get user ID from context (representing the user performing the update)
-	_, exists := c.Get("userID") // TODO: Use this userID for authorization check
+	userIDVal, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
-	// TODO: Add authorization logic here - does this user have permission to update this transfer?
-
-	// Fetch transfer from repository
-	transfer, err := h.Repo.GetTransferByID(uint(id))
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Transfer not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transfer: " + err.Error()})
-		}
+	userID, ok := userIDVal.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format in context"})
 		return
 	}
+	// Lock the transfer, then its item, so that changes to either are applied one at a time.
+	// The caller is checked against the locked transfer, so its parties cannot change under us.
+	var (
+		transfer      *domain.Transfer
+		ledgerEventID string
+	)
+	err = h.Repo.WithTx(func(tx repository.Repository) error {
+		transfer, err = tx.GetTransferByIDForUpdate(uint(id))
+		if err != nil {
+			return err
+		}
+		if transfer == nil {
+			return errTransferNotFound
+		}
+		if err := domain.CheckVersion("transfer", transfer.ID, transfer.Version, expected); err != nil {
+			return err
+		}
+		if err := domain.ValidateTransferStatusChange(transfer.Status, updateData.Status); err != nil {
+			return err
+		}
+		if err := domain.AuthorizeTransferStatusChange(*transfer, updateData.Status, userID); err != nil {
+			return err
+		}
+		item, err := tx.GetPropertyByIDForUpdate(transfer.PropertyID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch related inventory item: %w", err)
+		}
+		if item == nil {
+			return fmt.Errorf("related inventory item %d not found", transfer.PropertyID)
+		}
 
-	// Fetch the related inventory item using repository for serial number
-	item, err := h.Repo.GetPropertyByID(transfer.PropertyID)
-	if err != nil {
-		log.Printf("Error fetching related item %d for transfer %d update: %v", transfer.PropertyID, transfer.ID, err)
-		// Decide if this is fatal - maybe proceed without Ledger logging? For now, fail.
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch related inventory item for logging"})
-		return
-	}
+		// Update fields
+		transfer.Status = updateData.Status
+		if updateData.Notes != nil {
+			transfer.Notes = updateData.Notes
+		}
 
-	// Update fields
-	transfer.Status = updateData.Status
-	if updateData.Notes != nil {
-		transfer.Notes = updateData.Notes
-	}
+		// Update ResolvedDate based on status
+		if domain.IsOpenTransferStatus(transfer.Status) {
+			transfer.ResolvedDate = nil
+		} else {
+			now := time.Now().UTC()
+			transfer.ResolvedDate = &now
+		}
 
-	// Update ResolvedDate based on status
-	if transfer.Status == "Approved" || transfer.Status == "Rejected" || transfer.Status == "Completed" || transfer.Status == "Cancelled" {
-		now := time.Now().UTC()
-		transfer.ResolvedDate = &now
-	} else {
-		transfer.ResolvedDate = nil // Reset if moved back to pending?
-	}
+		// Completion moves custody of the item to the recipient
+		if transfer.Status == domain.TransferStatusCompleted {
+			if err := domain.CompleteTransfer(*transfer, item); err != nil {
+				return err
+			}
+			if err := tx.UpdateProperty(item); err != nil {
+				return fmt.Errorf("failed to reassign inventory item: %w", err)
+			}
+		}
 
-	// Save updated transfer using repository
-	if err := h.Repo.UpdateTransfer(transfer); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transfer status: " + err.Error()})
-		return
-	}
+		opts, err := userSignedWrite(h.EventSigner, updateData.LedgerSignature, func(eventID string) domain.LedgerEvent {
+			return ledger.TransferEvent(eventID, *transfer, item.SerialNumber, userID)
+		})
+		if err != nil {
+			return err
+		}
 
-	// Log the updated state to Ledger Service
-	errLedger := h.Ledger.LogTransferEvent(*transfer, item.SerialNumber)
-	if errLedger != nil {
-		// Log error but don't fail the request as DB update succeeded
-		log.Printf("WARNING: Failed to log transfer status update (ID: %d, SN: %s, NewStatus: %s) to Ledger: %v", transfer.ID, item.SerialNumber, transfer.Status, errLedger)
-		c.Writer.Write([]byte("\nWarning: Failed to log status update to immutable ledger")) // Optionally notify client
-	} else {
-		log.Printf("Successfully logged transfer status update (ID: %d, NewStatus: %s) to Ledger", transfer.ID, transfer.Status)
+		// Save updated transfer together with its ledger outbox entry
+		if err := tx.UpdateTransfer(transfer); err != nil {
+			return err
+		}
+		ledgerEventID, err = enqueueLedgerEvent(tx, func() (*domain.LedgerOutboxEntry, error) {
+			return outbox.NewTransferEntry(*transfer, item.SerialNumber, userID, opts)
+		})
+		return err
+	})
+	if err != nil {
+		var conflict *domain.VersionConflictError
+		if errors.As(err, &conflict) {
+			// The conflict is the answer even if the current transfer cannot be loaded
+			current, _ := h.Repo.GetTransferByID(uint(id))
+			respondTransferConflict(c, err, current)
+			return
+		}
+		c.JSON(transferErrorStatus(err), gin.H{"error": "Failed to update transfer status: " + err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, transfer)
+	setVersionETag(c, transfer.Version)
+	c.JSON(http.StatusOK, transferResponse{Transfer: transfer, LedgerEventID: ledgerEventID})
 }
 
 // GetAllTransfers returns all transfers
@@ -223,6 +313,12 @@ func (h *TransferHandler) GetTransferByID(c *gin.Context) {
 		}
 		return
 	}
+	if transfer == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transfer not found"})
+		return
+	}
+
+	setVersionETag(c, transfer.Version)
 	c.JSON(http.StatusOK, gin.H{"transfer": transfer})
 }
 
@@ -1,27 +1,33 @@
 package handlers
 
 import (
+	"fmt"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/digest"
 )
 
 // VerificationHandler handles ledger verification operations
 type VerificationHandler struct {
-	Ledger ledger.LedgerService
+	Ledger  ledger.LedgerService
+	Digests digest.Store // Stored ledger digests; nil when digest anchoring is disabled
 }
 
 // NewVerificationHandler creates a new verification handler
-func NewVerificationHandler(ledgerService ledger.LedgerService) *VerificationHandler {
-	return &VerificationHandler{Ledger: ledgerService}
+func NewVerificationHandler(ledgerService ledger.LedgerService, digests digest.Store) *VerificationHandler {
+	return &VerificationHandler{Ledger: ledgerService, Digests: digests}
 }
 
 // VerifyDatabaseLedger performs a full cryptographic verification of the database ledger.
 // It calls the underlying LedgerService's verification function, which for Azure SQL
-// typically executes sys.sp_verify_database_ledger.
+// typically executes sys.sp_verify_database_ledger, and then proves the ledger extends
+// every digest the worker has stored.
 // @Summary Verify Database Ledger
-// @Description Performs a full cryptographic verification of the entire database ledger.
+// @Description Performs a full cryptographic verification of the entire database ledger against its stored digests.
 // @Tags Verification
 // @Produce json
 // @Success 200 {object} map[string]string "status: ok, message: Ledger verification successful."
@@ -35,7 +41,7 @@ func (h *VerificationHandler) VerifyDatabaseLedger(c *gin.Context) {
 	documentID := "database-wide"
 	tableName := "all"
 
-	ok, err := h.Ledger.VerifyDocument(documentID, tableName)
+	ok, err := h.Ledger.VerifyDocument(c.Request.Context(), documentID, tableName)
 
 	if err != nil {
 		// Specific error might indicate tampering vs. other issues.
@@ -70,8 +76,37 @@ func (h *VerificationHandler) VerifyDatabaseLedger(c *gin.Context) {
 		return
 	}
 
+	if h.Digests == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "ok",
+			"message": "Ledger verification successful. Digest anchoring is disabled, so earlier history was not checked.",
+		})
+		return
+	}
+
+	report, err := digest.Verify(c.Request.Context(), h.Ledger, h.Digests)
+	if err != nil {
+		log.Printf("Ledger digest verification could not be completed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Ledger digest verification could not be completed",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if !report.Verified {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":  "unhealthy",
+			"message": fmt.Sprintf("Ledger does not extend the digest captured at %s.", report.FirstFailure.Digest.CapturedAt.Format(time.RFC3339)),
+			"digests": report,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "ok",
 		"message": "Ledger verification successful.",
+		"digests": report,
 	})
 }
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+)
+
+// UserLookup resolves the authenticated user. repository.Repository implements it.
+type UserLookup interface {
+	GetUserByID(id uint) (*domain.User, error)
+}
+
+// AdminRoles are the roles allowed on the admin routes, which expose ledger operations and
+// whole-ledger exports.
+var AdminRoles = []string{
+	domain.UserRoleAdmin,
+	domain.UserRoleSuperAdmin,
+	domain.UserRolePropertyOfficer,
+	domain.UserRoleInvestigator,
+}
+
+// RequireRole is a middleware that admits only users holding one of roles. It must run
+// after SessionAuthMiddleware, which sets the user ID.
+func RequireRole(users UserLookup, roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(c *gin.Context) {
+		userID, ok := c.Get("userID")
+		id, isUint := userID.(uint)
+		if !ok || !isUint {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+
+		user, err := users.GetUserByID(id)
+		if err != nil {
+			log.Printf("Error looking up user %d for role check: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check user role"})
+			c.Abort()
+			return
+		}
+		if user == nil || !allowed[user.Role] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient role"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
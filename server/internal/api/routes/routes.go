@@ -5,24 +5,34 @@ import (
 	"github.com/toole-brendan/handreceipt-go/internal/api/handlers"
 	"github.com/toole-brendan/handreceipt-go/internal/api/middleware"
 	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/accountability"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/digest"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/export"
 	"github.com/toole-brendan/handreceipt-go/internal/repository"
+	"github.com/toole-brendan/handreceipt-go/internal/services/signing"
 )
 
 // SetupRoutes configures all the API routes for the application
-func SetupRoutes(router *gin.Engine, ledgerService ledger.LedgerService, repo repository.Repository) {
+func SetupRoutes(router *gin.Engine, ledgerService ledger.LedgerService, repo repository.Repository, receiptSigner *signing.Signer, eventSigner *ledger.EventSigner, digests digest.Store, streamOptions ledger.StreamOptions) {
 	// Initialize session middleware
 	middleware.SetupSession(router)
 
 	// Create handlers
 	authHandler := handlers.NewAuthHandler(repo)
-	inventoryHandler := handlers.NewInventoryHandler(ledgerService, repo)
-	transferHandler := handlers.NewTransferHandler(ledgerService, repo)
+	inventoryHandler := handlers.NewInventoryHandler(ledgerService, repo, eventSigner)
+	transferHandler := handlers.NewTransferHandler(ledgerService, repo, eventSigner)
 	activityHandler := handlers.NewActivityHandler() // No ledger needed
-	verificationHandler := handlers.NewVerificationHandler(ledgerService)
-	correctionHandler := handlers.NewCorrectionHandler(ledgerService)
-	ledgerHandler := handlers.NewLedgerHandler(ledgerService)  // Create ledger handler
-	referenceDBHandler := handlers.NewReferenceDBHandler(repo) // Add ReferenceDB handler
-	userHandler := handlers.NewUserHandler(repo)               // Added User handler
+	verificationHandler := handlers.NewVerificationHandler(ledgerService, digests)
+	correctionHandler := handlers.NewCorrectionHandler(ledgerService, eventSigner)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService, receiptSigner, eventSigner, streamOptions) // Create ledger handler
+	referenceDBHandler := handlers.NewReferenceDBHandler(repo)                                           // Add ReferenceDB handler
+	userHandler := handlers.NewUserHandler(repo)                                                         // Added User handler
+	ledgerOutboxHandler := handlers.NewLedgerOutboxHandler(repo)
+	reconciliationHandler := handlers.NewReconciliationHandler(repo)
+	ledgerDivergenceHandler := handlers.NewLedgerDivergenceHandler(repo)
+	accountabilityHandler := handlers.NewAccountabilityHandler(accountability.NewService(ledgerService, repo))
+	ledgerExportHandler := handlers.NewLedgerExportHandler(export.NewExporter(ledgerService, repo, receiptSigner))
+	signingKeyHandler := handlers.NewSigningKeyHandler(repo)
 	// ... more handlers will be added in the future
 
 	// TODO: Update other handlers to use repository when needed
@@ -99,6 +109,12 @@ func SetupRoutes(router *gin.Engine, ledgerService ledger.LedgerService, repo re
 		ledgerRoutes := protected.Group("/ledger") // New group for general ledger
 		{
 			ledgerRoutes.GET("/history", ledgerHandler.GetLedgerHistoryHandler) // New route
+			ledgerRoutes.GET("/stream", ledgerHandler.StreamLedgerHandler)
+			ledgerRoutes.GET("/events/:eventId/receipt", ledgerHandler.GetEventReceiptHandler)
+			ledgerRoutes.GET("/receipt-key", ledgerHandler.GetReceiptKeyHandler)
+			ledgerRoutes.GET("/accountability/users/:userId", accountabilityHandler.GetUserAccountability)
+			ledgerRoutes.GET("/accountability/units/:unit", accountabilityHandler.GetUnitAccountability)
+			ledgerRoutes.GET("/accountability/items/:itemId", accountabilityHandler.GetItemAccountability)
 			// TODO: Add route for item-specific history (/ledger/item/:itemId/history) ?
 		}
 
@@ -117,5 +133,25 @@ func SetupRoutes(router *gin.Engine, ledgerService ledger.LedgerService, repo re
 			users.GET("/:id", userHandler.GetUserByID)
 			// POST /api/users from Node is handled by POST /api/auth/register
 		}
+
+		// The current user's ledger signing keys
+		signingKeys := protected.Group("/signing-keys")
+		{
+			signingKeys.GET("", signingKeyHandler.ListSigningKeys)
+			signingKeys.POST("", signingKeyHandler.EnrollSigningKey)
+			signingKeys.DELETE("/:keyId", signingKeyHandler.RevokeSigningKey)
+		}
+
+		// Admin routes
+		admin := protected.Group("/admin")
+		admin.Use(middleware.RequireRole(repo, middleware.AdminRoles...))
+		{
+			admin.GET("/ledger/outbox", ledgerOutboxHandler.GetOutboxBacklog)
+			admin.GET("/ledger/divergences", ledgerDivergenceHandler.ListDivergences)
+			admin.GET("/ledger/reconciliation", reconciliationHandler.ListReports)
+			admin.GET("/ledger/reconciliation/latest", reconciliationHandler.GetLatestReport)
+			admin.GET("/ledger/reconciliation/:id", reconciliationHandler.GetReportByID)
+			admin.GET("/ledger/export", ledgerExportHandler.ExportLedger)
+		}
 	}
 }
@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/api/middleware"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"github.com/toole-brendan/handreceipt-go/internal/repository"
+)
+
+// userRepository serves users by ID; the admin routes only look up the caller.
+type userRepository struct {
+	repository.Repository
+	users map[uint]domain.User
+}
+
+func (r userRepository) GetUserByID(id uint) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func TestAdminRoutes_RequireAdminRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	repo := userRepository{users: map[uint]domain.User{
+		1: {ID: 1, Role: domain.UserRoleUser},
+		2: {ID: 2, Role: domain.UserRoleInvestigator},
+	}}
+	SetupRoutes(router, nil, repo, nil, nil, nil, ledger.StreamOptions{})
+
+	get := func(userID uint) *httptest.ResponseRecorder {
+		token, err := middleware.GenerateToken(userID)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/ledger/export", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, get(1).Code, "a plain user may not export the ledger")
+	assert.Equal(t, http.StatusForbidden, get(3).Code, "an unknown user")
+	assert.Equal(t, http.StatusBadRequest, get(2).Code, "an investigator reaches the handler, which requires a time range")
+}
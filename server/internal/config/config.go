@@ -13,6 +13,8 @@ type Config struct {
 	Database DatabaseConfig `mapstructure:"database"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
 	ImmuDB   ImmuDBConfig   `mapstructure:"immudb"`
+	Ledger   LedgerConfig   `mapstructure:"ledger"`
+	Receipts ReceiptsConfig `mapstructure:"receipts"`
 	MinIO    MinIOConfig    `mapstructure:"minio"`
 	NSN      NSNConfig      `mapstructure:"nsn"`
 	Redis    RedisConfig    `mapstructure:"redis"`
@@ -66,12 +68,84 @@ type JWTConfig struct {
 
 // ImmuDBConfig holds ImmuDB configuration
 type ImmuDBConfig struct {
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
-	Username string `mapstructure:"username"`
-	Password string `mapstructure:"password"`
-	Database string `mapstructure:"database"`
-	Enabled  bool   `mapstructure:"enabled"`
+	Host     string             `mapstructure:"host"`
+	Port     int                `mapstructure:"port"`
+	Username string             `mapstructure:"username"`
+	Password string             `mapstructure:"password"`
+	Database string             `mapstructure:"database"`
+	Enabled  bool               `mapstructure:"enabled"`
+	Archive  AuditArchiveConfig `mapstructure:"archive"`
+}
+
+// AuditArchiveConfig holds archiving of aged ImmuDB audit events to MinIO
+type AuditArchiveConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	AfterDays   int    `mapstructure:"after_days"`   // Events older than this are archived
+	MinIOPrefix string `mapstructure:"minio_prefix"` // Object prefix for archive bundles
+}
+
+// LedgerConfig holds ledger service configuration
+type LedgerConfig struct {
+	LocalPath             string               `mapstructure:"local_path"`
+	AzureConnectionString string               `mapstructure:"azure_connection_string"`
+	AzureSchema           string               `mapstructure:"azure_schema"`
+	Backends              []string             `mapstructure:"backends"`
+	WritePolicy           string               `mapstructure:"write_policy"`
+	Timeouts              LedgerTimeoutsConfig `mapstructure:"timeouts"`
+	Outbox                OutboxConfig         `mapstructure:"outbox"`
+	Reconciliation        ReconciliationConfig `mapstructure:"reconciliation"`
+	Digests               DigestsConfig        `mapstructure:"digests"`
+	Signatures            SignaturesConfig     `mapstructure:"signatures"`
+}
+
+// SignaturesConfig holds ledger event signing configuration
+type SignaturesConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	ServerKeyPath string `mapstructure:"server_key_path"` // Signs events no user performed
+	UserKeyDir    string `mapstructure:"user_key_dir"`    // Keys the server once held for users, imported as revoked
+	// RequireUserSignatures refuses requests that record a user's action without their signature
+	RequireUserSignatures bool `mapstructure:"require_user_signatures"`
+}
+
+// ReceiptsConfig holds the key ledger receipts and exports are signed with
+type ReceiptsConfig struct {
+	SigningKeyPath string `mapstructure:"signing_key_path"`
+}
+
+// LedgerTimeoutsConfig holds per-operation ledger deadlines; 0 disables a deadline
+type LedgerTimeoutsConfig struct {
+	Write      time.Duration `mapstructure:"write"`
+	Read       time.Duration `mapstructure:"read"`
+	Verify     time.Duration `mapstructure:"verify"`
+	Initialize time.Duration `mapstructure:"initialize"`
+}
+
+// OutboxConfig holds ledger outbox relay configuration
+type OutboxConfig struct {
+	RunInServer  bool          `mapstructure:"run_in_server"` // API server drains the outbox and the worker does not
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size"`
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	BaseBackoff  time.Duration `mapstructure:"base_backoff"`
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`
+	Lease        time.Duration `mapstructure:"lease"` // How long a relay holds the entries it claimed
+}
+
+// ReconciliationConfig holds ledger-vs-database reconciliation job configuration
+type ReconciliationConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Schedule string        `mapstructure:"schedule"` // Cron spec
+	Timeout  time.Duration `mapstructure:"timeout"`
+}
+
+// DigestsConfig holds ledger digest anchoring configuration
+type DigestsConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	Schedule    string        `mapstructure:"schedule"` // Cron spec
+	Timeout     time.Duration `mapstructure:"timeout"`
+	Store       string        `mapstructure:"store"`        // "local" or "minio"
+	LocalDir    string        `mapstructure:"local_dir"`    // Directory for the local store
+	MinIOPrefix string        `mapstructure:"minio_prefix"` // Object prefix for the MinIO store
 }
 
 // MinIOConfig holds MinIO configuration
@@ -200,6 +274,38 @@ func setDefaults() {
 	viper.SetDefault("immudb.port", 3322)
 	viper.SetDefault("immudb.database", "defaultdb")
 	viper.SetDefault("immudb.enabled", true)
+	viper.SetDefault("immudb.archive.enabled", true)
+	viper.SetDefault("immudb.archive.after_days", 90)
+	viper.SetDefault("immudb.archive.minio_prefix", "audit-archives/")
+
+	// Ledger defaults
+	viper.SetDefault("ledger.local_path", "./data/ledger.jsonl")
+	viper.SetDefault("ledger.write_policy", "primary")
+	viper.SetDefault("ledger.timeouts.write", "5s")
+	viper.SetDefault("ledger.timeouts.read", "10s")
+	viper.SetDefault("ledger.timeouts.verify", "60s")
+	viper.SetDefault("ledger.timeouts.initialize", "5m")
+	viper.SetDefault("ledger.outbox.run_in_server", false)
+	viper.SetDefault("ledger.outbox.poll_interval", "10s")
+	viper.SetDefault("ledger.outbox.batch_size", 100)
+	viper.SetDefault("ledger.outbox.max_attempts", 10)
+	viper.SetDefault("ledger.outbox.base_backoff", "5s")
+	viper.SetDefault("ledger.outbox.max_backoff", "15m")
+	viper.SetDefault("ledger.outbox.lease", "5m")
+	viper.SetDefault("ledger.reconciliation.enabled", true)
+	viper.SetDefault("ledger.reconciliation.schedule", "0 4 * * *")
+	viper.SetDefault("ledger.reconciliation.timeout", "1h")
+	viper.SetDefault("ledger.digests.enabled", true)
+	viper.SetDefault("ledger.digests.schedule", "@hourly")
+	viper.SetDefault("ledger.digests.timeout", "5m")
+	viper.SetDefault("ledger.digests.store", "local")
+	viper.SetDefault("ledger.digests.local_dir", "./data/ledger-digests")
+	viper.SetDefault("ledger.digests.minio_prefix", "ledger-digests/")
+	viper.SetDefault("ledger.signatures.enabled", true)
+	viper.SetDefault("ledger.signatures.server_key_path", "./data/ledger_server_key.pem")
+	viper.SetDefault("ledger.signatures.user_key_dir", "./data/user-signing-keys")
+	viper.SetDefault("ledger.signatures.require_user_signatures", false)
+	viper.SetDefault("receipts.signing_key_path", "./data/receipt_signing_key.pem")
 
 	// MinIO defaults
 	viper.SetDefault("minio.endpoint", "localhost:9000")
@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Ledger event types. The values match the Azure SQL Ledger table names, which every
+// backend reports, so HistoryQuery.EventTypes filters the same way on all of them.
+const (
+	LedgerEventItemCreation = "EquipmentEvent"
+	LedgerEventTransfer     = "TransferEvent"
+	LedgerEventStatusChange = "StatusChangeEvent"
+	LedgerEventVerification = "VerificationEvent"
+	LedgerEventMaintenance  = "MaintenanceEvent"
+	LedgerEventCorrection   = "CorrectionEvent"
+)
+
+// LedgerEvent is a ledger event in the same shape whichever backend recorded it.
+// The envelope fields are common to every event; exactly one of the detail fields,
+// the one matching EventType, is set.
+type LedgerEvent struct {
+	EventID      string           `json:"eventId"`
+	EventType    string           `json:"eventType"`             // One of the LedgerEvent* constants
+	Timestamp    time.Time        `json:"timestamp"`             // When the event was recorded
+	ActorUserID  *uint64          `json:"actorUserId,omitempty"` // User who performed or initiated the action
+	ItemID       *uint64          `json:"itemId,omitempty"`      // Not set for corrections
+	SerialNumber string           `json:"serialNumber,omitempty"`
+	Ledger       LedgerTxMetadata `json:"ledger"`
+
+	ItemCreation *ItemCreationDetails `json:"itemCreation,omitempty"`
+	Transfer     *TransferDetails     `json:"transfer,omitempty"`
+	StatusChange *StatusChangeDetails `json:"statusChange,omitempty"`
+	Verification *VerificationDetails `json:"verification,omitempty"`
+	Maintenance  *MaintenanceDetails  `json:"maintenance,omitempty"`
+	Correction   *CorrectionDetails   `json:"correction,omitempty"`
+
+	// Signature by the acting user, or by the server for system events, over the event's
+	// canonical form. Nil for events recorded without signing.
+	Signature *EventSignature `json:"signature,omitempty"`
+
+	// Corrections logged against this event, oldest first. Backends leave it empty; it is
+	// filled in by the correction-aware history views.
+	Corrections []CorrectionEvent `json:"corrections,omitempty"`
+	// SignatureStatus is one of the SignatureStatus* constants. Backends leave it empty; it
+	// is filled in by the history views.
+	SignatureStatus string `json:"signatureStatus,omitempty"`
+}
+
+// Signature verification results reported in LedgerEvent.SignatureStatus.
+const (
+	SignatureStatusVerified   = "verified"    // Signed by the signer's current key
+	SignatureStatusInvalid    = "invalid"     // Does not match the event, or names another signer
+	SignatureStatusUnknownKey = "unknown_key" // Valid, but not made with a key the signer had enrolled when the event occurred
+	SignatureStatusUnsigned   = "unsigned"
+)
+
+// Signers of ledger events.
+const (
+	SignerUser   = "user"   // The user who performed the action, signing on their own device
+	SignerServer = "server" // The server, for events no user performed
+)
+
+// EventSignature is an Ed25519 signature over a ledger event's canonical form.
+type EventSignature struct {
+	Algorithm string  `json:"algorithm"` // Always Ed25519
+	Signer    string  `json:"signer"`    // SignerUser or SignerServer
+	UserID    *uint64 `json:"userId,omitempty"`
+	KeyID     string  `json:"keyId"`
+	PublicKey string  `json:"publicKey"` // Base64 Ed25519 public key
+	Value     string  `json:"value"`     // Base64 signature
+	// Payload is the base64 canonical event a user signed, kept because the backend may
+	// record fewer details than the user saw. It is empty for server signatures, which cover
+	// the event as the backend recorded it.
+	Payload string `json:"payload,omitempty"`
+}
+
+// LedgerWriteResult identifies the event a ledger write recorded, so callers can refer to
+// it later, for example in a correction.
+type LedgerWriteResult struct {
+	EventID string           `json:"eventId"`
+	Ledger  LedgerTxMetadata `json:"ledger"`
+}
+
+// LedgerTxMetadata locates an event in the backend that recorded it.
+type LedgerTxMetadata struct {
+	Backend        string          `json:"backend"`                  // immudb, azure_sql or local
+	TransactionID  *int64          `json:"transactionId,omitempty"`  // Ledger transaction (ImmuDB tx, Azure ledger transaction, local sequence)
+	SequenceNumber *int64          `json:"sequenceNumber,omitempty"` // Position within the transaction; always 0 outside Azure SQL
+	Key            string          `json:"key,omitempty"`            // ImmuDB key of the stored event
+	Hash           string          `json:"hash,omitempty"`           // Local ledger record hash
+	Proof          json.RawMessage `json:"proof,omitempty"`          // Backend-specific inclusion proof, when one was fetched
+}
+
+// ItemCreationDetails describes an item entering (or, on Azure SQL, leaving) the ledger.
+type ItemCreationDetails struct {
+	Action      string `json:"action"` // Created or Registered; Azure SQL also records Decommissioned
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Notes       string `json:"notes,omitempty"`
+	// StateRecorded reports whether the backend recorded the item's initial status and
+	// holder. Azure SQL does not; there Status and AssignedToUserID are always empty.
+	StateRecorded    bool    `json:"stateRecorded"`
+	Status           string  `json:"status,omitempty"`
+	AssignedToUserID *uint64 `json:"assignedToUserId,omitempty"` // Nil when unassigned
+}
+
+// TransferDetails describes one stage of a transfer request.
+type TransferDetails struct {
+	TransferID      string  `json:"transferId"`
+	Status          string  `json:"status"` // Requested, Approved, Rejected, Completed or Cancelled
+	FromUserID      uint64  `json:"fromUserId"`
+	ToUserID        uint64  `json:"toUserId"`
+	ApprovingUserID *uint64 `json:"approvingUserId,omitempty"`
+	Notes           string  `json:"notes,omitempty"`
+}
+
+// StatusChangeDetails describes a change of an item's status.
+type StatusChangeDetails struct {
+	PreviousStatus string `json:"previousStatus,omitempty"`
+	NewStatus      string `json:"newStatus"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// VerificationDetails describes the result of a sensitive item check.
+type VerificationDetails struct {
+	Result string `json:"result"` // e.g. Verified Present, Missing
+	Notes  string `json:"notes,omitempty"`
+}
+
+// MaintenanceDetails describes one stage of a maintenance task.
+type MaintenanceDetails struct {
+	MaintenanceRecordID string  `json:"maintenanceRecordId"`
+	Stage               string  `json:"stage"` // Scheduled, Started, Completed, Cancelled or Reported Defect
+	MaintenanceType     string  `json:"maintenanceType,omitempty"`
+	Description         string  `json:"description,omitempty"`
+	PerformingUserID    *uint64 `json:"performingUserId,omitempty"`
+}
+
+// CorrectionDetails references the event a correction amends.
+type CorrectionDetails struct {
+	OriginalEventID   string `json:"originalEventId"`
+	OriginalEventType string `json:"originalEventType"`
+	Reason            string `json:"reason"`
+}
@@ -6,13 +6,16 @@ import (
 
 // User represents a user in the system
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Username  string    `json:"username" gorm:"uniqueIndex;not null"`
-	Password  string    `json:"-" gorm:"not null"` // Password is omitted from JSON responses
-	Name      string    `json:"name" gorm:"not null"`
-	Rank      string    `json:"rank" gorm:"not null"`
-	CreatedAt time.Time `json:"createdAt" gorm:"not null;default:CURRENT_TIMESTAMP"`
-	UpdatedAt time.Time `json:"updatedAt" gorm:"not null;default:CURRENT_TIMESTAMP"` // Added UpdatedAt for consistency
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Username     string    `json:"username" gorm:"uniqueIndex;not null"`
+	Password     string    `json:"-" gorm:"not null"` // Password is omitted from JSON responses
+	Name         string    `json:"name" gorm:"not null"`
+	Rank         string    `json:"rank" gorm:"not null"`
+	Unit         string    `json:"unit,omitempty" gorm:"column:unit"`                  // Unit the user belongs to, e.g. "B Co, 1-502 IN"
+	Role         string    `json:"role" gorm:"column:role;not null;default:user"`      // One of the UserRole* constants
+	SupervisorID *uint     `json:"supervisorId,omitempty" gorm:"column:supervisor_id"` // Immediate superior; followed upward it gives the chain of command
+	CreatedAt    time.Time `json:"createdAt" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt    time.Time `json:"updatedAt" gorm:"not null;default:CURRENT_TIMESTAMP"` // Added UpdatedAt for consistency
 }
 
 // Property represents an individual piece of property in the inventory
@@ -22,12 +25,13 @@ type Property struct {
 	Name              string     `json:"name" gorm:"not null"`                            // Retained Name for easier display, though model has name too
 	SerialNumber      string     `json:"serialNumber" gorm:"column:serial_number;uniqueIndex;not null"`
 	Description       *string    `json:"description" gorm:"default:null"`
-	CurrentStatus     string     `json:"currentStatus" gorm:"column:current_status;not null"`
-	AssignedToUserID  *uint      `json:"assignedToUserId" gorm:"column:assigned_to_user_id"` // Tracks current assigned user
+	CurrentStatus     string     `json:"currentStatus" gorm:"column:current_status;not null"` // One of the PropertyStatus* constants
+	AssignedToUserID  *uint      `json:"assignedToUserId" gorm:"column:assigned_to_user_id"`  // Tracks current assigned user
 	LastVerifiedAt    *time.Time `json:"lastVerifiedAt" gorm:"column:last_verified_at"`
 	LastMaintenanceAt *time.Time `json:"lastMaintenanceAt" gorm:"column:last_maintenance_at"`
 	CreatedAt         time.Time  `json:"createdAt" gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
 	UpdatedAt         time.Time  `json:"updatedAt" gorm:"column:updated_at;not null;default:CURRENT_TIMESTAMP"`
+	Version           uint       `json:"version" gorm:"not null;default:1"` // Incremented by every update; see VersionConflictError
 
 	// Optional: Eager/Lazy load related data with GORM tags
 	// PropertyModel     *PropertyModel `json:"propertyModel,omitempty" gorm:"foreignKey:PropertyModelID"`
@@ -65,12 +69,13 @@ type Transfer struct {
 	PropertyID   uint       `json:"propertyId" gorm:"column:property_id;not null"` // Renamed from ItemID
 	FromUserID   uint       `json:"fromUserId" gorm:"column:from_user_id;not null"`
 	ToUserID     uint       `json:"toUserId" gorm:"column:to_user_id;not null"`
-	Status       string     `json:"status" gorm:"not null"` // e.g., Requested, Approved, Completed, Rejected
+	Status       string     `json:"status" gorm:"not null"` // One of the TransferStatus* constants
 	RequestDate  time.Time  `json:"requestDate" gorm:"column:request_date;not null;default:CURRENT_TIMESTAMP"`
 	ResolvedDate *time.Time `json:"resolvedDate" gorm:"column:resolved_date"`
 	Notes        *string    `json:"notes"`
 	CreatedAt    time.Time  `json:"createdAt" gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"` // Added CreatedAt
 	UpdatedAt    time.Time  `json:"updatedAt" gorm:"column:updated_at;not null;default:CURRENT_TIMESTAMP"` // Added UpdatedAt
+	Version      uint       `json:"version" gorm:"not null;default:1"`                                     // Incremented by every update; see VersionConflictError
 
 	// Property      *Property `json:"property,omitempty" gorm:"foreignKey:PropertyID"`
 	// FromUser      *User     `json:"fromUser,omitempty" gorm:"foreignKey:FromUserID"`
@@ -95,6 +100,7 @@ type CreateUserInput struct {
 	Password string `json:"password" binding:"required"`
 	Name     string `json:"name" binding:"required"`
 	Rank     string `json:"rank" binding:"required"`
+	Unit     string `json:"unit"`
 }
 
 // CreatePropertyInput represents input for creating a property item
@@ -103,8 +109,10 @@ type CreatePropertyInput struct {
 	Name             string  `json:"name" binding:"required"`
 	SerialNumber     string  `json:"serialNumber" binding:"required"`
 	Description      *string `json:"description"`
-	CurrentStatus    string  `json:"currentStatus" binding:"required"`
+	CurrentStatus    string  `json:"currentStatus" binding:"required"` // One of the PropertyStatus* constants; Found is not allowed
 	AssignedToUserID *uint   `json:"assignedToUserId"`
+
+	LedgerSignature *LedgerSignatureInput `json:"ledgerSignature"`
 }
 
 // CreateTransferInput represents input for creating a transfer request
@@ -113,12 +121,26 @@ type CreateTransferInput struct {
 	ToUserID   uint    `json:"toUserId" binding:"required"`
 	Notes      *string `json:"notes"`
 	// FromUserID and Status will likely be set by the backend logic
+
+	LedgerSignature *LedgerSignatureInput `json:"ledgerSignature"`
 }
 
 // UpdateTransferInput represents input for updating a transfer status
 type UpdateTransferInput struct {
-	Status string  `json:"status" binding:"required"` // e.g., Approved, Rejected, Completed
-	Notes  *string `json:"notes"`
+	Status  string  `json:"status" binding:"required"` // One of the TransferStatus* constants; Completed reassigns the item
+	Notes   *string `json:"notes"`
+	Version *uint   `json:"version"` // Version of the transfer the update is based on; If-Match may be used instead
+
+	LedgerSignature *LedgerSignatureInput `json:"ledgerSignature"`
+}
+
+// LedgerSignatureInput is the acting user's signature over the ledger event their request
+// records. The client picks the event ID, builds the event the request records and signs
+// its canonical form with a key the user has enrolled.
+type LedgerSignatureInput struct {
+	EventID string `json:"eventId" binding:"required"` // UUIDv7 to record the event under
+	KeyID   string `json:"keyId" binding:"required"`
+	Value   string `json:"value" binding:"required"` // Base64 Ed25519 signature
 }
 
 // CreateActivityInput represents input for creating an activity (consider deprecating)
@@ -149,17 +171,131 @@ type CorrectionEvent struct {
 	LedgerSequenceNumber *int64 `json:"ledgerSequenceNumber,omitempty"`
 }
 
-// GeneralLedgerEvent represents a consolidated event from any ledger table,
-// structured for frontend display.
-// NOTE: This struct corresponds to the output of the LedgerService.GetGeneralHistory method.
-// The `Details` field uses `any` for flexibility, but consider more specific types if feasible.
-type GeneralLedgerEvent struct {
-	EventID              string    `json:"eventId"`          // Event ID (UNIQUEIDENTIFIER as string)
-	EventType            string    `json:"eventType"`        // e.g., 'TRANSFER_REQUEST', 'ITEM_VERIFY'
-	Timestamp            time.Time `json:"timestamp"`        // Event timestamp
-	UserID               *uint64   `json:"userId,omitempty"` // User associated (BIGINT)
-	ItemID               *uint64   `json:"itemId,omitempty"` // Item associated (BIGINT)
-	Details              any       `json:"details"`          // JSON object with event-specific details
-	LedgerTransactionID  *int64    `json:"ledgerTransactionId,omitempty"`
-	LedgerSequenceNumber *int64    `json:"ledgerSequenceNumber,omitempty"`
+// Ledger outbox entry statuses
+const (
+	OutboxStatusPending   = "pending"   // Waiting to be delivered (or retried)
+	OutboxStatusDelivered = "delivered" // Written to the ledger
+	OutboxStatusDead      = "dead"      // Gave up after the maximum number of attempts
+)
+
+// LedgerOutboxEntry is a ledger event recorded in the same database transaction as the
+// state change it describes. The worker delivers pending entries to the LedgerService,
+// so a ledger outage delays the audit trail instead of losing events from it.
+type LedgerOutboxEntry struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	EventType     string     `json:"eventType" gorm:"column:event_type;not null"`        // Ledger event kind, e.g. TransferEvent
+	EventID       string     `json:"eventId" gorm:"column:event_id;not null;default:''"` // Ledger event ID, assigned when the entry is recorded
+	ItemID        *uint      `json:"itemId" gorm:"column:item_id;index"`                 // Item the event is about; entries for one item are delivered in order
+	Payload       string     `json:"payload" gorm:"type:jsonb;not null"`                 // Arguments for the LedgerService call
+	Status        string     `json:"status" gorm:"not null;default:pending;index:idx_ledger_outbox_due,priority:1"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	LastError     *string    `json:"lastError" gorm:"column:last_error"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt" gorm:"column:next_attempt_at;not null;default:CURRENT_TIMESTAMP;index:idx_ledger_outbox_due,priority:2"`
+	LockedUntil   *time.Time `json:"lockedUntil" gorm:"column:locked_until"` // End of the lease of the relay delivering the entry
+	DeliveredAt   *time.Time `json:"deliveredAt" gorm:"column:delivered_at"`
+	CreatedAt     time.Time  `json:"createdAt" gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt     time.Time  `json:"updatedAt" gorm:"column:updated_at;not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName pins the outbox table name.
+func (LedgerOutboxEntry) TableName() string {
+	return "ledger_outbox"
+}
+
+// LedgerOutboxStats summarizes the ledger outbox backlog.
+type LedgerOutboxStats struct {
+	Pending         int64      `json:"pending"`
+	Delivered       int64      `json:"delivered"`
+	Dead            int64      `json:"dead"`
+	OldestPendingAt *time.Time `json:"oldestPendingAt,omitempty"` // CreatedAt of the oldest undelivered entry
+}
+
+// Reconciliation finding kinds
+const (
+	DriftMissingEvent   = "missing_event"   // Property has no ledger creation event
+	DriftStatusMismatch = "status_mismatch" // Ledger-derived status differs from CurrentStatus
+	DriftHolderMismatch = "holder_mismatch" // Ledger-derived holder differs from AssignedToUserID
+	DriftOrphanEvent    = "orphan_event"    // Ledger event for an item with no properties row
+)
+
+// ReconciliationFinding is one difference between the ledger and the properties table.
+type ReconciliationFinding struct {
+	Kind         string  `json:"kind"`
+	ItemID       uint64  `json:"itemId"`
+	SerialNumber string  `json:"serialNumber,omitempty"`
+	Expected     *string `json:"expected,omitempty"` // Value derived from the ledger
+	Actual       *string `json:"actual,omitempty"`   // Value in Postgres
+	EventID      string  `json:"eventId,omitempty"`  // Ledger event the finding refers to, if any
+	Message      string  `json:"message"`
+}
+
+// ReconciliationReport records one ledger-vs-database reconciliation run.
+type ReconciliationReport struct {
+	ID             uint                    `json:"id" gorm:"primaryKey"`
+	StartedAt      time.Time               `json:"startedAt" gorm:"column:started_at;not null"`
+	CompletedAt    time.Time               `json:"completedAt" gorm:"column:completed_at;not null"`
+	ItemsChecked   int                     `json:"itemsChecked" gorm:"column:items_checked;not null"`
+	EventsReplayed int                     `json:"eventsReplayed" gorm:"column:events_replayed;not null"`
+	PendingOutbox  int64                   `json:"pendingOutbox" gorm:"column:pending_outbox;not null"` // Undelivered outbox entries at the start of the run, which may explain drift
+	DriftCount     int                     `json:"driftCount" gorm:"column:drift_count;not null"`
+	Findings       []ReconciliationFinding `json:"findings" gorm:"serializer:json;type:jsonb"`
+	CreatedAt      time.Time               `json:"createdAt" gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+}
+
+// Ledger divergence statuses
+const (
+	DivergenceStatusOpen     = "open"     // The backend is still missing the write
+	DivergenceStatusRepaired = "repaired" // The write has since been applied to the backend
+)
+
+// LedgerDivergence records a ledger write that reached some backends of a multi-backend
+// ledger but failed on one, so the backends no longer hold the same events. Operation and
+// Arguments are enough to replay the write against the backend that missed it.
+type LedgerDivergence struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Backend    string     `json:"backend" gorm:"not null;index"`                  // Backend that missed the write
+	Operation  string     `json:"operation" gorm:"not null"`                      // LedgerService method, e.g. LogTransferEvent
+	Arguments  string     `json:"arguments" gorm:"type:jsonb;not null"`           // Method arguments, JSON encoded
+	EventID    string     `json:"eventId" gorm:"column:event_id"`                 // ID the other backends recorded the event under
+	Error      string     `json:"error" gorm:"not null"`                          // Error the backend returned
+	Status     string     `json:"status" gorm:"not null;default:open;index"`      // DivergenceStatus* value
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty" gorm:"column:resolved_at"` // When the divergence was repaired
+	CreatedAt  time.Time  `json:"createdAt" gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName pins the divergence table name.
+func (LedgerDivergence) TableName() string {
+	return "ledger_divergences"
+}
+
+// SigningKey is an Ed25519 public key ledger event signatures are checked against. Users
+// enroll their own keys, whose private halves never leave their devices; the server's key,
+// with no UserID, signs events no user performed. A key only verifies events signed while
+// it was valid, so retiring a key leaves the events it signed verifiable.
+type SigningKey struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    *uint      `json:"userId" gorm:"column:user_id;index"`              // Owner of the key; nil for the server
+	KeyID     string     `json:"keyId" gorm:"column:key_id;not null;uniqueIndex"` // signing.KeyID of the public key
+	PublicKey string     `json:"publicKey" gorm:"column:public_key;not null"`     // Base64
+	ValidFrom time.Time  `json:"validFrom" gorm:"column:valid_from;not null"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty" gorm:"column:revoked_at"` // End of validity, if revoked
+	CreatedAt time.Time  `json:"createdAt" gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName pins the signing key table name.
+func (SigningKey) TableName() string {
+	return "signing_keys"
+}
+
+// ValidAt reports whether the key could sign events at t.
+func (k SigningKey) ValidAt(t time.Time) bool {
+	return !t.Before(k.ValidFrom) && (k.RevokedAt == nil || t.Before(*k.RevokedAt))
+}
+
+// OwnedBy reports whether the key belongs to userID, or to the server when userID is nil.
+func (k SigningKey) OwnedBy(userID *uint64) bool {
+	if k.UserID == nil || userID == nil {
+		return k.UserID == nil && userID == nil
+	}
+	return uint64(*k.UserID) == *userID
 }
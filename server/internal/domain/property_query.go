@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Sort fields for PropertyQuery.Sort. Ties are broken by ID, so pages are stable.
+const (
+	PropertySortID             = "id"
+	PropertySortName           = "name"
+	PropertySortSerialNumber   = "serialNumber"
+	PropertySortStatus         = "currentStatus"
+	PropertySortLastVerifiedAt = "lastVerifiedAt"
+	PropertySortCreatedAt      = "createdAt"
+	PropertySortUpdatedAt      = "updatedAt"
+)
+
+// Sort directions for PropertyQuery.Order.
+const (
+	SortAscending  = "asc"
+	SortDescending = "desc"
+)
+
+const (
+	// DefaultPropertyPageSize is the page size used when PropertyQuery.Limit is zero.
+	DefaultPropertyPageSize = 50
+	// MaxPropertyPageSize caps PropertyQuery.Limit.
+	MaxPropertyPageSize = 500
+)
+
+// ErrInvalidPropertyQuery is returned (wrapped) for a property query that cannot be run.
+var ErrInvalidPropertyQuery = errors.New("invalid property query")
+
+// propertySortFields lists the accepted PropertyQuery.Sort values.
+var propertySortFields = []string{
+	PropertySortID,
+	PropertySortName,
+	PropertySortSerialNumber,
+	PropertySortStatus,
+	PropertySortLastVerifiedAt,
+	PropertySortCreatedAt,
+	PropertySortUpdatedAt,
+}
+
+// PropertyQuery filters, sorts and pages a property listing. All filters are optional and
+// combined with AND.
+type PropertyQuery struct {
+	Statuses         []string   // PropertyStatus* values; any of them matches
+	PropertyModelID  *uint      // Items of this property model
+	PropertyTypeID   *uint      // Items whose property model is of this type
+	AssignedToUserID *uint      // Current holder
+	Unit             string     // Unit of the current holder
+	VerifiedBefore   *time.Time // Items never verified, or last verified before this time
+	Search           string     // Case-insensitive match on name, serial number or description
+	Sort             string     // One of the PropertySort* fields; PropertySortID when empty
+	Order            string     // SortAscending (default) or SortDescending
+	Page             int        // 1-based page number; 0 means the first page
+	Limit            int        // Page size; 0 means DefaultPropertyPageSize
+}
+
+// PropertyPage is one page of a property listing.
+type PropertyPage struct {
+	Items []Property `json:"items"`
+	Total int64      `json:"total"` // Items matching the filters, across every page
+	Page  int        `json:"page"`
+	Limit int        `json:"limit"`
+}
+
+// Normalize applies defaults and validates the query.
+func (q PropertyQuery) Normalize() (PropertyQuery, error) {
+	for _, status := range q.Statuses {
+		if !IsPropertyStatus(status) {
+			return q, fmt.Errorf("%w: %w %q", ErrInvalidPropertyQuery, ErrInvalidPropertyStatus, status)
+		}
+	}
+
+	if q.Sort == "" {
+		q.Sort = PropertySortID
+	}
+	valid := false
+	for _, field := range propertySortFields {
+		if q.Sort == field {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return q, fmt.Errorf("%w: sort must be one of %s", ErrInvalidPropertyQuery, strings.Join(propertySortFields, ", "))
+	}
+
+	switch q.Order {
+	case "":
+		q.Order = SortAscending
+	case SortAscending, SortDescending:
+	default:
+		return q, fmt.Errorf("%w: order must be %q or %q", ErrInvalidPropertyQuery, SortAscending, SortDescending)
+	}
+
+	if q.Page < 0 || q.Limit < 0 {
+		return q, fmt.Errorf("%w: page and limit must not be negative", ErrInvalidPropertyQuery)
+	}
+	if q.Page == 0 {
+		q.Page = 1
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultPropertyPageSize
+	}
+	if q.Limit > MaxPropertyPageSize {
+		q.Limit = MaxPropertyPageSize
+	}
+	q.Search = strings.TrimSpace(q.Search)
+	return q, nil
+}
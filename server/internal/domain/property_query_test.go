@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropertyQuery_Normalize(t *testing.T) {
+	q, err := PropertyQuery{Search: "  carbine "}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, PropertySortID, q.Sort)
+	assert.Equal(t, SortAscending, q.Order)
+	assert.Equal(t, 1, q.Page)
+	assert.Equal(t, DefaultPropertyPageSize, q.Limit)
+	assert.Equal(t, "carbine", q.Search)
+
+	q, err = PropertyQuery{Limit: MaxPropertyPageSize + 1}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, MaxPropertyPageSize, q.Limit)
+
+	for _, bad := range []PropertyQuery{
+		{Statuses: []string{"maintenance"}},
+		{Sort: "password"},
+		{Order: "sideways"},
+		{Page: -1},
+	} {
+		_, err := bad.Normalize()
+		assert.ErrorIs(t, err, ErrInvalidPropertyQuery, "%+v", bad)
+	}
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Property statuses. This is the one status vocabulary for Property.CurrentStatus, the API
+// and every ledger backend; the values match the CHECK constraint on the Azure SQL Ledger
+// StatusChangeEvents table.
+const (
+	PropertyStatusOperational    = "Operational"
+	PropertyStatusNonOperational = "Non-Operational"
+	PropertyStatusDamaged        = "Damaged"
+	PropertyStatusInRepair       = "In Repair"
+	PropertyStatusLost           = "Lost"
+	PropertyStatusFound          = "Found"
+)
+
+var (
+	// ErrInvalidPropertyStatus is returned (wrapped) for a status outside the canonical set.
+	ErrInvalidPropertyStatus = errors.New("invalid property status")
+	// ErrInvalidStatusTransition is returned (wrapped) when a status may not follow the current one.
+	ErrInvalidStatusTransition = errors.New("invalid property status transition")
+)
+
+// PropertyStatuses lists the canonical statuses in display order.
+var PropertyStatuses = []string{
+	PropertyStatusOperational,
+	PropertyStatusNonOperational,
+	PropertyStatusDamaged,
+	PropertyStatusInRepair,
+	PropertyStatusLost,
+	PropertyStatusFound,
+}
+
+// propertyStatusTransitions maps each status to the statuses it may change to. A lost item
+// can only be found; a found item is then assessed like any other.
+var propertyStatusTransitions = map[string][]string{
+	PropertyStatusOperational:    {PropertyStatusNonOperational, PropertyStatusDamaged, PropertyStatusInRepair, PropertyStatusLost},
+	PropertyStatusNonOperational: {PropertyStatusOperational, PropertyStatusDamaged, PropertyStatusInRepair, PropertyStatusLost},
+	PropertyStatusDamaged:        {PropertyStatusNonOperational, PropertyStatusInRepair, PropertyStatusLost},
+	PropertyStatusInRepair:       {PropertyStatusOperational, PropertyStatusNonOperational, PropertyStatusDamaged, PropertyStatusLost},
+	PropertyStatusLost:           {PropertyStatusFound},
+	PropertyStatusFound:          {PropertyStatusOperational, PropertyStatusNonOperational, PropertyStatusDamaged, PropertyStatusInRepair},
+}
+
+// ParsePropertyStatus returns the canonical spelling of status, matched case-insensitively
+// and ignoring surrounding whitespace.
+func ParsePropertyStatus(status string) (string, error) {
+	trimmed := strings.TrimSpace(status)
+	for _, canonical := range PropertyStatuses {
+		if strings.EqualFold(trimmed, canonical) {
+			return canonical, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q: must be one of %s", ErrInvalidPropertyStatus, status, strings.Join(PropertyStatuses, ", "))
+}
+
+// IsPropertyStatus reports whether status is exactly one of the canonical statuses.
+func IsPropertyStatus(status string) bool {
+	_, ok := propertyStatusTransitions[status]
+	return ok
+}
+
+// ValidateInitialPropertyStatus checks the status a new item is created with. Found is
+// refused because an item can only be found after being reported lost.
+func ValidateInitialPropertyStatus(status string) error {
+	if !IsPropertyStatus(status) {
+		return fmt.Errorf("%w %q", ErrInvalidPropertyStatus, status)
+	}
+	if status == PropertyStatusFound {
+		return fmt.Errorf("%w: a new item cannot start as %s", ErrInvalidStatusTransition, status)
+	}
+	return nil
+}
+
+// ValidatePropertyStatusTransition checks that an item may change from one status to
+// another. Items whose current status predates the canonical set may move to any status
+// an item can be created with.
+func ValidatePropertyStatusTransition(from, to string) error {
+	if !IsPropertyStatus(to) {
+		return fmt.Errorf("%w %q", ErrInvalidPropertyStatus, to)
+	}
+	allowed, known := propertyStatusTransitions[from]
+	if !known {
+		return ValidateInitialPropertyStatus(to)
+	}
+	for _, next := range allowed {
+		if next == to {
+			return nil
+		}
+	}
+	if from == to {
+		return fmt.Errorf("%w: item is already %s", ErrInvalidStatusTransition, from)
+	}
+	return fmt.Errorf("%w: %s cannot change to %s (allowed: %s)", ErrInvalidStatusTransition, from, to, strings.Join(allowed, ", "))
+}
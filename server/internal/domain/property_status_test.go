@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePropertyStatus(t *testing.T) {
+	status, err := ParsePropertyStatus(" in repair ")
+	require.NoError(t, err)
+	assert.Equal(t, PropertyStatusInRepair, status)
+
+	_, err = ParsePropertyStatus("maintenance")
+	assert.ErrorIs(t, err, ErrInvalidPropertyStatus)
+}
+
+func TestValidatePropertyStatusTransition(t *testing.T) {
+	assert.NoError(t, ValidatePropertyStatusTransition(PropertyStatusOperational, PropertyStatusLost))
+	assert.NoError(t, ValidatePropertyStatusTransition(PropertyStatusLost, PropertyStatusFound))
+	assert.NoError(t, ValidatePropertyStatusTransition(PropertyStatusFound, PropertyStatusOperational))
+
+	// Lost can only go to Found, and only a lost item can be found
+	assert.ErrorIs(t, ValidatePropertyStatusTransition(PropertyStatusLost, PropertyStatusOperational), ErrInvalidStatusTransition)
+	assert.ErrorIs(t, ValidatePropertyStatusTransition(PropertyStatusOperational, PropertyStatusFound), ErrInvalidStatusTransition)
+	assert.ErrorIs(t, ValidatePropertyStatusTransition(PropertyStatusDamaged, PropertyStatusDamaged), ErrInvalidStatusTransition)
+	assert.ErrorIs(t, ValidatePropertyStatusTransition(PropertyStatusDamaged, "Deadline - Supply"), ErrInvalidPropertyStatus)
+
+	// Legacy statuses may move into the canonical set
+	assert.NoError(t, ValidatePropertyStatusTransition("Unknown", PropertyStatusOperational))
+	assert.ErrorIs(t, ValidatePropertyStatusTransition("Unknown", PropertyStatusFound), ErrInvalidStatusTransition)
+}
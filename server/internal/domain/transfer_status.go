@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Transfer statuses. A transfer is open while Requested or Approved; Completed moves the
+// item to the recipient, and a transfer cannot change once it is resolved. See
+// transferStatusTransitions for the order they are reached in.
+const (
+	TransferStatusRequested = "Requested"
+	TransferStatusApproved  = "Approved"
+	TransferStatusRejected  = "Rejected"
+	TransferStatusCompleted = "Completed"
+	TransferStatusCancelled = "Cancelled"
+)
+
+var (
+	// ErrInvalidTransferStatus is returned (wrapped) for a status outside TransferStatuses.
+	ErrInvalidTransferStatus = errors.New("invalid transfer status")
+	// ErrTransferClosed is returned (wrapped) when a resolved transfer is changed again.
+	ErrTransferClosed = errors.New("transfer is already resolved")
+	// ErrInvalidTransferTransition is returned (wrapped) when an open transfer is moved to a
+	// status that may not follow its current one.
+	ErrInvalidTransferTransition = errors.New("invalid transfer status transition")
+	// ErrOpenTransferExists is returned (wrapped) when an item already has an open transfer.
+	ErrOpenTransferExists = errors.New("item already has an open transfer")
+	// ErrNotPropertyHolder is returned (wrapped) when someone other than the item's current
+	// holder tries to transfer it.
+	ErrNotPropertyHolder = errors.New("only the current holder can transfer an item")
+	// ErrHolderChanged is returned (wrapped) when a transfer is completed but its sender no
+	// longer holds the item.
+	ErrHolderChanged = errors.New("item is no longer held by the transfer's sender")
+	// ErrNotTransferParty is returned (wrapped) when a user moves a transfer to a status
+	// only the other party, or neither party, may set.
+	ErrNotTransferParty = errors.New("user may not set this transfer status")
+)
+
+// TransferStatuses lists the transfer statuses in lifecycle order.
+var TransferStatuses = []string{
+	TransferStatusRequested,
+	TransferStatusApproved,
+	TransferStatusRejected,
+	TransferStatusCompleted,
+	TransferStatusCancelled,
+}
+
+// transferStatusTransitions maps each open status to the statuses it may change to. A
+// transfer is approved before it is completed, and never returns to an earlier status.
+var transferStatusTransitions = map[string][]string{
+	TransferStatusRequested: {TransferStatusApproved, TransferStatusRejected, TransferStatusCancelled},
+	TransferStatusApproved:  {TransferStatusCompleted, TransferStatusCancelled},
+}
+
+// IsTransferStatus reports whether status is one of TransferStatuses.
+func IsTransferStatus(status string) bool {
+	for _, s := range TransferStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOpenTransferStatus reports whether a transfer with status is still open.
+func IsOpenTransferStatus(status string) bool {
+	_, open := transferStatusTransitions[status]
+	return open
+}
+
+// ValidateTransferRequest checks that fromUserID may start a transfer of property: only the
+// user the item is assigned to can hand it over.
+func ValidateTransferRequest(property Property, fromUserID uint) error {
+	if property.AssignedToUserID == nil || *property.AssignedToUserID != fromUserID {
+		return fmt.Errorf("%w: item %d is not assigned to user %d", ErrNotPropertyHolder, property.ID, fromUserID)
+	}
+	return nil
+}
+
+// ValidateTransferStatusChange checks that a transfer may change from one status to another,
+// following transferStatusTransitions. Resolved transfers may not change.
+func ValidateTransferStatusChange(from, to string) error {
+	if !IsTransferStatus(to) {
+		return fmt.Errorf("%w %q: must be one of %s", ErrInvalidTransferStatus, to, strings.Join(TransferStatuses, ", "))
+	}
+	allowed, open := transferStatusTransitions[from]
+	if !open {
+		return fmt.Errorf("%w: transfer is %s", ErrTransferClosed, from)
+	}
+	for _, next := range allowed {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s transfer cannot become %s", ErrInvalidTransferTransition, from, to)
+}
+
+// AuthorizeTransferStatusChange checks that userID may move transfer to status. The
+// recipient approves, rejects and completes a transfer; the sender may only cancel it.
+func AuthorizeTransferStatusChange(transfer Transfer, status string, userID uint) error {
+	party := transfer.ToUserID
+	if status == TransferStatusCancelled {
+		party = transfer.FromUserID
+	}
+	if userID != party {
+		return fmt.Errorf("%w: user %d cannot set transfer %d to %s", ErrNotTransferParty, userID, transfer.ID, status)
+	}
+	return nil
+}
+
+// CompleteTransfer assigns property to the recipient of transfer. The item must still be
+// held by the transfer's sender.
+func CompleteTransfer(transfer Transfer, property *Property) error {
+	if property.AssignedToUserID == nil || *property.AssignedToUserID != transfer.FromUserID {
+		return fmt.Errorf("%w: item %d, sender %d", ErrHolderChanged, property.ID, transfer.FromUserID)
+	}
+	recipient := transfer.ToUserID
+	property.AssignedToUserID = &recipient
+	return nil
+}
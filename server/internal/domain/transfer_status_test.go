@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTransferRequest(t *testing.T) {
+	holder := uint(7)
+	item := Property{ID: 1, AssignedToUserID: &holder}
+
+	assert.NoError(t, ValidateTransferRequest(item, 7))
+	assert.ErrorIs(t, ValidateTransferRequest(item, 8), ErrNotPropertyHolder)
+	assert.ErrorIs(t, ValidateTransferRequest(Property{ID: 2}, 7), ErrNotPropertyHolder, "an unassigned item has no holder")
+}
+
+func TestValidateTransferStatusChange(t *testing.T) {
+	assert.NoError(t, ValidateTransferStatusChange(TransferStatusRequested, TransferStatusApproved))
+	assert.NoError(t, ValidateTransferStatusChange(TransferStatusApproved, TransferStatusCompleted))
+	assert.ErrorIs(t, ValidateTransferStatusChange(TransferStatusRequested, "Shipped"), ErrInvalidTransferStatus)
+	assert.ErrorIs(t, ValidateTransferStatusChange(TransferStatusCompleted, TransferStatusCompleted), ErrTransferClosed)
+	assert.ErrorIs(t, ValidateTransferStatusChange(TransferStatusRejected, TransferStatusApproved), ErrTransferClosed)
+	assert.ErrorIs(t, ValidateTransferStatusChange(TransferStatusCancelled, TransferStatusRequested), ErrTransferClosed)
+
+	for _, c := range []struct{ from, to string }{
+		{TransferStatusRequested, TransferStatusCancelled},
+		{TransferStatusRequested, TransferStatusRejected},
+		{TransferStatusApproved, TransferStatusCancelled},
+	} {
+		assert.NoError(t, ValidateTransferStatusChange(c.from, c.to), "%s to %s", c.from, c.to)
+	}
+	for _, c := range []struct{ from, to string }{
+		{TransferStatusRequested, TransferStatusRequested},
+		{TransferStatusRequested, TransferStatusCompleted}, // Must be approved first
+		{TransferStatusApproved, TransferStatusRequested},
+		{TransferStatusApproved, TransferStatusApproved},
+		{TransferStatusApproved, TransferStatusRejected},
+	} {
+		assert.ErrorIs(t, ValidateTransferStatusChange(c.from, c.to), ErrInvalidTransferTransition, "%s to %s", c.from, c.to)
+	}
+}
+
+func TestAuthorizeTransferStatusChange(t *testing.T) {
+	transfer := Transfer{ID: 4, FromUserID: 1, ToUserID: 2}
+	for _, status := range []string{TransferStatusApproved, TransferStatusRejected, TransferStatusCompleted} {
+		assert.NoError(t, AuthorizeTransferStatusChange(transfer, status, 2), status)
+		assert.ErrorIs(t, AuthorizeTransferStatusChange(transfer, status, 1), ErrNotTransferParty, status)
+	}
+	assert.NoError(t, AuthorizeTransferStatusChange(transfer, TransferStatusCancelled, 1))
+	assert.ErrorIs(t, AuthorizeTransferStatusChange(transfer, TransferStatusCancelled, 2), ErrNotTransferParty)
+	assert.ErrorIs(t, AuthorizeTransferStatusChange(transfer, TransferStatusApproved, 3), ErrNotTransferParty)
+}
+
+func TestCompleteTransfer(t *testing.T) {
+	sender := uint(7)
+	item := &Property{ID: 1, AssignedToUserID: &sender}
+	transfer := Transfer{PropertyID: 1, FromUserID: 7, ToUserID: 9}
+
+	require.NoError(t, CompleteTransfer(transfer, item))
+	require.NotNil(t, item.AssignedToUserID)
+	assert.Equal(t, uint(9), *item.AssignedToUserID)
+
+	// The item has already moved, so the same transfer cannot move it again
+	assert.ErrorIs(t, CompleteTransfer(transfer, item), ErrHolderChanged)
+}
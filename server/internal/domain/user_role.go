@@ -0,0 +1,13 @@
+package domain
+
+// User roles, matching the user_role enum in the database.
+const (
+	UserRoleUser            = "user"
+	UserRoleAdmin           = "admin"
+	UserRoleSuperAdmin      = "super_admin"
+	UserRolePropertyOfficer = "property_officer"
+	UserRoleCommander       = "commander"
+	// UserRoleInvestigator may view the accountability of any user, unit or item, for
+	// inquiries such as financial liability investigations of property loss.
+	UserRoleInvestigator = "investigator"
+)
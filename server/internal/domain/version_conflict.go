@@ -0,0 +1,25 @@
+package domain
+
+import "fmt"
+
+// VersionConflictError is returned when an update was based on an out-of-date version of a
+// record because someone else changed it first. Nothing is written; the caller should
+// reload the record and decide again.
+type VersionConflictError struct {
+	Entity  string // "property" or "transfer"
+	ID      uint
+	Version uint // The version the update expected
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s %d has been modified since version %d", e.Entity, e.ID, e.Version)
+}
+
+// CheckVersion returns a *VersionConflictError if the client expected a version other than
+// current. A nil expected version matches any version.
+func CheckVersion(entity string, id, current uint, expected *uint) error {
+	if expected != nil && *expected != current {
+		return &VersionConflictError{Entity: entity, ID: id, Version: *expected}
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckVersion(t *testing.T) {
+	stale, current := uint(2), uint(3)
+
+	assert.NoError(t, CheckVersion("property", 7, 3, nil), "no expected version matches any version")
+	assert.NoError(t, CheckVersion("property", 7, 3, &current))
+
+	err := CheckVersion("property", 7, 3, &stale)
+	var conflict *VersionConflictError
+	if assert.True(t, errors.As(err, &conflict)) {
+		assert.Equal(t, VersionConflictError{Entity: "property", ID: 7, Version: 2}, *conflict)
+	}
+	assert.EqualError(t, err, "property 7 has been modified since version 2")
+}
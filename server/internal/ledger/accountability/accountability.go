@@ -0,0 +1,514 @@
+// Package accountability reconstructs who held what, and in which status, at a past point
+// in time by replaying the ledger. It never reads the properties table, which only holds
+// the current state.
+package accountability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+)
+
+var (
+	// ErrInvalidRange is returned (wrapped) when since is not before at.
+	ErrInvalidRange = errors.New("invalid time range")
+	// ErrItemNotFound is returned when the ledger has no events for an item up to the requested time.
+	ErrItemNotFound = errors.New("item not found in ledger")
+	// ErrUnitNotFound is returned when no user belongs to a unit.
+	ErrUnitNotFound = errors.New("unit not found")
+	// ErrForbidden is returned (wrapped) when the viewer may not see a report.
+	ErrForbidden = errors.New("not permitted to view this accountability")
+)
+
+// Scopes of a Report.
+const (
+	ScopeUser = "user"
+	ScopeUnit = "unit"
+	ScopeItem = "item"
+)
+
+// Kinds of a Change.
+const (
+	ChangeAdded    = "added"    // The item entered the scope
+	ChangeRemoved  = "removed"  // The item left the scope
+	ChangeModified = "modified" // The item stayed in scope but its status or holder changed
+)
+
+// ItemState is an item's custody and status at a point in time, as derived from its
+// ledger events. Fields stay empty until an event establishes them, since not every
+// backend records every field.
+type ItemState struct {
+	ItemID         uint64     `json:"itemId"`
+	SerialNumber   string     `json:"serialNumber,omitempty"`
+	Name           string     `json:"name,omitempty"`
+	Status         string     `json:"status,omitempty"`
+	HolderKnown    bool       `json:"holderKnown"`            // False until an event records who holds the item
+	HolderUserID   *uint64    `json:"holderUserId,omitempty"` // Nil when unassigned or unknown
+	Decommissioned bool       `json:"decommissioned,omitempty"`
+	LastVerifiedAt *time.Time `json:"lastVerifiedAt,omitempty"`
+	LastEventID    string     `json:"lastEventId"`
+	LastEventAt    time.Time  `json:"lastEventAt"`
+}
+
+// Change is a difference in an item's state between two points in time.
+type Change struct {
+	ItemID uint64     `json:"itemId"`
+	Kind   string     `json:"kind"` // One of the Change* constants
+	Before *ItemState `json:"before,omitempty"`
+	After  *ItemState `json:"after,omitempty"`
+}
+
+// Report is the reconstructed state of a user's, unit's or item's property.
+type Report struct {
+	Scope   string      `json:"scope"` // One of the Scope* constants
+	At      time.Time   `json:"at"`
+	UserIDs []uint64    `json:"userIds,omitempty"` // The holders whose property is reported; not set for items
+	Items   []ItemState `json:"items"`             // Ordered by item ID
+
+	// Since and Changes are set when the report is compared with an earlier time.
+	Since   *time.Time `json:"since,omitempty"`
+	Changes []Change   `json:"changes,omitempty"` // Ordered by item ID
+
+	EventsReplayed int `json:"eventsReplayed"`
+}
+
+// Directory resolves users and the members of a unit. repository.Repository implements it.
+type Directory interface {
+	GetUserByID(id uint) (*domain.User, error)
+	ListUsersByUnit(unit string) ([]domain.User, error)
+}
+
+// Service reconstructs point-in-time accountability from the ledger.
+//
+// A user's accountability may be viewed by the user, by the users above them in their chain
+// of command, and by investigators. Every method takes the ID of the viewing user and
+// returns ErrForbidden when the report covers someone the viewer may not see.
+type Service struct {
+	ledger ledger.LedgerService
+	users  Directory
+}
+
+// NewService creates an accountability service.
+func NewService(ledgerService ledger.LedgerService, users Directory) *Service {
+	return &Service{ledger: ledgerService, users: users}
+}
+
+// ForUser returns the items userID held at at and, when since is set, how that changed
+// since then.
+func (s *Service) ForUser(ctx context.Context, viewerID, userID uint64, at time.Time, since *time.Time) (*Report, error) {
+	if err := checkRange(at, since); err != nil {
+		return nil, err
+	}
+	if err := s.authorize(viewerID, []uint64{userID}, nil, false); err != nil {
+		return nil, err
+	}
+	return s.forHolders(ctx, ScopeUser, []uint64{userID}, at, since)
+}
+
+// ForUnit returns the items held by the members of unit at at and, when since is set, how
+// that changed since then. The ledger does not record unit membership, so members are the
+// users who belong to the unit now. The viewer must be permitted to see every member.
+func (s *Service) ForUnit(ctx context.Context, viewerID uint64, unit string, at time.Time, since *time.Time) (*Report, error) {
+	if err := checkRange(at, since); err != nil {
+		return nil, err
+	}
+	users, err := s.users.ListUsersByUnit(unit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members of unit %q: %w", unit, err)
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrUnitNotFound, unit)
+	}
+
+	userIDs := make([]uint64, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, uint64(user.ID))
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+	if err := s.authorize(viewerID, userIDs, users, false); err != nil {
+		return nil, err
+	}
+	return s.forHolders(ctx, ScopeUnit, userIDs, at, since)
+}
+
+// ForItem returns the state of an item at at and, when since is set, how it changed since
+// then. The viewer must be permitted to see everyone who held the item over that period;
+// only investigators may see an item whose holder is not known at some point in it. It
+// returns ErrItemNotFound, to a viewer permitted to know, if the ledger has no events for
+// the item up to at.
+func (s *Service) ForItem(ctx context.Context, viewerID, itemID uint64, at time.Time, since *time.Time) (*Report, error) {
+	if err := checkRange(at, since); err != nil {
+		return nil, err
+	}
+	corrections, err := s.corrections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.itemEvents(ctx, itemID, corrections, at)
+	if err != nil {
+		return nil, err
+	}
+
+	r := newReplay()
+	before := r.replayUntil(events, since)
+	var window []ItemState // The item's states over the reported period
+	if since != nil {
+		if state, ok := before[itemID]; ok {
+			window = append(window, state)
+		}
+		for ; r.next < len(events); r.next++ {
+			r.apply(events[r.next])
+			if state, ok := r.states[itemID]; ok {
+				window = append(window, *state)
+			}
+		}
+	}
+	after := r.replayUntil(events, &at)
+	state, found := after[itemID]
+	if since == nil && found {
+		window = append(window, state)
+	}
+
+	var holders []uint64
+	holderUnknown := !found
+	for _, held := range window {
+		if held.HolderUserID == nil {
+			holderUnknown = true
+			continue
+		}
+		holders = append(holders, *held.HolderUserID)
+	}
+	if err := s.authorize(viewerID, holders, nil, holderUnknown); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: item %d", ErrItemNotFound, itemID)
+	}
+
+	report := &Report{Scope: ScopeItem, At: at, Items: []ItemState{state}, EventsReplayed: r.replayed}
+	if since != nil {
+		report.Since = since
+		report.Changes = diff(before, after)
+	}
+	return report, nil
+}
+
+// forHolders reports the items held by any of userIDs. Rather than replaying the whole
+// ledger, it replays the history of each item that reached one of the holders by at.
+func (s *Service) forHolders(ctx context.Context, scope string, userIDs []uint64, at time.Time, since *time.Time) (*Report, error) {
+	itemIDs, err := s.itemsInvolving(ctx, userIDs, at)
+	if err != nil {
+		return nil, err
+	}
+	corrections, err := s.corrections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	holders := make(map[uint64]bool, len(userIDs))
+	for _, id := range userIDs {
+		holders[id] = true
+	}
+
+	before := make(map[uint64]ItemState)
+	after := make(map[uint64]ItemState)
+	replayed := 0
+	for _, itemID := range itemIDs {
+		events, err := s.itemEvents(ctx, itemID, corrections, at)
+		if err != nil {
+			return nil, err
+		}
+
+		r := newReplay()
+		for id, state := range heldBy(r.replayUntil(events, since), holders) {
+			before[id] = state
+		}
+		for id, state := range heldBy(r.replayUntil(events, &at), holders) {
+			after[id] = state
+		}
+		replayed += r.replayed
+	}
+
+	report := &Report{Scope: scope, At: at, UserIDs: userIDs, Items: sortedStates(after), EventsReplayed: replayed}
+	if since != nil {
+		report.Since = since
+		report.Changes = diff(before, after)
+	}
+	return report, nil
+}
+
+// itemsInvolving returns the IDs of the items in the ledger events of userIDs up to and
+// including at. An item only reaches a holder through its creation or a transfer, both of
+// which the ledger indexes under the holder, so this includes every item they could hold.
+func (s *Service) itemsInvolving(ctx context.Context, userIDs []uint64, at time.Time) ([]uint64, error) {
+	until := at.Add(time.Nanosecond) // HistoryQuery.To is exclusive
+	seen := make(map[uint64]bool)
+	var itemIDs []uint64
+	for _, userID := range userIDs {
+		userID := userID
+		query := ledger.HistoryQuery{UserID: &userID, To: &until, Limit: ledger.MaxHistoryLimit, Order: ledger.SortAsc}
+		for {
+			page, err := s.ledger.GetGeneralHistory(ctx, query)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read ledger history of user %d: %w", userID, err)
+			}
+			for _, event := range page.Events {
+				if event.ItemID != nil && !seen[*event.ItemID] {
+					seen[*event.ItemID] = true
+					itemIDs = append(itemIDs, *event.ItemID)
+				}
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+	}
+	sort.Slice(itemIDs, func(i, j int) bool { return itemIDs[i] < itemIDs[j] })
+	return itemIDs, nil
+}
+
+// corrections reads every correction in the ledger, including corrections logged after the
+// reported time: the reconstruction reflects what is now known to have been true then.
+func (s *Service) corrections(ctx context.Context) ([]domain.CorrectionEvent, error) {
+	corrections, err := s.ledger.GetAllCorrectionEvents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ledger corrections: %w", err)
+	}
+	return corrections, nil
+}
+
+// itemEvents returns an item's events up to and including at, with corrections applied,
+// oldest first.
+func (s *Service) itemEvents(ctx context.Context, itemID uint64, corrections []domain.CorrectionEvent, at time.Time) ([]domain.LedgerEvent, error) {
+	history, err := s.ledger.GetItemHistory(ctx, uint(itemID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of item %d: %w", itemID, err)
+	}
+
+	events := ledger.EffectiveHistory(history, corrections)
+	kept := events[:0]
+	for _, event := range events {
+		if !event.Timestamp.After(at) {
+			kept = append(kept, event)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Timestamp.Before(kept[j].Timestamp) })
+	return kept, nil
+}
+
+// authorize returns ErrForbidden unless the viewer may see the property of every one of
+// userIDs: the viewer is an investigator, or each user is the viewer or has the viewer in
+// their chain of command. Only investigators pass when holderUnknown is set. known are
+// users already loaded, which saves looking them up.
+func (s *Service) authorize(viewerID uint64, userIDs []uint64, known []domain.User, holderUnknown bool) error {
+	users := make(map[uint64]*domain.User, len(known))
+	for i := range known {
+		users[uint64(known[i].ID)] = &known[i]
+	}
+	lookup := func(id uint64) (*domain.User, error) {
+		if user, ok := users[id]; ok {
+			return user, nil
+		}
+		user, err := s.users.GetUserByID(uint(id))
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up user %d: %w", id, err)
+		}
+		users[id] = user
+		return user, nil
+	}
+
+	viewer, err := lookup(viewerID)
+	if err != nil {
+		return err
+	}
+	if viewer == nil {
+		return fmt.Errorf("%w: unknown user %d", ErrForbidden, viewerID)
+	}
+	if viewer.Role == domain.UserRoleInvestigator {
+		return nil
+	}
+	if holderUnknown {
+		return fmt.Errorf("%w: the holder is not known", ErrForbidden)
+	}
+
+	for _, userID := range userIDs {
+		commands, err := inChainOfCommand(lookup, userID, viewerID)
+		if err != nil {
+			return err
+		}
+		if userID != viewerID && !commands {
+			return fmt.Errorf("%w: user %d is not in the chain of command of user %d", ErrForbidden, viewerID, userID)
+		}
+	}
+	return nil
+}
+
+// inChainOfCommand reports whether superiorID is above userID, following supervisors upward.
+func inChainOfCommand(lookup func(uint64) (*domain.User, error), userID, superiorID uint64) (bool, error) {
+	visited := map[uint64]bool{userID: true}
+	for id := userID; ; {
+		user, err := lookup(id)
+		if err != nil || user == nil || user.SupervisorID == nil {
+			return false, err
+		}
+		id = uint64(*user.SupervisorID)
+		if id == superiorID {
+			return true, nil
+		}
+		if visited[id] { // A cycle of supervisors
+			return false, nil
+		}
+		visited[id] = true
+	}
+}
+
+// checkRange validates the comparison time, if any.
+func checkRange(at time.Time, since *time.Time) error {
+	if since != nil && !since.Before(at) {
+		return fmt.Errorf("%w: since must be before at", ErrInvalidRange)
+	}
+	return nil
+}
+
+// replay advances item states through a sequence of events, oldest first.
+type replay struct {
+	states   map[uint64]*ItemState
+	next     int // Index of the next event to apply
+	replayed int
+}
+
+func newReplay() *replay {
+	return &replay{states: make(map[uint64]*ItemState)}
+}
+
+// replayUntil applies the remaining events up to and including until, and returns a copy of
+// every item's state at that point. A nil until applies nothing.
+func (r *replay) replayUntil(events []domain.LedgerEvent, until *time.Time) map[uint64]ItemState {
+	if until != nil {
+		for ; r.next < len(events) && !events[r.next].Timestamp.After(*until); r.next++ {
+			r.apply(events[r.next])
+		}
+	}
+
+	snapshot := make(map[uint64]ItemState, len(r.states))
+	for id, state := range r.states {
+		snapshot[id] = *state
+	}
+	return snapshot
+}
+
+// apply advances the state of the event's item.
+func (r *replay) apply(event domain.LedgerEvent) {
+	if event.ItemID == nil {
+		return
+	}
+	r.replayed++
+
+	itemID := *event.ItemID
+	state, ok := r.states[itemID]
+	if !ok {
+		state = &ItemState{ItemID: itemID}
+		r.states[itemID] = state
+	}
+	if event.SerialNumber != "" {
+		state.SerialNumber = event.SerialNumber
+	}
+	state.LastEventID = event.EventID
+	state.LastEventAt = event.Timestamp
+
+	switch {
+	case event.ItemCreation != nil:
+		creation := event.ItemCreation
+		if creation.Name != "" {
+			state.Name = creation.Name
+		}
+		switch creation.Action {
+		case "Decommissioned":
+			state.Decommissioned = true
+			state.HolderKnown = true
+			state.HolderUserID = nil
+		default:
+			if creation.StateRecorded {
+				if creation.Status != "" {
+					state.Status = creation.Status
+				}
+				state.HolderKnown = true
+				state.HolderUserID = creation.AssignedToUserID
+			}
+		}
+	case event.Transfer != nil:
+		if event.Transfer.Status == "Completed" {
+			to := event.Transfer.ToUserID
+			state.HolderKnown = true
+			state.HolderUserID = &to
+		}
+	case event.StatusChange != nil:
+		if event.StatusChange.NewStatus != "" {
+			state.Status = event.StatusChange.NewStatus
+		}
+	case event.Verification != nil:
+		verifiedAt := event.Timestamp
+		state.LastVerifiedAt = &verifiedAt
+	}
+}
+
+// heldBy returns the states of the items held by one of holders.
+func heldBy(states map[uint64]ItemState, holders map[uint64]bool) map[uint64]ItemState {
+	held := make(map[uint64]ItemState)
+	for id, state := range states {
+		if state.HolderUserID != nil && holders[*state.HolderUserID] && !state.Decommissioned {
+			held[id] = state
+		}
+	}
+	return held
+}
+
+// sortedStates returns states ordered by item ID.
+func sortedStates(states map[uint64]ItemState) []ItemState {
+	sorted := make([]ItemState, 0, len(states))
+	for _, state := range states {
+		sorted = append(sorted, state)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ItemID < sorted[j].ItemID })
+	return sorted
+}
+
+// diff reports the items that entered, left or changed within a scope between two snapshots.
+func diff(before, after map[uint64]ItemState) []Change {
+	var changes []Change
+	for id, b := range before {
+		b := b
+		a, ok := after[id]
+		switch {
+		case !ok:
+			changes = append(changes, Change{ItemID: id, Kind: ChangeRemoved, Before: &b})
+		case changed(b, a):
+			changes = append(changes, Change{ItemID: id, Kind: ChangeModified, Before: &b, After: &a})
+		}
+	}
+	for id, a := range after {
+		a := a
+		if _, ok := before[id]; !ok {
+			changes = append(changes, Change{ItemID: id, Kind: ChangeAdded, After: &a})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ItemID < changes[j].ItemID })
+	return changes
+}
+
+// changed reports whether an item's status or custody differs between two states.
+func changed(before, after ItemState) bool {
+	if before.Status != after.Status || before.HolderKnown != after.HolderKnown || before.Decommissioned != after.Decommissioned {
+		return true
+	}
+	if (before.HolderUserID == nil) != (after.HolderUserID == nil) {
+		return true
+	}
+	return before.HolderUserID != nil && *before.HolderUserID != *after.HolderUserID
+}
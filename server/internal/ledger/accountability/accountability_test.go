@@ -0,0 +1,219 @@
+package accountability
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+)
+
+func uintPtr(v uint) *uint { return &v }
+
+// fakeDirectory holds users 1 to 3, each supervising the next, an investigator (4) and a
+// user outside their chain of command (5). B Co is users 1 and 2.
+type fakeDirectory map[uint]domain.User
+
+func newFakeDirectory() fakeDirectory {
+	return fakeDirectory{
+		1: {ID: 1, Unit: "B Co", Role: domain.UserRoleCommander},
+		2: {ID: 2, Unit: "B Co", Role: domain.UserRoleUser, SupervisorID: uintPtr(1)},
+		3: {ID: 3, Unit: "C Co", Role: domain.UserRoleUser, SupervisorID: uintPtr(2)},
+		4: {ID: 4, Role: domain.UserRoleInvestigator},
+		5: {ID: 5, Unit: "D Co", Role: domain.UserRoleUser},
+	}
+}
+
+func (f fakeDirectory) GetUserByID(id uint) (*domain.User, error) {
+	user, ok := f[id]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (f fakeDirectory) ListUsersByUnit(unit string) ([]domain.User, error) {
+	var users []domain.User
+	for id := uint(1); id <= uint(len(f)); id++ {
+		if user, ok := f[id]; ok && user.Unit == unit {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// queryRecorder records the general history queries made through it.
+type queryRecorder struct {
+	ledger.LedgerService
+	queries []ledger.HistoryQuery
+}
+
+func (r *queryRecorder) GetGeneralHistory(ctx context.Context, query ledger.HistoryQuery) (*ledger.HistoryPage, error) {
+	r.queries = append(r.queries, query)
+	return r.LedgerService.GetGeneralHistory(ctx, query)
+}
+
+func newTestLedger(t *testing.T) (*ledger.LocalLedgerService, time.Time) {
+	ctx := context.Background()
+	svc, err := ledger.NewLocalLedgerService(filepath.Join(t.TempDir(), "ledger.jsonl"))
+	require.NoError(t, err)
+	require.NoError(t, svc.Initialize(ctx))
+	t.Cleanup(func() { svc.Close() })
+
+	_, err = svc.LogItemCreation(ctx, domain.Property{ID: 1, SerialNumber: "SN-1", Name: "M4 Carbine", CurrentStatus: "Operational", AssignedToUserID: uintPtr(1)}, 1, ledger.WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogItemCreation(ctx, domain.Property{ID: 2, SerialNumber: "SN-2", Name: "PVS-14", CurrentStatus: "Operational", AssignedToUserID: uintPtr(2)}, 1, ledger.WriteOptions{})
+	require.NoError(t, err)
+	// Later: item 1 is handed to user 2 and item 2 is damaged
+	_, err = svc.LogTransferEvent(ctx, domain.Transfer{ID: 5, PropertyID: 1, FromUserID: 1, ToUserID: 2, Status: "Completed"}, "SN-1", 1, ledger.WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogStatusChange(ctx, 2, "SN-2", "Operational", "Damaged", 2, ledger.WriteOptions{})
+	require.NoError(t, err)
+	// A transfer to user 3 that was logged in error and corrected
+	_, err = svc.LogTransferEvent(ctx, domain.Transfer{ID: 6, PropertyID: 2, FromUserID: 2, ToUserID: 3, Status: "Completed"}, "SN-2", 2, ledger.WriteOptions{})
+	require.NoError(t, err)
+
+	history, err := svc.GetItemHistory(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	_, err = svc.LogCorrectionEvent(ctx, history[2].EventID, domain.LedgerEventTransfer, "wrong recipient", 1, ledger.WriteOptions{})
+	require.NoError(t, err)
+
+	return svc, history[0].Timestamp // Both items created, nothing else happened yet
+}
+
+func TestService_ReconstructsPointInTime(t *testing.T) {
+	ctx := context.Background()
+	svc, before := newTestLedger(t)
+	now := time.Now()
+
+	recorder := &queryRecorder{LedgerService: svc}
+	service := NewService(recorder, newFakeDirectory())
+	const viewer = 4 // An investigator
+
+	report, err := service.ForUser(ctx, viewer, 2, before, nil)
+	require.NoError(t, err)
+	require.Len(t, report.Items, 1)
+	assert.Equal(t, uint64(2), report.Items[0].ItemID)
+	assert.Equal(t, "Operational", report.Items[0].Status)
+	assert.Equal(t, "PVS-14", report.Items[0].Name)
+
+	report, err = service.ForUser(ctx, viewer, 2, now, &before)
+	require.NoError(t, err)
+	require.Len(t, report.Items, 2, "the corrected transfer to user 3 is ignored")
+	assert.Equal(t, "Damaged", report.Items[1].Status)
+	require.Len(t, report.Changes, 2)
+	assert.Equal(t, ChangeAdded, report.Changes[0].Kind)
+	assert.Equal(t, uint64(1), report.Changes[0].ItemID)
+	assert.Equal(t, ChangeModified, report.Changes[1].Kind)
+	assert.Equal(t, "Operational", report.Changes[1].Before.Status)
+
+	report, err = service.ForUser(ctx, viewer, 1, now, &before)
+	require.NoError(t, err)
+	assert.Empty(t, report.Items)
+	require.Len(t, report.Changes, 1)
+	assert.Equal(t, ChangeRemoved, report.Changes[0].Kind)
+
+	report, err = service.ForUser(ctx, viewer, 3, now, nil)
+	require.NoError(t, err)
+	assert.Empty(t, report.Items)
+
+	// Moving within the unit is a modification, not a removal
+	report, err = service.ForUnit(ctx, viewer, "B Co", now, &before)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2}, report.UserIDs)
+	require.Len(t, report.Items, 2)
+	require.Len(t, report.Changes, 2)
+	assert.Equal(t, ChangeModified, report.Changes[0].Kind)
+	assert.Equal(t, uint64(1), *report.Changes[0].Before.HolderUserID)
+	assert.Equal(t, uint64(2), *report.Changes[0].After.HolderUserID)
+
+	report, err = service.ForItem(ctx, viewer, 1, before, nil)
+	require.NoError(t, err)
+	require.Len(t, report.Items, 1)
+	assert.True(t, report.Items[0].HolderKnown)
+	assert.Equal(t, uint64(1), *report.Items[0].HolderUserID)
+
+	_, err = service.ForItem(ctx, viewer, 99, now, nil)
+	assert.ErrorIs(t, err, ErrItemNotFound)
+	_, err = service.ForItem(ctx, viewer, 1, before.Add(-time.Hour), nil)
+	assert.ErrorIs(t, err, ErrItemNotFound, "the item did not exist yet")
+	_, err = service.ForUnit(ctx, viewer, "E Co", now, nil)
+	assert.ErrorIs(t, err, ErrUnitNotFound)
+	_, err = service.ForUser(ctx, viewer, 1, before, &now)
+	assert.ErrorIs(t, err, ErrInvalidRange)
+
+	for _, query := range recorder.queries {
+		assert.NotNil(t, query.UserID, "reports read the holders' histories, not the whole ledger")
+	}
+}
+
+func TestService_RestrictsToChainOfCommand(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestLedger(t)
+	service := NewService(svc, newFakeDirectory())
+	now := time.Now()
+
+	// Users see themselves, and superiors see everyone below them
+	for _, c := range []struct{ viewer, user uint64 }{{3, 3}, {2, 3}, {1, 3}, {4, 3}} {
+		_, err := service.ForUser(ctx, c.viewer, c.user, now, nil)
+		assert.NoError(t, err, "user %d viewing user %d", c.viewer, c.user)
+	}
+	for _, c := range []struct{ viewer, user uint64 }{{3, 2}, {5, 3}, {99, 3}} {
+		_, err := service.ForUser(ctx, c.viewer, c.user, now, nil)
+		assert.ErrorIs(t, err, ErrForbidden, "user %d viewing user %d", c.viewer, c.user)
+	}
+
+	_, err := service.ForUnit(ctx, 1, "B Co", now, nil)
+	assert.NoError(t, err)
+	_, err = service.ForUnit(ctx, 2, "B Co", now, nil)
+	assert.ErrorIs(t, err, ErrForbidden, "user 2 does not command user 1")
+
+	// Item 1 is held by user 2
+	_, err = service.ForItem(ctx, 1, 1, now, nil)
+	assert.NoError(t, err)
+	_, err = service.ForItem(ctx, 5, 1, now, nil)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// Only investigators learn whether an item exists
+	_, err = service.ForItem(ctx, 1, 99, now, nil)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = service.ForItem(ctx, 4, 99, now, nil)
+	assert.ErrorIs(t, err, ErrItemNotFound)
+}
+
+func TestService_ItemReportsCoverEveryHolder(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestLedger(t)
+	service := NewService(svc, newFakeDirectory())
+	t0 := time.Now().Add(time.Minute)
+	at := func(d time.Duration) ledger.WriteOptions { return ledger.WriteOptions{OccurredAt: t0.Add(d)} }
+
+	// Item 3 is unassigned. Item 4 passes from user 2 to user 5, outside user 1's command, and back.
+	_, err := svc.LogItemCreation(ctx, domain.Property{ID: 3, SerialNumber: "SN-3", CurrentStatus: "Operational"}, 1, at(time.Second))
+	require.NoError(t, err)
+	_, err = svc.LogItemCreation(ctx, domain.Property{ID: 4, SerialNumber: "SN-4", CurrentStatus: "Operational", AssignedToUserID: uintPtr(2)}, 1, at(2*time.Second))
+	require.NoError(t, err)
+	_, err = svc.LogTransferEvent(ctx, domain.Transfer{ID: 7, PropertyID: 4, FromUserID: 2, ToUserID: 5, Status: "Completed"}, "SN-4", 5, at(4*time.Second))
+	require.NoError(t, err)
+	_, err = svc.LogTransferEvent(ctx, domain.Transfer{ID: 8, PropertyID: 4, FromUserID: 5, ToUserID: 2, Status: "Completed"}, "SN-4", 2, at(6*time.Second))
+	require.NoError(t, err)
+	end, since := t0.Add(10*time.Second), t0.Add(3*time.Second)
+
+	_, err = service.ForItem(ctx, 1, 3, end, nil)
+	assert.ErrorIs(t, err, ErrForbidden, "an item with no known holder")
+	_, err = service.ForItem(ctx, 4, 3, end, nil)
+	assert.NoError(t, err)
+
+	_, err = service.ForItem(ctx, 1, 4, end, nil)
+	assert.NoError(t, err, "user 2 holds the item at the end")
+	_, err = service.ForItem(ctx, 1, 4, end, &since)
+	assert.ErrorIs(t, err, ErrForbidden, "user 5 held the item in between")
+	report, err := service.ForItem(ctx, 4, 4, end, &since)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), *report.Items[0].HolderUserID)
+}
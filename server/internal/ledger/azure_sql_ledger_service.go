@@ -4,12 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
-	_ "github.com/microsoft/go-mssqldb" // Azure SQL Database driver
+	mssql "github.com/microsoft/go-mssqldb" // Azure SQL Database driver
 	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/receipt"
 	// Consider using Viper for configuration management if not already set up globally
 )
 
@@ -18,13 +21,18 @@ var _ LedgerService = (*AzureSqlLedgerService)(nil)
 
 // AzureSqlLedgerService implements the LedgerService interface for Azure SQL Database Ledger.
 type AzureSqlLedgerService struct {
-	db *sql.DB // Standard SQL database connection pool
-	// Add configuration fields if needed (e.g., table names)
+	db         *sql.DB         // Standard SQL database connection pool
+	schemaMode AzureSchemaMode // What Initialize does with the HandReceipt schema
+	signer     *EventSigner    // Signs each event; nil leaves events unsigned
 }
 
 // NewAzureSqlLedgerService creates a new Azure SQL Database Ledger service.
 // It requires database connection details, likely fetched from configuration.
-func NewAzureSqlLedgerService(connectionString string) (*AzureSqlLedgerService, error) {
+func NewAzureSqlLedgerService(connectionString string, schemaMode AzureSchemaMode) (*AzureSqlLedgerService, error) {
+	if _, err := ParseAzureSchemaMode(string(schemaMode)); err != nil {
+		return nil, err
+	}
+
 	// TODO: Make sure the correct Azure SQL driver is imported above
 	// Use sql.Open() with the appropriate driver name ("sqlserver" for go-mssqldb)
 	db, err := sql.Open("sqlserver", connectionString) // Replace "sqlserver" if using a different driver name
@@ -43,26 +51,96 @@ func NewAzureSqlLedgerService(connectionString string) (*AzureSqlLedgerService,
 	log.Println("Successfully connected to Azure SQL Database")
 
 	service := &AzureSqlLedgerService{
-		db: db,
+		db:         db,
+		schemaMode: schemaMode,
 	}
 
 	return service, nil
 }
 
-// Initialize performs any setup needed for the ledger service.
-// For Azure SQL, this might involve ensuring ledger tables exist or are configured.
-func (s *AzureSqlLedgerService) Initialize() error {
-	// TODO: Optionally, check if required ledger tables exist and create/configure if needed.
-	// This might involve executing `CREATE TABLE ... WITH (SYSTEM_VERSIONING = ON, LEDGER = ON)` statements.
-	// Be cautious about auto-creating tables in production environments.
-	log.Println("AzureSqlLedgerService Initialize: No specific initialization actions implemented yet.")
-	return nil
+// SetEventSigner makes the ledger sign the system events it writes from now on. Signatures
+// are stored in HandReceipt.EventSignatures, created by schema version 2; the payloads users
+// sign need schema version 3. Call it before the
+// service is shared between goroutines.
+func (s *AzureSqlLedgerService) SetEventSigner(signer *EventSigner) {
+	s.signer = signer
+}
+
+// queryer runs queries on a connection pool or in a transaction.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// insertEvent runs an INSERT into the table of an event of eventType, which must set EventID
+// from the @EventID parameter, and stores the event's signature in the same transaction:
+// the user's signature from opts, or, when events are signed, the server's signature of a
+// system event. The server signs the event as GetEvent reads it back, so the signature
+// covers exactly what readers see.
+func (s *AzureSqlLedgerService) insertEvent(ctx context.Context, opts WriteOptions, eventType string, insert string, args ...interface{}) (*domain.LedgerWriteResult, error) {
+	eventID, preassigned := opts.eventID()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if preassigned {
+		existing, err := queryEvent(ctx, tx, eventID)
+		if err == nil {
+			return existingWrite(existing, eventType)
+		}
+		if !errors.Is(err, ErrEventNotFound) {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, insert, append(args, sql.Named("EventID", eventID), sql.Named("OccurredAt", opts.timestamp()))...); err != nil {
+		return nil, err
+	}
+	event, err := queryEvent(ctx, tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := opts.signature(s.signer, *event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign event %s: %w", eventID, err)
+	}
+	if signature != nil {
+		var signerUserID sql.NullInt64
+		if signature.UserID != nil {
+			signerUserID = sql.NullInt64{Int64: int64(*signature.UserID), Valid: true}
+		}
+		var payload sql.NullString
+		if signature.Payload != "" {
+			payload = sql.NullString{String: signature.Payload, Valid: true}
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO HandReceipt.EventSignatures (EventID, Algorithm, Signer, SignerUserID, KeyID, PublicKey, Signature, SignedPayload)
+			 VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8)`,
+			eventID, signature.Algorithm, signature.Signer, signerUserID, signature.KeyID, signature.PublicKey, signature.Value, payload,
+		); err != nil {
+			return nil, fmt.Errorf("failed to store signature of event %s: %w", eventID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &domain.LedgerWriteResult{EventID: eventID, Ledger: event.Ledger}, nil
+}
+
+// Initialize applies or verifies the HandReceipt ledger schema, as the schema mode given to
+// NewAzureSqlLedgerService says. It returns an error wrapping ErrIncompatibleSchema if the
+// database holds a schema version this build cannot use.
+func (s *AzureSqlLedgerService) Initialize(ctx context.Context) error {
+	return s.ensureSchema(ctx, s.schemaMode)
 }
 
 // LogItemCreation logs an equipment creation/registration event to the Azure SQL Ledger.
 // This implementation assumes the event type is 'Created' based on the function name.
-func (s *AzureSqlLedgerService) LogItemCreation(property domain.Property, userID uint) error {
-	ctx := context.Background() // Or use a more specific context if available
+func (s *AzureSqlLedgerService) LogItemCreation(ctx context.Context, property domain.Property, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
 	log.Printf("AzureSqlLedgerService: Logging Equipment Event - ItemID: %d, UserID: %d, Type: Created", property.ID, userID)
 
 	// EventType is hardcoded to 'Created' for this function
@@ -70,9 +148,9 @@ func (s *AzureSqlLedgerService) LogItemCreation(property domain.Property, userID
 	// Notes are not provided by the interface, setting to NULL
 	var notes sql.NullString
 
-	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO HandReceipt.EquipmentEvents (ItemID, PerformingUserID, EventType, Notes, EventTimestamp)
-		 VALUES (@p1, @p2, @p3, @p4, SYSUTCDATETIME())`, // Use SYSUTCDATETIME() for DB-generated timestamp
+	result, err := s.insertEvent(ctx, opts, domain.LedgerEventItemCreation,
+		`INSERT INTO HandReceipt.EquipmentEvents (EventID, ItemID, PerformingUserID, EventType, Notes, EventTimestamp)
+		 VALUES (@EventID, @p1, @p2, @p3, @p4, @OccurredAt)`,
 		property.ID, // Get ItemID from the domain.Property object
 		userID,      // UserID passed as argument
 		eventType,
@@ -81,16 +159,15 @@ func (s *AzureSqlLedgerService) LogItemCreation(property domain.Property, userID
 
 	if err != nil {
 		log.Printf("Error logging Equipment Event to Azure SQL Ledger: %v", err)
-		return fmt.Errorf("failed to log Equipment Event: %w", err)
+		return nil, fmt.Errorf("failed to log Equipment Event: %w", err)
 	}
 	log.Printf("Successfully logged Equipment Event - ItemID: %d, Type: %s", property.ID, eventType)
-	return nil
+	return result, nil
 }
 
 // LogTransferEvent logs a specific stage of an equipment transfer to the Azure SQL Ledger.
 // It uses the transfer.Status as the EventType for the ledger entry.
-func (s *AzureSqlLedgerService) LogTransferEvent(transfer domain.Transfer, serialNumber string) error {
-	ctx := context.Background()
+func (s *AzureSqlLedgerService) LogTransferEvent(ctx context.Context, transfer domain.Transfer, serialNumber string, actorUserID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
 	eventType := transfer.Status // Map domain.Transfer.Status to EventType
 	// Use transfer.ID as the grouping identifier for the request. Convert uint to string.
 	transferRequestID := fmt.Sprintf("%d", transfer.ID)
@@ -100,13 +177,12 @@ func (s *AzureSqlLedgerService) LogTransferEvent(transfer domain.Transfer, seria
 	// Validate EventType (derived from transfer.Status) against allowed values in the schema
 	allowedTypes := map[string]bool{"Requested": true, "Approved": true, "Rejected": true, "Completed": true, "Cancelled": true}
 	if !allowedTypes[eventType] {
-		return fmt.Errorf("invalid EventType (from transfer.Status) '%s' for TransferEvents", eventType)
+		return nil, fmt.Errorf("invalid EventType (from transfer.Status) '%s' for TransferEvents", eventType)
 	}
 
-	// Assumptions:
-	// - InitiatingUserID is the FromUserID for this event log.
-	// - ApprovingUserID is not provided by the interface, setting to NULL.
-	initiatingUserID := transfer.FromUserID
+	// InitiatingUserID is the user who performed this step of the transfer. ApprovingUserID
+	// is not provided by the interface, setting to NULL.
+	initiatingUserID := actorUserID
 	var approvingUserID sql.NullInt64 // Set to NULL
 	// Handle optional notes from domain.Transfer
 	notesDB := sql.NullString{}
@@ -115,37 +191,35 @@ func (s *AzureSqlLedgerService) LogTransferEvent(transfer domain.Transfer, seria
 		notesDB.Valid = true
 	}
 
-	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO HandReceipt.TransferEvents (TransferRequestID, ItemID, FromUserID, ToUserID, InitiatingUserID, ApprovingUserID, EventType, Notes, EventTimestamp)
-		 VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, SYSUTCDATETIME())`,
+	result, err := s.insertEvent(ctx, opts, domain.LedgerEventTransfer,
+		`INSERT INTO HandReceipt.TransferEvents (EventID, TransferRequestID, ItemID, FromUserID, ToUserID, InitiatingUserID, ApprovingUserID, EventType, Notes, EventTimestamp)
+		 VALUES (@EventID, @p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, @OccurredAt)`,
 		transferRequestID,
 		transfer.PropertyID,
 		transfer.FromUserID,
 		transfer.ToUserID,
-		initiatingUserID, // Assumed initiator
-		approvingUserID,  // Assumed NULL approver
+		initiatingUserID,
+		approvingUserID, // Assumed NULL approver
 		eventType,
 		notesDB, // Use sql.NullString for nullable notes
 	)
 
 	if err != nil {
 		log.Printf("Error logging Transfer Event to Azure SQL Ledger: %v", err)
-		return fmt.Errorf("failed to log Transfer Event: %w", err)
+		return nil, fmt.Errorf("failed to log Transfer Event: %w", err)
 	}
 	log.Printf("Successfully logged Transfer Event - RequestID: %s, ItemID: %d, Type: %s", transferRequestID, transfer.PropertyID, eventType)
-	return nil
+	return result, nil
 }
 
 // LogStatusChange logs a status change event for an item to the Azure SQL Ledger.
-func (s *AzureSqlLedgerService) LogStatusChange(itemID uint, serialNumber string, oldStatus string, newStatus string, userID uint) error {
-	ctx := context.Background() // Or use a more specific context if available
+func (s *AzureSqlLedgerService) LogStatusChange(ctx context.Context, itemID uint, serialNumber string, oldStatus string, newStatus string, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
 	// Log using provided parameters, including serialNumber even if not directly inserted
 	log.Printf("AzureSqlLedgerService: Logging Status Change - ItemID: %d, SN: %s, UserID: %d, FromStatus: %s, ToStatus: %s", itemID, serialNumber, userID, oldStatus, newStatus)
 
-	// Validate NewStatus against allowed values in the schema
-	allowedStatuses := map[string]bool{"Operational": true, "Non-Operational": true, "Damaged": true, "Lost": true, "Found": true, "In Repair": true}
-	if !allowedStatuses[newStatus] {
-		return fmt.Errorf("invalid NewStatus '%s' for StatusChangeEvents", newStatus)
+	// The schema's CHECK constraint on NewStatus holds the canonical property statuses
+	if !domain.IsPropertyStatus(newStatus) {
+		return nil, fmt.Errorf("%w '%s' for StatusChangeEvents", domain.ErrInvalidPropertyStatus, newStatus)
 	}
 
 	// Prepare parameters for DB insertion
@@ -153,9 +227,9 @@ func (s *AzureSqlLedgerService) LogStatusChange(itemID uint, serialNumber string
 	// Reason is not provided by the interface, set to NULL
 	var reasonDB sql.NullString
 
-	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO HandReceipt.StatusChangeEvents (ItemID, ReportingUserID, PreviousStatus, NewStatus, Reason, ChangeTimestamp)
-		 VALUES (@p1, @p2, @p3, @p4, @p5, SYSUTCDATETIME())`,
+	result, err := s.insertEvent(ctx, opts, domain.LedgerEventStatusChange,
+		`INSERT INTO HandReceipt.StatusChangeEvents (EventID, ItemID, ReportingUserID, PreviousStatus, NewStatus, Reason, ChangeTimestamp)
+		 VALUES (@EventID, @p1, @p2, @p3, @p4, @p5, @OccurredAt)`,
 		itemID,
 		userID,           // Map userID from interface to ReportingUserID
 		previousStatusDB, // Use sql.NullString for nullable PreviousStatus
@@ -165,16 +239,15 @@ func (s *AzureSqlLedgerService) LogStatusChange(itemID uint, serialNumber string
 
 	if err != nil {
 		log.Printf("Error logging Status Change event to Azure SQL Ledger: %v", err)
-		return fmt.Errorf("failed to log Status Change event: %w", err)
+		return nil, fmt.Errorf("failed to log Status Change event: %w", err)
 	}
 	log.Printf("Successfully logged Status Change - ItemID: %d, NewStatus: %s", itemID, newStatus)
-	return nil
+	return result, nil
 }
 
 // LogVerificationEvent logs a verification event for an item to the Azure SQL Ledger.
 // Maps the interface's verificationType to the DB's VerificationStatus.
-func (s *AzureSqlLedgerService) LogVerificationEvent(itemID uint, serialNumber string, userID uint, verificationType string) error {
-	ctx := context.Background()            // Or use a more specific context if available
+func (s *AzureSqlLedgerService) LogVerificationEvent(ctx context.Context, itemID uint, serialNumber string, userID uint, verificationType string, opts WriteOptions) (*domain.LedgerWriteResult, error) {
 	verificationStatus := verificationType // Map interface param to DB column meaning
 
 	log.Printf("AzureSqlLedgerService: Logging Verification Event - ItemID: %d, SN: %s, UserID: %d, Status(Type): %s", itemID, serialNumber, userID, verificationStatus)
@@ -184,15 +257,15 @@ func (s *AzureSqlLedgerService) LogVerificationEvent(itemID uint, serialNumber s
 	if !allowedStatuses[verificationStatus] {
 		// Allow any string if validation needs to be less strict? Or return error?
 		// Returning error for now to enforce schema constraints.
-		return fmt.Errorf("invalid VerificationStatus (from verificationType) '%s' for VerificationEvents", verificationStatus)
+		return nil, fmt.Errorf("invalid VerificationStatus (from verificationType) '%s' for VerificationEvents", verificationStatus)
 	}
 
 	// Notes are not provided by the interface, setting to NULL
 	var notesDB sql.NullString
 
-	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO HandReceipt.VerificationEvents (ItemID, VerifyingUserID, VerificationStatus, Notes, VerificationTimestamp)
-		 VALUES (@p1, @p2, @p3, @p4, SYSUTCDATETIME())`,
+	result, err := s.insertEvent(ctx, opts, domain.LedgerEventVerification,
+		`INSERT INTO HandReceipt.VerificationEvents (EventID, ItemID, VerifyingUserID, VerificationStatus, Notes, VerificationTimestamp)
+		 VALUES (@EventID, @p1, @p2, @p3, @p4, @OccurredAt)`,
 		itemID,
 		userID, // Map userID from interface to VerifyingUserID
 		verificationStatus,
@@ -201,21 +274,20 @@ func (s *AzureSqlLedgerService) LogVerificationEvent(itemID uint, serialNumber s
 
 	if err != nil {
 		log.Printf("Error logging Verification event to Azure SQL Ledger: %v", err)
-		return fmt.Errorf("failed to log Verification event: %w", err)
+		return nil, fmt.Errorf("failed to log Verification event: %w", err)
 	}
 	log.Printf("Successfully logged Verification Event - ItemID: %d, Status: %s", itemID, verificationStatus)
-	return nil
+	return result, nil
 }
 
 // LogMaintenanceEvent logs a maintenance event for an item to the Azure SQL Ledger.
-func (s *AzureSqlLedgerService) LogMaintenanceEvent(maintenanceRecordID string, itemID uint, initiatingUserID uint, performingUserID sql.NullInt64, eventType string, maintenanceType sql.NullString, description string) error {
-	ctx := context.Background()
+func (s *AzureSqlLedgerService) LogMaintenanceEvent(ctx context.Context, maintenanceRecordID string, itemID uint, initiatingUserID uint, performingUserID sql.NullInt64, eventType string, maintenanceType sql.NullString, description string, opts WriteOptions) (*domain.LedgerWriteResult, error) {
 	log.Printf("AzureSqlLedgerService: Logging Maintenance Event - RecordID: %s, ItemID: %d, Type: %s", maintenanceRecordID, itemID, eventType)
 
 	// Validate EventType against allowed values
 	allowedTypes := map[string]bool{"Scheduled": true, "Started": true, "Completed": true, "Cancelled": true, "Reported Defect": true}
 	if !allowedTypes[eventType] {
-		return fmt.Errorf("invalid EventType '%s' for MaintenanceEvents", eventType)
+		return nil, fmt.Errorf("invalid EventType '%s' for MaintenanceEvents", eventType)
 	}
 
 	// Ensure performingUserID is null if not applicable
@@ -223,9 +295,9 @@ func (s *AzureSqlLedgerService) LogMaintenanceEvent(maintenanceRecordID string,
 		performingUserID = sql.NullInt64{}
 	}
 
-	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO HandReceipt.MaintenanceEvents (MaintenanceRecordID, ItemID, InitiatingUserID, PerformingUserID, EventType, MaintenanceType, Description, EventTimestamp)
-		 VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, SYSUTCDATETIME())`,
+	result, err := s.insertEvent(ctx, opts, domain.LedgerEventMaintenance,
+		`INSERT INTO HandReceipt.MaintenanceEvents (EventID, MaintenanceRecordID, ItemID, InitiatingUserID, PerformingUserID, EventType, MaintenanceType, Description, EventTimestamp)
+		 VALUES (@EventID, @p1, @p2, @p3, @p4, @p5, @p6, @p7, @OccurredAt)`,
 		maintenanceRecordID,
 		itemID,
 		initiatingUserID,
@@ -237,10 +309,10 @@ func (s *AzureSqlLedgerService) LogMaintenanceEvent(maintenanceRecordID string,
 
 	if err != nil {
 		log.Printf("Error logging Maintenance event to Azure SQL Ledger: %v", err)
-		return fmt.Errorf("failed to log Maintenance event: %w", err)
+		return nil, fmt.Errorf("failed to log Maintenance event: %w", err)
 	}
 	log.Printf("Successfully logged Maintenance Event - RecordID: %s, ItemID: %d, Type: %s", maintenanceRecordID, itemID, eventType)
-	return nil
+	return result, nil
 }
 
 // LogCorrectionEvent logs a correction event referencing a previous ledger event.
@@ -251,20 +323,22 @@ func (s *AzureSqlLedgerService) LogMaintenanceEvent(maintenanceRecordID string,
 //     referencing the transaction ID or EventID of the record being corrected.
 //
 // This function implements Strategy A (Separate Correction Table).
-func (s *AzureSqlLedgerService) LogCorrectionEvent(originalEventID string, eventType string, reason string, userID uint) error {
-	ctx := context.Background()
+func (s *AzureSqlLedgerService) LogCorrectionEvent(ctx context.Context, originalEventID string, eventType string, reason string, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
 	log.Printf("AzureSqlLedgerService: Logging correction event for Original Event: %s (Type: %s) by UserID: %d", originalEventID, eventType, userID)
 
 	// Basic validation (consider adding more robust validation if needed)
 	if originalEventID == "" || eventType == "" || reason == "" {
-		return fmt.Errorf("missing required parameters for correction event")
+		return nil, fmt.Errorf("missing required parameters for correction event")
+	}
+	if err := checkCorrectionTarget(ctx, s, originalEventID, eventType); err != nil {
+		return nil, err
 	}
 
 	// Assuming OriginalEventID is provided as a string representation of a UNIQUEIDENTIFIER
 	// SQL Server will handle the conversion if the string format is correct.
-	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO HandReceipt.CorrectionEvents (OriginalEventID, OriginalEventType, Reason, CorrectingUserID, CorrectionTimestamp)
-		 VALUES (@p1, @p2, @p3, @p4, SYSUTCDATETIME())`,
+	result, err := s.insertEvent(ctx, opts, domain.LedgerEventCorrection,
+		`INSERT INTO HandReceipt.CorrectionEvents (EventID, OriginalEventID, OriginalEventType, Reason, CorrectingUserID, CorrectionTimestamp)
+		 VALUES (@EventID, @p1, @p2, @p3, @p4, @OccurredAt)`,
 		originalEventID,
 		eventType,
 		reason,
@@ -273,108 +347,56 @@ func (s *AzureSqlLedgerService) LogCorrectionEvent(originalEventID string, event
 
 	if err != nil {
 		log.Printf("Error logging correction event to Azure SQL Ledger: %v", err)
-		return fmt.Errorf("failed to log correction event: %w", err)
+		return nil, fmt.Errorf("failed to log correction event: %w", err)
 	}
 
 	log.Printf("Successfully logged correction event for Original Event ID: %s", originalEventID)
-	return nil
+	return result, nil
 }
 
-// GetItemHistory retrieves the history of an item from the Azure SQL Ledger tables based on its ItemID.
-func (s *AzureSqlLedgerService) GetItemHistory(itemID uint) ([]map[string]interface{}, error) {
-	ctx := context.Background()
-	log.Printf("AzureSqlLedgerService: Getting history for ItemID: %d", itemID)
-	var history []map[string]interface{}
-
-	// Query across all relevant ledger history views using UNION ALL.
-	// Filter each part of the UNION by ItemID.
-	// Order the final result set by EventTimestamp.
-	query := `
-	SELECT
-		'EquipmentEvent' as RecordType, EventID, ItemID, PerformingUserID, EventTimestamp, EventType, Notes,
-		ledger_transaction_id, ledger_sequence_number, ledger_operation_type_desc
-	FROM HandReceipt.EquipmentEvents_LedgerHistory
-	WHERE ItemID = @p1
-	UNION ALL
-	SELECT
-		'TransferEvent' as RecordType, EventID, ItemID, FromUserID, ToUserID, InitiatingUserID, ApprovingUserID, EventTimestamp, EventType, Notes,
-		ledger_transaction_id, ledger_sequence_number, ledger_operation_type_desc
-	FROM HandReceipt.TransferEvents_LedgerHistory
-	WHERE ItemID = @p1
-	UNION ALL
-	SELECT
-		'VerificationEvent' as RecordType, EventID, ItemID, VerifyingUserID, VerificationTimestamp AS EventTimestamp, VerificationStatus AS EventType, Notes, -- Aliasing columns for consistency
-		ledger_transaction_id, ledger_sequence_number, ledger_operation_type_desc
-	FROM HandReceipt.VerificationEvents_LedgerHistory
-	WHERE ItemID = @p1
-	UNION ALL
-	SELECT
-		'MaintenanceEvent' as RecordType, EventID, ItemID, InitiatingUserID, PerformingUserID, EventTimestamp, EventType, Description AS Notes, -- Aliasing Description to Notes
-		ledger_transaction_id, ledger_sequence_number, ledger_operation_type_desc
-	FROM HandReceipt.MaintenanceEvents_LedgerHistory
-	WHERE ItemID = @p1
-	UNION ALL
-	SELECT
-		'StatusChangeEvent' as RecordType, EventID, ItemID, ReportingUserID, ChangeTimestamp AS EventTimestamp, NewStatus AS EventType, Reason AS Notes, -- Aliasing columns
-		ledger_transaction_id, ledger_sequence_number, ledger_operation_type_desc
-	FROM HandReceipt.StatusChangeEvents_LedgerHistory
-	WHERE ItemID = @p1
-	ORDER BY EventTimestamp ASC;
-	`
-
-	rows, err := s.db.QueryContext(ctx, query, itemID) // Use itemID in query
+// GetEvent retrieves a single event of any type from the Azure SQL Ledger history views.
+func (s *AzureSqlLedgerService) GetEvent(ctx context.Context, eventID string) (*domain.LedgerEvent, error) {
+	return queryEvent(ctx, s.db, eventID)
+}
+
+// queryEvent reads a single event through q, or returns ErrEventNotFound.
+func queryEvent(ctx context.Context, q queryer, eventID string) (*domain.LedgerEvent, error) {
+	rows, err := q.QueryContext(ctx, generalHistoryCTE+`
+	SELECT TOP (1) eventId, eventType, timestamp, userId, itemId, detailsJson, ledgerTransactionId, ledgerSequenceNumber, signatureJson
+	FROM CombinedHistory
+	WHERE eventId = @p1;`, eventID)
 	if err != nil {
-		log.Printf("Error querying item history by ItemID from Azure SQL Ledger: %v", err)
-		return nil, fmt.Errorf("failed to query item history by ItemID: %w", err)
+		return nil, fmt.Errorf("failed to query ledger event: %w", err)
 	}
-	defer rows.Close()
-
-	cols, err := rows.Columns()
+	events, err := scanGeneralHistoryRows(rows)
+	rows.Close()
 	if err != nil {
-		log.Printf("Error getting columns for history query: %v", err)
-		return nil, fmt.Errorf("failed to get history columns: %w", err)
+		return nil, err
 	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEventNotFound, eventID)
+	}
+	return &events[0], nil
+}
 
-	for rows.Next() {
-		// Create a slice of interface{}'s to represent the row's values.
-		columns := make([]interface{}, len(cols))
-		columnPointers := make([]interface{}, len(cols))
-		for i := range columns {
-			columnPointers[i] = &columns[i]
-		}
-
-		// Scan the result into the column pointers...
-		if err := rows.Scan(columnPointers...); err != nil {
-			log.Printf("Error scanning history row: %v", err)
-			// Consider continuing and logging error vs returning partial history
-			return nil, fmt.Errorf("failed to scan history row: %w", err)
-		}
+// GetItemHistory retrieves the history of an item from the Azure SQL Ledger history views, oldest first.
+func (s *AzureSqlLedgerService) GetItemHistory(ctx context.Context, itemID uint) ([]domain.LedgerEvent, error) {
+	log.Printf("AzureSqlLedgerService: Getting history for ItemID: %d", itemID)
 
-		// Create map for the row
-		m := make(map[string]interface{})
-		for i, colName := range cols {
-			val := columnPointers[i].(*interface{})
-			// Assign value, handling potential NULLs from the DB explicitly if necessary
-			if *val == nil {
-				m[colName] = nil
-			} else {
-				// Convert []byte to string for simplicity, handle other types as needed
-				switch v := (*val).(type) {
-				case []byte:
-					m[colName] = string(v)
-				case time.Time: // Format timestamps nicely
-					m[colName] = v.Format(time.RFC3339)
-				default:
-					m[colName] = *val
-				}
-			}
-		}
-		history = append(history, m)
+	rows, err := s.db.QueryContext(ctx, generalHistoryCTE+`
+	SELECT eventId, eventType, timestamp, userId, itemId, detailsJson, ledgerTransactionId, ledgerSequenceNumber, signatureJson
+	FROM CombinedHistory
+	WHERE itemId = @p1
+	ORDER BY timestamp ASC, eventId ASC;`, itemID)
+	if err != nil {
+		log.Printf("Error querying item history by ItemID from Azure SQL Ledger: %v", err)
+		return nil, fmt.Errorf("failed to query item history by ItemID: %w", err)
 	}
+	defer rows.Close()
 
-	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating history rows: %v", err)
-		return nil, fmt.Errorf("failed during history row iteration: %w", err)
+	history, err := scanGeneralHistoryRows(rows)
+	if err != nil {
+		return nil, err
 	}
 
 	log.Printf("Retrieved %d history events for ItemID: %d", len(history), itemID)
@@ -382,8 +404,7 @@ func (s *AzureSqlLedgerService) GetItemHistory(itemID uint) ([]map[string]interf
 }
 
 // GetAllCorrectionEvents retrieves all correction events from the ledger.
-func (s *AzureSqlLedgerService) GetAllCorrectionEvents() ([]domain.CorrectionEvent, error) {
-	ctx := context.Background()
+func (s *AzureSqlLedgerService) GetAllCorrectionEvents(ctx context.Context) ([]domain.CorrectionEvent, error) {
 	log.Println("AzureSqlLedgerService: Getting all correction events")
 
 	query := `SELECT EventID, OriginalEventID, OriginalEventType, Reason, CorrectingUserID, CorrectionTimestamp, ledger_transaction_id, ledger_sequence_number
@@ -400,8 +421,7 @@ func (s *AzureSqlLedgerService) GetAllCorrectionEvents() ([]domain.CorrectionEve
 }
 
 // GetCorrectionEventsByOriginalID retrieves correction events related to a specific original event ID.
-func (s *AzureSqlLedgerService) GetCorrectionEventsByOriginalID(originalEventID string) ([]domain.CorrectionEvent, error) {
-	ctx := context.Background()
+func (s *AzureSqlLedgerService) GetCorrectionEventsByOriginalID(ctx context.Context, originalEventID string) ([]domain.CorrectionEvent, error) {
 	log.Printf("AzureSqlLedgerService: Getting correction events for OriginalEventID: %s", originalEventID)
 
 	query := `SELECT EventID, OriginalEventID, OriginalEventType, Reason, CorrectingUserID, CorrectionTimestamp, ledger_transaction_id, ledger_sequence_number
@@ -419,8 +439,7 @@ func (s *AzureSqlLedgerService) GetCorrectionEventsByOriginalID(originalEventID
 }
 
 // GetCorrectionEventByID retrieves a specific correction event by its own EventID.
-func (s *AzureSqlLedgerService) GetCorrectionEventByID(eventID string) (*domain.CorrectionEvent, error) {
-	ctx := context.Background()
+func (s *AzureSqlLedgerService) GetCorrectionEventByID(ctx context.Context, eventID string) (*domain.CorrectionEvent, error) {
 	log.Printf("AzureSqlLedgerService: Getting correction event by EventID: %s", eventID)
 
 	query := `SELECT TOP 1 EventID, OriginalEventID, OriginalEventType, Reason, CorrectingUserID, CorrectionTimestamp, ledger_transaction_id, ledger_sequence_number
@@ -491,21 +510,18 @@ func scanCorrectionEvents(rows *sql.Rows) ([]domain.CorrectionEvent, error) {
 	return events, nil
 }
 
-// GetGeneralHistory retrieves a consolidated view of all ledger event types.
-func (s *AzureSqlLedgerService) GetGeneralHistory() ([]domain.GeneralLedgerEvent, error) {
-	ctx := context.Background()
-	log.Println("AzureSqlLedgerService: Getting general ledger history")
-	var history []domain.GeneralLedgerEvent
-
-	// Combine history views using UNION ALL, mapping columns to GeneralLedgerEvent
-	// NOTE: JSON_OBJECT requires SQL Server 2022+. Use string concatenation or fetch individual
-	// fields and build JSON in Go for older versions.
-	// Ensure correct column names based on your schema (e.g., UserID vs PerformingUserID)
-	query := `
-	WITH CombinedHistory AS (
+// generalHistoryCTE combines the history views into one row set of ledger events, each with
+// its signature from HandReceipt.EventSignatures as a JSON object (NULL when unsigned).
+// Besides the returned columns it exposes the transfer parties and transfer request ID so
+// HistoryQuery filters can be evaluated in SQL. EventID is cast to a string so that ordering
+// and cursor comparisons agree.
+// NOTE: JSON_OBJECT requires SQL Server 2022+. Use string concatenation or fetch individual
+// fields and build JSON in Go for older versions.
+const generalHistoryCTE = `
+	WITH EventHistory AS (
 		-- Equipment Events
 		SELECT
-			EventID AS eventId,
+			CAST(EventID AS NVARCHAR(36)) AS eventId,
 			'EquipmentEvent' AS eventType,
 			EventTimestamp AS timestamp,
 			TRY_CAST(PerformingUserID AS BIGINT) AS userId,
@@ -515,14 +531,18 @@ func (s *AzureSqlLedgerService) GetGeneralHistory() ([]domain.GeneralLedgerEvent
 				'notes': Notes
 			) AS detailsJson, -- Construct JSON details
 			ledger_transaction_id AS ledgerTransactionId,
-			ledger_sequence_number AS ledgerSequenceNumber
+			ledger_sequence_number AS ledgerSequenceNumber,
+			CAST(NULL AS NVARCHAR(36)) AS transferRequestId,
+			CAST(NULL AS BIGINT) AS fromUserId,
+			CAST(NULL AS BIGINT) AS toUserId,
+			CAST(NULL AS BIGINT) AS otherUserId
 		FROM HandReceipt.EquipmentEvents_LedgerHistory
 
 		UNION ALL
 
 		-- Transfer Events
 		SELECT
-			EventID AS eventId,
+			CAST(EventID AS NVARCHAR(36)) AS eventId,
 			'TransferEvent' AS eventType,
 			EventTimestamp AS timestamp,
 			TRY_CAST(InitiatingUserID AS BIGINT) AS userId, -- Or ApprovingUserID depending on context needed
@@ -531,18 +551,23 @@ func (s *AzureSqlLedgerService) GetGeneralHistory() ([]domain.GeneralLedgerEvent
 				'transferRequestId': TransferRequestID,
 				'fromUserId': FromUserID,
 				'toUserId': ToUserID,
+				'approvingUserId': ApprovingUserID,
 				'eventTypeDetail': EventType,
 				'notes': Notes
 			) AS detailsJson,
 			ledger_transaction_id AS ledgerTransactionId,
-			ledger_sequence_number AS ledgerSequenceNumber
+			ledger_sequence_number AS ledgerSequenceNumber,
+			CAST(TransferRequestID AS NVARCHAR(36)) AS transferRequestId,
+			TRY_CAST(FromUserID AS BIGINT) AS fromUserId,
+			TRY_CAST(ToUserID AS BIGINT) AS toUserId,
+			TRY_CAST(ApprovingUserID AS BIGINT) AS otherUserId
 		FROM HandReceipt.TransferEvents_LedgerHistory
 
 		UNION ALL
 
 		-- Verification Events
 		SELECT
-			EventID AS eventId,
+			CAST(EventID AS NVARCHAR(36)) AS eventId,
 			'VerificationEvent' AS eventType,
 			VerificationTimestamp AS timestamp,
 			TRY_CAST(VerifyingUserID AS BIGINT) AS userId,
@@ -552,14 +577,15 @@ func (s *AzureSqlLedgerService) GetGeneralHistory() ([]domain.GeneralLedgerEvent
 				'notes': Notes
 			) AS detailsJson,
 			ledger_transaction_id AS ledgerTransactionId,
-			ledger_sequence_number AS ledgerSequenceNumber
+			ledger_sequence_number AS ledgerSequenceNumber,
+			NULL, NULL, NULL, NULL
 		FROM HandReceipt.VerificationEvents_LedgerHistory
 
 		UNION ALL
 
 		-- Maintenance Events
 		SELECT
-			EventID AS eventId,
+			CAST(EventID AS NVARCHAR(36)) AS eventId,
 			'MaintenanceEvent' AS eventType,
 			EventTimestamp AS timestamp,
 			TRY_CAST(InitiatingUserID AS BIGINT) AS userId, -- Or PerformingUserID
@@ -568,17 +594,20 @@ func (s *AzureSqlLedgerService) GetGeneralHistory() ([]domain.GeneralLedgerEvent
 				'maintenanceRecordId': MaintenanceRecordID,
 				'eventTypeDetail': EventType,
 				'maintenanceType': MaintenanceType,
-				'description': Description
+				'description': Description,
+				'performingUserId': PerformingUserID
 			) AS detailsJson,
 			ledger_transaction_id AS ledgerTransactionId,
-			ledger_sequence_number AS ledgerSequenceNumber
+			ledger_sequence_number AS ledgerSequenceNumber,
+			NULL, NULL, NULL,
+			TRY_CAST(PerformingUserID AS BIGINT)
 		FROM HandReceipt.MaintenanceEvents_LedgerHistory
 
 		UNION ALL
 
 		-- Status Change Events
 		SELECT
-			EventID AS eventId,
+			CAST(EventID AS NVARCHAR(36)) AS eventId,
 			'StatusChangeEvent' AS eventType,
 			ChangeTimestamp AS timestamp,
 			TRY_CAST(ReportingUserID AS BIGINT) AS userId,
@@ -589,30 +618,297 @@ func (s *AzureSqlLedgerService) GetGeneralHistory() ([]domain.GeneralLedgerEvent
 				'reason': Reason
 			) AS detailsJson,
 			ledger_transaction_id AS ledgerTransactionId,
-			ledger_sequence_number AS ledgerSequenceNumber
+			ledger_sequence_number AS ledgerSequenceNumber,
+			NULL, NULL, NULL, NULL
 		FROM HandReceipt.StatusChangeEvents_LedgerHistory
-	)
-	SELECT eventId, eventType, timestamp, userId, itemId, detailsJson, ledgerTransactionId, ledgerSequenceNumber
+
+		UNION ALL
+
+		-- Correction Events
+		SELECT
+			CAST(EventID AS NVARCHAR(36)) AS eventId,
+			'CorrectionEvent' AS eventType,
+			CorrectionTimestamp AS timestamp,
+			TRY_CAST(CorrectingUserID AS BIGINT) AS userId,
+			CAST(NULL AS BIGINT) AS itemId,
+			JSON_OBJECT(
+				'originalEventId': OriginalEventID,
+				'originalEventType': OriginalEventType,
+				'reason': Reason
+			) AS detailsJson,
+			ledger_transaction_id AS ledgerTransactionId,
+			ledger_sequence_number AS ledgerSequenceNumber,
+			NULL, NULL, NULL, NULL
+		FROM HandReceipt.CorrectionEvents_LedgerHistory
+	),
+	CombinedHistory AS (
+		SELECT h.*,
+			CASE WHEN sig.EventID IS NULL THEN NULL ELSE JSON_OBJECT(
+				'algorithm': sig.Algorithm,
+				'signer': sig.Signer,
+				'userId': sig.SignerUserID,
+				'keyId': sig.KeyID,
+				'publicKey': sig.PublicKey,
+				'value': sig.Signature,
+				'payload': sig.SignedPayload
+			) END AS signatureJson
+		FROM EventHistory h
+		LEFT JOIN HandReceipt.EventSignatures sig ON CAST(sig.EventID AS NVARCHAR(36)) = h.eventId
+	)`
+
+// GetGeneralHistory retrieves one page of ledger events matching query from the ledger history views.
+// Filters and keyset pagination on (timestamp, eventId) are evaluated in SQL. The ledger tables do not
+// record serial numbers, so a SerialNumber filter is rejected; callers should filter by ItemID instead.
+func (s *AzureSqlLedgerService) GetGeneralHistory(ctx context.Context, query HistoryQuery) (*HistoryPage, error) {
+	log.Println("AzureSqlLedgerService: Getting general ledger history")
+
+	query, cursor, err := query.normalize()
+	if err != nil {
+		return nil, err
+	}
+	if query.SerialNumber != "" {
+		return nil, fmt.Errorf("%w: the Azure SQL ledger does not record serial numbers, filter by item ID", ErrInvalidHistoryQuery)
+	}
+
+	var conditions []string
+	var args []interface{}
+	// param adds a query argument and returns its placeholder name
+	param := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("@p%d", len(args))
+	}
+
+	if query.From != nil {
+		conditions = append(conditions, "timestamp >= "+param(*query.From))
+	}
+	if query.To != nil {
+		conditions = append(conditions, "timestamp < "+param(*query.To))
+	}
+	if len(query.EventTypes) > 0 {
+		placeholders := make([]string, len(query.EventTypes))
+		for i, eventType := range query.EventTypes {
+			placeholders[i] = param(eventType)
+		}
+		conditions = append(conditions, "eventType IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if query.UserID != nil {
+		p := param(int64(*query.UserID))
+		conditions = append(conditions, fmt.Sprintf("(userId = %[1]s OR fromUserId = %[1]s OR toUserId = %[1]s OR otherUserId = %[1]s)", p))
+	}
+	if query.ItemID != nil {
+		conditions = append(conditions, "itemId = "+param(int64(*query.ItemID)))
+	}
+	if query.TransferID != nil {
+		// LogTransferEvent records the transfer ID in decimal form
+		conditions = append(conditions, "transferRequestId = "+param(fmt.Sprintf("%d", *query.TransferID)))
+	}
+
+	direction, comparison := "DESC", "<"
+	if query.Order == SortAsc {
+		direction, comparison = "ASC", ">"
+	}
+	if cursor != nil {
+		ts, id := param(cursor.Timestamp), param(cursor.EventID)
+		conditions = append(conditions, fmt.Sprintf("(timestamp %[1]s %[2]s OR (timestamp = %[2]s AND eventId %[1]s %[3]s))", comparison, ts, id))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	sqlQuery := fmt.Sprintf(`%s
+	SELECT TOP (%s) eventId, eventType, timestamp, userId, itemId, detailsJson, ledgerTransactionId, ledgerSequenceNumber, signatureJson
 	FROM CombinedHistory
-	ORDER BY timestamp DESC; -- Order by most recent first
-	`
+	%s
+	ORDER BY timestamp %s, eventId %s;`, generalHistoryCTE, param(query.Limit+1), where, direction, direction)
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
 		log.Printf("Error querying general history from Azure SQL Ledger: %v", err)
 		return nil, fmt.Errorf("failed to query general history: %w", err)
 	}
 	defer rows.Close()
 
+	history, err := scanGeneralHistoryRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &HistoryPage{Events: history}
+	if len(history) > query.Limit {
+		page.Events = history[:query.Limit]
+		page.NextCursor = encodeHistoryCursor(page.Events[query.Limit-1])
+	}
+
+	log.Printf("Retrieved %d general history events", len(page.Events))
+	return page, nil
+}
+
+// CommitPosition is a row's place in Azure SQL Ledger commit order. The zero value
+// precedes every row.
+type CommitPosition struct {
+	TransactionID  int64 `json:"transactionId"`
+	SequenceNumber int64 `json:"sequenceNumber"`
+}
+
+// EventsInCommitOrder returns up to limit ledger events committed after position, ordered
+// by ledger transaction and sequence number, i.e. the order in which they were written.
+func (s *AzureSqlLedgerService) EventsInCommitOrder(ctx context.Context, after CommitPosition, limit int) ([]domain.LedgerEvent, error) {
+	rows, err := s.db.QueryContext(ctx, generalHistoryCTE+`
+	SELECT TOP (@p1) eventId, eventType, timestamp, userId, itemId, detailsJson, ledgerTransactionId, ledgerSequenceNumber, signatureJson
+	FROM CombinedHistory
+	WHERE ledgerTransactionId > @p2 OR (ledgerTransactionId = @p2 AND ledgerSequenceNumber > @p3)
+	ORDER BY ledgerTransactionId ASC, ledgerSequenceNumber ASC;`, limit, after.TransactionID, after.SequenceNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ledger events in commit order: %w", err)
+	}
+	defer rows.Close()
+
+	return scanGeneralHistoryRows(rows)
+}
+
+// GetEventReceipt returns a receipt for one ledger history row: the row as served by
+// GetGeneralHistory, the ledger transaction that wrote it, and a fresh database digest.
+// Azure SQL does not expose per-row inclusion proofs; the digest lets the holder have
+// sys.sp_verify_database_ledger confirm the transaction later.
+func (s *AzureSqlLedgerService) GetEventReceipt(ctx context.Context, eventID string) (*receipt.Receipt, error) {
+	found, err := s.GetEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := *found
+	if event.Ledger.TransactionID == nil || event.Ledger.SequenceNumber == nil {
+		return nil, fmt.Errorf("ledger event %s has no ledger transaction", eventID)
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ledger event: %w", err)
+	}
+
+	proof := &receipt.AzureSQLProof{
+		TransactionID:  *event.Ledger.TransactionID,
+		SequenceNumber: *event.Ledger.SequenceNumber,
+	}
+	err = s.db.QueryRowContext(ctx,
+		`SELECT block_id, commit_time FROM sys.database_ledger_transactions WHERE transaction_id = @p1`,
+		proof.TransactionID,
+	).Scan(&proof.BlockID, &proof.CommitTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ledger transaction %d: %w", proof.TransactionID, err)
+	}
+
+	// Generated after the lookup above, so the digest's block includes the transaction
+	var digest string
+	if err := s.db.QueryRowContext(ctx, "EXECUTE sys.sp_generate_database_ledger_digest").Scan(&digest); err != nil {
+		return nil, fmt.Errorf("failed to generate ledger digest: %w", err)
+	}
+	proof.Digest = json.RawMessage(digest)
+
+	return &receipt.Receipt{
+		Version:   receipt.Version,
+		Backend:   receipt.BackendAzureSQL,
+		EventID:   event.EventID,
+		EventType: event.EventType,
+		Event:     payload,
+		IssuedAt:  time.Now().UTC(),
+		AzureSQL:  proof,
+	}, nil
+}
+
+// CaptureDigest generates a database ledger digest: the hash of the latest closed block,
+// which commits to every earlier block and transaction.
+func (s *AzureSqlLedgerService) CaptureDigest(ctx context.Context) (*Digest, error) {
+	var raw sql.NullString
+	if err := s.db.QueryRowContext(ctx, "EXECUTE sys.sp_generate_database_ledger_digest").Scan(&raw); err != nil {
+		return nil, fmt.Errorf("failed to generate ledger digest: %w", err)
+	}
+	if !raw.Valid {
+		return nil, fmt.Errorf("failed to generate ledger digest: the database has no ledger blocks yet")
+	}
+
+	var parsed struct {
+		DatabaseName string `json:"database_name"`
+		BlockID      uint64 `json:"block_id"`
+		Hash         string `json:"hash"`
+	}
+	if err := json.Unmarshal([]byte(raw.String), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse ledger digest: %w", err)
+	}
+
+	return &Digest{
+		Backend:    receipt.BackendAzureSQL,
+		Database:   parsed.DatabaseName,
+		Position:   parsed.BlockID,
+		Hash:       parsed.Hash,
+		CapturedAt: time.Now().UTC(),
+		Raw:        json.RawMessage(raw.String),
+	}, nil
+}
+
+// VerifyDigest runs sys.sp_verify_database_ledger against the digest, which recomputes the
+// ledger up to the digest's block and fails if it no longer produces the digest's hash.
+// The procedure verifies the whole database, so each call can take a while.
+func (s *AzureSqlLedgerService) VerifyDigest(ctx context.Context, digest Digest) error {
+	var database string
+	if err := s.db.QueryRowContext(ctx, "SELECT DB_NAME()").Scan(&database); err != nil {
+		return fmt.Errorf("failed to read database name: %w", err)
+	}
+	if digest.Backend != receipt.BackendAzureSQL || digest.Database != database {
+		return fmt.Errorf("%w: captured from %s database %q", ErrForeignDigest, digest.Backend, digest.Database)
+	}
+	if len(digest.Raw) == 0 {
+		return fmt.Errorf("%w: digest has no Azure SQL ledger digest", ErrDigestMismatch)
+	}
+
+	digests := "[" + string(digest.Raw) + "]"
+	if _, err := s.db.ExecContext(ctx, "EXECUTE sys.sp_verify_database_ledger @digests = @p1", digests); err != nil {
+		// Errors raised by the procedure itself mean verification ran and failed
+		var sqlErr mssql.Error
+		if errors.As(err, &sqlErr) {
+			return fmt.Errorf("%w: block %d: %s", ErrDigestMismatch, digest.Position, sqlErr.Message)
+		}
+		return fmt.Errorf("failed to verify ledger digest for block %d: %w", digest.Position, err)
+	}
+	return nil
+}
+
+// azureEventDetails holds every key the detailsJson column of CombinedHistory can carry.
+type azureEventDetails struct {
+	EventTypeDetail     string  `json:"eventTypeDetail"`
+	Notes               string  `json:"notes"`
+	TransferRequestID   string  `json:"transferRequestId"`
+	FromUserID          *uint64 `json:"fromUserId"`
+	ToUserID            *uint64 `json:"toUserId"`
+	ApprovingUserID     *uint64 `json:"approvingUserId"`
+	VerificationStatus  string  `json:"verificationStatus"`
+	MaintenanceRecordID string  `json:"maintenanceRecordId"`
+	MaintenanceType     string  `json:"maintenanceType"`
+	Description         string  `json:"description"`
+	PerformingUserID    *uint64 `json:"performingUserId"`
+	PreviousStatus      string  `json:"previousStatus"`
+	NewStatus           string  `json:"newStatus"`
+	Reason              string  `json:"reason"`
+	OriginalEventID     string  `json:"originalEventId"`
+	OriginalEventType   string  `json:"originalEventType"`
+}
+
+// scanGeneralHistoryRows reads rows selected from CombinedHistory (eventId, eventType, timestamp,
+// userId, itemId, detailsJson, ledgerTransactionId, ledgerSequenceNumber, signatureJson) into events.
+func scanGeneralHistoryRows(rows *sql.Rows) ([]domain.LedgerEvent, error) {
+	history := []domain.LedgerEvent{}
 	for rows.Next() {
-		var event domain.GeneralLedgerEvent
-		var detailsJSON string // Scan JSON details as string
+		event := domain.LedgerEvent{Ledger: domain.LedgerTxMetadata{Backend: receipt.BackendAzureSQL}}
 		// Use sql.Null types for potentially null fields from the DB
 		var eventIDStr sql.NullString
 		var userID sql.NullInt64
 		var itemID sql.NullInt64
+		var detailsJSON sql.NullString
 		var ledgerTxID sql.NullInt64
 		var ledgerSeqNum sql.NullInt64
+		var signatureJSON sql.NullString
 
 		if err := rows.Scan(
 			&eventIDStr,
@@ -623,6 +919,7 @@ func (s *AzureSqlLedgerService) GetGeneralHistory() ([]domain.GeneralLedgerEvent
 			&detailsJSON,
 			&ledgerTxID,
 			&ledgerSeqNum,
+			&signatureJSON,
 		); err != nil {
 			log.Printf("Error scanning general history row: %v", err)
 			return nil, fmt.Errorf("failed to scan general history row: %w", err)
@@ -634,53 +931,91 @@ func (s *AzureSqlLedgerService) GetGeneralHistory() ([]domain.GeneralLedgerEvent
 		}
 		if userID.Valid {
 			u64 := uint64(userID.Int64)
-			event.UserID = &u64
+			event.ActorUserID = &u64
 		}
 		if itemID.Valid {
 			i64 := uint64(itemID.Int64)
 			event.ItemID = &i64
 		}
 		if ledgerTxID.Valid {
-			event.LedgerTransactionID = &ledgerTxID.Int64
+			event.Ledger.TransactionID = &ledgerTxID.Int64
 		}
 		if ledgerSeqNum.Valid {
-			event.LedgerSequenceNumber = &ledgerSeqNum.Int64
+			event.Ledger.SequenceNumber = &ledgerSeqNum.Int64
+		}
+
+		var details azureEventDetails
+		if detailsJSON.Valid && detailsJSON.String != "" {
+			if err := json.Unmarshal([]byte(detailsJSON.String), &details); err != nil {
+				return nil, fmt.Errorf("failed to decode details of ledger event %s: %w", event.EventID, err)
+			}
 		}
+		details.apply(&event)
 
-		// Unmarshal the JSON details string into the 'any' field
-		if detailsJSON != "" {
-			// Use json.Unmarshal or a similar library
-			// For simplicity, we might just assign the raw string if the frontend can handle it,
-			// or unmarshal into a map[string]interface{}
-			var detailsMap map[string]interface{}
-			if err := json.Unmarshal([]byte(detailsJSON), &detailsMap); err == nil {
-				event.Details = detailsMap
-			} else {
-				log.Printf("Warning: Failed to unmarshal details JSON for event %s: %v", event.EventID, err)
-				event.Details = detailsJSON // Assign raw string as fallback
+		if signatureJSON.Valid && signatureJSON.String != "" {
+			var signature domain.EventSignature
+			if err := json.Unmarshal([]byte(signatureJSON.String), &signature); err != nil {
+				return nil, fmt.Errorf("failed to decode signature of ledger event %s: %w", event.EventID, err)
 			}
-		} else {
-			event.Details = nil // Or an empty map: map[string]interface{}{}
+			event.Signature = &signature
 		}
 
 		history = append(history, event)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		log.Printf("Error iterating general history rows: %v", err)
 		return nil, fmt.Errorf("failed during general history row iteration: %w", err)
 	}
-
-	log.Printf("Retrieved %d general history events", len(history))
 	return history, nil
 }
 
+// apply sets the event's detail field matching its type.
+func (d azureEventDetails) apply(event *domain.LedgerEvent) {
+	switch event.EventType {
+	case domain.LedgerEventItemCreation:
+		// The equipment table does not record the item's status or holder
+		event.ItemCreation = &domain.ItemCreationDetails{Action: d.EventTypeDetail, Notes: d.Notes}
+	case domain.LedgerEventTransfer:
+		transfer := &domain.TransferDetails{
+			TransferID:      d.TransferRequestID,
+			Status:          d.EventTypeDetail,
+			ApprovingUserID: d.ApprovingUserID,
+			Notes:           d.Notes,
+		}
+		if d.FromUserID != nil {
+			transfer.FromUserID = *d.FromUserID
+		}
+		if d.ToUserID != nil {
+			transfer.ToUserID = *d.ToUserID
+		}
+		event.Transfer = transfer
+	case domain.LedgerEventStatusChange:
+		event.StatusChange = &domain.StatusChangeDetails{PreviousStatus: d.PreviousStatus, NewStatus: d.NewStatus, Reason: d.Reason}
+	case domain.LedgerEventVerification:
+		event.Verification = &domain.VerificationDetails{Result: d.VerificationStatus, Notes: d.Notes}
+	case domain.LedgerEventMaintenance:
+		event.Maintenance = &domain.MaintenanceDetails{
+			MaintenanceRecordID: d.MaintenanceRecordID,
+			Stage:               d.EventTypeDetail,
+			MaintenanceType:     d.MaintenanceType,
+			Description:         d.Description,
+			PerformingUserID:    d.PerformingUserID,
+		}
+	case domain.LedgerEventCorrection:
+		event.Correction = &domain.CorrectionDetails{
+			OriginalEventID:   d.OriginalEventID,
+			OriginalEventType: d.OriginalEventType,
+			Reason:            d.Reason,
+		}
+	}
+}
+
 // VerifyDocument checks the integrity of the database ledger using Azure SQL Ledger's built-in procedure.
 // NOTE: This implementation uses `sys.sp_verify_database_ledger` which verifies the *entire database*.
 // The interface parameters `documentID` and `tableName` are currently ignored.
-// True verification often involves comparing current database digests with previously stored, trusted digests.
-func (s *AzureSqlLedgerService) VerifyDocument(documentID string, tableName string) (bool, error) {
-	ctx := context.Background()
+// Checking the ledger against previously stored, trusted digests is done by VerifyDigest.
+func (s *AzureSqlLedgerService) VerifyDocument(ctx context.Context, documentID string, tableName string) (bool, error) {
 	log.Printf("AzureSqlLedgerService: Verifying ledger integrity (Database-wide check). Called with documentID: '%s', tableName: '%s' (parameters ignored).", documentID, tableName)
 
 	// This procedure verifies the integrity of all ledger tables in the database.
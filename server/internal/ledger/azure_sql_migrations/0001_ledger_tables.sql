@@ -0,0 +1,168 @@
+-- HandReceipt ledger schema, version 1.
+--
+-- Every event table is an append-only ledger table: rows can be inserted but never updated
+-- or deleted, and each insert is recorded in the database ledger. The _LedgerHistory views
+-- expose each table with the ledger transaction and sequence number that wrote the row,
+-- which is what AzureSqlLedgerService reads.
+--
+-- Batches are separated by GO lines. Every batch is safe to run again.
+
+IF SCHEMA_ID('HandReceipt') IS NULL
+    EXEC('CREATE SCHEMA HandReceipt');
+GO
+
+-- Equipment creation, registration and decommissioning
+IF OBJECT_ID('HandReceipt.EquipmentEvents', 'U') IS NULL
+BEGIN
+    CREATE TABLE HandReceipt.EquipmentEvents (
+        EventID UNIQUEIDENTIFIER NOT NULL DEFAULT NEWID() PRIMARY KEY NONCLUSTERED,
+        ItemID INT NOT NULL,
+        PerformingUserID INT NOT NULL,
+        EventTimestamp DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+        EventType NVARCHAR(50) NOT NULL CHECK (EventType IN ('Created', 'Registered', 'Decommissioned')),
+        Notes NVARCHAR(MAX) NULL
+    )
+    WITH (LEDGER = ON (APPEND_ONLY = ON));
+
+    CREATE INDEX IX_EquipmentEvents_ItemID ON HandReceipt.EquipmentEvents (ItemID);
+END
+GO
+
+-- Sensitive item verifications
+IF OBJECT_ID('HandReceipt.VerificationEvents', 'U') IS NULL
+BEGIN
+    CREATE TABLE HandReceipt.VerificationEvents (
+        EventID UNIQUEIDENTIFIER NOT NULL DEFAULT NEWID() PRIMARY KEY NONCLUSTERED,
+        ItemID INT NOT NULL,
+        VerifyingUserID INT NOT NULL,
+        VerificationTimestamp DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+        VerificationStatus NVARCHAR(100) NOT NULL CHECK (VerificationStatus IN ('Verified Present', 'Missing', 'Requires Attention', 'Status Unchanged')),
+        Notes NVARCHAR(MAX) NULL
+    )
+    WITH (LEDGER = ON (APPEND_ONLY = ON));
+
+    CREATE INDEX IX_VerificationEvents_ItemID ON HandReceipt.VerificationEvents (ItemID);
+END
+GO
+
+-- Transfer workflow stages. TransferRequestID holds the application's transfer ID, which
+-- is numeric, or the GUID of transfers recorded before it was.
+IF OBJECT_ID('HandReceipt.TransferEvents', 'U') IS NULL
+BEGIN
+    CREATE TABLE HandReceipt.TransferEvents (
+        EventID UNIQUEIDENTIFIER NOT NULL DEFAULT NEWID() PRIMARY KEY NONCLUSTERED,
+        TransferRequestID NVARCHAR(36) NOT NULL,
+        ItemID INT NOT NULL,
+        FromUserID INT NOT NULL,
+        ToUserID INT NOT NULL,
+        InitiatingUserID INT NOT NULL,
+        ApprovingUserID INT NULL,
+        EventTimestamp DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+        EventType NVARCHAR(50) NOT NULL CHECK (EventType IN ('Requested', 'Approved', 'Rejected', 'Completed', 'Cancelled')),
+        Notes NVARCHAR(MAX) NULL
+    )
+    WITH (LEDGER = ON (APPEND_ONLY = ON));
+
+    CREATE INDEX IX_TransferEvents_ItemID ON HandReceipt.TransferEvents (ItemID);
+    CREATE INDEX IX_TransferEvents_TransferRequestID ON HandReceipt.TransferEvents (TransferRequestID);
+END
+GO
+
+-- Maintenance stages
+IF OBJECT_ID('HandReceipt.MaintenanceEvents', 'U') IS NULL
+BEGIN
+    CREATE TABLE HandReceipt.MaintenanceEvents (
+        EventID UNIQUEIDENTIFIER NOT NULL DEFAULT NEWID() PRIMARY KEY NONCLUSTERED,
+        MaintenanceRecordID UNIQUEIDENTIFIER NOT NULL,
+        ItemID INT NOT NULL,
+        InitiatingUserID INT NOT NULL,
+        PerformingUserID INT NULL,
+        EventTimestamp DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+        EventType NVARCHAR(50) NOT NULL CHECK (EventType IN ('Scheduled', 'Started', 'Completed', 'Cancelled', 'Reported Defect')),
+        MaintenanceType NVARCHAR(100) NULL,
+        Description NVARCHAR(MAX) NULL
+    )
+    WITH (LEDGER = ON (APPEND_ONLY = ON));
+
+    CREATE INDEX IX_MaintenanceEvents_ItemID ON HandReceipt.MaintenanceEvents (ItemID);
+END
+GO
+
+-- Equipment status changes
+IF OBJECT_ID('HandReceipt.StatusChangeEvents', 'U') IS NULL
+BEGIN
+    CREATE TABLE HandReceipt.StatusChangeEvents (
+        EventID UNIQUEIDENTIFIER NOT NULL DEFAULT NEWID() PRIMARY KEY NONCLUSTERED,
+        ItemID INT NOT NULL,
+        ReportingUserID INT NOT NULL,
+        ChangeTimestamp DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+        PreviousStatus NVARCHAR(100) NULL,
+        NewStatus NVARCHAR(100) NOT NULL CHECK (NewStatus IN ('Operational', 'Non-Operational', 'Damaged', 'Lost', 'Found', 'In Repair')),
+        Reason NVARCHAR(MAX) NULL
+    )
+    WITH (LEDGER = ON (APPEND_ONLY = ON));
+
+    CREATE INDEX IX_StatusChangeEvents_ItemID ON HandReceipt.StatusChangeEvents (ItemID);
+END
+GO
+
+-- Corrections of earlier events in any table
+IF OBJECT_ID('HandReceipt.CorrectionEvents', 'U') IS NULL
+BEGIN
+    CREATE TABLE HandReceipt.CorrectionEvents (
+        EventID UNIQUEIDENTIFIER NOT NULL DEFAULT NEWID() PRIMARY KEY NONCLUSTERED,
+        OriginalEventID UNIQUEIDENTIFIER NOT NULL,
+        OriginalEventType NVARCHAR(50) NOT NULL,
+        Reason NVARCHAR(MAX) NOT NULL,
+        CorrectingUserID BIGINT NOT NULL,
+        CorrectionTimestamp DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+    )
+    WITH (LEDGER = ON (APPEND_ONLY = ON));
+
+    CREATE INDEX IX_CorrectionEvents_OriginalEventID ON HandReceipt.CorrectionEvents (OriginalEventID);
+END
+GO
+
+CREATE OR ALTER VIEW HandReceipt.EquipmentEvents_LedgerHistory AS
+SELECT EventID, ItemID, PerformingUserID, EventTimestamp, EventType, Notes,
+       ledger_start_transaction_id AS ledger_transaction_id,
+       ledger_start_sequence_number AS ledger_sequence_number
+FROM HandReceipt.EquipmentEvents;
+GO
+
+CREATE OR ALTER VIEW HandReceipt.VerificationEvents_LedgerHistory AS
+SELECT EventID, ItemID, VerifyingUserID, VerificationTimestamp, VerificationStatus, Notes,
+       ledger_start_transaction_id AS ledger_transaction_id,
+       ledger_start_sequence_number AS ledger_sequence_number
+FROM HandReceipt.VerificationEvents;
+GO
+
+CREATE OR ALTER VIEW HandReceipt.TransferEvents_LedgerHistory AS
+SELECT EventID, TransferRequestID, ItemID, FromUserID, ToUserID, InitiatingUserID, ApprovingUserID,
+       EventTimestamp, EventType, Notes,
+       ledger_start_transaction_id AS ledger_transaction_id,
+       ledger_start_sequence_number AS ledger_sequence_number
+FROM HandReceipt.TransferEvents;
+GO
+
+CREATE OR ALTER VIEW HandReceipt.MaintenanceEvents_LedgerHistory AS
+SELECT EventID, MaintenanceRecordID, ItemID, InitiatingUserID, PerformingUserID, EventTimestamp,
+       EventType, MaintenanceType, Description,
+       ledger_start_transaction_id AS ledger_transaction_id,
+       ledger_start_sequence_number AS ledger_sequence_number
+FROM HandReceipt.MaintenanceEvents;
+GO
+
+CREATE OR ALTER VIEW HandReceipt.StatusChangeEvents_LedgerHistory AS
+SELECT EventID, ItemID, ReportingUserID, ChangeTimestamp, PreviousStatus, NewStatus, Reason,
+       ledger_start_transaction_id AS ledger_transaction_id,
+       ledger_start_sequence_number AS ledger_sequence_number
+FROM HandReceipt.StatusChangeEvents;
+GO
+
+CREATE OR ALTER VIEW HandReceipt.CorrectionEvents_LedgerHistory AS
+SELECT EventID, OriginalEventID, OriginalEventType, Reason, CorrectingUserID, CorrectionTimestamp,
+       ledger_start_transaction_id AS ledger_transaction_id,
+       ledger_start_sequence_number AS ledger_sequence_number
+FROM HandReceipt.CorrectionEvents;
+GO
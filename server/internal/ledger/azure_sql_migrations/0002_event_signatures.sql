@@ -0,0 +1,21 @@
+-- HandReceipt ledger schema, version 2.
+--
+-- Signatures over ledger events, by the user who performed them or by the server. Each
+-- signature is written in the same transaction as its event, and the table is append-only
+-- like the event tables.
+
+IF OBJECT_ID('HandReceipt.EventSignatures', 'U') IS NULL
+BEGIN
+    CREATE TABLE HandReceipt.EventSignatures (
+        EventID UNIQUEIDENTIFIER NOT NULL PRIMARY KEY NONCLUSTERED,
+        Algorithm NVARCHAR(20) NOT NULL,
+        Signer NVARCHAR(10) NOT NULL CHECK (Signer IN ('user', 'server')),
+        SignerUserID BIGINT NULL,
+        KeyID NVARCHAR(64) NOT NULL,
+        PublicKey NVARCHAR(100) NOT NULL,
+        Signature NVARCHAR(200) NOT NULL,
+        SignedAt DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+    )
+    WITH (LEDGER = ON (APPEND_ONLY = ON));
+END
+GO
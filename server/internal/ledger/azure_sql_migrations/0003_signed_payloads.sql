@@ -0,0 +1,9 @@
+-- HandReceipt ledger schema, version 3.
+--
+-- Users sign ledger events on their own devices, over the event as they saw it. The
+-- signed payload is stored with the signature, as the event tables record fewer details
+-- than users sign. Server signatures leave it NULL.
+
+IF COL_LENGTH('HandReceipt.EventSignatures', 'SignedPayload') IS NULL
+    ALTER TABLE HandReceipt.EventSignatures ADD SignedPayload NVARCHAR(MAX) NULL;
+GO
@@ -0,0 +1,318 @@
+package ledger
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AzureSchemaMode says what AzureSqlLedgerService.Initialize does with the HandReceipt schema.
+type AzureSchemaMode string
+
+const (
+	AzureSchemaOff    AzureSchemaMode = "off"    // Leave the schema alone (default)
+	AzureSchemaVerify AzureSchemaMode = "verify" // Require the schema to be at the version this build expects
+	AzureSchemaApply  AzureSchemaMode = "apply"  // Apply missing migrations, then verify
+)
+
+// ParseAzureSchemaMode converts a configured mode name, defaulting to AzureSchemaOff when empty.
+func ParseAzureSchemaMode(name string) (AzureSchemaMode, error) {
+	switch mode := AzureSchemaMode(name); mode {
+	case "":
+		return AzureSchemaOff, nil
+	case AzureSchemaOff, AzureSchemaVerify, AzureSchemaApply:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown Azure SQL schema mode %q: must be %q, %q or %q", name, AzureSchemaOff, AzureSchemaVerify, AzureSchemaApply)
+	}
+}
+
+// ErrIncompatibleSchema is returned (wrapped) by Initialize when the database holds a
+// HandReceipt schema this build cannot use.
+var ErrIncompatibleSchema = errors.New("incompatible Azure SQL ledger schema")
+
+// Migrations are named NNNN_description.sql and applied in version order. Every batch must
+// be safe to run again, since a migration that fails part way is rerun from the start.
+// Once released a migration must not change: its checksum is recorded, and a database
+// whose recorded checksum differs is refused.
+//
+//go:embed azure_sql_migrations/*.sql
+var azureMigrationFiles embed.FS
+
+// azureSchemaLock names the application lock that keeps two processes from migrating at once.
+const azureSchemaLock = "HandReceiptLedgerSchema"
+
+// azureMigrationTable records the applied migrations. It is created before any migration runs.
+const azureMigrationTable = `
+IF SCHEMA_ID('HandReceipt') IS NULL
+    EXEC('CREATE SCHEMA HandReceipt');
+IF OBJECT_ID('HandReceipt.SchemaMigrations', 'U') IS NULL
+    CREATE TABLE HandReceipt.SchemaMigrations (
+        Version INT NOT NULL PRIMARY KEY,
+        Description NVARCHAR(200) NOT NULL,
+        Checksum CHAR(64) NOT NULL,
+        AppliedAt DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+    );`
+
+// azureMigration is one embedded migration script.
+type azureMigration struct {
+	Version     int
+	Description string
+	Checksum    string   // SHA-256 of the script
+	Batches     []string // Statements batches, split on GO lines
+}
+
+// appliedMigration is a row of HandReceipt.SchemaMigrations.
+type appliedMigration struct {
+	Version  int
+	Checksum string
+}
+
+// loadAzureMigrations reads the embedded migrations in version order. Versions must run
+// from 1 without gaps.
+func loadAzureMigrations() ([]azureMigration, error) {
+	entries, err := azureMigrationFiles.ReadDir("azure_sql_migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]azureMigration, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, description, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s is not named NNNN_description.sql", name)
+		}
+
+		script, err := azureMigrationFiles.ReadFile(path.Join("azure_sql_migrations", name))
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(script)
+		migrations = append(migrations, azureMigration{
+			Version:     version,
+			Description: strings.ReplaceAll(description, "_", " "),
+			Checksum:    hex.EncodeToString(sum[:]),
+			Batches:     splitBatches(string(script)),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must run from 1 without gaps: found %d at position %d", m.Version, i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// splitBatches splits a script into batches on lines holding only GO, as sqlcmd does.
+// Batches that are empty or hold only comments are dropped.
+func splitBatches(script string) []string {
+	var batches []string
+	var current strings.Builder
+	flush := func() {
+		batch := strings.TrimSpace(current.String())
+		current.Reset()
+		if hasStatement(batch) {
+			batches = append(batches, batch)
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(script))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.EqualFold(strings.TrimSpace(line), "GO") {
+			flush()
+			continue
+		}
+		current.WriteString(line)
+		current.WriteByte('\n')
+	}
+	flush()
+	return batches
+}
+
+// hasStatement reports whether batch holds anything but whitespace and line comments.
+func hasStatement(batch string) bool {
+	for _, line := range strings.Split(batch, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return true
+		}
+	}
+	return false
+}
+
+// AzureSchemaVersion returns the HandReceipt schema version this build reads and writes.
+func AzureSchemaVersion() int {
+	migrations, err := loadAzureMigrations()
+	if err != nil {
+		return 0
+	}
+	return len(migrations)
+}
+
+// pendingAzureMigrations checks the recorded migrations against the embedded ones and
+// returns those still to apply. A recorded version this build does not know, or one whose
+// checksum differs, makes the schema incompatible.
+func pendingAzureMigrations(applied []appliedMigration, available []azureMigration) ([]azureMigration, error) {
+	recorded := make(map[int]string, len(applied))
+	for _, a := range applied {
+		if a.Version > len(available) {
+			return nil, fmt.Errorf("%w: database is at version %d, this build supports up to %d", ErrIncompatibleSchema, a.Version, len(available))
+		}
+		recorded[a.Version] = strings.TrimSpace(a.Checksum)
+	}
+
+	var pending []azureMigration
+	for _, m := range available {
+		checksum, ok := recorded[m.Version]
+		if !ok {
+			pending = append(pending, m)
+			continue
+		}
+		if len(pending) > 0 {
+			return nil, fmt.Errorf("%w: version %d is recorded but earlier version %d is not", ErrIncompatibleSchema, m.Version, pending[0].Version)
+		}
+		if checksum != m.Checksum {
+			return nil, fmt.Errorf("%w: version %d (%s) was applied from a different script", ErrIncompatibleSchema, m.Version, m.Description)
+		}
+	}
+	return pending, nil
+}
+
+// ensureSchema applies or verifies the HandReceipt schema according to mode.
+func (s *AzureSqlLedgerService) ensureSchema(ctx context.Context, mode AzureSchemaMode) error {
+	if mode == AzureSchemaOff || mode == "" {
+		return nil
+	}
+
+	available, err := loadAzureMigrations()
+	if err != nil {
+		return fmt.Errorf("failed to load Azure SQL migrations: %w", err)
+	}
+
+	// The lock is held by the session, so every statement runs on one connection
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open connection for schema check: %w", err)
+	}
+	defer conn.Close()
+
+	if mode == AzureSchemaApply {
+		var status int
+		err := conn.QueryRowContext(ctx, `DECLARE @status INT;
+			EXEC @status = sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = 60000;
+			SELECT @status;`, azureSchemaLock).Scan(&status)
+		if err != nil {
+			return fmt.Errorf("failed to lock Azure SQL schema: %w", err)
+		}
+		if status < 0 {
+			return fmt.Errorf("timed out waiting for another process to finish migrating the Azure SQL schema (sp_getapplock returned %d)", status)
+		}
+		defer conn.ExecContext(context.Background(), `EXEC sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'`, azureSchemaLock)
+
+		if err := s.checkUnversionedTables(ctx, conn); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, azureMigrationTable); err != nil {
+			return fmt.Errorf("failed to create schema version table: %w", err)
+		}
+	}
+
+	applied, err := readAppliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+	pending, err := pendingAzureMigrations(applied, available)
+	if err != nil {
+		return err
+	}
+
+	if mode == AzureSchemaVerify {
+		if len(applied) == 0 {
+			if err := s.checkUnversionedTables(ctx, conn); err != nil {
+				return err
+			}
+			return fmt.Errorf("%w: schema is not provisioned; start once with the schema mode set to %q", ErrIncompatibleSchema, AzureSchemaApply)
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%w: database is at version %d, this build needs version %d", ErrIncompatibleSchema, pending[0].Version-1, len(available))
+		}
+		log.Printf("Azure SQL ledger schema is at version %d", len(available))
+		return nil
+	}
+
+	for _, m := range pending {
+		for i, batch := range m.Batches {
+			if _, err := conn.ExecContext(ctx, batch); err != nil {
+				return fmt.Errorf("migration %d (%s) failed in batch %d: %w", m.Version, m.Description, i+1, err)
+			}
+		}
+		if _, err := conn.ExecContext(ctx,
+			`INSERT INTO HandReceipt.SchemaMigrations (Version, Description, Checksum) VALUES (@p1, @p2, @p3)`,
+			m.Version, m.Description, m.Checksum); err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+		}
+		log.Printf("Applied Azure SQL ledger migration %d (%s)", m.Version, m.Description)
+	}
+	log.Printf("Azure SQL ledger schema is at version %d", len(available))
+	return nil
+}
+
+// readAppliedMigrations returns the recorded migrations, or none if the version table does not exist.
+func readAppliedMigrations(ctx context.Context, conn *sql.Conn) ([]appliedMigration, error) {
+	var exists bool
+	if err := conn.QueryRowContext(ctx,
+		`SELECT CAST(CASE WHEN OBJECT_ID('HandReceipt.SchemaMigrations', 'U') IS NULL THEN 0 ELSE 1 END AS BIT)`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to look for schema version table: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT Version, Checksum FROM HandReceipt.SchemaMigrations ORDER BY Version`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	defer rows.Close()
+
+	var applied []appliedMigration
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.Version, &a.Checksum); err != nil {
+			return nil, fmt.Errorf("failed to read schema version: %w", err)
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+// checkUnversionedTables refuses a database whose ledger tables were created without
+// migrations, such as by the former hand-run schema script. Those are updatable ledger
+// tables without the _LedgerHistory views, and ledger tables cannot be converted in place.
+func (s *AzureSqlLedgerService) checkUnversionedTables(ctx context.Context, conn *sql.Conn) error {
+	var unversioned bool
+	err := conn.QueryRowContext(ctx, `SELECT CAST(CASE
+			WHEN OBJECT_ID('HandReceipt.SchemaMigrations', 'U') IS NULL AND OBJECT_ID('HandReceipt.EquipmentEvents', 'U') IS NOT NULL THEN 1
+			ELSE 0 END AS BIT)`).Scan(&unversioned)
+	if err != nil {
+		return fmt.Errorf("failed to inspect Azure SQL schema: %w", err)
+	}
+	if unversioned {
+		return fmt.Errorf("%w: HandReceipt ledger tables exist without a recorded schema version; provision a new database, or set the schema mode to %q to use them as they are", ErrIncompatibleSchema, AzureSchemaOff)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package ledger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAzureMigrations(t *testing.T) {
+	migrations, err := loadAzureMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	assert.Equal(t, len(migrations), AzureSchemaVersion())
+
+	first := migrations[0]
+	assert.Equal(t, 1, first.Version)
+	assert.Equal(t, "ledger tables", first.Description)
+	assert.Len(t, first.Checksum, 64)
+
+	// CREATE VIEW must start its batch, so each view needs a batch of its own
+	var views int
+	for _, batch := range first.Batches {
+		assert.NotEqual(t, "GO", strings.TrimSpace(batch))
+		if strings.Contains(batch, "CREATE OR ALTER VIEW") {
+			views++
+			assert.True(t, strings.HasPrefix(batch, "CREATE OR ALTER VIEW"), batch)
+		}
+	}
+	assert.Equal(t, 6, views, "every event table has a _LedgerHistory view")
+}
+
+func TestSplitBatches(t *testing.T) {
+	script := "-- header comment\nGO\nSELECT 1;\n  go  \nSELECT 2;\nSELECT 3;\nGO\n-- trailing comment\n"
+	assert.Equal(t, []string{"SELECT 1;", "SELECT 2;\nSELECT 3;"}, splitBatches(script))
+}
+
+func TestPendingAzureMigrations(t *testing.T) {
+	available := []azureMigration{
+		{Version: 1, Description: "ledger tables", Checksum: "aaa"},
+		{Version: 2, Description: "more tables", Checksum: "bbb"},
+	}
+	versions := func(ms []azureMigration) []int {
+		var v []int
+		for _, m := range ms {
+			v = append(v, m.Version)
+		}
+		return v
+	}
+
+	pending, err := pendingAzureMigrations(nil, available)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, versions(pending), "a new database needs every migration")
+
+	pending, err = pendingAzureMigrations([]appliedMigration{{Version: 1, Checksum: "aaa "}}, available)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, versions(pending), "CHAR padding on recorded checksums is ignored")
+
+	pending, err = pendingAzureMigrations([]appliedMigration{{Version: 1, Checksum: "aaa"}, {Version: 2, Checksum: "bbb"}}, available)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	_, err = pendingAzureMigrations([]appliedMigration{{Version: 3, Checksum: "ccc"}}, available)
+	assert.ErrorIs(t, err, ErrIncompatibleSchema, "a database newer than the build is refused")
+
+	_, err = pendingAzureMigrations([]appliedMigration{{Version: 1, Checksum: "edited"}}, available)
+	assert.ErrorIs(t, err, ErrIncompatibleSchema, "a migration applied from another script is refused")
+
+	_, err = pendingAzureMigrations([]appliedMigration{{Version: 2, Checksum: "bbb"}}, available)
+	assert.ErrorIs(t, err, ErrIncompatibleSchema, "a gap in the recorded versions is refused")
+}
@@ -0,0 +1,136 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+)
+
+// ErrInvalidCorrection is returned (wrapped) when a correction does not match the event it targets.
+var ErrInvalidCorrection = errors.New("invalid correction")
+
+// eventGetter is the part of LedgerService used to validate corrections.
+type eventGetter interface {
+	GetEvent(ctx context.Context, eventID string) (*domain.LedgerEvent, error)
+}
+
+// checkCorrectionTarget verifies that the event a correction targets exists and has the
+// type the correction claims. Corrections may target other corrections, which retracts them.
+func checkCorrectionTarget(ctx context.Context, ledger eventGetter, originalEventID string, eventType string) error {
+	if originalEventID == "" || eventType == "" {
+		return fmt.Errorf("%w: original event ID and type are required", ErrInvalidCorrection)
+	}
+
+	original, err := ledger.GetEvent(ctx, originalEventID)
+	if err != nil {
+		return err
+	}
+	if original.EventType != eventType {
+		return fmt.Errorf("%w: event %s is a %s, not a %s", ErrInvalidCorrection, originalEventID, original.EventType, eventType)
+	}
+	return nil
+}
+
+// HistoryView selects how a history view presents corrections.
+type HistoryView string
+
+const (
+	ViewRaw       HistoryView = "raw"       // Every event as recorded, annotated with its corrections (default)
+	ViewEffective HistoryView = "effective" // Corrections applied: voided events and corrections left out
+)
+
+// ParseHistoryView converts a view name, defaulting to ViewRaw when empty.
+func ParseHistoryView(name string) (HistoryView, error) {
+	switch view := HistoryView(name); view {
+	case "":
+		return ViewRaw, nil
+	case ViewRaw, ViewEffective:
+		return view, nil
+	default:
+		return "", fmt.Errorf("%w: view must be %q or %q", ErrInvalidHistoryQuery, ViewRaw, ViewEffective)
+	}
+}
+
+// ApplyCorrections reads every correction from the ledger and annotates events with the
+// ones that target them. For ViewEffective it also drops the events they void, as
+// EffectiveHistory does.
+func ApplyCorrections(ctx context.Context, svc LedgerService, events []domain.LedgerEvent, view HistoryView) ([]domain.LedgerEvent, error) {
+	corrections, err := svc.GetAllCorrectionEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	AnnotateCorrections(events, corrections)
+	if view == ViewEffective {
+		return EffectiveHistory(events, corrections), nil
+	}
+	return events, nil
+}
+
+// AnnotateCorrections sets LedgerEvent.Corrections on each event to the corrections that
+// target it, oldest first. corrections is typically the result of GetAllCorrectionEvents.
+func AnnotateCorrections(events []domain.LedgerEvent, corrections []domain.CorrectionEvent) {
+	byOriginal := correctionsByOriginal(corrections)
+	for i := range events {
+		events[i].Corrections = byOriginal[correctionKey(events[i].EventID)]
+	}
+}
+
+// EffectiveHistory applies corrections to events: it drops every event voided by a
+// correction, and the correction events themselves, leaving the corrected chain of custody.
+// A correction that is itself corrected is retracted, so the event it targeted stands.
+// corrections must include every correction in the ledger, not only those in events.
+func EffectiveHistory(events []domain.LedgerEvent, corrections []domain.CorrectionEvent) []domain.LedgerEvent {
+	byOriginal := correctionsByOriginal(corrections)
+
+	// A correction is in force unless a correction in force targets it
+	inForce := make(map[string]bool)
+	var voided func(eventID string) bool
+	voided = func(eventID string) bool {
+		for _, c := range byOriginal[correctionKey(eventID)] {
+			active, ok := inForce[c.EventID]
+			if !ok {
+				active = !voided(c.EventID)
+				inForce[c.EventID] = active
+			}
+			if active {
+				return true
+			}
+		}
+		return false
+	}
+
+	effective := make([]domain.LedgerEvent, 0, len(events))
+	for _, event := range events {
+		if event.EventType == domain.LedgerEventCorrection || voided(event.EventID) {
+			continue
+		}
+		effective = append(effective, event)
+	}
+	return effective
+}
+
+// correctionsByOriginal groups corrections by the event they target, oldest first.
+func correctionsByOriginal(corrections []domain.CorrectionEvent) map[string][]domain.CorrectionEvent {
+	sorted := append([]domain.CorrectionEvent(nil), corrections...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CorrectionTimestamp.Before(sorted[j].CorrectionTimestamp)
+	})
+
+	byOriginal := make(map[string][]domain.CorrectionEvent)
+	for _, c := range sorted {
+		key := correctionKey(c.OriginalEventID)
+		byOriginal[key] = append(byOriginal[key], c)
+	}
+	return byOriginal
+}
+
+// correctionKey normalizes an event ID for matching, since Azure SQL may render the same
+// UNIQUEIDENTIFIER in a different case than the ID the correction was logged with.
+func correctionKey(eventID string) string {
+	return strings.ToLower(eventID)
+}
@@ -0,0 +1,65 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+)
+
+func eventIDs(events []domain.LedgerEvent) []string {
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.EventID
+	}
+	return ids
+}
+
+func TestApplyCorrections_RawAndEffectiveViews(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := setupLocalLedger(t)
+
+	_, err := svc.LogTransferEvent(ctx, domain.Transfer{ID: 3, PropertyID: 7, FromUserID: 1, ToUserID: 2, Status: "Completed"}, "SN-7", 1, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogTransferEvent(ctx, domain.Transfer{ID: 4, PropertyID: 7, FromUserID: 2, ToUserID: 5, Status: "Completed"}, "SN-7", 2, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogStatusChange(ctx, 7, "SN-7", "Operational", "Lost", 5, WriteOptions{})
+	require.NoError(t, err)
+	history, err := svc.GetItemHistory(ctx, 7)
+	require.NoError(t, err)
+	first, second, lost := history[0].EventID, history[1].EventID, history[2].EventID
+
+	// The second transfer is voided; the status change is voided and then the correction retracted
+	_, err = svc.LogCorrectionEvent(ctx, second, domain.LedgerEventTransfer, "sent to the wrong soldier", 1, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogCorrectionEvent(ctx, lost, domain.LedgerEventStatusChange, "item was not lost", 1, WriteOptions{})
+	require.NoError(t, err)
+	corrections, err := svc.GetCorrectionEventsByOriginalID(ctx, lost)
+	require.NoError(t, err)
+	_, err = svc.LogCorrectionEvent(ctx, corrections[0].EventID, domain.LedgerEventCorrection, "item was found to be lost after all", 1, WriteOptions{})
+	require.NoError(t, err)
+
+	raw, err := ApplyCorrections(ctx, svc, history, ViewRaw)
+	require.NoError(t, err)
+	assert.Equal(t, []string{first, second, lost}, eventIDs(raw))
+	assert.Empty(t, raw[0].Corrections)
+	require.Len(t, raw[1].Corrections, 1)
+	assert.Equal(t, "sent to the wrong soldier", raw[1].Corrections[0].Reason)
+	assert.Len(t, raw[2].Corrections, 1)
+
+	effective, err := ApplyCorrections(ctx, svc, history, ViewEffective)
+	require.NoError(t, err)
+	assert.Equal(t, []string{first, lost}, eventIDs(effective))
+
+	page, err := svc.GetGeneralHistory(ctx, HistoryQuery{Order: SortAsc})
+	require.NoError(t, err)
+	require.Len(t, page.Events, 6)
+	effective, err = ApplyCorrections(ctx, svc, page.Events, ViewEffective)
+	require.NoError(t, err)
+	assert.Equal(t, []string{first, lost}, eventIDs(effective), "correction events are left out of the effective view")
+
+	_, err = ParseHistoryView("corrected")
+	assert.ErrorIs(t, err, ErrInvalidHistoryQuery)
+}
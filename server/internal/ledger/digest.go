@@ -0,0 +1,28 @@
+package ledger
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrDigestMismatch is returned (wrapped) by VerifyDigest when the current ledger does
+	// not extend the digest, i.e. history up to the digest was rewritten or truncated.
+	ErrDigestMismatch = errors.New("ledger does not extend digest")
+	// ErrForeignDigest is returned (wrapped) by VerifyDigest for a digest captured from a
+	// different ledger backend or database, which the current ledger cannot be checked against.
+	ErrForeignDigest = errors.New("digest belongs to a different ledger")
+)
+
+// Digest commits to the whole ledger as of one point in its history. Digests are captured
+// on a schedule and kept outside the ledger, so a later check can prove the ledger has only
+// been appended to since.
+type Digest struct {
+	Backend    string          `json:"backend"`            // receipt.Backend* value of the ledger it was captured from
+	Database   string          `json:"database,omitempty"` // ImmuDB or Azure SQL database name
+	Position   uint64          `json:"position"`           // ImmuDB transaction ID, Azure SQL block ID, or local sequence number
+	Hash       string          `json:"hash"`               // Ledger hash at Position, as reported by the backend
+	CapturedAt time.Time       `json:"capturedAt"`
+	Raw        json.RawMessage `json:"raw,omitempty"` // Backend digest as produced, e.g. the Azure SQL digest JSON
+}
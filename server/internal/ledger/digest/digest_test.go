@@ -0,0 +1,135 @@
+package digest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+)
+
+// openLocalLedger opens (or reopens) the local ledger at path.
+func openLocalLedger(t *testing.T, path string) *ledger.LocalLedgerService {
+	svc, err := ledger.NewLocalLedgerService(path)
+	require.NoError(t, err)
+	require.NoError(t, svc.Initialize(context.Background()))
+	t.Cleanup(func() { svc.Close() })
+	return svc
+}
+
+func logStatusChanges(t *testing.T, svc ledger.LedgerService, statuses ...string) {
+	for _, status := range statuses {
+		_, err := svc.LogStatusChange(context.Background(), 7, "SN-7", "Operational", status, 1, ledger.WriteOptions{})
+		require.NoError(t, err)
+	}
+}
+
+func TestVerify_LedgerExtendsDigests(t *testing.T) {
+	ctx := context.Background()
+	svc := openLocalLedger(t, filepath.Join(t.TempDir(), "ledger.jsonl"))
+	store, err := NewDirStore(t.TempDir())
+	require.NoError(t, err)
+
+	// A digest of the empty ledger, then one after each batch of events
+	_, err = Capture(ctx, svc, store)
+	require.NoError(t, err)
+	logStatusChanges(t, svc, "Damaged", "Operational")
+	_, err = Capture(ctx, svc, store)
+	require.NoError(t, err)
+	logStatusChanges(t, svc, "Lost")
+	latest, err := Capture(ctx, svc, store)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), latest.Position)
+
+	report, err := Verify(ctx, svc, store)
+	require.NoError(t, err)
+	assert.True(t, report.Verified)
+	assert.Equal(t, 3, report.DigestsStored)
+	assert.Equal(t, 3, report.DigestsChecked)
+	assert.Nil(t, report.FirstFailure)
+	require.NotNil(t, report.LastVerified)
+	assert.Equal(t, latest.Hash, report.LastVerified.Hash)
+}
+
+func TestVerify_ReportsFirstDigestNotExtended(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ledger.jsonl")
+	store, err := NewDirStore(t.TempDir())
+	require.NoError(t, err)
+
+	original := openLocalLedger(t, path)
+	logStatusChanges(t, original, "Damaged")
+	first, err := Capture(ctx, original, store)
+	require.NoError(t, err)
+	logStatusChanges(t, original, "Operational")
+	second, err := Capture(ctx, original, store)
+	require.NoError(t, err)
+	require.NoError(t, original.Close())
+
+	// Rewrite history with a valid chain that diverges after the first digest
+	forgedPath := filepath.Join(dir, "forged.jsonl")
+	require.NoError(t, os.WriteFile(forgedPath, firstLine(t, path), 0o644))
+	forger := openLocalLedger(t, forgedPath)
+	logStatusChanges(t, forger, "Lost", "Operational")
+	require.NoError(t, forger.Close())
+	require.NoError(t, os.Rename(forgedPath, path))
+
+	report, err := Verify(ctx, openLocalLedger(t, path), store)
+	require.NoError(t, err)
+	assert.False(t, report.Verified)
+	require.NotNil(t, report.LastVerified)
+	assert.Equal(t, first.Hash, report.LastVerified.Hash)
+	require.NotNil(t, report.FirstFailure)
+	assert.Equal(t, second.Hash, report.FirstFailure.Digest.Hash)
+	assert.Contains(t, report.FirstFailure.Reason, "record 2")
+}
+
+func TestVerify_SkipsForeignDigests(t *testing.T) {
+	ctx := context.Background()
+	svc := openLocalLedger(t, filepath.Join(t.TempDir(), "ledger.jsonl"))
+	store, err := NewDirStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Append(ctx, ledger.Digest{Backend: "immudb", Database: "defaultdb", Position: 12, Hash: "ab", CapturedAt: time.Now().UTC()}))
+	_, err = Capture(ctx, svc, store)
+	require.NoError(t, err)
+
+	report, err := Verify(ctx, svc, store)
+	require.NoError(t, err)
+	assert.True(t, report.Verified)
+	assert.Equal(t, 1, report.DigestsSkipped)
+	assert.Equal(t, 1, report.DigestsChecked)
+}
+
+func TestDirStore_NeverOverwrites(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewDirStore(t.TempDir())
+	require.NoError(t, err)
+
+	digest := ledger.Digest{Backend: "local", Position: 1, Hash: "ab", CapturedAt: time.Now().UTC()}
+	require.NoError(t, store.Append(ctx, digest))
+	digest.Hash = "cd"
+	assert.ErrorIs(t, store.Append(ctx, digest), ErrDigestExists)
+
+	digests, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, digests, 1)
+	assert.Equal(t, "ab", digests[0].Hash)
+}
+
+// firstLine returns the first entry of a ledger file, newline included.
+func firstLine(t *testing.T, path string) []byte {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	for i, b := range data {
+		if b == '\n' {
+			return data[:i+1]
+		}
+	}
+	return data
+}
@@ -0,0 +1,91 @@
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+)
+
+// DirStore keeps one read-only JSON file per digest in a local directory. Files are created
+// exclusively, so an existing digest is never overwritten. For tamper resistance the
+// directory should live on different storage, and under different credentials, than the ledger.
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore creates a store in dir, creating the directory if it does not exist.
+func NewDirStore(dir string) (*DirStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("digest directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create digest directory: %w", err)
+	}
+	return &DirStore{dir: dir}, nil
+}
+
+// Append writes the digest to a new file.
+func (s *DirStore) Append(ctx context.Context, digest ledger.Digest) error {
+	data, err := json.Marshal(digest)
+	if err != nil {
+		return fmt.Errorf("failed to encode digest: %w", err)
+	}
+
+	path := filepath.Join(s.dir, objectName(digest))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o444)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%w: %s", ErrDigestExists, path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create digest file: %w", err)
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write digest file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync digest file: %w", err)
+	}
+	return file.Close()
+}
+
+// List reads every digest file in the directory, oldest first.
+func (s *DirStore) List(ctx context.Context) ([]ledger.Digest, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list digest directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && isDigestName(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	digests := make([]ledger.Digest, 0, len(names))
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read digest %s: %w", name, err)
+		}
+		var digest ledger.Digest
+		if err := json.Unmarshal(data, &digest); err != nil {
+			return nil, fmt.Errorf("failed to decode digest %s: %w", name, err)
+		}
+		digests = append(digests, digest)
+	}
+	return digests, nil
+}
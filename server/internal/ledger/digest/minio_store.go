@@ -0,0 +1,93 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"github.com/toole-brendan/handreceipt-go/internal/services/storage"
+)
+
+// MinIOStore keeps one JSON object per digest under a prefix in a MinIO bucket. It refuses
+// to overwrite an existing object; enable object locking (WORM retention) on the bucket so
+// that MinIO enforces the same for everyone else.
+type MinIOStore struct {
+	storage *storage.MinIOService
+	prefix  string
+}
+
+// NewMinIOStore creates a store for objects under prefix (e.g. "ledger-digests/").
+func NewMinIOStore(storageService *storage.MinIOService, prefix string) *MinIOStore {
+	return &MinIOStore{storage: storageService, prefix: prefix}
+}
+
+// Append uploads the digest as a new object.
+func (s *MinIOStore) Append(ctx context.Context, digest ledger.Digest) error {
+	data, err := json.Marshal(digest)
+	if err != nil {
+		return fmt.Errorf("failed to encode digest: %w", err)
+	}
+
+	name := s.prefix + objectName(digest)
+	_, err = s.storage.GetFileInfo(ctx, name)
+	if err == nil {
+		return fmt.Errorf("%w: %s", ErrDigestExists, name)
+	}
+	var notFound minio.ErrorResponse
+	if !errors.As(err, &notFound) || notFound.Code != "NoSuchKey" {
+		return err
+	}
+
+	return s.storage.UploadFile(ctx, name, bytes.NewReader(data), int64(len(data)), "application/json")
+}
+
+// List downloads every digest object under the prefix, oldest first.
+func (s *MinIOStore) List(ctx context.Context) ([]ledger.Digest, error) {
+	files, err := s.storage.ListFiles(ctx, s.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, name := range files {
+		if isDigestName(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	digests := make([]ledger.Digest, 0, len(names))
+	for _, name := range names {
+		digest, err := s.read(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		digests = append(digests, *digest)
+	}
+	return digests, nil
+}
+
+// read downloads and decodes one digest object.
+func (s *MinIOStore) read(ctx context.Context, name string) (*ledger.Digest, error) {
+	object, err := s.storage.DownloadFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read digest %s: %w", name, err)
+	}
+	var digest ledger.Digest
+	if err := json.Unmarshal(data, &digest); err != nil {
+		return nil, fmt.Errorf("failed to decode digest %s: %w", name, err)
+	}
+	return &digest, nil
+}
@@ -0,0 +1,33 @@
+// Package digest anchors the ledger: it captures ledger digests on a schedule, keeps them in
+// an append-only store outside the ledger, and proves the current ledger extends every one.
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+)
+
+// ErrDigestExists is returned by Store.Append when a digest with the same name is already stored.
+var ErrDigestExists = errors.New("digest already stored")
+
+// Store keeps captured digests. Stores only ever add digests; none is replaced or removed.
+type Store interface {
+	// Append stores a digest.
+	Append(ctx context.Context, digest ledger.Digest) error
+	// List returns every stored digest, oldest first.
+	List(ctx context.Context) ([]ledger.Digest, error)
+}
+
+// objectName names a stored digest so that names sort in capture order.
+func objectName(d ledger.Digest) string {
+	return fmt.Sprintf("%s-%s-%020d.json", d.CapturedAt.UTC().Format("20060102T150405.000000000Z"), d.Backend, d.Position)
+}
+
+// isDigestName reports whether name looks like one written by objectName.
+func isDigestName(name string) bool {
+	return strings.HasSuffix(name, ".json")
+}
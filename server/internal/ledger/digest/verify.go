@@ -0,0 +1,71 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+)
+
+// Report is the outcome of checking the ledger against every stored digest.
+type Report struct {
+	DigestsStored  int            `json:"digestsStored"`
+	DigestsChecked int            `json:"digestsChecked"`
+	DigestsSkipped int            `json:"digestsSkipped"` // Captured from another ledger backend or database
+	Verified       bool           `json:"verified"`       // Every checked digest is extended by the current ledger
+	LastVerified   *ledger.Digest `json:"lastVerified,omitempty"`
+	FirstFailure   *Failure       `json:"firstFailure,omitempty"`
+}
+
+// Failure is the oldest digest the current ledger does not extend. History was changed at
+// or before this digest, and after Report.LastVerified when that is set.
+type Failure struct {
+	Digest ledger.Digest `json:"digest"`
+	Reason string        `json:"reason"`
+}
+
+// Capture captures a digest of the ledger and appends it to the store.
+func Capture(ctx context.Context, ledgerService ledger.LedgerService, store Store) (*ledger.Digest, error) {
+	digest, err := ledgerService.CaptureDigest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Append(ctx, *digest); err != nil {
+		return nil, fmt.Errorf("failed to store ledger digest: %w", err)
+	}
+	return digest, nil
+}
+
+// Verify checks the current ledger against the stored digests, oldest first, and stops at
+// the first digest it does not extend. Errors are returned only when a check could not be
+// completed; a ledger that fails a check is reported through Report.FirstFailure.
+func Verify(ctx context.Context, ledgerService ledger.LedgerService, store Store) (*Report, error) {
+	digests, err := store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger digests: %w", err)
+	}
+
+	report := &Report{DigestsStored: len(digests), Verified: true}
+	for i := range digests {
+		digest := digests[i]
+
+		err := ledgerService.VerifyDigest(ctx, digest)
+		switch {
+		case err == nil:
+			report.DigestsChecked++
+			report.LastVerified = &digest
+		case errors.Is(err, ledger.ErrForeignDigest):
+			report.DigestsSkipped++
+		case errors.Is(err, ledger.ErrDigestMismatch):
+			report.DigestsChecked++
+			report.Verified = false
+			report.FirstFailure = &Failure{Digest: digest, Reason: err.Error()}
+			return report, nil
+		default:
+			return nil, fmt.Errorf("failed to verify digest captured at %s: %w", digest.CapturedAt.Format(time.RFC3339), err)
+		}
+	}
+	return report, nil
+}
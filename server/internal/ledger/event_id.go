@@ -0,0 +1,67 @@
+package ledger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+)
+
+// NewEventID returns a new ledger event ID. IDs are UUIDv7, so they are unique across
+// backends and processes and sort in the order they were issued.
+func NewEventID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
+
+// WriteOptions controls how a LedgerService Log* method records its event. The zero value
+// records the event under a new ID.
+type WriteOptions struct {
+	// EventID, if set, is the ID to record the event under instead of a new one. A backend
+	// that already holds an event with that ID returns it without writing it again, so a
+	// retried write is recorded once.
+	EventID string
+	// OccurredAt, if set, is when the recorded action took place, which the backend stores
+	// as the event's timestamp instead of the time of the write. Writes delivered late, such
+	// as from the outbox, set it so the ledger orders and replays events as they happened.
+	OccurredAt time.Time
+	// Signature, if set, is the acting user's signature over the event, already checked by
+	// EventSigner.VerifyUserSignature, which the backend stores with the event. Otherwise
+	// the backend signs events no user performed with the server's key.
+	Signature *domain.EventSignature
+}
+
+// eventID returns the event ID a write should use: the preassigned opts.EventID, reported
+// by preassigned, or a new one.
+func (opts WriteOptions) eventID() (eventID string, preassigned bool) {
+	if opts.EventID != "" {
+		return opts.EventID, true
+	}
+	return NewEventID(), false
+}
+
+// timestamp returns the time a write should record: opts.OccurredAt, or now.
+func (opts WriteOptions) timestamp() time.Time {
+	if !opts.OccurredAt.IsZero() {
+		return opts.OccurredAt.UTC()
+	}
+	return time.Now().UTC()
+}
+
+// signature returns the signature to store with event: opts.Signature, or the server's
+// signature of a system event, or nil.
+func (opts WriteOptions) signature(signer *EventSigner, event domain.LedgerEvent) (*domain.EventSignature, error) {
+	if opts.Signature != nil {
+		return opts.Signature, nil
+	}
+	return signer.SignSystemEvent(event)
+}
+
+// existingWrite reports an earlier write of a preassigned event ID. It is an error for the
+// ID to be taken by an event of a different type.
+func existingWrite(event *domain.LedgerEvent, eventType string) (*domain.LedgerWriteResult, error) {
+	if event.EventType != eventType {
+		return nil, fmt.Errorf("ledger event ID %s is already used by a %s", event.EventID, event.EventType)
+	}
+	return &domain.LedgerWriteResult{EventID: event.EventID, Ledger: event.Ledger}, nil
+}
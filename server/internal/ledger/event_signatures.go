@@ -0,0 +1,351 @@
+package ledger
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/services/signing"
+)
+
+// Layouts of canonicalEvent. Keep verifying the old layouts when adding one.
+const (
+	canonicalEventServer = 1 // Signed by the server, over the event as its backend records it
+	canonicalEventUser   = 2 // Signed by the acting user's client, before the server assigns IDs
+)
+
+// canonicalEvent is the part of a ledger event a signature covers: what happened, to what
+// and by whom. The timestamp and ledger location are left out, as the backend assigns them
+// when it commits the event, after it has been signed.
+type canonicalEvent struct {
+	Version      int     `json:"v"`
+	EventID      string  `json:"eventId"`
+	EventType    string  `json:"eventType"`
+	ActorUserID  *uint64 `json:"actorUserId"`
+	ItemID       *uint64 `json:"itemId"`
+	SerialNumber string  `json:"serialNumber"`
+
+	ItemCreation *domain.ItemCreationDetails `json:"itemCreation,omitempty"`
+	Transfer     *domain.TransferDetails     `json:"transfer,omitempty"`
+	StatusChange *domain.StatusChangeDetails `json:"statusChange,omitempty"`
+	Verification *domain.VerificationDetails `json:"verification,omitempty"`
+	Maintenance  *domain.MaintenanceDetails  `json:"maintenance,omitempty"`
+	Correction   *domain.CorrectionDetails   `json:"correction,omitempty"`
+}
+
+func newCanonicalEvent(version int, event domain.LedgerEvent) canonicalEvent {
+	return canonicalEvent{
+		Version:      version,
+		EventID:      event.EventID,
+		EventType:    event.EventType,
+		ActorUserID:  event.ActorUserID,
+		ItemID:       event.ItemID,
+		SerialNumber: event.SerialNumber,
+		ItemCreation: event.ItemCreation,
+		Transfer:     event.Transfer,
+		StatusChange: event.StatusChange,
+		Verification: event.Verification,
+		Maintenance:  event.Maintenance,
+		Correction:   event.Correction,
+	}
+}
+
+// CanonicalEventBytes returns the bytes a user signs for an event they perform: the JSON
+// encoding of the event's identity, actor, item and details, with fields in the order of
+// the domain types, omitempty fields left out and no HTML escaping. IDs the server assigns
+// when it records the action, the ID of a new item and of a transfer, are left out, so
+// the client can sign before sending the request.
+func CanonicalEventBytes(event domain.LedgerEvent) ([]byte, error) {
+	canonical := newCanonicalEvent(canonicalEventUser, event)
+	if event.EventType == domain.LedgerEventItemCreation {
+		canonical.ItemID = nil
+	}
+	if event.Transfer != nil {
+		transfer := *event.Transfer
+		transfer.TransferID = ""
+		canonical.Transfer = &transfer
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(canonical); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// serverEventBytes returns the bytes a server signature covers: the event as its backend
+// records it.
+func serverEventBytes(event domain.LedgerEvent) ([]byte, error) {
+	return json.Marshal(newCanonicalEvent(canonicalEventServer, event))
+}
+
+// ErrInvalidEventSignature is returned (wrapped) by VerifyUserSignature when a user's
+// signature does not verify.
+var ErrInvalidEventSignature = errors.New("invalid ledger event signature")
+
+// KeyDirectory holds the enrolled signing keys. The repository implements it.
+type KeyDirectory interface {
+	CreateSigningKey(key *domain.SigningKey) error
+	GetSigningKeyByKeyID(keyID string) (*domain.SigningKey, error) // nil if not enrolled
+}
+
+// EventSigner signs the ledger events no user performed and verifies event signatures.
+// Users sign the events they perform on their own devices, with keys enrolled in the key
+// directory; the server only checks those signatures and never holds the users' keys. A
+// nil *EventSigner signs nothing.
+type EventSigner struct {
+	server      *signing.Signer
+	keys        KeyDirectory
+	requireUser bool
+}
+
+// NewEventSigner creates an event signer that signs system events with server and looks
+// signing keys up in keys. The server's key must be enrolled; see EnrollKey. Processes
+// that only write to the ledger may pass nil keys; they cannot verify signatures.
+func NewEventSigner(server *signing.Signer, keys KeyDirectory) *EventSigner {
+	return &EventSigner{server: server, keys: keys}
+}
+
+// RequireUserSignatures makes handlers refuse to record a user's action without their
+// signature. Call it before the signer is shared between goroutines.
+func (s *EventSigner) RequireUserSignatures() {
+	s.requireUser = true
+}
+
+// UserSignaturesRequired reports whether users must sign the events they perform. It is
+// false when s is nil.
+func (s *EventSigner) UserSignaturesRequired() bool {
+	return s != nil && s.requireUser
+}
+
+// signingUser returns the user who signs event, or nil when the server does. User ID 0
+// is the system.
+func signingUser(event domain.LedgerEvent) *uint64 {
+	if event.ActorUserID == nil || *event.ActorUserID == 0 {
+		return nil
+	}
+	return event.ActorUserID
+}
+
+// SignSystemEvent signs event, as its backend will return it, with the server's key. Only
+// events no user performed are signed; for others, and when s is nil, it returns nil.
+func (s *EventSigner) SignSystemEvent(event domain.LedgerEvent) (*domain.EventSignature, error) {
+	if s == nil || signingUser(event) != nil {
+		return nil, nil
+	}
+	payload, err := serverEventBytes(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ledger event for signing: %w", err)
+	}
+	return &domain.EventSignature{
+		Algorithm: "Ed25519",
+		Signer:    domain.SignerServer,
+		KeyID:     s.server.KeyID(),
+		PublicKey: base64.StdEncoding.EncodeToString(s.server.PublicKey()),
+		Value:     base64.StdEncoding.EncodeToString(s.server.Sign(payload)),
+	}, nil
+}
+
+// VerifyUserSignature checks value, a base64 signature over CanonicalEventBytes(event),
+// when the actor of event performs it at at. The key must be the actor's and valid at at.
+// It returns the signature to record with the event, or an error wrapping
+// ErrInvalidEventSignature.
+func (s *EventSigner) VerifyUserSignature(event domain.LedgerEvent, keyID, value string, at time.Time) (*domain.EventSignature, error) {
+	userID := signingUser(event)
+	if userID == nil {
+		return nil, fmt.Errorf("%w: the event has no acting user", ErrInvalidEventSignature)
+	}
+	key, err := s.keys.GetSigningKeyByKeyID(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up signing key %s: %w", keyID, err)
+	}
+	if key == nil || !key.OwnedBy(userID) {
+		return nil, fmt.Errorf("%w: key %s is not enrolled by user %d", ErrInvalidEventSignature, keyID, *userID)
+	}
+	if !key.ValidAt(at) {
+		return nil, fmt.Errorf("%w: key %s is not valid at %s", ErrInvalidEventSignature, keyID, at.Format(time.RFC3339))
+	}
+
+	publicKey, err := base64.StdEncoding.DecodeString(key.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("enrolled signing key %s is malformed", keyID)
+	}
+	signature, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEventSignature, err)
+	}
+	payload, err := CanonicalEventBytes(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ledger event for verification: %w", err)
+	}
+	if !ed25519.Verify(publicKey, payload, signature) {
+		return nil, fmt.Errorf("%w: the signature does not match event %s", ErrInvalidEventSignature, event.EventID)
+	}
+
+	id := *userID
+	return &domain.EventSignature{
+		Algorithm: "Ed25519",
+		Signer:    domain.SignerUser,
+		UserID:    &id,
+		KeyID:     keyID,
+		PublicKey: key.PublicKey,
+		Value:     value,
+		Payload:   base64.StdEncoding.EncodeToString(payload),
+	}, nil
+}
+
+// Verify returns the domain.SignatureStatus* value for event. The signature must match the
+// event and name its actor (or the server); it is verified only if made with a key the
+// signer had enrolled when the event occurred. A nil s checks the first two but reports at
+// best unknown_key.
+func (s *EventSigner) Verify(event domain.LedgerEvent) string {
+	return s.verify(event, s.lookupKey)
+}
+
+// VerifySignatures sets SignatureStatus on each event.
+func (s *EventSigner) VerifySignatures(events []domain.LedgerEvent) {
+	keys := make(map[string]*domain.SigningKey)
+	lookup := func(keyID string) (*domain.SigningKey, error) {
+		if key, ok := keys[keyID]; ok {
+			return key, nil
+		}
+		key, err := s.lookupKey(keyID)
+		if err == nil {
+			keys[keyID] = key
+		}
+		return key, err
+	}
+	for i := range events {
+		events[i].SignatureStatus = s.verify(events[i], lookup)
+	}
+}
+
+func (s *EventSigner) lookupKey(keyID string) (*domain.SigningKey, error) {
+	if s == nil || s.keys == nil {
+		return nil, nil
+	}
+	return s.keys.GetSigningKeyByKeyID(keyID)
+}
+
+func (s *EventSigner) verify(event domain.LedgerEvent, lookup func(keyID string) (*domain.SigningKey, error)) string {
+	sig := event.Signature
+	if sig == nil {
+		return domain.SignatureStatusUnsigned
+	}
+
+	userID := signingUser(event)
+	switch {
+	case sig.Algorithm != "Ed25519":
+		return domain.SignatureStatusInvalid
+	case userID == nil && (sig.Signer != domain.SignerServer || sig.UserID != nil || sig.Payload != ""):
+		return domain.SignatureStatusInvalid
+	case userID != nil && (sig.Signer != domain.SignerUser || sig.UserID == nil || *sig.UserID != *userID):
+		return domain.SignatureStatusInvalid
+	}
+
+	publicKey, err := base64.StdEncoding.DecodeString(sig.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize || signing.KeyID(publicKey) != sig.KeyID {
+		return domain.SignatureStatusInvalid
+	}
+	value, err := base64.StdEncoding.DecodeString(sig.Value)
+	if err != nil {
+		return domain.SignatureStatusInvalid
+	}
+	if sig.Payload != "" {
+		payload, err := base64.StdEncoding.DecodeString(sig.Payload)
+		if err != nil || !ed25519.Verify(publicKey, payload, value) || !signedAsRecorded(payload, event) {
+			return domain.SignatureStatusInvalid
+		}
+	} else {
+		// Events users signed before they enrolled their own keys were signed by the
+		// server on their behalf, over the event as recorded.
+		payload, err := serverEventBytes(event)
+		if err != nil || !ed25519.Verify(publicKey, payload, value) {
+			return domain.SignatureStatusInvalid
+		}
+	}
+
+	key, err := lookup(sig.KeyID)
+	if err != nil {
+		log.Printf("Failed to load key %s to verify ledger event %s: %v", sig.KeyID, event.EventID, err)
+		return domain.SignatureStatusUnknownKey
+	}
+	if key == nil || !key.OwnedBy(userID) || key.PublicKey != sig.PublicKey || !key.ValidAt(event.Timestamp) {
+		return domain.SignatureStatusUnknownKey
+	}
+	return domain.SignatureStatusVerified
+}
+
+// signedAsRecorded reports whether payload, the canonical event a user signed, is the
+// event its backend recorded: the event ID, type and actor must match, and so must every
+// detail the backend recorded. Backends record different subsets of an event's details,
+// so details a backend leaves out are covered by the payload alone.
+func signedAsRecorded(payload []byte, event domain.LedgerEvent) bool {
+	recordedBytes, err := CanonicalEventBytes(event)
+	if err != nil {
+		return false
+	}
+	var signed, recorded map[string]interface{}
+	if json.Unmarshal(payload, &signed) != nil || json.Unmarshal(recordedBytes, &recorded) != nil {
+		return false
+	}
+	if signed["v"] != float64(canonicalEventUser) {
+		return false
+	}
+	for _, field := range []string{"eventId", "eventType", "actorUserId"} {
+		if !reflect.DeepEqual(signed[field], recorded[field]) {
+			return false
+		}
+	}
+	return covers(signed, recorded)
+}
+
+// covers reports whether every non-empty value in recorded equals the value at the same
+// place in signed.
+func covers(signed, recorded interface{}) bool {
+	switch r := recorded.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		s, _ := signed.(map[string]interface{})
+		for field, value := range r {
+			if !covers(s[field], value) {
+				return false
+			}
+		}
+		return true
+	case string:
+		if r == "" {
+			return true
+		}
+	case bool:
+		if !r {
+			return true
+		}
+	case float64:
+		if r == 0 {
+			return true
+		}
+	}
+	return reflect.DeepEqual(signed, recorded)
+}
+
+// SigningBackend is implemented by the ledger backends that can sign the events they write.
+type SigningBackend interface {
+	SetEventSigner(signer *EventSigner)
+}
+
+var (
+	_ SigningBackend = (*LocalLedgerService)(nil)
+	_ SigningBackend = (*ImmuDBLedgerService)(nil)
+	_ SigningBackend = (*AzureSqlLedgerService)(nil)
+)
@@ -0,0 +1,216 @@
+package ledger
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/services/signing"
+)
+
+// memKeys is an in-memory KeyDirectory.
+type memKeys map[string]*domain.SigningKey
+
+func (k memKeys) CreateSigningKey(key *domain.SigningKey) error {
+	k[key.KeyID] = key
+	return nil
+}
+
+func (k memKeys) GetSigningKeyByKeyID(keyID string) (*domain.SigningKey, error) {
+	return k[keyID], nil
+}
+
+// newTestEventSigner returns a signer whose server key is enrolled in keys.
+func newTestEventSigner(t *testing.T, keys memKeys) *EventSigner {
+	server := newTestSigner(t)
+	_, err := EnrollKey(keys, nil, server.PublicKey(), time.Time{})
+	require.NoError(t, err)
+	return NewEventSigner(server, keys)
+}
+
+// enrollUserKey generates a key on the "device" of userID and enrolls its public half.
+func enrollUserKey(t *testing.T, keys memKeys, userID uint, validFrom time.Time) (ed25519.PrivateKey, string) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := EnrollKey(keys, &userID, public, validFrom)
+	require.NoError(t, err)
+	return private, key.KeyID
+}
+
+// signOnDevice signs event as a client does.
+func signOnDevice(t *testing.T, key ed25519.PrivateKey, event domain.LedgerEvent) string {
+	payload, err := CanonicalEventBytes(event)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+}
+
+func TestLocalLedger_SignsEvents(t *testing.T) {
+	ctx := context.Background()
+	svc, path := setupLocalLedger(t)
+	keys := memKeys{}
+	signer := newTestEventSigner(t, keys)
+	svc.SetEventSigner(signer)
+	recipientKey, recipientKeyID := enrollUserKey(t, keys, 2, time.Now().Add(-time.Hour))
+
+	_, err := svc.LogItemCreation(ctx, domain.Property{ID: 7, SerialNumber: "SN-7", Name: "M4 Carbine", CurrentStatus: "Operational"}, 0, WriteOptions{})
+	require.NoError(t, err)
+
+	// The recipient approves a transfer the sender requested, signing on their device
+	transfer := domain.Transfer{ID: 3, PropertyID: 7, FromUserID: 1, ToUserID: 2, Status: "Approved"}
+	event := TransferEvent(NewEventID(), transfer, "SN-7", 2)
+	now := time.Now().UTC()
+	signature, err := signer.VerifyUserSignature(event, recipientKeyID, signOnDevice(t, recipientKey, event), now)
+	require.NoError(t, err)
+	_, err = svc.LogTransferEvent(ctx, transfer, "SN-7", 2, WriteOptions{EventID: event.EventID, OccurredAt: now, Signature: signature})
+	require.NoError(t, err)
+
+	_, err = svc.LogVerificationEvent(ctx, 7, "SN-7", 2, "Verified Present", WriteOptions{})
+	require.NoError(t, err)
+
+	// Signatures survive a reload from disk
+	require.NoError(t, svc.Close())
+	reopened, err := NewLocalLedgerService(path)
+	require.NoError(t, err)
+	require.NoError(t, reopened.Initialize(ctx))
+	defer reopened.Close()
+
+	history, err := reopened.GetItemHistory(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	signer.VerifySignatures(history)
+
+	assert.Equal(t, domain.SignerServer, history[0].Signature.Signer, "system events are signed by the server")
+	assert.Equal(t, domain.SignatureStatusVerified, history[0].SignatureStatus)
+	assert.Equal(t, uint64(2), *history[1].ActorUserID, "transfer events name the user who acted")
+	assert.Equal(t, uint64(2), *history[1].Signature.UserID)
+	assert.Equal(t, domain.SignatureStatusVerified, history[1].SignatureStatus)
+	assert.Nil(t, history[2].Signature, "the server never signs for a user")
+	assert.Equal(t, domain.SignatureStatusUnsigned, history[2].SignatureStatus)
+}
+
+func TestEventSigner_VerifyUserSignature(t *testing.T) {
+	keys := memKeys{}
+	signer := newTestEventSigner(t, keys)
+	now := time.Now().UTC()
+	key, keyID := enrollUserKey(t, keys, 5, now.Add(-time.Hour))
+	otherKey, otherKeyID := enrollUserKey(t, keys, 6, now.Add(-time.Hour))
+
+	event := StatusChangeEvent(NewEventID(), 9, "SN-9", "Operational", "Lost", 5)
+	signature, err := signer.VerifyUserSignature(event, keyID, signOnDevice(t, key, event), now)
+	require.NoError(t, err)
+	assert.Equal(t, domain.SignerUser, signature.Signer)
+	assert.Equal(t, uint64(5), *signature.UserID)
+	assert.NotEmpty(t, signature.Payload)
+
+	tampered := StatusChangeEvent(event.EventID, 9, "SN-9", "Operational", "Found", 5)
+	_, err = signer.VerifyUserSignature(tampered, keyID, signOnDevice(t, key, event), now)
+	assert.ErrorIs(t, err, ErrInvalidEventSignature)
+
+	// Users can only sign as themselves
+	_, err = signer.VerifyUserSignature(event, otherKeyID, signOnDevice(t, otherKey, event), now)
+	assert.ErrorIs(t, err, ErrInvalidEventSignature)
+
+	// Revoked keys sign nothing new
+	revokedAt := now.Add(-time.Minute)
+	keys[keyID].RevokedAt = &revokedAt
+	_, err = signer.VerifyUserSignature(event, keyID, signOnDevice(t, key, event), now)
+	assert.ErrorIs(t, err, ErrInvalidEventSignature)
+}
+
+func TestEventSigner_Verify(t *testing.T) {
+	keys := memKeys{}
+	signer := newTestEventSigner(t, keys)
+	signedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	key, keyID := enrollUserKey(t, keys, 5, signedAt.Add(-time.Hour))
+
+	event := StatusChangeEvent(NewEventID(), 9, "SN-9", "Operational", "Lost", 5)
+	signature, err := signer.VerifyUserSignature(event, keyID, signOnDevice(t, key, event), signedAt)
+	require.NoError(t, err)
+	event.Signature = signature
+	event.Timestamp = signedAt
+	assert.Equal(t, domain.SignatureStatusVerified, signer.Verify(event))
+
+	// The ledger location is assigned after signing, and a backend may record fewer
+	// details than the user signed
+	recorded := event
+	recorded.Ledger.Backend = "azure_sql"
+	recorded.SerialNumber = ""
+	assert.Equal(t, domain.SignatureStatusVerified, signer.Verify(recorded))
+
+	tampered := event
+	tampered.StatusChange = &domain.StatusChangeDetails{PreviousStatus: "Operational", NewStatus: "Found"}
+	assert.Equal(t, domain.SignatureStatusInvalid, signer.Verify(tampered))
+
+	// A signature cannot be passed off as another user's
+	otherActor := uint64(6)
+	reattributed := event
+	reattributed.ActorUserID = &otherActor
+	assert.Equal(t, domain.SignatureStatusInvalid, signer.Verify(reattributed))
+
+	// Rotating the key leaves the events it signed verifiable, but not ones dated later
+	revokedAt := signedAt.Add(24 * time.Hour)
+	keys[keyID].RevokedAt = &revokedAt
+	enrollUserKey(t, keys, 5, revokedAt)
+	assert.Equal(t, domain.SignatureStatusVerified, signer.Verify(event))
+	backdated := event
+	backdated.Timestamp = revokedAt.Add(time.Second)
+	assert.Equal(t, domain.SignatureStatusUnknownKey, signer.Verify(backdated))
+
+	// Valid signatures by keys that were never enrolled
+	assert.Equal(t, domain.SignatureStatusUnknownKey, newTestEventSigner(t, memKeys{}).Verify(event))
+	var noSigner *EventSigner
+	assert.Equal(t, domain.SignatureStatusUnknownKey, noSigner.Verify(event))
+
+	event.Signature = nil
+	assert.Equal(t, domain.SignatureStatusUnsigned, signer.Verify(event))
+	unsigned, err := noSigner.SignSystemEvent(domain.LedgerEvent{EventID: "system-event"})
+	require.NoError(t, err)
+	assert.Nil(t, unsigned)
+}
+
+func TestImportServerHeldKeys(t *testing.T) {
+	dir := t.TempDir()
+	held, err := signing.LoadOrCreateSigner(dir + "/user-5.pem")
+	require.NoError(t, err)
+
+	// An event the server signed for user 5 before users enrolled their own keys
+	actor := uint64(5)
+	event := domain.LedgerEvent{
+		EventID:      "legacy-event",
+		EventType:    domain.LedgerEventVerification,
+		Timestamp:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		ActorUserID:  &actor,
+		Verification: &domain.VerificationDetails{Result: "Verified Present"},
+	}
+	payload, err := serverEventBytes(event)
+	require.NoError(t, err)
+	event.Signature = &domain.EventSignature{
+		Algorithm: "Ed25519",
+		Signer:    domain.SignerUser,
+		UserID:    &actor,
+		KeyID:     held.KeyID(),
+		PublicKey: base64.StdEncoding.EncodeToString(held.PublicKey()),
+		Value:     base64.StdEncoding.EncodeToString(held.Sign(payload)),
+	}
+
+	keys := memKeys{}
+	signer := newTestEventSigner(t, keys)
+	assert.Equal(t, domain.SignatureStatusUnknownKey, signer.Verify(event))
+
+	retiredAt := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	imported, err := ImportServerHeldKeys(keys, dir, retiredAt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, imported)
+	assert.Equal(t, domain.SignatureStatusVerified, signer.Verify(event))
+	assert.False(t, keys[held.KeyID()].ValidAt(retiredAt), "imported keys sign nothing new")
+
+	imported, err = ImportServerHeldKeys(keys, dir, retiredAt)
+	require.NoError(t, err)
+	assert.Zero(t, imported)
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Destination receives the files of an export, one at a time.
+type Destination interface {
+	Create(name string) (io.WriteCloser, error)
+}
+
+// dirDestination writes export files to a directory.
+type dirDestination struct {
+	dir string
+}
+
+// NewDirDestination returns a destination writing to dir, creating it if needed.
+func NewDirDestination(dir string) (Destination, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	return &dirDestination{dir: dir}, nil
+}
+
+func (d *dirDestination) Create(name string) (io.WriteCloser, error) {
+	return os.Create(filepath.Join(d.dir, name))
+}
+
+// zipDestination writes export files into a zip archive.
+type zipDestination struct {
+	zw *zip.Writer
+}
+
+// NewZipDestination returns a destination adding files to zw. The caller closes zw.
+func NewZipDestination(zw *zip.Writer) Destination {
+	return &zipDestination{zw: zw}
+}
+
+func (d *zipDestination) Create(name string) (io.WriteCloser, error) {
+	w, err := d.zw.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return nopCloser{w}, nil
+}
+
+// nopCloser adds a no-op Close to a zip entry, which is closed by the next Create.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,421 @@
+// Package export writes ledger extracts that auditors can verify offline: the events as
+// JSON Lines and CSV, and a manifest holding the SHA-256 hash of each file and a digest of
+// the ledger at export time, with a detached server signature over the manifest.
+package export
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+)
+
+// ManifestVersion is the manifest format version written by this package.
+const ManifestVersion = 1
+
+// Files of an export.
+const (
+	EventsJSONLFile = "events.jsonl"
+	EventsCSVFile   = "events.csv"
+	ManifestFile    = "manifest.json"
+	SignatureFile   = "manifest.sig" // Detached signature over the bytes of ManifestFile
+)
+
+// SignatureAlgorithm is the algorithm of the server signature over a manifest.
+const SignatureAlgorithm = "Ed25519"
+
+var (
+	// ErrInvalidFilter is returned (wrapped) when an export's time range is empty.
+	ErrInvalidFilter = errors.New("invalid export filter")
+	// ErrUnitNotFound is returned when no user belongs to the unit an export is for.
+	ErrUnitNotFound = errors.New("unit not found")
+	// ErrInvalidExport is returned (wrapped) when an export fails verification.
+	ErrInvalidExport = errors.New("export does not verify")
+)
+
+// Filter selects the events an export holds. All fields are optional.
+type Filter struct {
+	From *time.Time `json:"from,omitempty"` // Inclusive lower bound on the event timestamp
+	To   *time.Time `json:"to,omitempty"`   // Exclusive upper bound on the event timestamp
+	Unit string     `json:"unit,omitempty"` // Events involving a member of the unit
+	// UserIDs are the unit's members at export time. Export fills it in from Unit.
+	UserIDs []uint64 `json:"userIds,omitempty"`
+}
+
+// FileEntry records one file of an export.
+type FileEntry struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"` // Hex-encoded
+	Size   int64  `json:"size"`
+}
+
+// Manifest describes an export. It is written to ManifestFile and signed.
+type Manifest struct {
+	Version    int         `json:"version"`
+	ExportedAt time.Time   `json:"exportedAt"`
+	Filter     Filter      `json:"filter"`
+	EventCount int         `json:"eventCount"`
+	Files      []FileEntry `json:"files"`
+	// LedgerDigest was captured after the events were read, so it commits to a ledger that
+	// contains every exported event. ledger.VerifyDigest later proves the ledger still extends it.
+	LedgerDigest *ledger.Digest `json:"ledgerDigest"`
+}
+
+// Signature is the exporting server's signature over a manifest. It is written to SignatureFile.
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"keyId"`
+	PublicKey string `json:"publicKey"` // Base64 Ed25519 public key
+	Value     string `json:"value"`     // Base64 signature over the bytes of ManifestFile
+}
+
+// Signer signs manifests on behalf of the exporting server. *signing.Signer implements it.
+type Signer interface {
+	KeyID() string
+	PublicKey() ed25519.PublicKey
+	Sign(payload []byte) []byte
+}
+
+// UnitDirectory resolves the members of a unit. repository.Repository implements it.
+type UnitDirectory interface {
+	ListUsersByUnit(unit string) ([]domain.User, error)
+}
+
+// Exporter writes ledger exports.
+type Exporter struct {
+	ledger ledger.LedgerService
+	units  UnitDirectory
+	signer Signer
+}
+
+// NewExporter creates an exporter that reads ledgerService and signs manifests with signer.
+// units may be nil if exports are never filtered by unit.
+func NewExporter(ledgerService ledger.LedgerService, units UnitDirectory, signer Signer) *Exporter {
+	return &Exporter{ledger: ledgerService, units: units, signer: signer}
+}
+
+// Export writes the events matching filter, oldest first, and the signed manifest to dst.
+func (e *Exporter) Export(ctx context.Context, filter Filter, dst Destination) (*Manifest, error) {
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidFilter)
+	}
+	filter.UserIDs = nil
+	if filter.Unit != "" {
+		members, err := e.unitMembers(filter.Unit)
+		if err != nil {
+			return nil, err
+		}
+		filter.UserIDs = members
+	}
+
+	// The events are spooled to a temporary file a page at a time, so an export of any size
+	// is never held in memory; the spool is events.jsonl, and events.csv is written from it
+	spool, err := os.CreateTemp("", "ledger-export-*.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export spool: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	count, err := e.spoolEvents(ctx, filter, spool)
+	if err != nil {
+		return nil, err
+	}
+	ledgerDigest, err := e.ledger.CaptureDigest(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture ledger digest: %w", err)
+	}
+
+	manifest := &Manifest{
+		Version:      ManifestVersion,
+		ExportedAt:   time.Now().UTC(),
+		Filter:       filter,
+		EventCount:   count,
+		LedgerDigest: ledgerDigest,
+	}
+	for _, file := range []struct {
+		name  string
+		write func(io.Writer, io.Reader) error
+	}{{EventsJSONLFile, copyJSONLines}, {EventsCSVFile, writeCSV}} {
+		if _, err := spool.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind export spool: %w", err)
+		}
+		entry, err := writeHashed(dst, file.name, func(w io.Writer) error { return file.write(w, spool) })
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+		manifest.Files = append(manifest.Files, entry)
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	signatureBytes, err := json.MarshalIndent(Signature{
+		Algorithm: SignatureAlgorithm,
+		KeyID:     e.signer.KeyID(),
+		PublicKey: base64.StdEncoding.EncodeToString(e.signer.PublicKey()),
+		Value:     base64.StdEncoding.EncodeToString(e.signer.Sign(manifestBytes)),
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest signature: %w", err)
+	}
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{{ManifestFile, manifestBytes}, {SignatureFile, signatureBytes}} {
+		if err := writeFile(dst, file.name, file.data); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+	return manifest, nil
+}
+
+// unitMembers returns the IDs of a unit's members.
+func (e *Exporter) unitMembers(unit string) ([]uint64, error) {
+	if e.units == nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnitNotFound, unit)
+	}
+	users, err := e.units.ListUsersByUnit(unit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members of unit %q: %w", unit, err)
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrUnitNotFound, unit)
+	}
+
+	ids := make([]uint64, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, uint64(user.ID))
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// spoolEvents writes the events matching filter to w as JSON Lines, oldest first, and
+// returns how many it wrote. For a unit it reads each member's history, as HistoryQuery
+// filters on one user, and merges them.
+func (e *Exporter) spoolEvents(ctx context.Context, filter Filter, w io.Writer) (int, error) {
+	query := ledger.HistoryQuery{From: filter.From, To: filter.To, Limit: ledger.MaxHistoryLimit, Order: ledger.SortAsc}
+	var readers []*historyReader
+	if filter.UserIDs == nil {
+		readers = append(readers, &historyReader{ledger: e.ledger, query: query})
+	} else {
+		// The merge holds a page of each member's history, so the pages are kept small
+		query.Limit = ledger.DefaultHistoryLimit
+		for _, id := range filter.UserIDs {
+			id := id
+			query.UserID = &id
+			readers = append(readers, &historyReader{ledger: e.ledger, query: query})
+		}
+	}
+
+	// An event involving several members comes next in each of their histories at once,
+	// so it is written the first time and skipped after
+	encoder := json.NewEncoder(w)
+	count := 0
+	var lastID string
+	for {
+		var (
+			next  *historyReader
+			event *domain.LedgerEvent
+		)
+		for _, r := range readers {
+			candidate, err := r.peek(ctx)
+			if err != nil {
+				return 0, err
+			}
+			if candidate != nil && (event == nil || eventBefore(*candidate, *event)) {
+				next, event = r, candidate
+			}
+		}
+		if next == nil {
+			return count, nil
+		}
+
+		if event.EventID != lastID {
+			if err := encoder.Encode(event); err != nil {
+				return 0, fmt.Errorf("failed to spool events: %w", err)
+			}
+			count++
+			lastID = event.EventID
+		}
+		next.pop()
+	}
+}
+
+// historyReader reads the events matching a query in order, a page at a time.
+type historyReader struct {
+	ledger ledger.LedgerService
+	query  ledger.HistoryQuery
+	page   []domain.LedgerEvent
+	done   bool
+}
+
+// peek returns the next event without consuming it, or nil when there are none left.
+func (r *historyReader) peek(ctx context.Context) (*domain.LedgerEvent, error) {
+	for len(r.page) == 0 {
+		if r.done {
+			return nil, nil
+		}
+		page, err := r.ledger.GetGeneralHistory(ctx, r.query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ledger history: %w", err)
+		}
+		r.page = page.Events
+		r.query.Cursor = page.NextCursor
+		r.done = page.NextCursor == ""
+	}
+	return &r.page[0], nil
+}
+
+// pop consumes the event peek returned.
+func (r *historyReader) pop() {
+	r.page = r.page[1:]
+}
+
+// eventBefore orders events oldest first, by event ID among events at the same time.
+func eventBefore(a, b domain.LedgerEvent) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.Before(b.Timestamp)
+	}
+	return a.EventID < b.EventID
+}
+
+// copyJSONLines writes the spooled events as they are, one JSON-encoded event per line.
+func copyJSONLines(w io.Writer, spool io.Reader) error {
+	_, err := io.Copy(w, spool)
+	return err
+}
+
+// csvHeader lists the columns of EventsCSVFile. details holds the JSON encoding of the
+// event's detail field.
+var csvHeader = []string{
+	"eventId", "eventType", "timestamp", "actorUserId", "itemId", "serialNumber",
+	"ledgerBackend", "ledgerTransactionId", "details", "signer", "signerUserId", "signatureKeyId",
+}
+
+// writeCSV writes one row under csvHeader for each spooled event.
+func writeCSV(w io.Writer, spool io.Reader) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(spool)
+	for {
+		var event domain.LedgerEvent
+		if err := decoder.Decode(&event); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		details, err := json.Marshal(eventDetails(event))
+		if err != nil {
+			return err
+		}
+		row := []string{
+			event.EventID,
+			event.EventType,
+			event.Timestamp.UTC().Format(time.RFC3339Nano),
+			optionalID(event.ActorUserID),
+			optionalID(event.ItemID),
+			event.SerialNumber,
+			event.Ledger.Backend,
+			"",
+			string(details),
+			"", "", "",
+		}
+		if event.Ledger.TransactionID != nil {
+			row[7] = strconv.FormatInt(*event.Ledger.TransactionID, 10)
+		}
+		if sig := event.Signature; sig != nil {
+			row[9], row[10], row[11] = sig.Signer, optionalID(sig.UserID), sig.KeyID
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// eventDetails returns the detail field matching the event's type.
+func eventDetails(event domain.LedgerEvent) interface{} {
+	switch {
+	case event.ItemCreation != nil:
+		return event.ItemCreation
+	case event.Transfer != nil:
+		return event.Transfer
+	case event.StatusChange != nil:
+		return event.StatusChange
+	case event.Verification != nil:
+		return event.Verification
+	case event.Maintenance != nil:
+		return event.Maintenance
+	case event.Correction != nil:
+		return event.Correction
+	}
+	return nil
+}
+
+// optionalID formats an optional ID, or "" when it is nil.
+func optionalID(id *uint64) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(*id, 10)
+}
+
+// hashingWriter counts and hashes what is written through it.
+type hashingWriter struct {
+	w    io.Writer
+	hash hash.Hash
+	size int64
+}
+
+func (h *hashingWriter) Write(p []byte) (int, error) {
+	n, err := h.w.Write(p)
+	h.hash.Write(p[:n])
+	h.size += int64(n)
+	return n, err
+}
+
+// writeHashed creates name in dst, fills it with write and returns its manifest entry.
+func writeHashed(dst Destination, name string, write func(io.Writer) error) (FileEntry, error) {
+	file, err := dst.Create(name)
+	if err != nil {
+		return FileEntry{}, err
+	}
+	hw := &hashingWriter{w: file, hash: sha256.New()}
+	if err := write(hw); err != nil {
+		file.Close()
+		return FileEntry{}, err
+	}
+	if err := file.Close(); err != nil {
+		return FileEntry{}, err
+	}
+	return FileEntry{Name: name, SHA256: hex.EncodeToString(hw.hash.Sum(nil)), Size: hw.size}, nil
+}
+
+// writeFile creates name in dst holding data.
+func writeFile(dst Destination, name string, data []byte) error {
+	_, err := writeHashed(dst, name, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	return err
+}
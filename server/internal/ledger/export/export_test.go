@@ -0,0 +1,107 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"github.com/toole-brendan/handreceipt-go/internal/services/signing"
+)
+
+type fakeUnits map[string][]domain.User
+
+func (f fakeUnits) ListUsersByUnit(unit string) ([]domain.User, error) { return f[unit], nil }
+
+func newTestExporter(t *testing.T) (*Exporter, *signing.Signer) {
+	ctx := context.Background()
+	svc, err := ledger.NewLocalLedgerService(filepath.Join(t.TempDir(), "ledger.jsonl"))
+	require.NoError(t, err)
+	require.NoError(t, svc.Initialize(ctx))
+	t.Cleanup(func() { svc.Close() })
+
+	_, err = svc.LogItemCreation(ctx, domain.Property{ID: 1, SerialNumber: "SN-1", Name: "M4 Carbine", CurrentStatus: "Operational"}, 1, ledger.WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogTransferEvent(ctx, domain.Transfer{ID: 5, PropertyID: 1, FromUserID: 1, ToUserID: 2, Status: "Requested"}, "SN-1", 1, ledger.WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogStatusChange(ctx, 1, "SN-1", "Operational", "Damaged", 3, ledger.WriteOptions{})
+	require.NoError(t, err)
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer := signing.NewSigner(key)
+	return NewExporter(svc, fakeUnits{"A Co": {{ID: 1}, {ID: 2}}, "B Co": {{ID: 2}}}, signer), signer
+}
+
+func TestExporter_ExportsVerifiableFiles(t *testing.T) {
+	exporter, signer := newTestExporter(t)
+	dir := t.TempDir()
+	dst, err := NewDirDestination(dir)
+	require.NoError(t, err)
+
+	manifest, err := exporter.Export(context.Background(), Filter{}, dst)
+	require.NoError(t, err)
+	assert.Equal(t, 3, manifest.EventCount)
+	require.NotNil(t, manifest.LedgerDigest)
+	assert.Equal(t, uint64(3), manifest.LedgerDigest.Position, "the digest covers every exported event")
+
+	data, err := os.ReadFile(filepath.Join(dir, EventsCSVFile))
+	require.NoError(t, err)
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	assert.Equal(t, csvHeader, rows[0])
+	assert.Equal(t, domain.LedgerEventItemCreation, rows[1][1])
+
+	result, err := Verify(os.DirFS(dir), signer.PublicKey())
+	require.NoError(t, err)
+	assert.True(t, result.ServerTrusted)
+	assert.Equal(t, signer.KeyID(), result.ServerKeyID)
+
+	// Another server's key
+	otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, err = Verify(os.DirFS(dir), otherKey)
+	assert.ErrorIs(t, err, ErrInvalidExport)
+
+	// An edited event file
+	require.NoError(t, os.WriteFile(filepath.Join(dir, EventsCSVFile), bytes.Replace(data, []byte("Damaged"), []byte("Repaired"), 1), 0o644))
+	_, err = Verify(os.DirFS(dir), nil)
+	assert.ErrorIs(t, err, ErrInvalidExport)
+}
+
+func TestExporter_FiltersByUnitIntoZip(t *testing.T) {
+	exporter, _ := newTestExporter(t)
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	manifest, err := exporter.Export(context.Background(), Filter{Unit: "B Co"}, NewZipDestination(zw))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	assert.Equal(t, []uint64{2}, manifest.Filter.UserIDs)
+	assert.Equal(t, 1, manifest.EventCount, "only the transfer involves user 2")
+
+	zr, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	require.NoError(t, err)
+	result, err := Verify(zr, nil)
+	require.NoError(t, err)
+	assert.False(t, result.ServerTrusted)
+	assert.NotEmpty(t, result.Notes)
+
+	// The transfer involves both members of A Co but is exported once
+	manifest, err = exporter.Export(context.Background(), Filter{Unit: "A Co"}, NewZipDestination(zip.NewWriter(&bytes.Buffer{})))
+	require.NoError(t, err)
+	assert.Equal(t, 2, manifest.EventCount)
+
+	_, err = exporter.Export(context.Background(), Filter{Unit: "C Co"}, NewZipDestination(zip.NewWriter(&bytes.Buffer{})))
+	assert.ErrorIs(t, err, ErrUnitNotFound)
+}
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// VerifyResult describes what a successful verification established.
+type VerifyResult struct {
+	Manifest      *Manifest `json:"manifest"`
+	ServerKeyID   string    `json:"serverKeyId"`
+	ServerTrusted bool      `json:"serverTrusted"` // Signature checked against a trusted server key
+	Notes         []string  `json:"notes,omitempty"`
+}
+
+// Verify checks an export offline: the manifest signature, against trusted when it is
+// given, and the hash and size of every file the manifest lists. Without trusted, the
+// signature is checked against the key it embeds, which proves integrity but not origin.
+func Verify(fsys fs.FS, trusted ed25519.PublicKey) (*VerifyResult, error) {
+	manifestBytes, err := fs.ReadFile(fsys, ManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+	signatureBytes, err := fs.ReadFile(fsys, SignatureFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+
+	result := &VerifyResult{}
+	if err := verifySignature(manifestBytes, signatureBytes, trusted, result); err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: malformed manifest: %v", ErrInvalidExport, err)
+	}
+	if manifest.Version != ManifestVersion {
+		return nil, fmt.Errorf("%w: unsupported manifest version %d", ErrInvalidExport, manifest.Version)
+	}
+	for _, entry := range manifest.Files {
+		if err := verifyFile(fsys, entry); err != nil {
+			return nil, err
+		}
+	}
+	result.Manifest = &manifest
+	return result, nil
+}
+
+// verifySignature checks the detached signature over the manifest bytes.
+func verifySignature(manifestBytes, signatureBytes []byte, trusted ed25519.PublicKey, result *VerifyResult) error {
+	var sig Signature
+	if err := json.Unmarshal(signatureBytes, &sig); err != nil {
+		return fmt.Errorf("%w: malformed signature file: %v", ErrInvalidExport, err)
+	}
+	if sig.Algorithm != SignatureAlgorithm {
+		return fmt.Errorf("%w: unsupported signature algorithm %q", ErrInvalidExport, sig.Algorithm)
+	}
+
+	embedded, err := base64.StdEncoding.DecodeString(sig.PublicKey)
+	if err != nil || len(embedded) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: malformed public key", ErrInvalidExport)
+	}
+	value, err := base64.StdEncoding.DecodeString(sig.Value)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidExport)
+	}
+
+	key := ed25519.PublicKey(embedded)
+	if trusted != nil {
+		if !key.Equal(trusted) {
+			return fmt.Errorf("%w: manifest was signed by key %s, not the trusted server key", ErrInvalidExport, sig.KeyID)
+		}
+		result.ServerTrusted = true
+	} else {
+		result.Notes = append(result.Notes, "manifest signature checked against the key embedded in the export; supply the server's public key to confirm who issued it")
+	}
+
+	if !ed25519.Verify(key, manifestBytes, value) {
+		return fmt.Errorf("%w: manifest signature does not verify", ErrInvalidExport)
+	}
+	result.ServerKeyID = sig.KeyID
+	return nil
+}
+
+// verifyFile checks one file against its manifest entry.
+func verifyFile(fsys fs.FS, entry FileEntry) error {
+	file, err := fsys.Open(entry.Name)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return fmt.Errorf("%w: failed to read %s: %v", ErrInvalidExport, entry.Name, err)
+	}
+	if size != entry.Size || hex.EncodeToString(hash.Sum(nil)) != entry.SHA256 {
+		return fmt.Errorf("%w: %s does not match its manifest hash", ErrInvalidExport, entry.Name)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package ledger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+)
+
+// SortOrder is the timestamp order of a history page.
+type SortOrder string
+
+const (
+	SortDesc SortOrder = "desc" // Most recent first (default)
+	SortAsc  SortOrder = "asc"  // Oldest first
+
+	// DefaultHistoryLimit is the page size used when HistoryQuery.Limit is zero.
+	DefaultHistoryLimit = 100
+	// MaxHistoryLimit caps HistoryQuery.Limit.
+	MaxHistoryLimit = 1000
+)
+
+// ErrInvalidHistoryQuery is returned (wrapped) for malformed queries, such as a bad cursor
+// or a filter the backend cannot evaluate.
+var ErrInvalidHistoryQuery = errors.New("invalid history query")
+
+// HistoryQuery filters and pages GetGeneralHistory. All filters are optional and combined with AND.
+type HistoryQuery struct {
+	From         *time.Time // Inclusive lower bound on the event timestamp
+	To           *time.Time // Exclusive upper bound on the event timestamp
+	EventTypes   []string   // LedgerEvent.EventType values, e.g. "TransferEvent"
+	UserID       *uint64    // Acting user, either party of a transfer, or the user a created item was assigned to
+	ItemID       *uint64
+	SerialNumber string
+	TransferID   *uint64
+	Cursor       string // HistoryPage.NextCursor from the previous page
+	Limit        int    // Page size; 0 means DefaultHistoryLimit
+	Order        SortOrder
+}
+
+// HistoryPage is one page of general ledger history.
+type HistoryPage struct {
+	Events     []domain.LedgerEvent `json:"events"`
+	NextCursor string               `json:"nextCursor,omitempty"` // Empty on the last page
+}
+
+// historyCursor is the position after the last event of a page. It is opaque to clients.
+type historyCursor struct {
+	Timestamp time.Time `json:"t"`
+	EventID   string    `json:"id"`
+}
+
+// normalize applies defaults, validates the query and decodes its cursor.
+func (q HistoryQuery) normalize() (HistoryQuery, *historyCursor, error) {
+	switch q.Order {
+	case "":
+		q.Order = SortDesc
+	case SortDesc, SortAsc:
+	default:
+		return q, nil, fmt.Errorf("%w: order must be %q or %q", ErrInvalidHistoryQuery, SortAsc, SortDesc)
+	}
+
+	if q.Limit < 0 {
+		return q, nil, fmt.Errorf("%w: limit must not be negative", ErrInvalidHistoryQuery)
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultHistoryLimit
+	}
+	if q.Limit > MaxHistoryLimit {
+		q.Limit = MaxHistoryLimit
+	}
+
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return q, nil, fmt.Errorf("%w: from must be before to", ErrInvalidHistoryQuery)
+	}
+
+	if q.Cursor == "" {
+		return q, nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return q, nil, fmt.Errorf("%w: malformed cursor", ErrInvalidHistoryQuery)
+	}
+	var cursor historyCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.EventID == "" {
+		return q, nil, fmt.Errorf("%w: malformed cursor", ErrInvalidHistoryQuery)
+	}
+	return q, &cursor, nil
+}
+
+// encodeHistoryCursor returns the cursor that resumes after event.
+func encodeHistoryCursor(event domain.LedgerEvent) string {
+	raw, _ := json.Marshal(historyCursor{Timestamp: event.Timestamp, EventID: event.EventID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// after reports whether event comes after the cursor in the given order.
+func (c *historyCursor) after(event domain.LedgerEvent, order SortOrder) bool {
+	if c == nil {
+		return true
+	}
+	return historyLess(c.Timestamp, c.EventID, event.Timestamp, event.EventID, order)
+}
+
+// historyLess orders events by (timestamp, event ID) in the given direction.
+func historyLess(ts1 time.Time, id1 string, ts2 time.Time, id2 string, order SortOrder) bool {
+	if !ts1.Equal(ts2) {
+		if order == SortAsc {
+			return ts1.Before(ts2)
+		}
+		return ts1.After(ts2)
+	}
+	if order == SortAsc {
+		return id1 < id2
+	}
+	return id1 > id2
+}
+
+// historyMatches reports whether event satisfies every filter in q.
+func historyMatches(e domain.LedgerEvent, q HistoryQuery) bool {
+	if q.From != nil && e.Timestamp.Before(*q.From) {
+		return false
+	}
+	if q.To != nil && !e.Timestamp.Before(*q.To) {
+		return false
+	}
+	if len(q.EventTypes) > 0 && !containsString(q.EventTypes, e.EventType) {
+		return false
+	}
+	if q.ItemID != nil && (e.ItemID == nil || *e.ItemID != *q.ItemID) {
+		return false
+	}
+	if q.SerialNumber != "" && e.SerialNumber != q.SerialNumber {
+		return false
+	}
+	if q.TransferID != nil {
+		if e.Transfer == nil {
+			return false
+		}
+		id, err := strconv.ParseUint(e.Transfer.TransferID, 10, 64)
+		if err != nil || id != *q.TransferID {
+			return false
+		}
+	}
+	if q.UserID != nil {
+		found := false
+		for _, id := range eventUserIDs(e) {
+			if id == *q.UserID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// eventUserIDs returns every user an event involves: the actor, both parties and the
+// approver of a transfer, the user who performed maintenance, and the user a created item
+// was assigned to.
+func eventUserIDs(e domain.LedgerEvent) []uint64 {
+	var ids []uint64
+	if e.ActorUserID != nil {
+		ids = append(ids, *e.ActorUserID)
+	}
+	if e.Transfer != nil {
+		ids = append(ids, e.Transfer.FromUserID, e.Transfer.ToUserID)
+		if e.Transfer.ApprovingUserID != nil {
+			ids = append(ids, *e.Transfer.ApprovingUserID)
+		}
+	}
+	if e.Maintenance != nil && e.Maintenance.PerformingUserID != nil {
+		ids = append(ids, *e.Maintenance.PerformingUserID)
+	}
+	if e.ItemCreation != nil && e.ItemCreation.AssignedToUserID != nil {
+		ids = append(ids, *e.ItemCreation.AssignedToUserID)
+	}
+	return ids
+}
+
+// pageHistory filters, sorts and pages events in memory, for backends without a query engine.
+func pageHistory(events []domain.LedgerEvent, q HistoryQuery, cursor *historyCursor) *HistoryPage {
+	matched := make([]domain.LedgerEvent, 0, len(events))
+	for _, event := range events {
+		if historyMatches(event, q) && cursor.after(event, q.Order) {
+			matched = append(matched, event)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return historyLess(matched[i].Timestamp, matched[i].EventID, matched[j].Timestamp, matched[j].EventID, q.Order)
+	})
+
+	page := &HistoryPage{Events: matched}
+	if len(matched) > q.Limit {
+		page.Events = matched[:q.Limit]
+		page.NextCursor = encodeHistoryCursor(page.Events[q.Limit-1])
+	}
+	return page
+}
+
+// containsString reports whether values contains v.
+func containsString(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
+
+// numericID converts an ID stored in an event (a Go integer before a JSON round trip,
+// a float64 or decimal string after one) to uint64.
+func numericID(v interface{}) (uint64, bool) {
+	switch n := v.(type) {
+	case uint:
+		return uint64(n), true
+	case uint64:
+		return n, true
+	case int64:
+		return uint64(n), true
+	case float64:
+		return uint64(n), true
+	case string:
+		id, err := strconv.ParseUint(n, 10, 64)
+		return id, err == nil
+	}
+	return 0, false
+}
+
+// stringField returns a string stored in an event, or "" if it is absent.
+func stringField(fields map[string]interface{}, key string) string {
+	s, _ := fields[key].(string)
+	return s
+}
+
+// idField returns an ID stored in an event, or nil if it is absent or null.
+func idField(fields map[string]interface{}, key string) *uint64 {
+	id, ok := numericID(fields[key])
+	if !ok {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,144 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+)
+
+// ImportEvent writes an event recorded by another ledger backend, keeping its event ID,
+// timestamp and signature; it is not signed again. It reports false, without writing, when an event with the same ID is already
+// stored, so an interrupted import can be rerun.
+func (s *ImmuDBLedgerService) ImportEvent(ctx context.Context, event domain.LedgerEvent) (bool, error) {
+	if event.EventID == "" {
+		return false, fmt.Errorf("cannot import a ledger event without an event ID")
+	}
+	prefix, ok := eventKeyPrefixes[event.EventType]
+	if !ok {
+		return false, fmt.Errorf("cannot import ledger event %s of unknown type %q", event.EventID, event.EventType)
+	}
+
+	_, err := s.primaryKeyForEvent(ctx, event.EventID)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, ErrEventNotFound) {
+		return false, err
+	}
+
+	stored := storedEvent(event)
+	// Index fields are read the way they are after a JSON round trip
+	raw, err := json.Marshal(stored)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return false, fmt.Errorf("failed to decode event: %w", err)
+	}
+
+	if _, err := s.writeEvent(ctx, prefix+event.EventID, stored, eventIndexes(decoded)...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// storedEvent is the inverse of immudbRecord.toLedgerEvent: it lays an event out in the
+// stored format the Log* methods write, plus the fields only other backends record.
+func storedEvent(event domain.LedgerEvent) map[string]interface{} {
+	stored := map[string]interface{}{
+		"event_id":  event.EventID,
+		"timestamp": event.Timestamp.UTC(),
+	}
+	if event.SerialNumber != "" {
+		stored["serial_number"] = event.SerialNumber
+	}
+	if event.Signature != nil {
+		stored["signature"] = event.Signature
+	}
+	// setID stores an optional ID field, leaving it out when nil
+	setID := func(key string, id *uint64) {
+		if id != nil {
+			stored[key] = *id
+		}
+	}
+	// setString stores an optional string field, leaving it out when empty
+	setString := func(key, value string) {
+		if value != "" {
+			stored[key] = value
+		}
+	}
+
+	switch {
+	case event.ItemCreation != nil:
+		d := event.ItemCreation
+		stored["event_type"] = "ItemCreation"
+		setID("item_id", event.ItemID)
+		setID("user_id", event.ActorUserID)
+		details := map[string]interface{}{
+			"action":      d.Action,
+			"name":        d.Name,
+			"description": d.Description,
+		}
+		if d.Notes != "" {
+			details["notes"] = d.Notes
+		}
+		if d.StateRecorded {
+			details["status"] = d.Status
+			details["assigned_to_user_id"] = d.AssignedToUserID
+		}
+		stored["details"] = details
+	case event.Transfer != nil:
+		d := event.Transfer
+		stored["event_type"] = "TransferEvent"
+		setID("property_id", event.ItemID)
+		setID("initiating_user_id", event.ActorUserID)
+		setID("approving_user_id", d.ApprovingUserID)
+		if id, err := strconv.ParseUint(d.TransferID, 10, 64); err == nil {
+			stored["transfer_id"] = id
+		} else {
+			setString("transfer_id", d.TransferID)
+		}
+		stored["from_user_id"] = d.FromUserID
+		stored["to_user_id"] = d.ToUserID
+		stored["status"] = d.Status
+		setString("notes", d.Notes)
+	case event.StatusChange != nil:
+		d := event.StatusChange
+		stored["event_type"] = "StatusChange"
+		setID("item_id", event.ItemID)
+		setID("user_id", event.ActorUserID)
+		stored["old_status"] = d.PreviousStatus
+		stored["new_status"] = d.NewStatus
+		setString("reason", d.Reason)
+	case event.Verification != nil:
+		d := event.Verification
+		stored["event_type"] = "VerificationEvent"
+		setID("item_id", event.ItemID)
+		setID("user_id", event.ActorUserID)
+		stored["verification_type"] = d.Result
+		setString("notes", d.Notes)
+	case event.Maintenance != nil:
+		d := event.Maintenance
+		stored["event_type"] = "MaintenanceEvent"
+		setID("item_id", event.ItemID)
+		setID("initiating_user_id", event.ActorUserID)
+		setID("performing_user_id", d.PerformingUserID)
+		stored["maintenance_record_id"] = d.MaintenanceRecordID
+		stored["event_type_detail"] = d.Stage
+		setString("maintenance_type", d.MaintenanceType)
+		stored["description"] = d.Description
+	case event.Correction != nil:
+		d := event.Correction
+		stored["event_type"] = "CorrectionEvent"
+		setID("user_id", event.ActorUserID)
+		stored["original_event_id"] = d.OriginalEventID
+		stored["correction_type"] = d.OriginalEventType
+		stored["reason"] = d.Reason
+	}
+	return stored
+}
@@ -1,24 +1,102 @@
 package ledger
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
 	immuclient "github.com/codenotary/immudb/pkg/client"
 	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/receipt"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
+// Secondary index key spaces. Each index key has the form
+// <prefix><zero-padded id or escaped value>:<unix nanos>:<primary key>
+// and stores the primary key of the event as its value, so a prefix scan
+// returns an entity's events in chronological order.
+const (
+	itemIndexPrefix     = "idx:item:"
+	serialIndexPrefix   = "idx:serial:"
+	userIndexPrefix     = "idx:user:"
+	transferIndexPrefix = "idx:transfer:"
+
+	// Record-level key spaces, written for every event regardless of the entities it touches.
+	timeIndexPrefix               = "idx:time:"
+	typeIndexPrefix               = "idx:type:"
+	eventIDIndexPrefix            = "idx:event:"
+	correctionOriginalIndexPrefix = "idx:correction:original:"
+
+	// indexVersionKey records which index key spaces events written before them have been backfilled into.
+	indexVersionKey = "meta:index_version"
+	indexVersion    = "3"
+
+	// scanPageSize is the number of entries requested per Scan call.
+	scanPageSize = 500
+)
+
+// legacyEventPrefixes are the primary key prefixes used by every Log* method.
+var legacyEventPrefixes = []string{"item_creation_", "transfer_", "status_change_", "verification_", "maintenance_", "correction_"}
+
+// eventKeyPrefixes are the primary key prefixes, by event type. An event's primary key is its
+// prefix followed by its event ID. Events written before event IDs were time-ordered UUIDs
+// have the item, transfer, maintenance record or corrected event ID and the Unix second in
+// place of the event ID.
+var eventKeyPrefixes = map[string]string{
+	domain.LedgerEventItemCreation: "item_creation_",
+	domain.LedgerEventTransfer:     "transfer_",
+	domain.LedgerEventStatusChange: "status_change_",
+	domain.LedgerEventVerification: "verification_",
+	domain.LedgerEventMaintenance:  "maintenance_",
+	domain.LedgerEventCorrection:   "correction_",
+}
+
+// itemIndex returns the index prefix for an item ID.
+func itemIndex(itemID uint64) string { return fmt.Sprintf("%s%020d:", itemIndexPrefix, itemID) }
+
+// serialIndex returns the index prefix for a serial number.
+func serialIndex(serialNumber string) string {
+	return serialIndexPrefix + url.QueryEscape(serialNumber) + ":"
+}
+
+// userIndex returns the index prefix for a user ID.
+func userIndex(userID uint64) string { return fmt.Sprintf("%s%020d:", userIndexPrefix, userID) }
+
+// typeIndex returns the index prefix for an event type (the stored event_type value).
+func typeIndex(eventType string) string { return typeIndexPrefix + eventType + ":" }
+
+// eventIDIndex returns the index prefix for an event ID.
+func eventIDIndex(eventID string) string { return eventIDIndexPrefix + url.QueryEscape(eventID) + ":" }
+
+// correctionOriginalIndex returns the index prefix for corrections of an original event.
+func correctionOriginalIndex(originalEventID string) string {
+	return correctionOriginalIndexPrefix + url.QueryEscape(originalEventID) + ":"
+}
+
+// transferIndex returns the index prefix for a transfer ID.
+func transferIndex(transferID uint64) string {
+	return fmt.Sprintf("%s%020d:", transferIndexPrefix, transferID)
+}
+
 // Ensure ImmuDBLedgerService implements LedgerService interface at compile time
 var _ LedgerService = (*ImmuDBLedgerService)(nil)
 
 // ImmuDBLedgerService implements the LedgerService interface using ImmuDB
 type ImmuDBLedgerService struct {
 	client immuclient.ImmuClient
-	ctx    context.Context
+	signer *EventSigner // Signs each event; nil leaves events unsigned
 }
 
 // NewImmuDBLedgerService creates a new ImmuDB ledger service
@@ -40,57 +118,191 @@ func NewImmuDBLedgerService(host string, port int, username, password, database
 
 	return &ImmuDBLedgerService{
 		client: client,
-		ctx:    context.Background(),
 	}, nil
 }
 
-// Initialize performs any setup needed for the ledger service
-func (s *ImmuDBLedgerService) Initialize() error {
-	log.Println("ImmuDBLedgerService Initialize: ImmuDB is ready")
+// SetEventSigner makes the ledger sign the system events it writes from now on. Call it before
+// the service is shared between goroutines.
+func (s *ImmuDBLedgerService) SetEventSigner(signer *EventSigner) {
+	s.signer = signer
+}
+
+// Initialize performs any setup needed for the ledger service.
+// Events written before secondary indexes existed are indexed once, guarded by indexVersionKey.
+func (s *ImmuDBLedgerService) Initialize(ctx context.Context) error {
+	entry, err := s.client.Get(ctx, []byte(indexVersionKey))
+	if err == nil && string(entry.Value) == indexVersion {
+		log.Println("ImmuDBLedgerService Initialize: ImmuDB is ready")
+		return nil
+	}
+
+	indexed, err := s.backfillIndexes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to index existing ledger events: %w", err)
+	}
+	if _, err := s.client.Set(ctx, []byte(indexVersionKey), []byte(indexVersion)); err != nil {
+		return fmt.Errorf("failed to record ledger index version: %w", err)
+	}
+
+	log.Printf("ImmuDBLedgerService Initialize: indexed %d existing events, ImmuDB is ready", indexed)
 	return nil
 }
 
+// backfillIndexes writes secondary index keys for events stored before indexing was introduced.
+func (s *ImmuDBLedgerService) backfillIndexes(ctx context.Context) (int, error) {
+	count := 0
+	for _, prefix := range legacyEventPrefixes {
+		entries, err := s.scanPrefix(ctx, prefix)
+		if err != nil {
+			return count, err
+		}
+
+		for _, entry := range entries {
+			var event map[string]interface{}
+			if err := json.Unmarshal(entry.Value, &event); err != nil {
+				log.Printf("Skipping unreadable ledger entry %s during indexing: %v", entry.Key, err)
+				continue
+			}
+
+			ts, _ := time.Parse(time.RFC3339Nano, fmt.Sprint(event["timestamp"]))
+			indexes := append(eventIndexes(event), recordIndexes(string(entry.Key), event)...)
+			kvs := indexKeyValues(string(entry.Key), ts, indexes)
+			if len(kvs) == 0 {
+				continue
+			}
+			if _, err := s.client.SetAll(ctx, &schema.SetRequest{KVs: kvs}); err != nil {
+				return count, fmt.Errorf("failed to write index keys for %s: %w", entry.Key, err)
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// eventIndexes derives the index prefixes an event belongs to from its stored fields.
+func eventIndexes(event map[string]interface{}) []string {
+	var indexes []string
+	idField := func(name string) (uint64, bool) {
+		v, ok := event[name].(float64)
+		return uint64(v), ok
+	}
+
+	for _, field := range []string{"item_id", "property_id"} {
+		if id, ok := idField(field); ok {
+			indexes = append(indexes, itemIndex(id))
+		}
+	}
+	if sn, ok := event["serial_number"].(string); ok && sn != "" {
+		indexes = append(indexes, serialIndex(sn))
+	}
+	seenUsers := map[uint64]bool{}
+	for _, field := range []string{"user_id", "from_user_id", "to_user_id", "initiating_user_id", "performing_user_id", "approving_user_id"} {
+		if id, ok := idField(field); ok && !seenUsers[id] {
+			seenUsers[id] = true
+			indexes = append(indexes, userIndex(id))
+		}
+	}
+	if details, ok := event["details"].(map[string]interface{}); ok {
+		if v, ok := details["assigned_to_user_id"].(float64); ok && !seenUsers[uint64(v)] {
+			indexes = append(indexes, userIndex(uint64(v)))
+		}
+	}
+	if id, ok := idField("transfer_id"); ok {
+		indexes = append(indexes, transferIndex(id))
+	}
+	return indexes
+}
+
+// recordIndexes returns the record-level index prefixes for an event: the global time index,
+// its event type, its event ID and, for corrections, the event being corrected.
+func recordIndexes(primaryKey string, event map[string]interface{}) []string {
+	eventType, _ := event["event_type"].(string)
+	indexes := []string{timeIndexPrefix, typeIndex(eventType), eventIDIndex(eventIDOf(primaryKey, event))}
+	if originalID, ok := event["original_event_id"].(string); ok && originalID != "" {
+		indexes = append(indexes, correctionOriginalIndex(originalID))
+	}
+	return indexes
+}
+
+// eventIDOf returns the stored event ID, falling back to the primary key for
+// events written before event IDs were assigned.
+func eventIDOf(primaryKey string, event map[string]interface{}) string {
+	if id, ok := event["event_id"].(string); ok && id != "" {
+		return id
+	}
+	return primaryKey
+}
+
+// indexKeyValues builds the index entries pointing at primaryKey.
+func indexKeyValues(primaryKey string, ts time.Time, indexes []string) []*schema.KeyValue {
+	kvs := make([]*schema.KeyValue, 0, len(indexes))
+	for _, prefix := range indexes {
+		kvs = append(kvs, &schema.KeyValue{
+			Key:   []byte(fmt.Sprintf("%s%020d:%s", prefix, ts.UnixNano(), primaryKey)),
+			Value: []byte(primaryKey),
+		})
+	}
+	return kvs
+}
+
 // LogItemCreation logs an equipment creation/registration event to ImmuDB
-func (s *ImmuDBLedgerService) LogItemCreation(property domain.Property, userID uint) error {
+func (s *ImmuDBLedgerService) LogItemCreation(ctx context.Context, property domain.Property, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
 	event := map[string]interface{}{
 		"event_type":    "ItemCreation",
 		"item_id":       property.ID,
 		"serial_number": property.SerialNumber,
 		"user_id":       userID,
-		"timestamp":     time.Now().UTC(),
+		"timestamp":     opts.timestamp(),
 		"details": map[string]interface{}{
-			"name":        property.Name,
-			"description": property.Description,
-			"status":      property.CurrentStatus,
+			"name":                property.Name,
+			"description":         property.Description,
+			"status":              property.CurrentStatus,
+			"assigned_to_user_id": property.AssignedToUserID,
 		},
 	}
 
-	return s.storeEvent(fmt.Sprintf("item_creation_%d_%d", property.ID, time.Now().Unix()), event)
+	indexes := []string{itemIndex(uint64(property.ID)), serialIndex(property.SerialNumber), userIndex(uint64(userID))}
+	if property.AssignedToUserID != nil && *property.AssignedToUserID != userID {
+		indexes = append(indexes, userIndex(uint64(*property.AssignedToUserID)))
+	}
+	return s.storeEvent(ctx, opts, domain.LedgerEventItemCreation, event, indexes...)
 }
 
 // LogTransferEvent logs a transfer event to ImmuDB
-func (s *ImmuDBLedgerService) LogTransferEvent(transfer domain.Transfer, serialNumber string) error {
+func (s *ImmuDBLedgerService) LogTransferEvent(ctx context.Context, transfer domain.Transfer, serialNumber string, actorUserID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
 	event := map[string]interface{}{
-		"event_type":    "TransferEvent",
-		"transfer_id":   transfer.ID,
-		"property_id":   transfer.PropertyID,
-		"serial_number": serialNumber,
-		"from_user_id":  transfer.FromUserID,
-		"to_user_id":    transfer.ToUserID,
-		"status":        transfer.Status,
-		"timestamp":     time.Now().UTC(),
-		"request_date":  transfer.RequestDate,
+		"event_type":         "TransferEvent",
+		"transfer_id":        transfer.ID,
+		"property_id":        transfer.PropertyID,
+		"serial_number":      serialNumber,
+		"from_user_id":       transfer.FromUserID,
+		"to_user_id":         transfer.ToUserID,
+		"initiating_user_id": actorUserID,
+		"status":             transfer.Status,
+		"timestamp":          opts.timestamp(),
+		"request_date":       transfer.RequestDate,
 	}
 
 	if transfer.Notes != nil {
 		event["notes"] = *transfer.Notes
 	}
 
-	return s.storeEvent(fmt.Sprintf("transfer_%d_%d", transfer.ID, time.Now().Unix()), event)
+	indexes := []string{itemIndex(uint64(transfer.PropertyID)), serialIndex(serialNumber), transferIndex(uint64(transfer.ID)), userIndex(uint64(transfer.FromUserID))}
+	if transfer.ToUserID != transfer.FromUserID {
+		indexes = append(indexes, userIndex(uint64(transfer.ToUserID)))
+	}
+	if actorUserID != transfer.FromUserID && actorUserID != transfer.ToUserID {
+		indexes = append(indexes, userIndex(uint64(actorUserID)))
+	}
+
+	return s.storeEvent(ctx, opts, domain.LedgerEventTransfer, event, indexes...)
 }
 
 // LogStatusChange logs a status change event to ImmuDB
-func (s *ImmuDBLedgerService) LogStatusChange(itemID uint, serialNumber string, oldStatus string, newStatus string, userID uint) error {
+func (s *ImmuDBLedgerService) LogStatusChange(ctx context.Context, itemID uint, serialNumber string, oldStatus string, newStatus string, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	if !domain.IsPropertyStatus(newStatus) {
+		return nil, fmt.Errorf("%w '%s'", domain.ErrInvalidPropertyStatus, newStatus)
+	}
 	event := map[string]interface{}{
 		"event_type":    "StatusChange",
 		"item_id":       itemID,
@@ -98,28 +310,30 @@ func (s *ImmuDBLedgerService) LogStatusChange(itemID uint, serialNumber string,
 		"user_id":       userID,
 		"old_status":    oldStatus,
 		"new_status":    newStatus,
-		"timestamp":     time.Now().UTC(),
+		"timestamp":     opts.timestamp(),
 	}
 
-	return s.storeEvent(fmt.Sprintf("status_change_%d_%d", itemID, time.Now().Unix()), event)
+	return s.storeEvent(ctx, opts, domain.LedgerEventStatusChange, event,
+		itemIndex(uint64(itemID)), serialIndex(serialNumber), userIndex(uint64(userID)))
 }
 
 // LogVerificationEvent logs a verification event to ImmuDB
-func (s *ImmuDBLedgerService) LogVerificationEvent(itemID uint, serialNumber string, userID uint, verificationType string) error {
+func (s *ImmuDBLedgerService) LogVerificationEvent(ctx context.Context, itemID uint, serialNumber string, userID uint, verificationType string, opts WriteOptions) (*domain.LedgerWriteResult, error) {
 	event := map[string]interface{}{
 		"event_type":        "VerificationEvent",
 		"item_id":           itemID,
 		"serial_number":     serialNumber,
 		"user_id":           userID,
 		"verification_type": verificationType,
-		"timestamp":         time.Now().UTC(),
+		"timestamp":         opts.timestamp(),
 	}
 
-	return s.storeEvent(fmt.Sprintf("verification_%d_%d", itemID, time.Now().Unix()), event)
+	return s.storeEvent(ctx, opts, domain.LedgerEventVerification, event,
+		itemIndex(uint64(itemID)), serialIndex(serialNumber), userIndex(uint64(userID)))
 }
 
 // LogMaintenanceEvent logs a maintenance event to ImmuDB
-func (s *ImmuDBLedgerService) LogMaintenanceEvent(maintenanceRecordID string, itemID uint, initiatingUserID uint, performingUserID sql.NullInt64, eventType string, maintenanceType sql.NullString, description string) error {
+func (s *ImmuDBLedgerService) LogMaintenanceEvent(ctx context.Context, maintenanceRecordID string, itemID uint, initiatingUserID uint, performingUserID sql.NullInt64, eventType string, maintenanceType sql.NullString, description string, opts WriteOptions) (*domain.LedgerWriteResult, error) {
 	event := map[string]interface{}{
 		"event_type":            "MaintenanceEvent",
 		"maintenance_record_id": maintenanceRecordID,
@@ -127,58 +341,150 @@ func (s *ImmuDBLedgerService) LogMaintenanceEvent(maintenanceRecordID string, it
 		"initiating_user_id":    initiatingUserID,
 		"event_type_detail":     eventType,
 		"description":           description,
-		"timestamp":             time.Now().UTC(),
+		"timestamp":             opts.timestamp(),
 	}
 
+	indexes := []string{itemIndex(uint64(itemID)), userIndex(uint64(initiatingUserID))}
 	if performingUserID.Valid {
 		event["performing_user_id"] = performingUserID.Int64
+		if uint64(performingUserID.Int64) != uint64(initiatingUserID) {
+			indexes = append(indexes, userIndex(uint64(performingUserID.Int64)))
+		}
 	}
 	if maintenanceType.Valid {
 		event["maintenance_type"] = maintenanceType.String
 	}
 
-	return s.storeEvent(fmt.Sprintf("maintenance_%s_%d", maintenanceRecordID, time.Now().Unix()), event)
+	return s.storeEvent(ctx, opts, domain.LedgerEventMaintenance, event, indexes...)
 }
 
 // LogCorrectionEvent logs a correction event to ImmuDB
-func (s *ImmuDBLedgerService) LogCorrectionEvent(originalEventID string, eventType string, reason string, userID uint) error {
+func (s *ImmuDBLedgerService) LogCorrectionEvent(ctx context.Context, originalEventID string, eventType string, reason string, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	if err := checkCorrectionTarget(ctx, s, originalEventID, eventType); err != nil {
+		return nil, err
+	}
+
 	event := map[string]interface{}{
 		"event_type":        "CorrectionEvent",
 		"original_event_id": originalEventID,
 		"correction_type":   eventType,
 		"reason":            reason,
 		"user_id":           userID,
-		"timestamp":         time.Now().UTC(),
+		"timestamp":         opts.timestamp(),
 	}
 
-	return s.storeEvent(fmt.Sprintf("correction_%s_%d", originalEventID, time.Now().Unix()), event)
+	return s.storeEvent(ctx, opts, domain.LedgerEventCorrection, event, userIndex(uint64(userID)))
 }
 
-// GetItemHistory retrieves the history of an item from ImmuDB
-func (s *ImmuDBLedgerService) GetItemHistory(itemID uint) ([]map[string]interface{}, error) {
-	// ImmuDB doesn't have SQL-like queries, so we need to scan for keys related to this item
-	// This is a simplified implementation - in production you might want to use a more sophisticated indexing strategy
+// GetEvent reads a single event by its event ID, or returns ErrEventNotFound.
+func (s *ImmuDBLedgerService) GetEvent(ctx context.Context, eventID string) (*domain.LedgerEvent, error) {
+	key, err := s.primaryKeyForEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
 
-	var history []map[string]interface{}
+	entry, err := s.client.VerifiedGet(ctx, []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("verified read of %s failed: %w", key, err)
+	}
+	var stored map[string]interface{}
+	if err := json.Unmarshal(entry.Value, &stored); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event %s: %w", key, err)
+	}
+
+	event := immudbRecord{key: key, txID: entry.Tx, event: stored}.toLedgerEvent()
+	return &event, nil
+}
 
-	// For now, return a placeholder indicating ImmuDB integration is in progress
-	placeholder := map[string]interface{}{
-		"event_type": "SystemNote",
-		"message":    fmt.Sprintf("ImmuDB history retrieval for item %d is being implemented", itemID),
-		"timestamp":  time.Now().UTC(),
+// GetItemHistory retrieves the history of an item from ImmuDB, oldest first.
+// Events are located through the item index and each one is read with a verified get,
+// so the returned events carry their transaction ID and inclusion proof.
+func (s *ImmuDBLedgerService) GetItemHistory(ctx context.Context, itemID uint) ([]domain.LedgerEvent, error) {
+	history, err := s.historyByIndex(ctx, itemIndex(uint64(itemID)))
+	if err != nil {
+		log.Printf("Error retrieving history for ItemID %d from ImmuDB: %v", itemID, err)
+		return nil, fmt.Errorf("failed to retrieve item history: %w", err)
 	}
 
-	history = append(history, placeholder)
+	log.Printf("Retrieved %d history events for ItemID: %d", len(history), itemID)
 	return history, nil
 }
 
+// historyByIndex reads every event under an index prefix, oldest first, together with
+// its inclusion proof.
+func (s *ImmuDBLedgerService) historyByIndex(ctx context.Context, prefix string) ([]domain.LedgerEvent, error) {
+	records, err := s.recordsByIndex(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]domain.LedgerEvent, 0, len(records))
+	for _, record := range records {
+		event := record.toLedgerEvent()
+		if event.Ledger.Proof, err = s.inclusionProof(ctx, record); err != nil {
+			return nil, err
+		}
+		history = append(history, event)
+	}
+	return history, nil
+}
+
+// inclusionProof returns the JSON-encoded proof that the record is included in its transaction.
+// The record has already been read with VerifiedGet, which checks the proof against the
+// client's trusted state but does not return it, so the proof is fetched for the caller to keep.
+func (s *ImmuDBLedgerService) inclusionProof(ctx context.Context, record immudbRecord) (json.RawMessage, error) {
+	proof, err := s.client.GetServiceClient().VerifiableGet(ctx, &schema.VerifiableGetRequest{
+		KeyRequest:   &schema.KeyRequest{Key: []byte(record.key), AtTx: record.txID},
+		ProveSinceTx: record.txID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch inclusion proof for %s: %w", record.key, err)
+	}
+
+	terms := make([]string, 0, len(proof.InclusionProof.GetTerms()))
+	for _, term := range proof.InclusionProof.GetTerms() {
+		terms = append(terms, hex.EncodeToString(term))
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"leaf":  proof.InclusionProof.GetLeaf(),
+		"width": proof.InclusionProof.GetWidth(),
+		"terms": terms,
+	})
+}
+
+// scanPrefix returns every entry whose key starts with prefix, paging through the results.
+func (s *ImmuDBLedgerService) scanPrefix(ctx context.Context, prefix string) ([]*schema.Entry, error) {
+	var (
+		all     []*schema.Entry
+		seekKey []byte
+	)
+
+	for {
+		entries, err := s.client.Scan(ctx, &schema.ScanRequest{
+			Prefix:  []byte(prefix),
+			SeekKey: seekKey,
+			Limit:   scanPageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan prefix %s: %w", strings.TrimSuffix(prefix, ":"), err)
+		}
+
+		all = append(all, entries.Entries...)
+		if len(entries.Entries) < scanPageSize {
+			return all, nil
+		}
+		seekKey = entries.Entries[len(entries.Entries)-1].Key
+	}
+}
+
 // VerifyDocument verifies the integrity of a document in ImmuDB
-func (s *ImmuDBLedgerService) VerifyDocument(documentID string, tableName string) (bool, error) {
+func (s *ImmuDBLedgerService) VerifyDocument(ctx context.Context, documentID string, tableName string) (bool, error) {
 	// ImmuDB provides cryptographic verification by default
 	// For now, we'll implement a basic verification by checking if the key exists
 	key := []byte(documentID)
 
-	_, err := s.client.Get(s.ctx, key)
+	_, err := s.client.Get(ctx, key)
 	if err != nil {
 		log.Printf("Document verification failed for %s: %v", documentID, err)
 		return false, nil // Document doesn't exist or is corrupted
@@ -187,52 +493,639 @@ func (s *ImmuDBLedgerService) VerifyDocument(documentID string, tableName string
 	return true, nil
 }
 
-// GetAllCorrectionEvents retrieves all correction events from ImmuDB
-func (s *ImmuDBLedgerService) GetAllCorrectionEvents() ([]domain.CorrectionEvent, error) {
-	// Placeholder implementation
-	return []domain.CorrectionEvent{}, nil
+// GetAllCorrectionEvents retrieves all correction events from ImmuDB, most recent first.
+func (s *ImmuDBLedgerService) GetAllCorrectionEvents(ctx context.Context) ([]domain.CorrectionEvent, error) {
+	events, err := s.correctionEvents(ctx, typeIndex("CorrectionEvent"))
+	if err != nil {
+		log.Printf("Error retrieving correction events from ImmuDB: %v", err)
+		return nil, fmt.Errorf("failed to retrieve correction events: %w", err)
+	}
+
+	log.Printf("Retrieved %d correction events", len(events))
+	return events, nil
+}
+
+// GetCorrectionEventsByOriginalID retrieves correction events by original event ID, most recent first.
+func (s *ImmuDBLedgerService) GetCorrectionEventsByOriginalID(ctx context.Context, originalEventID string) ([]domain.CorrectionEvent, error) {
+	events, err := s.correctionEvents(ctx, correctionOriginalIndex(originalEventID))
+	if err != nil {
+		log.Printf("Error retrieving corrections for original event %s from ImmuDB: %v", originalEventID, err)
+		return nil, fmt.Errorf("failed to retrieve correction events: %w", err)
+	}
+
+	log.Printf("Retrieved %d correction events for OriginalEventID: %s", len(events), originalEventID)
+	return events, nil
+}
+
+// GetCorrectionEventByID retrieves a specific correction event by ID.
+// Returns nil, nil if no correction with that ID exists, matching the Azure SQL backend.
+func (s *ImmuDBLedgerService) GetCorrectionEventByID(ctx context.Context, eventID string) (*domain.CorrectionEvent, error) {
+	events, err := s.correctionEvents(ctx, eventIDIndex(eventID))
+	if err != nil {
+		log.Printf("Error retrieving correction event %s from ImmuDB: %v", eventID, err)
+		return nil, fmt.Errorf("failed to retrieve correction event: %w", err)
+	}
+	if len(events) == 0 {
+		log.Printf("Correction event with ID %s not found", eventID)
+		return nil, nil
+	}
+	return &events[0], nil
+}
+
+// GetGeneralHistory retrieves one page of ledger events matching query.
+// The most selective secondary index for the query is scanned in the page's order, starting
+// at the cursor, until a page of matching events has been found; the remaining filters are
+// applied to the events read from it. Only the events on the returned page are read with a
+// verified get, so a page costs the same however long the ledger is.
+func (s *ImmuDBLedgerService) GetGeneralHistory(ctx context.Context, query HistoryQuery) (*HistoryPage, error) {
+	query, cursor, err := query.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := timeIndexPrefix
+	switch {
+	case query.TransferID != nil:
+		prefix = transferIndex(*query.TransferID)
+	case query.ItemID != nil:
+		prefix = itemIndex(*query.ItemID)
+	case query.SerialNumber != "":
+		prefix = serialIndex(query.SerialNumber)
+	case query.UserID != nil:
+		prefix = userIndex(*query.UserID)
+	}
+
+	// The cursor narrows the window to the events not yet returned
+	from, to := query.From, query.To
+	if cursor != nil {
+		if query.Order == SortAsc && (from == nil || cursor.Timestamp.After(*from)) {
+			from = &cursor.Timestamp
+		}
+		if query.Order == SortDesc {
+			end := cursor.Timestamp.Add(time.Nanosecond)
+			if to == nil || end.Before(*to) {
+				to = &end
+			}
+		}
+	}
+
+	candidates, err := s.scanPage(ctx, prefix, from, to, query, cursor)
+	if err != nil {
+		log.Printf("Error querying general history from ImmuDB: %v", err)
+		return nil, fmt.Errorf("failed to query general history: %w", err)
+	}
+
+	events := make([]domain.LedgerEvent, 0, len(candidates))
+	records := make(map[string]immudbRecord, len(candidates))
+	for _, record := range candidates {
+		event := record.toLedgerEvent()
+		events = append(events, event)
+		records[event.EventID] = record
+	}
+
+	page := pageHistory(events, query, cursor)
+	for i, event := range page.Events {
+		verified, err := s.verifiedRecord(ctx, records[event.EventID])
+		if err != nil {
+			log.Printf("Error querying general history from ImmuDB: %v", err)
+			return nil, fmt.Errorf("failed to query general history: %w", err)
+		}
+		page.Events[i] = verified.toLedgerEvent()
+	}
+
+	log.Printf("Retrieved %d general history events", len(page.Events))
+	return page, nil
+}
+
+// scanPage scans the index under prefix over [from, to) in the query's order and returns the
+// unverified records of the events that match query and follow the cursor, stopping once it
+// has more than a page of them. Index keys order events with the same timestamp by key rather
+// than by event ID, so the scan also reads every event that shares the timestamp of the first
+// event past the page; pageHistory then puts them in page order.
+func (s *ImmuDBLedgerService) scanPage(ctx context.Context, prefix string, from, to *time.Time, query HistoryQuery, cursor *historyCursor) ([]immudbRecord, error) {
+	var lower, upper []byte
+	if from != nil {
+		lower = []byte(fmt.Sprintf("%s%020d", prefix, from.UnixNano()))
+	}
+	if to != nil {
+		upper = []byte(fmt.Sprintf("%s%020d", prefix, to.UnixNano()))
+	}
+
+	scan := &schema.ScanRequest{
+		Prefix: []byte(prefix),
+		Limit:  uint64(query.Limit + 1),
+	}
+	if query.Order == SortDesc {
+		scan.Desc = true
+		scan.SeekKey, scan.EndKey = upper, lower
+	} else {
+		scan.SeekKey, scan.EndKey = lower, upper
+	}
+
+	var (
+		records  []immudbRecord
+		boundary string // Index timestamp of the first matching event past the page
+		seen     = make(map[string]bool)
+	)
+	for {
+		entries, err := s.client.Scan(ctx, scan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan prefix %s: %w", strings.TrimSuffix(prefix, ":"), err)
+		}
+
+		for _, indexEntry := range entries.Entries {
+			scan.SeekKey = indexEntry.Key
+
+			at := string(indexEntry.Key[len(prefix) : len(prefix)+20])
+			if len(records) > query.Limit && at != boundary {
+				return records, nil
+			}
+
+			primaryKey := string(indexEntry.Value)
+			if seen[primaryKey] {
+				continue // Several index keys may point at the same event
+			}
+			seen[primaryKey] = true
+
+			record, err := s.readRecord(ctx, primaryKey)
+			if err != nil {
+				return nil, err
+			}
+			event := record.toLedgerEvent()
+			if !historyMatches(event, query) || !cursor.after(event, query.Order) {
+				continue
+			}
+			records = append(records, record)
+			if len(records) == query.Limit+1 {
+				boundary = at
+			}
+		}
+
+		if uint64(len(entries.Entries)) < scan.Limit {
+			return records, nil
+		}
+	}
+}
+
+// readRecord reads an event without verifying it, for deciding whether it belongs on a page.
+func (s *ImmuDBLedgerService) readRecord(ctx context.Context, primaryKey string) (immudbRecord, error) {
+	entry, err := s.client.Get(ctx, []byte(primaryKey))
+	if err != nil {
+		return immudbRecord{}, fmt.Errorf("read of %s failed: %w", primaryKey, err)
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(entry.Value, &event); err != nil {
+		return immudbRecord{}, fmt.Errorf("failed to unmarshal event %s: %w", primaryKey, err)
+	}
+	return immudbRecord{key: primaryKey, txID: entry.Tx, event: event}, nil
 }
 
-// GetCorrectionEventsByOriginalID retrieves correction events by original event ID
-func (s *ImmuDBLedgerService) GetCorrectionEventsByOriginalID(originalEventID string) ([]domain.CorrectionEvent, error) {
-	// Placeholder implementation
-	return []domain.CorrectionEvent{}, nil
+// verifiedRecord re-reads record with a verified get and checks that it was read as stored.
+func (s *ImmuDBLedgerService) verifiedRecord(ctx context.Context, record immudbRecord) (immudbRecord, error) {
+	entry, err := s.client.VerifiedGet(ctx, []byte(record.key))
+	if err != nil {
+		return immudbRecord{}, fmt.Errorf("verified read of %s failed: %w", record.key, err)
+	}
+	if entry.Tx != record.txID {
+		return immudbRecord{}, fmt.Errorf("verified read of %s returned transaction %d, scanned %d", record.key, entry.Tx, record.txID)
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(entry.Value, &event); err != nil {
+		return immudbRecord{}, fmt.Errorf("failed to unmarshal event %s: %w", record.key, err)
+	}
+	return immudbRecord{key: record.key, txID: entry.Tx, event: event}, nil
 }
 
-// GetCorrectionEventByID retrieves a specific correction event by ID
-func (s *ImmuDBLedgerService) GetCorrectionEventByID(eventID string) (*domain.CorrectionEvent, error) {
-	// Placeholder implementation
-	return nil, fmt.Errorf("correction event not found: %s", eventID)
+// GetEventReceipt returns a receipt proving the event's entry is included in its transaction
+// and that the transaction is part of the current, server-signed database state. The linear
+// advance proof is filled in so the receipt verifies without contacting ImmuDB.
+func (s *ImmuDBLedgerService) GetEventReceipt(ctx context.Context, eventID string) (*receipt.Receipt, error) {
+	key, err := s.primaryKeyForEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The state is read after the event was found, so it always includes the event's transaction
+	state, err := s.client.CurrentState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ImmuDB state: %w", err)
+	}
+
+	entry, err := s.client.GetServiceClient().VerifiableGet(ctx, &schema.VerifiableGetRequest{
+		KeyRequest:   &schema.KeyRequest{Key: []byte(key)},
+		ProveSinceTx: state.TxId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch proof for %s: %w", key, err)
+	}
+
+	dualProof := schema.DualProofFromProto(entry.VerifiableTx.DualProof)
+	if err := schema.FillMissingLinearAdvanceProof(ctx, dualProof, entry.Entry.Tx, state.TxId, s.client.GetServiceClient()); err != nil {
+		return nil, fmt.Errorf("failed to complete proof for %s: %w", key, err)
+	}
+	entry.VerifiableTx.DualProof = schema.DualProofToProto(dualProof)
+
+	proof, err := receipt.NewImmuDBProof(key, entry, state)
+	if err != nil {
+		return nil, err
+	}
+	if err := receipt.VerifyImmuDBProof(proof, entry.Entry.Value, nil); err != nil {
+		return nil, fmt.Errorf("ImmuDB returned an invalid proof for %s: %w", key, err)
+	}
+
+	var event map[string]interface{}
+	if err := json.Unmarshal(entry.Entry.Value, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event %s: %w", key, err)
+	}
+	eventType, _ := event["event_type"].(string)
+
+	return &receipt.Receipt{
+		Version:   receipt.Version,
+		Backend:   receipt.BackendImmuDB,
+		EventID:   eventIDOf(key, event),
+		EventType: eventType,
+		Event:     entry.Entry.Value,
+		IssuedAt:  time.Now().UTC(),
+		ImmuDB:    proof,
+	}, nil
 }
 
-// GetGeneralHistory retrieves a consolidated view of all ledger events
-func (s *ImmuDBLedgerService) GetGeneralHistory() ([]domain.GeneralLedgerEvent, error) {
-	// Placeholder implementation
-	return []domain.GeneralLedgerEvent{}, nil
+// CaptureDigest returns the current database state: the last transaction ID and its
+// accumulated linear hash, which commits to every earlier transaction. The state, including
+// the server's signature when state signing is enabled, is kept as the raw digest.
+func (s *ImmuDBLedgerService) CaptureDigest(ctx context.Context) (*Digest, error) {
+	state, err := s.client.CurrentState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ImmuDB state: %w", err)
+	}
+
+	raw, err := protojson.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ImmuDB state: %w", err)
+	}
+
+	return &Digest{
+		Backend:    receipt.BackendImmuDB,
+		Database:   state.Db,
+		Position:   state.TxId,
+		Hash:       hex.EncodeToString(state.TxHash),
+		CapturedAt: time.Now().UTC(),
+		Raw:        raw,
+	}, nil
+}
+
+// VerifyDigest asks ImmuDB for a consistency proof from the digest's transaction to the
+// current state and checks it locally, so a server that rewrote history cannot pass.
+func (s *ImmuDBLedgerService) VerifyDigest(ctx context.Context, digest Digest) error {
+	state, err := s.client.CurrentState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read ImmuDB state: %w", err)
+	}
+	if digest.Backend != receipt.BackendImmuDB || digest.Database != state.Db {
+		return fmt.Errorf("%w: captured from %s database %q", ErrForeignDigest, digest.Backend, digest.Database)
+	}
+
+	digestHash, err := hex.DecodeString(digest.Hash)
+	if err != nil || len(digestHash) != sha256.Size {
+		return fmt.Errorf("%w: malformed hash %q", ErrDigestMismatch, digest.Hash)
+	}
+	if digest.Position == 0 {
+		return nil
+	}
+	if state.TxId < digest.Position {
+		return fmt.Errorf("%w: digest covers transaction %d but the database ends at %d", ErrDigestMismatch, digest.Position, state.TxId)
+	}
+	if state.TxId == digest.Position {
+		if !bytes.Equal(state.TxHash, digestHash) {
+			return fmt.Errorf("%w: transaction %d has hash %x, digest has %s", ErrDigestMismatch, state.TxId, state.TxHash, digest.Hash)
+		}
+		return nil
+	}
+
+	vTx, err := s.client.GetServiceClient().VerifiableTxById(ctx, &schema.VerifiableTxRequest{
+		Tx:           state.TxId,
+		ProveSinceTx: digest.Position,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch consistency proof from transaction %d: %w", digest.Position, err)
+	}
+
+	dualProof := schema.DualProofFromProto(vTx.DualProof)
+	if err := schema.FillMissingLinearAdvanceProof(ctx, dualProof, digest.Position, state.TxId, s.client.GetServiceClient()); err != nil {
+		return fmt.Errorf("failed to complete consistency proof from transaction %d: %w", digest.Position, err)
+	}
+	if !store.VerifyDualProof(dualProof, digest.Position, state.TxId, schema.DigestFromProto(digestHash), schema.DigestFromProto(state.TxHash)) {
+		return fmt.Errorf("%w: no valid consistency proof from transaction %d to %d", ErrDigestMismatch, digest.Position, state.TxId)
+	}
+	return nil
+}
+
+// primaryKeyForEvent resolves an event ID to the key the event is stored under. Events
+// written before event IDs were assigned use their primary key as their ID.
+func (s *ImmuDBLedgerService) primaryKeyForEvent(ctx context.Context, eventID string) (string, error) {
+	entries, err := s.scanPrefix(ctx, eventIDIndex(eventID))
+	if err != nil {
+		return "", err
+	}
+	if len(entries) > 0 {
+		return string(entries[0].Value), nil
+	}
+
+	for _, prefix := range legacyEventPrefixes {
+		if !strings.HasPrefix(eventID, prefix) {
+			continue
+		}
+		if _, err := s.client.Get(ctx, []byte(eventID)); err == nil {
+			return eventID, nil
+		} else if !strings.Contains(err.Error(), "key not found") {
+			return "", fmt.Errorf("failed to read %s: %w", eventID, err)
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrEventNotFound, eventID)
+}
+
+// immudbRecord is a decoded ledger event together with where it is stored in ImmuDB.
+type immudbRecord struct {
+	key   string
+	txID  uint64
+	event map[string]interface{}
+}
+
+// recordsByIndex resolves and reads every event under an index prefix, oldest first.
+func (s *ImmuDBLedgerService) recordsByIndex(ctx context.Context, prefix string) ([]immudbRecord, error) {
+	indexEntries, err := s.scanPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]immudbRecord, 0, len(indexEntries))
+	seen := make(map[string]bool, len(indexEntries))
+	for _, indexEntry := range indexEntries {
+		primaryKey := string(indexEntry.Value)
+		if seen[primaryKey] {
+			continue // Several index keys may point at the same event
+		}
+		seen[primaryKey] = true
+
+		entry, err := s.client.VerifiedGet(ctx, []byte(primaryKey))
+		if err != nil {
+			return nil, fmt.Errorf("verified read of %s failed: %w", primaryKey, err)
+		}
+		var event map[string]interface{}
+		if err := json.Unmarshal(entry.Value, &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event %s: %w", primaryKey, err)
+		}
+		records = append(records, immudbRecord{key: primaryKey, txID: entry.Tx, event: event})
+	}
+	return records, nil
+}
+
+// correctionEvents reads the correction events under an index prefix, most recent first.
+func (s *ImmuDBLedgerService) correctionEvents(ctx context.Context, prefix string) ([]domain.CorrectionEvent, error) {
+	records, err := s.recordsByIndex(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []domain.CorrectionEvent{}
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].event["event_type"] != "CorrectionEvent" {
+			continue
+		}
+		events = append(events, records[i].toCorrectionEvent())
+	}
+	return events, nil
+}
+
+// ledgerIDs returns the ImmuDB transaction ID in place of the Azure ledger transaction ID.
+// ImmuDB has no per-transaction sequence number; each event is written in its own transaction, so it is always 0.
+func (r immudbRecord) ledgerIDs() (*int64, *int64) {
+	txID, seq := int64(r.txID), int64(0)
+	return &txID, &seq
+}
+
+// timestamp parses the stored event timestamp.
+func (r immudbRecord) timestamp() time.Time {
+	ts, _ := time.Parse(time.RFC3339Nano, fmt.Sprint(r.event["timestamp"]))
+	return ts
+}
+
+// uint64Field returns a numeric event field, or nil if it is absent.
+func (r immudbRecord) uint64Field(name string) *uint64 {
+	v, ok := r.event[name].(float64)
+	if !ok {
+		return nil
+	}
+	u := uint64(v)
+	return &u
+}
+
+// signature decodes the stored event signature, or returns nil if the event is unsigned.
+func (r immudbRecord) signature() *domain.EventSignature {
+	stored, ok := r.event["signature"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	raw, err := json.Marshal(stored)
+	if err != nil {
+		return nil
+	}
+	var signature domain.EventSignature
+	if err := json.Unmarshal(raw, &signature); err != nil {
+		return nil
+	}
+	return &signature
+}
+
+// toCorrectionEvent maps a stored correction onto domain.CorrectionEvent.
+func (r immudbRecord) toCorrectionEvent() domain.CorrectionEvent {
+	event := domain.CorrectionEvent{
+		EventID:             eventIDOf(r.key, r.event),
+		CorrectionTimestamp: r.timestamp(),
+	}
+	event.OriginalEventID, _ = r.event["original_event_id"].(string)
+	event.OriginalEventType, _ = r.event["correction_type"].(string)
+	event.Reason, _ = r.event["reason"].(string)
+	if userID := r.uint64Field("user_id"); userID != nil {
+		event.CorrectingUserID = *userID
+	}
+	event.LedgerTransactionID, event.LedgerSequenceNumber = r.ledgerIDs()
+	return event
+}
+
+// toLedgerEvent maps a stored event onto the backend-independent event model.
+func (r immudbRecord) toLedgerEvent() domain.LedgerEvent {
+	event := domain.LedgerEvent{
+		EventID:      eventIDOf(r.key, r.event),
+		Timestamp:    r.timestamp(),
+		ActorUserID:  r.uint64Field("user_id"),
+		ItemID:       r.uint64Field("item_id"),
+		SerialNumber: stringField(r.event, "serial_number"),
+		Signature:    r.signature(),
+		Ledger:       domain.LedgerTxMetadata{Backend: receipt.BackendImmuDB, Key: r.key},
+	}
+	event.Ledger.TransactionID, event.Ledger.SequenceNumber = r.ledgerIDs()
+
+	switch r.event["event_type"] {
+	case "ItemCreation":
+		event.EventType = domain.LedgerEventItemCreation
+		details, _ := r.event["details"].(map[string]interface{})
+		_, stateRecorded := details["status"]
+		action := stringField(details, "action") // Only set on events imported from another ledger
+		if action == "" {
+			action = "Created"
+		}
+		event.ItemCreation = &domain.ItemCreationDetails{
+			Action:           action,
+			Name:             stringField(details, "name"),
+			Description:      stringField(details, "description"),
+			Notes:            stringField(details, "notes"),
+			StateRecorded:    stateRecorded,
+			Status:           stringField(details, "status"),
+			AssignedToUserID: idField(details, "assigned_to_user_id"),
+		}
+	case "TransferEvent":
+		event.EventType = domain.LedgerEventTransfer
+		event.ActorUserID = r.uint64Field("initiating_user_id")
+		if event.ActorUserID == nil {
+			event.ActorUserID = r.uint64Field("from_user_id")
+		}
+		event.ItemID = r.uint64Field("property_id")
+		transfer := &domain.TransferDetails{
+			TransferID:      stringField(r.event, "transfer_id"), // Non-numeric IDs imported from Azure SQL
+			Status:          stringField(r.event, "status"),
+			ApprovingUserID: r.uint64Field("approving_user_id"),
+			Notes:           stringField(r.event, "notes"),
+		}
+		if id := r.uint64Field("transfer_id"); id != nil {
+			transfer.TransferID = strconv.FormatUint(*id, 10)
+		}
+		if id := r.uint64Field("from_user_id"); id != nil {
+			transfer.FromUserID = *id
+		}
+		if id := r.uint64Field("to_user_id"); id != nil {
+			transfer.ToUserID = *id
+		}
+		event.Transfer = transfer
+	case "StatusChange":
+		event.EventType = domain.LedgerEventStatusChange
+		event.StatusChange = &domain.StatusChangeDetails{
+			PreviousStatus: stringField(r.event, "old_status"),
+			NewStatus:      stringField(r.event, "new_status"),
+			Reason:         stringField(r.event, "reason"),
+		}
+	case "VerificationEvent":
+		event.EventType = domain.LedgerEventVerification
+		event.Verification = &domain.VerificationDetails{
+			Result: stringField(r.event, "verification_type"),
+			Notes:  stringField(r.event, "notes"),
+		}
+	case "MaintenanceEvent":
+		event.EventType = domain.LedgerEventMaintenance
+		event.ActorUserID = r.uint64Field("initiating_user_id")
+		event.Maintenance = &domain.MaintenanceDetails{
+			MaintenanceRecordID: stringField(r.event, "maintenance_record_id"),
+			Stage:               stringField(r.event, "event_type_detail"),
+			MaintenanceType:     stringField(r.event, "maintenance_type"),
+			Description:         stringField(r.event, "description"),
+			PerformingUserID:    r.uint64Field("performing_user_id"),
+		}
+	case "CorrectionEvent":
+		event.EventType = domain.LedgerEventCorrection
+		event.Correction = &domain.CorrectionDetails{
+			OriginalEventID:   stringField(r.event, "original_event_id"),
+			OriginalEventType: stringField(r.event, "correction_type"),
+			Reason:            stringField(r.event, "reason"),
+		}
+	default:
+		event.EventType = stringField(r.event, "event_type")
+	}
+	return event
 }
 
 // Close cleans up resources
 func (s *ImmuDBLedgerService) Close() error {
 	if s.client != nil {
 		log.Println("Closing ImmuDB connection")
-		return s.client.CloseSession(s.ctx)
+		return s.client.CloseSession(context.Background())
 	}
 	return nil
 }
 
-// storeEvent is a helper method to store events in ImmuDB
-func (s *ImmuDBLedgerService) storeEvent(key string, event map[string]interface{}) error {
+// storeEvent is a helper method to store events in ImmuDB.
+// Each event is given an event ID, which names its primary key, and signed, and the event and
+// its secondary index keys are written atomically in a single transaction.
+func (s *ImmuDBLedgerService) storeEvent(ctx context.Context, opts WriteOptions, eventType string, event map[string]interface{}, indexes ...string) (*domain.LedgerWriteResult, error) {
+	eventID, preassigned := opts.eventID()
+	if preassigned {
+		existing, err := s.GetEvent(ctx, eventID)
+		if err == nil {
+			return existingWrite(existing, eventType)
+		}
+		if !errors.Is(err, ErrEventNotFound) {
+			return nil, err
+		}
+	}
+	event["event_id"] = eventID
+	key := eventKeyPrefixes[eventType] + eventID
+
+	if opts.Signature != nil || s.signer != nil {
+		// Sign the event as it will read back from its stored JSON
+		raw, err := json.Marshal(event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal event: %w", err)
+		}
+		var stored map[string]interface{}
+		if err := json.Unmarshal(raw, &stored); err != nil {
+			return nil, fmt.Errorf("failed to decode event: %w", err)
+		}
+		signature, err := opts.signature(s.signer, immudbRecord{key: key, event: stored}.toLedgerEvent())
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign event: %w", err)
+		}
+		if signature != nil {
+			event["signature"] = signature
+		}
+	}
+
+	txID, err := s.writeEvent(ctx, key, event, indexes...)
+	if err != nil {
+		return nil, err
+	}
+	transactionID, seq := int64(txID), int64(0)
+	return &domain.LedgerWriteResult{
+		EventID: eventID,
+		Ledger: domain.LedgerTxMetadata{
+			Backend:        receipt.BackendImmuDB,
+			TransactionID:  &transactionID,
+			SequenceNumber: &seq,
+			Key:            key,
+		},
+	}, nil
+}
+
+// writeEvent writes an event, which must already carry its event ID, and its index keys in a
+// single transaction, and returns the transaction ID. The write fails rather than overwrite
+// an existing key.
+func (s *ImmuDBLedgerService) writeEvent(ctx context.Context, key string, event map[string]interface{}, indexes ...string) (uint64, error) {
 	eventJSON, err := json.Marshal(event)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+		return 0, fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	_, err = s.client.Set(s.ctx, []byte(key), eventJSON)
+	ts, _ := event["timestamp"].(time.Time)
+	indexes = append(indexes, recordIndexes(key, event)...)
+	kvs := append([]*schema.KeyValue{{Key: []byte(key), Value: eventJSON}}, indexKeyValues(key, ts, indexes)...)
+
+	hdr, err := s.client.SetAll(ctx, &schema.SetRequest{
+		KVs:           kvs,
+		Preconditions: []*schema.Precondition{schema.PreconditionKeyMustNotExist([]byte(key))},
+	})
 	if err != nil {
 		log.Printf("Error storing event to ImmuDB: %v", err)
-		return fmt.Errorf("failed to store event in ImmuDB: %w", err)
+		return 0, fmt.Errorf("failed to store event in ImmuDB: %w", err)
 	}
 
-	log.Printf("Successfully logged %s event to ImmuDB", event["event_type"])
-	return nil
+	log.Printf("Successfully logged %s event to ImmuDB in transaction %d", event["event_type"], hdr.Id)
+	return hdr.Id, nil
 }
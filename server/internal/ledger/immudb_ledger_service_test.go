@@ -0,0 +1,203 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	immuclient "github.com/codenotary/immudb/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+)
+
+// storedRecord decodes a stored ImmuDB event the way recordsByIndex does.
+func storedRecord(t *testing.T, key string, txID uint64, value string) immudbRecord {
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(value), &event))
+	return immudbRecord{key: key, txID: txID, event: event}
+}
+
+func TestImmuDBRecord_ToLedgerEvent(t *testing.T) {
+	created := storedRecord(t, "item_creation_7_1", 11, `{"event_type":"ItemCreation","event_id":"e1","item_id":7,"serial_number":"SN-7","user_id":1,
+		"timestamp":"2026-01-02T03:04:05Z","details":{"name":"M4 Carbine","description":"Rifle","status":"Operational","assigned_to_user_id":3}}`).toLedgerEvent()
+	assert.Equal(t, domain.LedgerEventItemCreation, created.EventType)
+	assert.Equal(t, "e1", created.EventID)
+	assert.Equal(t, uint64(7), *created.ItemID)
+	assert.Equal(t, uint64(1), *created.ActorUserID)
+	assert.Equal(t, "SN-7", created.SerialNumber)
+	assert.Equal(t, "immudb", created.Ledger.Backend)
+	assert.Equal(t, "item_creation_7_1", created.Ledger.Key)
+	assert.Equal(t, int64(11), *created.Ledger.TransactionID)
+	require.NotNil(t, created.ItemCreation)
+	assert.Equal(t, "Created", created.ItemCreation.Action)
+	assert.True(t, created.ItemCreation.StateRecorded)
+	assert.Equal(t, uint64(3), *created.ItemCreation.AssignedToUserID)
+
+	transfer := storedRecord(t, "transfer_5_1", 12, `{"event_type":"TransferEvent","transfer_id":5,"property_id":7,"serial_number":"SN-7",
+		"from_user_id":3,"to_user_id":4,"status":"Completed","notes":"handover","timestamp":"2026-01-02T03:04:06Z"}`).toLedgerEvent()
+	assert.Equal(t, domain.LedgerEventTransfer, transfer.EventType)
+	assert.Equal(t, "transfer_5_1", transfer.EventID, "events written before event IDs fall back to the key")
+	assert.Equal(t, uint64(7), *transfer.ItemID)
+	assert.Equal(t, uint64(3), *transfer.ActorUserID)
+	assert.Equal(t, &domain.TransferDetails{TransferID: "5", Status: "Completed", FromUserID: 3, ToUserID: 4, Notes: "handover"}, transfer.Transfer)
+
+	maintenance := storedRecord(t, "maintenance_m1_1", 13, `{"event_type":"MaintenanceEvent","maintenance_record_id":"m1","item_id":7,
+		"initiating_user_id":2,"performing_user_id":5,"event_type_detail":"Started","timestamp":"2026-01-02T03:04:07Z"}`).toLedgerEvent()
+	assert.Equal(t, uint64(2), *maintenance.ActorUserID)
+	require.NotNil(t, maintenance.Maintenance)
+	assert.Equal(t, "Started", maintenance.Maintenance.Stage)
+	assert.Equal(t, uint64(5), *maintenance.Maintenance.PerformingUserID)
+
+	correction := storedRecord(t, "correction_e1_1", 14, `{"event_type":"CorrectionEvent","original_event_id":"e1","correction_type":"EquipmentEvent",
+		"reason":"wrong serial","user_id":1,"timestamp":"2026-01-02T03:04:08Z"}`).toLedgerEvent()
+	assert.Equal(t, domain.LedgerEventCorrection, correction.EventType)
+	assert.Nil(t, correction.ItemID)
+	assert.Equal(t, &domain.CorrectionDetails{OriginalEventID: "e1", OriginalEventType: "EquipmentEvent", Reason: "wrong serial"}, correction.Correction)
+}
+
+func TestStoredEvent_RoundTripsImportedEvents(t *testing.T) {
+	ts := time.Date(2023, 5, 6, 7, 8, 9, 123456700, time.UTC)
+	id := func(v uint64) *uint64 { return &v }
+	events := []domain.LedgerEvent{
+		{EventType: domain.LedgerEventItemCreation, ActorUserID: id(1), ItemID: id(7),
+			ItemCreation: &domain.ItemCreationDetails{Action: "Registered", Notes: "from depot"}},
+		{EventType: domain.LedgerEventTransfer, ActorUserID: id(9), ItemID: id(7),
+			Transfer: &domain.TransferDetails{TransferID: "6F9619FF-8B86-D011-B42D-00C04FC964FF", Status: "Approved", FromUserID: 1, ToUserID: 2, ApprovingUserID: id(3)}},
+		{EventType: domain.LedgerEventStatusChange, ActorUserID: id(2), ItemID: id(7),
+			StatusChange: &domain.StatusChangeDetails{PreviousStatus: "Operational", NewStatus: "Damaged", Reason: "dropped"}},
+		{EventType: domain.LedgerEventVerification, ActorUserID: id(2), ItemID: id(7),
+			Verification: &domain.VerificationDetails{Result: "Verified Present", Notes: "in arms room"}},
+		{EventType: domain.LedgerEventMaintenance, ActorUserID: id(2), ItemID: id(7),
+			Maintenance: &domain.MaintenanceDetails{MaintenanceRecordID: "m-1", Stage: "Completed", MaintenanceType: "Corrective", PerformingUserID: id(4)}},
+		{EventType: domain.LedgerEventCorrection, ActorUserID: id(5),
+			Correction: &domain.CorrectionDetails{OriginalEventID: "e-1", OriginalEventType: "StatusChangeEvent", Reason: "wrong item"}},
+	}
+
+	for i, event := range events {
+		event.EventID = fmt.Sprintf("event-%d", i)
+		event.Timestamp = ts
+		raw, err := json.Marshal(storedEvent(event))
+		require.NoError(t, err)
+
+		got := storedRecord(t, eventKeyPrefixes[event.EventType]+event.EventID, 1, string(raw)).toLedgerEvent()
+		got.Ledger = domain.LedgerTxMetadata{}
+		assert.Equal(t, event, got, event.EventType)
+	}
+}
+
+func TestEventIndexes_IndexesCreationAssignee(t *testing.T) {
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"event_type":"ItemCreation","item_id":7,"user_id":1,"details":{"assigned_to_user_id":2}}`), &event))
+	assert.Equal(t, []string{itemIndex(7), userIndex(1), userIndex(2)}, eventIndexes(event))
+
+	// Created by the assignee themselves
+	require.NoError(t, json.Unmarshal([]byte(`{"event_type":"ItemCreation","item_id":7,"user_id":2,"details":{"assigned_to_user_id":2}}`), &event))
+	assert.Equal(t, []string{itemIndex(7), userIndex(2)}, eventIndexes(event))
+}
+
+// scanClient is an in-memory ImmuDB holding a fixed set of keys. It implements the reads
+// GetGeneralHistory makes and counts them.
+type scanClient struct {
+	immuclient.ImmuClient
+	values       map[string][]byte
+	gets         int
+	verifiedGets int
+}
+
+func (c *scanClient) Scan(ctx context.Context, req *schema.ScanRequest) (*schema.Entries, error) {
+	var keys []string
+	for key := range c.values {
+		k := []byte(key)
+		switch {
+		case !bytes.HasPrefix(k, req.Prefix):
+		case req.Desc && (len(req.SeekKey) > 0 && bytes.Compare(k, req.SeekKey) >= 0 || bytes.Compare(k, req.EndKey) <= 0 && len(req.EndKey) > 0):
+		case !req.Desc && (bytes.Compare(k, req.SeekKey) <= 0 && len(req.SeekKey) > 0 || len(req.EndKey) > 0 && bytes.Compare(k, req.EndKey) >= 0):
+		default:
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if req.Desc {
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	}
+	if uint64(len(keys)) > req.Limit {
+		keys = keys[:req.Limit]
+	}
+
+	entries := &schema.Entries{}
+	for _, key := range keys {
+		entries.Entries = append(entries.Entries, &schema.Entry{Key: []byte(key), Value: c.values[key], Tx: 1})
+	}
+	return entries, nil
+}
+
+func (c *scanClient) Get(ctx context.Context, key []byte, opts ...immuclient.GetOption) (*schema.Entry, error) {
+	c.gets++
+	return &schema.Entry{Key: key, Value: c.values[string(key)], Tx: 1}, nil
+}
+
+func (c *scanClient) VerifiedGet(ctx context.Context, key []byte, opts ...immuclient.GetOption) (*schema.Entry, error) {
+	c.verifiedGets++
+	return &schema.Entry{Key: key, Value: c.values[string(key)], Tx: 1}, nil
+}
+
+func TestImmuDBLedger_GeneralHistoryReadsOnlyThePage(t *testing.T) {
+	ctx := context.Background()
+	client := &scanClient{values: map[string][]byte{}}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	actor := uint64(1)
+
+	var want []string
+	for i := 0; i < 20; i++ {
+		event := domain.LedgerEvent{
+			EventID:      fmt.Sprintf("event-%02d", i),
+			EventType:    domain.LedgerEventVerification,
+			Timestamp:    start.Add(time.Duration(i/2) * time.Minute), // Events share timestamps in pairs
+			ActorUserID:  &actor,
+			Verification: &domain.VerificationDetails{Result: "Verified Present"},
+		}
+		// Keys order each pair the other way round from their event IDs
+		key := fmt.Sprintf("verification_%02d", 20-i)
+		raw, err := json.Marshal(storedEvent(event))
+		require.NoError(t, err)
+		client.values[key] = raw
+		for _, kv := range indexKeyValues(key, event.Timestamp, []string{timeIndexPrefix}) {
+			client.values[string(kv.Key)] = kv.Value
+		}
+		want = append([]string{event.EventID}, want...)
+	}
+	svc := &ImmuDBLedgerService{client: client}
+
+	for _, order := range []SortOrder{SortDesc, SortAsc} {
+		var got []string
+		query := HistoryQuery{Limit: 3, Order: order}
+		for {
+			client.gets, client.verifiedGets = 0, 0
+			page, err := svc.GetGeneralHistory(ctx, query)
+			require.NoError(t, err)
+			for _, event := range page.Events {
+				got = append(got, event.EventID)
+			}
+
+			assert.Equal(t, len(page.Events), client.verifiedGets, "only the page is verified")
+			// The scan reads a page and one more event, and the events sharing a timestamp with
+			// the cursor or with that next event
+			assert.LessOrEqual(t, client.gets, query.Limit+3, "the scan stops after the page")
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+
+		if order == SortAsc {
+			sort.Strings(want)
+		}
+		assert.Equal(t, want, got, order)
+	}
+}
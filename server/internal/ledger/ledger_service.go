@@ -1,51 +1,80 @@
 package ledger
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 
 	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/receipt"
 )
 
+// ErrEventNotFound is returned when a ledger event ID does not exist.
+var ErrEventNotFound = errors.New("ledger event not found")
+
 // LedgerService defines the interface for interacting with an immutable ledger.
 // This allows for different implementations (e.g., QLDB, Azure SQL Ledger, Mock).
+// Every call takes a context so request cancellation and deadlines reach the backend.
+//
+// Each Log* method returns the ID of the event it recorded, a UUIDv7 from NewEventID unless
+// one was preassigned with WriteOptions.EventID, and where the backend stored it.
 type LedgerService interface {
 	// LogItemCreation logs an item creation event.
-	LogItemCreation(property domain.Property, userID uint) error
+	LogItemCreation(ctx context.Context, property domain.Property, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error)
 
-	// LogTransferEvent logs a transfer event (creation or update).
-	LogTransferEvent(transfer domain.Transfer, serialNumber string) error
+	// LogTransferEvent logs a transfer event (creation or update) performed by actorUserID,
+	// the user who requested, approved, rejected, completed or cancelled the transfer.
+	LogTransferEvent(ctx context.Context, transfer domain.Transfer, serialNumber string, actorUserID uint, opts WriteOptions) (*domain.LedgerWriteResult, error)
 
-	// LogStatusChange logs a status change event for an item.
-	LogStatusChange(itemID uint, serialNumber string, oldStatus string, newStatus string, userID uint) error
+	// LogStatusChange logs a status change event for an item. newStatus must be one of the
+	// domain.PropertyStatus* constants (domain.ErrInvalidPropertyStatus otherwise).
+	LogStatusChange(ctx context.Context, itemID uint, serialNumber string, oldStatus string, newStatus string, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error)
 
 	// LogVerificationEvent logs a verification event for an item.
-	LogVerificationEvent(itemID uint, serialNumber string, userID uint, verificationType string) error
+	LogVerificationEvent(ctx context.Context, itemID uint, serialNumber string, userID uint, verificationType string, opts WriteOptions) (*domain.LedgerWriteResult, error)
 
 	// LogMaintenanceEvent logs a maintenance event for an item.
-	LogMaintenanceEvent(maintenanceRecordID string, itemID uint, initiatingUserID uint, performingUserID sql.NullInt64, eventType string, maintenanceType sql.NullString, description string) error
+	LogMaintenanceEvent(ctx context.Context, maintenanceRecordID string, itemID uint, initiatingUserID uint, performingUserID sql.NullInt64, eventType string, maintenanceType sql.NullString, description string, opts WriteOptions) (*domain.LedgerWriteResult, error)
 
 	// LogCorrectionEvent logs a correction event referencing a previous ledger event.
-	LogCorrectionEvent(originalEventID string, eventType string, reason string, userID uint) error
+	// The original event must exist (ErrEventNotFound otherwise) and eventType must be its
+	// LedgerEvent.EventType (ErrInvalidCorrection otherwise).
+	LogCorrectionEvent(ctx context.Context, originalEventID string, eventType string, reason string, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error)
+
+	// GetEvent retrieves a single ledger event of any type. Unknown IDs return ErrEventNotFound.
+	GetEvent(ctx context.Context, eventID string) (*domain.LedgerEvent, error)
 
-	// GetItemHistory retrieves the history of an item based on its serial number.
-	GetItemHistory(itemID uint) ([]map[string]interface{}, error)
+	// GetItemHistory retrieves every event recorded for an item, oldest first.
+	GetItemHistory(ctx context.Context, itemID uint) ([]domain.LedgerEvent, error)
 
 	// VerifyDocument checks the integrity of a ledger document (implementation specific).
 	// For mock/development, this might always return true.
 	// For Azure SQL Ledger, this would involve calling verification stored procedures/functions.
-	VerifyDocument(documentID string, tableName string) (bool, error)
+	VerifyDocument(ctx context.Context, documentID string, tableName string) (bool, error)
 
 	// Query Correction Events
-	GetAllCorrectionEvents() ([]domain.CorrectionEvent, error)
-	GetCorrectionEventsByOriginalID(originalEventID string) ([]domain.CorrectionEvent, error)
-	GetCorrectionEventByID(eventID string) (*domain.CorrectionEvent, error)
+	GetAllCorrectionEvents(ctx context.Context) ([]domain.CorrectionEvent, error)
+	GetCorrectionEventsByOriginalID(ctx context.Context, originalEventID string) ([]domain.CorrectionEvent, error)
+	GetCorrectionEventByID(ctx context.Context, eventID string) (*domain.CorrectionEvent, error)
+
+	// GetGeneralHistory retrieves one page of a consolidated view of all ledger event types,
+	// filtered by query. Malformed queries return an error wrapping ErrInvalidHistoryQuery.
+	GetGeneralHistory(ctx context.Context, query HistoryQuery) (*HistoryPage, error)
+
+	// GetEventReceipt builds an unsigned receipt proving the event is recorded in the ledger.
+	// The proof is checked before it is returned. Unknown IDs return ErrEventNotFound.
+	GetEventReceipt(ctx context.Context, eventID string) (*receipt.Receipt, error)
+
+	// CaptureDigest returns a digest of the ledger as it stands now.
+	CaptureDigest(ctx context.Context) (*Digest, error)
 
-	// GetGeneralHistory retrieves a consolidated view of all ledger event types.
-	// TODO: Add filtering/pagination parameters (e.g., time range, event type, user ID, item ID).
-	GetGeneralHistory() ([]domain.GeneralLedgerEvent, error)
+	// VerifyDigest proves the current ledger extends a previously captured digest. It returns
+	// an error wrapping ErrDigestMismatch if it does not, and ErrForeignDigest if the digest
+	// was captured from another ledger.
+	VerifyDigest(ctx context.Context, digest Digest) error
 
 	// Initialize prepares the ledger service (e.g., connects, ensures tables/ledger exist).
-	Initialize() error
+	Initialize(ctx context.Context) error
 
 	// Close cleans up resources used by the ledger service.
 	Close() error
@@ -0,0 +1,693 @@
+package ledger
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/receipt"
+)
+
+// Ensure LocalLedgerService implements LedgerService interface at compile time
+var _ LedgerService = (*LocalLedgerService)(nil)
+
+// genesisHash is the PrevHash of the first record in a local ledger.
+const genesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// LocalLedgerService implements the LedgerService interface on top of an
+// append-only JSON Lines file. Every record stores the SHA-256 hash of its
+// predecessor, so any edit, deletion or reordering of the file breaks the chain
+// and is detected on load and by VerifyDocument.
+//
+// It needs no external service, which makes it the reference backend for
+// developer machines, CI and air-gapped laptops.
+type LocalLedgerService struct {
+	path   string
+	signer *EventSigner // Signs each event; nil leaves events unsigned
+
+	mu      sync.RWMutex
+	file    *os.File
+	records []localRecord
+}
+
+// localRecord is a single event in the local ledger. RecordType and the keys of
+// Details follow the Azure SQL Ledger history views; toLedgerEvent maps them onto
+// domain.LedgerEvent.
+type localRecord struct {
+	Sequence   int64                  `json:"sequence"`
+	EventID    string                 `json:"eventId"`
+	RecordType string                 `json:"recordType"` // e.g. EquipmentEvent, TransferEvent
+	EventType  string                 `json:"eventType"`  // e.g. Created, Requested, Verified Present
+	Timestamp  time.Time              `json:"timestamp"`
+	UserID     *uint64                `json:"userId,omitempty"`
+	ItemID     *uint64                `json:"itemId,omitempty"`
+	Details    map[string]interface{} `json:"details"`
+	Signature  *domain.EventSignature `json:"signature,omitempty"`
+	PrevHash   string                 `json:"prevHash"`
+
+	hash string // hash of this record, kept in memory only
+	raw  []byte // serialized record the hash covers, kept in memory only
+}
+
+// localLine is the on-disk representation of a record. The hash covers the
+// exact bytes of Record, which already include the previous record's hash.
+type localLine struct {
+	Record json.RawMessage `json:"record"`
+	Hash   string          `json:"hash"`
+}
+
+// NewLocalLedgerService creates a new file-backed ledger service.
+// The file (and its parent directory) is created on Initialize if it does not exist.
+func NewLocalLedgerService(path string) (*LocalLedgerService, error) {
+	if path == "" {
+		return nil, fmt.Errorf("local ledger path is required")
+	}
+	return &LocalLedgerService{path: path}, nil
+}
+
+// SetEventSigner makes the ledger sign the system events it writes from now on.
+func (s *LocalLedgerService) SetEventSigner(signer *EventSigner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signer = signer
+}
+
+// Initialize opens the ledger file, loads existing records and verifies the hash chain.
+func (s *LocalLedgerService) Initialize(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create local ledger directory: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open local ledger file: %w", err)
+	}
+
+	records, err := readLocalRecords(file)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to load local ledger %s: %w", s.path, err)
+	}
+
+	s.file = file
+	s.records = records
+	log.Printf("LocalLedgerService Initialize: loaded %d records from %s", len(records), s.path)
+	return nil
+}
+
+// readLocalRecords parses every line of the ledger file and verifies the chain.
+func readLocalRecords(r io.Reader) ([]localRecord, error) {
+	var records []localRecord
+	prevHash := genesisHash
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var line localLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("line %d: malformed entry: %w", lineNo, err)
+		}
+
+		var record localRecord
+		if err := json.Unmarshal(line.Record, &record); err != nil {
+			return nil, fmt.Errorf("line %d: malformed record: %w", lineNo, err)
+		}
+
+		if record.Sequence != int64(len(records)+1) {
+			return nil, fmt.Errorf("line %d: expected sequence %d, found %d", lineNo, len(records)+1, record.Sequence)
+		}
+		if record.PrevHash != prevHash {
+			return nil, fmt.Errorf("line %d: hash chain broken (prevHash %s, expected %s)", lineNo, record.PrevHash, prevHash)
+		}
+		if computed := hashLocalRecord(line.Record); computed != line.Hash {
+			return nil, fmt.Errorf("line %d: record hash mismatch (stored %s, computed %s)", lineNo, line.Hash, computed)
+		}
+
+		record.hash = line.Hash
+		record.raw = line.Record
+		records = append(records, record)
+		prevHash = line.Hash
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// hashLocalRecord returns the hex-encoded SHA-256 hash of a serialized record.
+func hashLocalRecord(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// append assigns sequence, ID, timestamp, signature and chain hash to a record and persists it.
+// A cancelled context is honoured until the write starts; a started write always completes.
+func (s *LocalLedgerService) append(ctx context.Context, opts WriteOptions, record localRecord) (*domain.LedgerWriteResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("local ledger write abandoned: %w", err)
+	}
+
+	if s.file == nil {
+		return nil, fmt.Errorf("local ledger is not initialized")
+	}
+
+	eventID, preassigned := opts.eventID()
+	if preassigned {
+		for _, existing := range s.records {
+			if existing.EventID == eventID {
+				event := existing.toLedgerEvent()
+				return existingWrite(&event, record.RecordType)
+			}
+		}
+	}
+
+	record.Sequence = int64(len(s.records) + 1)
+	record.EventID = eventID
+	record.Timestamp = opts.timestamp()
+	record.PrevHash = genesisHash
+	if len(s.records) > 0 {
+		record.PrevHash = s.records[len(s.records)-1].hash
+	}
+
+	// Keep the details as they read back from disk, so that mapping them, for signing or
+	// later reads, does not depend on whether the record was written in this process
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ledger record: %w", err)
+	}
+	var stored localRecord
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode ledger record: %w", err)
+	}
+	record.Details = stored.Details
+
+	if record.Signature, err = opts.signature(s.signer, record.toLedgerEvent()); err != nil {
+		return nil, fmt.Errorf("failed to sign ledger record: %w", err)
+	}
+	if raw, err = json.Marshal(record); err != nil {
+		return nil, fmt.Errorf("failed to marshal ledger record: %w", err)
+	}
+	record.hash = hashLocalRecord(raw)
+	record.raw = raw
+
+	line, err := json.Marshal(localLine{Record: raw, Hash: record.hash})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write to local ledger: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to sync local ledger: %w", err)
+	}
+
+	s.records = append(s.records, record)
+	log.Printf("Successfully logged %s (%s) to local ledger at sequence %d", record.RecordType, record.EventType, record.Sequence)
+	return &domain.LedgerWriteResult{EventID: record.EventID, Ledger: record.toLedgerEvent().Ledger}, nil
+}
+
+// uint64Ptr converts a uint ID into the pointer form used by ledger records.
+func uint64Ptr(v uint) *uint64 {
+	u := uint64(v)
+	return &u
+}
+
+// LogItemCreation logs an equipment creation event.
+func (s *LocalLedgerService) LogItemCreation(ctx context.Context, property domain.Property, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	details := map[string]interface{}{
+		"eventTypeDetail":  "Created",
+		"serialNumber":     property.SerialNumber,
+		"name":             property.Name,
+		"status":           property.CurrentStatus,
+		"assignedToUserId": property.AssignedToUserID, // null when unassigned
+	}
+
+	return s.append(ctx, opts, localRecord{
+		RecordType: "EquipmentEvent",
+		EventType:  "Created",
+		UserID:     uint64Ptr(userID),
+		ItemID:     uint64Ptr(property.ID),
+		Details:    details,
+	})
+}
+
+// LogTransferEvent logs a transfer event, using transfer.Status as the event type.
+func (s *LocalLedgerService) LogTransferEvent(ctx context.Context, transfer domain.Transfer, serialNumber string, actorUserID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	details := map[string]interface{}{
+		"transferRequestId": fmt.Sprintf("%d", transfer.ID),
+		"fromUserId":        transfer.FromUserID,
+		"toUserId":          transfer.ToUserID,
+		"eventTypeDetail":   transfer.Status,
+		"serialNumber":      serialNumber,
+	}
+	if transfer.Notes != nil {
+		details["notes"] = *transfer.Notes
+	}
+
+	return s.append(ctx, opts, localRecord{
+		RecordType: "TransferEvent",
+		EventType:  transfer.Status,
+		UserID:     uint64Ptr(actorUserID),
+		ItemID:     uint64Ptr(transfer.PropertyID),
+		Details:    details,
+	})
+}
+
+// LogStatusChange logs a status change event for an item.
+func (s *LocalLedgerService) LogStatusChange(ctx context.Context, itemID uint, serialNumber string, oldStatus string, newStatus string, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	if !domain.IsPropertyStatus(newStatus) {
+		return nil, fmt.Errorf("%w '%s'", domain.ErrInvalidPropertyStatus, newStatus)
+	}
+	return s.append(ctx, opts, localRecord{
+		RecordType: "StatusChangeEvent",
+		EventType:  newStatus,
+		UserID:     uint64Ptr(userID),
+		ItemID:     uint64Ptr(itemID),
+		Details: map[string]interface{}{
+			"previousStatus": oldStatus,
+			"newStatus":      newStatus,
+			"serialNumber":   serialNumber,
+		},
+	})
+}
+
+// LogVerificationEvent logs a verification event for an item.
+func (s *LocalLedgerService) LogVerificationEvent(ctx context.Context, itemID uint, serialNumber string, userID uint, verificationType string, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	return s.append(ctx, opts, localRecord{
+		RecordType: "VerificationEvent",
+		EventType:  verificationType,
+		UserID:     uint64Ptr(userID),
+		ItemID:     uint64Ptr(itemID),
+		Details: map[string]interface{}{
+			"verificationStatus": verificationType,
+			"serialNumber":       serialNumber,
+		},
+	})
+}
+
+// LogMaintenanceEvent logs a maintenance event for an item.
+func (s *LocalLedgerService) LogMaintenanceEvent(ctx context.Context, maintenanceRecordID string, itemID uint, initiatingUserID uint, performingUserID sql.NullInt64, eventType string, maintenanceType sql.NullString, description string, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	details := map[string]interface{}{
+		"maintenanceRecordId": maintenanceRecordID,
+		"eventTypeDetail":     eventType,
+		"description":         description,
+	}
+	if performingUserID.Valid {
+		details["performingUserId"] = performingUserID.Int64
+	}
+	if maintenanceType.Valid {
+		details["maintenanceType"] = maintenanceType.String
+	}
+
+	return s.append(ctx, opts, localRecord{
+		RecordType: "MaintenanceEvent",
+		EventType:  eventType,
+		UserID:     uint64Ptr(initiatingUserID),
+		ItemID:     uint64Ptr(itemID),
+		Details:    details,
+	})
+}
+
+// LogCorrectionEvent logs a correction event referencing a previous ledger event.
+func (s *LocalLedgerService) LogCorrectionEvent(ctx context.Context, originalEventID string, eventType string, reason string, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	if originalEventID == "" || eventType == "" || reason == "" {
+		return nil, fmt.Errorf("missing required parameters for correction event")
+	}
+	if err := checkCorrectionTarget(ctx, s, originalEventID, eventType); err != nil {
+		return nil, err
+	}
+
+	return s.append(ctx, opts, localRecord{
+		RecordType: "CorrectionEvent",
+		EventType:  eventType,
+		UserID:     uint64Ptr(userID),
+		Details: map[string]interface{}{
+			"originalEventId":   originalEventID,
+			"originalEventType": eventType,
+			"reason":            reason,
+		},
+	})
+}
+
+// GetEvent retrieves a single event by ID.
+func (s *LocalLedgerService) GetEvent(ctx context.Context, eventID string) (*domain.LedgerEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, record := range s.records {
+		if record.EventID == eventID {
+			event := record.toLedgerEvent()
+			return &event, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrEventNotFound, eventID)
+}
+
+// GetItemHistory retrieves all events recorded for an item, oldest first.
+func (s *LocalLedgerService) GetItemHistory(ctx context.Context, itemID uint) ([]domain.LedgerEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := []domain.LedgerEvent{}
+	for _, record := range s.records {
+		if record.ItemID != nil && *record.ItemID == uint64(itemID) {
+			history = append(history, record.toLedgerEvent())
+		}
+	}
+	return history, nil
+}
+
+// VerifyDocument re-reads the ledger file and verifies the complete hash chain.
+// If documentID names an event (rather than "database-wide"), that event must also exist.
+// tableName is accepted for interface compatibility and is ignored.
+func (s *LocalLedgerService) VerifyDocument(ctx context.Context, documentID string, tableName string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.file == nil {
+		return false, fmt.Errorf("local ledger is not initialized")
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	onDisk, err := s.readVerified()
+	if err != nil {
+		log.Printf("Local ledger verification failed: %v", err)
+		return false, fmt.Errorf("local ledger verification failed: %w", err)
+	}
+
+	if documentID != "" && documentID != "database-wide" {
+		for _, record := range onDisk {
+			if record.EventID == documentID {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// readVerified re-reads the ledger file, verifying its hash chain, and checks that it holds
+// exactly the records committed by this process. The caller must hold s.mu.
+func (s *LocalLedgerService) readVerified() ([]localRecord, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local ledger: %w", err)
+	}
+	defer file.Close()
+
+	onDisk, err := readLocalRecords(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(onDisk) != len(s.records) {
+		return nil, fmt.Errorf("%d records on disk, %d expected", len(onDisk), len(s.records))
+	}
+	for i := range onDisk {
+		if onDisk[i].hash != s.records[i].hash {
+			return nil, fmt.Errorf("record %d differs from committed state", onDisk[i].Sequence)
+		}
+	}
+	return onDisk, nil
+}
+
+// toCorrectionEvent maps a correction record onto the domain type.
+func (r localRecord) toCorrectionEvent() domain.CorrectionEvent {
+	event := domain.CorrectionEvent{
+		EventID:             r.EventID,
+		CorrectionTimestamp: r.Timestamp,
+	}
+	event.OriginalEventID, _ = r.Details["originalEventId"].(string)
+	event.OriginalEventType, _ = r.Details["originalEventType"].(string)
+	event.Reason, _ = r.Details["reason"].(string)
+	if r.UserID != nil {
+		event.CorrectingUserID = *r.UserID
+	}
+	txID, seq := r.Sequence, int64(0)
+	event.LedgerTransactionID = &txID
+	event.LedgerSequenceNumber = &seq
+	return event
+}
+
+// toLedgerEvent maps a record onto the backend-independent event model.
+func (r localRecord) toLedgerEvent() domain.LedgerEvent {
+	txID, seq := r.Sequence, int64(0)
+	event := domain.LedgerEvent{
+		EventID:      r.EventID,
+		EventType:    r.RecordType,
+		Timestamp:    r.Timestamp,
+		ActorUserID:  r.UserID,
+		ItemID:       r.ItemID,
+		SerialNumber: stringField(r.Details, "serialNumber"),
+		Signature:    r.Signature,
+		Ledger: domain.LedgerTxMetadata{
+			Backend:        receipt.BackendLocal,
+			TransactionID:  &txID,
+			SequenceNumber: &seq,
+			Hash:           r.hash,
+		},
+	}
+
+	d := r.Details
+	switch r.RecordType {
+	case domain.LedgerEventItemCreation:
+		_, stateRecorded := d["status"]
+		event.ItemCreation = &domain.ItemCreationDetails{
+			Action:           stringField(d, "eventTypeDetail"),
+			Name:             stringField(d, "name"),
+			StateRecorded:    stateRecorded,
+			Status:           stringField(d, "status"),
+			AssignedToUserID: idField(d, "assignedToUserId"),
+		}
+	case domain.LedgerEventTransfer:
+		transfer := &domain.TransferDetails{
+			TransferID: stringField(d, "transferRequestId"),
+			Status:     r.EventType,
+			Notes:      stringField(d, "notes"),
+		}
+		if id := idField(d, "fromUserId"); id != nil {
+			transfer.FromUserID = *id
+		}
+		if id := idField(d, "toUserId"); id != nil {
+			transfer.ToUserID = *id
+		}
+		event.Transfer = transfer
+	case domain.LedgerEventStatusChange:
+		event.StatusChange = &domain.StatusChangeDetails{
+			PreviousStatus: stringField(d, "previousStatus"),
+			NewStatus:      stringField(d, "newStatus"),
+		}
+	case domain.LedgerEventVerification:
+		event.Verification = &domain.VerificationDetails{Result: stringField(d, "verificationStatus")}
+	case domain.LedgerEventMaintenance:
+		event.Maintenance = &domain.MaintenanceDetails{
+			MaintenanceRecordID: stringField(d, "maintenanceRecordId"),
+			Stage:               stringField(d, "eventTypeDetail"),
+			MaintenanceType:     stringField(d, "maintenanceType"),
+			Description:         stringField(d, "description"),
+			PerformingUserID:    idField(d, "performingUserId"),
+		}
+	case domain.LedgerEventCorrection:
+		event.Correction = &domain.CorrectionDetails{
+			OriginalEventID:   stringField(d, "originalEventId"),
+			OriginalEventType: stringField(d, "originalEventType"),
+			Reason:            stringField(d, "reason"),
+		}
+	}
+	return event
+}
+
+// correctionEvents returns the correction records matching a predicate, newest first.
+func (s *LocalLedgerService) correctionEvents(match func(domain.CorrectionEvent) bool) []domain.CorrectionEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := []domain.CorrectionEvent{}
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if s.records[i].RecordType != "CorrectionEvent" {
+			continue
+		}
+		event := s.records[i].toCorrectionEvent()
+		if match(event) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// GetAllCorrectionEvents retrieves all correction events, newest first.
+func (s *LocalLedgerService) GetAllCorrectionEvents(ctx context.Context) ([]domain.CorrectionEvent, error) {
+	return s.correctionEvents(func(domain.CorrectionEvent) bool { return true }), nil
+}
+
+// GetCorrectionEventsByOriginalID retrieves correction events for a specific original event.
+func (s *LocalLedgerService) GetCorrectionEventsByOriginalID(ctx context.Context, originalEventID string) ([]domain.CorrectionEvent, error) {
+	return s.correctionEvents(func(e domain.CorrectionEvent) bool { return e.OriginalEventID == originalEventID }), nil
+}
+
+// GetCorrectionEventByID retrieves a specific correction event, or nil if it does not exist.
+func (s *LocalLedgerService) GetCorrectionEventByID(ctx context.Context, eventID string) (*domain.CorrectionEvent, error) {
+	events := s.correctionEvents(func(e domain.CorrectionEvent) bool { return e.EventID == eventID })
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return &events[0], nil
+}
+
+// GetGeneralHistory retrieves one page of ledger events matching query.
+func (s *LocalLedgerService) GetGeneralHistory(ctx context.Context, query HistoryQuery) (*HistoryPage, error) {
+	query, cursor, err := query.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]domain.LedgerEvent, 0, len(s.records))
+	for _, record := range s.records {
+		events = append(events, record.toLedgerEvent())
+	}
+
+	return pageHistory(events, query, cursor), nil
+}
+
+// GetEventReceipt returns a receipt carrying the event's record and the hashes of the records
+// after it, up to the current head. Later records are other users' events, so only their
+// hashes leave the ledger.
+func (s *LocalLedgerService) GetEventReceipt(ctx context.Context, eventID string) (*receipt.Receipt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	index := -1
+	for i := range s.records {
+		if s.records[i].EventID == eventID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEventNotFound, eventID)
+	}
+
+	record := s.records[index]
+	head := s.records[len(s.records)-1]
+	successors := make([]string, 0, len(s.records)-index-1)
+	for _, next := range s.records[index+1:] {
+		successors = append(successors, next.hash)
+	}
+
+	r := &receipt.Receipt{
+		Version:   receipt.Version,
+		Backend:   receipt.BackendLocal,
+		EventID:   record.EventID,
+		EventType: record.RecordType,
+		Event:     record.raw,
+		IssuedAt:  time.Now().UTC(),
+		Local: &receipt.LocalProof{
+			Sequence:        record.Sequence,
+			Hash:            record.hash,
+			SuccessorHashes: successors,
+			HeadSequence:    head.Sequence,
+			HeadHash:        head.hash,
+		},
+	}
+	return r, nil
+}
+
+// CaptureDigest returns the sequence number and hash of the newest record. An empty ledger
+// is digested as sequence 0 with the genesis hash.
+func (s *LocalLedgerService) CaptureDigest(ctx context.Context) (*Digest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.file == nil {
+		return nil, fmt.Errorf("local ledger is not initialized")
+	}
+
+	digest := &Digest{Backend: receipt.BackendLocal, Hash: genesisHash, CapturedAt: time.Now().UTC()}
+	if len(s.records) > 0 {
+		head := s.records[len(s.records)-1]
+		digest.Position = uint64(head.Sequence)
+		digest.Hash = head.hash
+	}
+	return digest, nil
+}
+
+// VerifyDigest re-reads the ledger file, verifies its hash chain, and checks that the record
+// at the digest's sequence number still has the digest's hash.
+func (s *LocalLedgerService) VerifyDigest(ctx context.Context, digest Digest) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.file == nil {
+		return fmt.Errorf("local ledger is not initialized")
+	}
+	if digest.Backend != receipt.BackendLocal {
+		return fmt.Errorf("%w: captured from %s", ErrForeignDigest, digest.Backend)
+	}
+
+	if digest.Position == 0 {
+		if digest.Hash != genesisHash {
+			return fmt.Errorf("%w: empty-ledger digest has hash %s", ErrDigestMismatch, digest.Hash)
+		}
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	onDisk, err := s.readVerified()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDigestMismatch, err)
+	}
+	if digest.Position > uint64(len(onDisk)) {
+		return fmt.Errorf("%w: digest covers sequence %d but the ledger ends at %d", ErrDigestMismatch, digest.Position, len(onDisk))
+	}
+	if record := onDisk[digest.Position-1]; record.hash != digest.Hash {
+		return fmt.Errorf("%w: record %d has hash %s, digest has %s", ErrDigestMismatch, record.Sequence, record.hash, digest.Hash)
+	}
+	return nil
+}
+
+// Close closes the ledger file.
+func (s *LocalLedgerService) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		log.Println("Closing local ledger file")
+		err := s.file.Close()
+		s.file = nil
+		return err
+	}
+	return nil
+}
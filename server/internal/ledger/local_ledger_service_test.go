@@ -0,0 +1,240 @@
+package ledger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+)
+
+// Helper function to create an initialized local ledger in a temp directory
+func setupLocalLedger(t *testing.T) (*LocalLedgerService, string) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	svc, err := NewLocalLedgerService(path)
+	require.NoError(t, err)
+	require.NoError(t, svc.Initialize(context.Background()))
+	t.Cleanup(func() { svc.Close() })
+	return svc, path
+}
+
+func TestLocalLedger_HistoryAndReload(t *testing.T) {
+	ctx := context.Background()
+	svc, path := setupLocalLedger(t)
+
+	property := domain.Property{ID: 7, SerialNumber: "SN-7", Name: "M4 Carbine", CurrentStatus: "Operational"}
+	_, err := svc.LogItemCreation(ctx, property, 1, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogTransferEvent(ctx, domain.Transfer{ID: 3, PropertyID: 7, FromUserID: 1, ToUserID: 2, Status: "Requested"}, "SN-7", 1, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogStatusChange(ctx, 7, "SN-7", "Operational", "Damaged", 2, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogStatusChange(ctx, 7, "SN-7", "Damaged", "maintenance", 2, WriteOptions{})
+	assert.ErrorIs(t, err, domain.ErrInvalidPropertyStatus)
+	_, err = svc.LogVerificationEvent(ctx, 8, "SN-8", 2, "Verified Present", WriteOptions{})
+	require.NoError(t, err)
+
+	history, err := svc.GetItemHistory(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	require.NotNil(t, history[0].ItemCreation)
+	assert.Equal(t, domain.LedgerEventItemCreation, history[0].EventType)
+	assert.Equal(t, "M4 Carbine", history[0].ItemCreation.Name)
+	assert.True(t, history[0].ItemCreation.StateRecorded)
+	assert.Nil(t, history[0].ItemCreation.AssignedToUserID)
+	require.NotNil(t, history[1].Transfer)
+	assert.Equal(t, domain.TransferDetails{TransferID: "3", Status: "Requested", FromUserID: 1, ToUserID: 2}, *history[1].Transfer)
+	require.NotNil(t, history[2].StatusChange)
+	assert.Equal(t, "Damaged", history[2].StatusChange.NewStatus)
+	assert.Equal(t, "SN-7", history[2].SerialNumber)
+	assert.Equal(t, "local", history[2].Ledger.Backend)
+	assert.Equal(t, int64(3), *history[2].Ledger.TransactionID)
+
+	general, err := svc.GetGeneralHistory(ctx, HistoryQuery{})
+	require.NoError(t, err)
+	require.Len(t, general.Events, 4)
+	assert.Equal(t, "VerificationEvent", general.Events[0].EventType, "general history is most recent first")
+	assert.Empty(t, general.NextCursor)
+
+	// Re-open the same file: records and chain must survive a restart
+	require.NoError(t, svc.Close())
+	reopened, err := NewLocalLedgerService(path)
+	require.NoError(t, err)
+	require.NoError(t, reopened.Initialize(ctx))
+	defer reopened.Close()
+
+	ok, err := reopened.VerifyDocument(ctx, "database-wide", "all")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = reopened.LogStatusChange(ctx, 7, "SN-7", "Damaged", "In Repair", 2, WriteOptions{})
+	require.NoError(t, err)
+	reloaded, err := reopened.GetItemHistory(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, reloaded, 4)
+	assert.Equal(t, history, reloaded[:3], "events read back from disk map the same way")
+}
+
+func TestLocalLedger_WritesReturnTimeOrderedEventIDs(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := setupLocalLedger(t)
+
+	// Two changes to the same item in the same second are separate events
+	first, err := svc.LogStatusChange(ctx, 7, "SN-7", "Operational", "Damaged", 2, WriteOptions{})
+	require.NoError(t, err)
+	second, err := svc.LogStatusChange(ctx, 7, "SN-7", "Damaged", "In Repair", 2, WriteOptions{})
+	require.NoError(t, err)
+	assert.Less(t, first.EventID, second.EventID)
+	assert.Equal(t, "local", second.Ledger.Backend)
+	require.NotNil(t, second.Ledger.TransactionID)
+	assert.Equal(t, int64(2), *second.Ledger.TransactionID)
+
+	history, err := svc.GetItemHistory(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, first.EventID, history[0].EventID)
+	assert.Equal(t, second.EventID, history[1].EventID)
+
+	// A write under a preassigned ID that is already recorded returns the recorded event
+	retry := WriteOptions{EventID: second.EventID}
+	again, err := svc.LogStatusChange(ctx, 7, "SN-7", "Damaged", "In Repair", 2, retry)
+	require.NoError(t, err)
+	assert.Equal(t, second, again)
+	_, err = svc.LogVerificationEvent(ctx, 7, "SN-7", 2, "Verified Present", retry)
+	assert.Error(t, err, "the ID belongs to a status change")
+
+	history, err = svc.GetItemHistory(ctx, 7)
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+}
+
+func TestLocalLedger_GeneralHistoryQuery(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := setupLocalLedger(t)
+
+	_, err := svc.LogItemCreation(ctx, domain.Property{ID: 1, SerialNumber: "SN-1", CurrentStatus: "Operational"}, 1, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogTransferEvent(ctx, domain.Transfer{ID: 9, PropertyID: 1, FromUserID: 1, ToUserID: 2, Status: "Requested"}, "SN-1", 1, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogTransferEvent(ctx, domain.Transfer{ID: 9, PropertyID: 1, FromUserID: 1, ToUserID: 2, Status: "Approved"}, "SN-1", 1, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogStatusChange(ctx, 2, "SN-2", "Operational", "Damaged", 3, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogVerificationEvent(ctx, 1, "SN-1", 2, "Verified Present", WriteOptions{})
+	require.NoError(t, err)
+
+	// Page through everything oldest first, two at a time
+	var seen []string
+	query := HistoryQuery{Limit: 2, Order: SortAsc}
+	for {
+		page, err := svc.GetGeneralHistory(ctx, query)
+		require.NoError(t, err)
+		for _, e := range page.Events {
+			seen = append(seen, e.EventType)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"EquipmentEvent", "TransferEvent", "TransferEvent", "StatusChangeEvent", "VerificationEvent"}, seen)
+
+	recipient := uint64(2)
+	page, err := svc.GetGeneralHistory(ctx, HistoryQuery{UserID: &recipient})
+	require.NoError(t, err)
+	assert.Len(t, page.Events, 3, "transfers to the user and the user's own verification")
+
+	transferID := uint64(9)
+	page, err = svc.GetGeneralHistory(ctx, HistoryQuery{TransferID: &transferID, EventTypes: []string{"TransferEvent"}})
+	require.NoError(t, err)
+	assert.Len(t, page.Events, 2)
+
+	page, err = svc.GetGeneralHistory(ctx, HistoryQuery{SerialNumber: "SN-2"})
+	require.NoError(t, err)
+	require.Len(t, page.Events, 1)
+	assert.Equal(t, "StatusChangeEvent", page.Events[0].EventType)
+
+	future := time.Now().Add(time.Hour)
+	page, err = svc.GetGeneralHistory(ctx, HistoryQuery{From: &future})
+	require.NoError(t, err)
+	assert.Empty(t, page.Events)
+
+	_, err = svc.GetGeneralHistory(ctx, HistoryQuery{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, ErrInvalidHistoryQuery)
+}
+
+func TestLocalLedger_Corrections(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := setupLocalLedger(t)
+
+	_, err := svc.LogTransferEvent(ctx, domain.Transfer{ID: 3, PropertyID: 7, FromUserID: 1, ToUserID: 2, Status: "Requested"}, "SN-7", 1, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogStatusChange(ctx, 7, "SN-7", "Operational", "Damaged", 2, WriteOptions{})
+	require.NoError(t, err)
+	history, err := svc.GetItemHistory(ctx, 7)
+	require.NoError(t, err)
+	transferID, statusID := history[0].EventID, history[1].EventID
+
+	_, err = svc.LogCorrectionEvent(ctx, "", "TransferEvent", "typo", 1, WriteOptions{})
+	assert.Error(t, err)
+	_, err = svc.LogCorrectionEvent(ctx, "does-not-exist", "TransferEvent", "typo", 1, WriteOptions{})
+	assert.ErrorIs(t, err, ErrEventNotFound)
+	_, err = svc.LogCorrectionEvent(ctx, transferID, "StatusChangeEvent", "typo", 1, WriteOptions{})
+	assert.ErrorIs(t, err, ErrInvalidCorrection, "the type must match the original event")
+	_, err = svc.LogCorrectionEvent(ctx, transferID, "TransferEvent", "wrong recipient", 1, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogCorrectionEvent(ctx, statusID, "StatusChangeEvent", "wrong status", 2, WriteOptions{})
+	require.NoError(t, err)
+
+	all, err := svc.GetAllCorrectionEvents(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, statusID, all[0].OriginalEventID, "corrections are most recent first")
+
+	byOriginal, err := svc.GetCorrectionEventsByOriginalID(ctx, transferID)
+	require.NoError(t, err)
+	require.Len(t, byOriginal, 1)
+	assert.Equal(t, "wrong recipient", byOriginal[0].Reason)
+	assert.Equal(t, uint64(1), byOriginal[0].CorrectingUserID)
+
+	event, err := svc.GetCorrectionEventByID(ctx, byOriginal[0].EventID)
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, transferID, event.OriginalEventID)
+
+	missing, err := svc.GetCorrectionEventByID(ctx, "does-not-exist")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestLocalLedger_DetectsTampering(t *testing.T) {
+	ctx := context.Background()
+	svc, path := setupLocalLedger(t)
+
+	_, err := svc.LogItemCreation(ctx, domain.Property{ID: 1, SerialNumber: "SN-1", CurrentStatus: "Operational"}, 1, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogStatusChange(ctx, 1, "SN-1", "Operational", "Lost", 1, WriteOptions{})
+	require.NoError(t, err)
+	digest, err := svc.CaptureDigest(ctx)
+	require.NoError(t, err)
+	require.NoError(t, svc.VerifyDigest(ctx, *digest))
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Replace(string(raw), `"Lost"`, `"Found"`, 1)), 0o640))
+
+	ok, err := svc.VerifyDocument(ctx, "database-wide", "all")
+	assert.Error(t, err)
+	assert.False(t, ok)
+	assert.ErrorIs(t, svc.VerifyDigest(ctx, *digest), ErrDigestMismatch, "digests are checked against the file, not memory")
+
+	require.NoError(t, svc.Close())
+	reopened, err := NewLocalLedgerService(path)
+	require.NoError(t, err)
+	assert.Error(t, reopened.Initialize(ctx), "a tampered ledger must refuse to load")
+}
@@ -0,0 +1,55 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// loadCheckpoint reads the progress saved at path. A missing file, or an empty path,
+// means the migration starts from the beginning.
+func loadCheckpoint(path string) (*Progress, error) {
+	progress := &Progress{}
+	if path == "" {
+		return progress, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return progress, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, progress); err != nil {
+		return nil, fmt.Errorf("failed to decode migration checkpoint %s: %w", path, err)
+	}
+	return progress, nil
+}
+
+// saveCheckpoint replaces the checkpoint at path, writing to a temporary file first so that
+// a crash never leaves a partial checkpoint behind.
+func saveCheckpoint(path string, progress *Progress) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(progress, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode migration checkpoint: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return fmt.Errorf("failed to write migration checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace migration checkpoint: %w", err)
+	}
+	return nil
+}
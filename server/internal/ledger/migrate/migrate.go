@@ -0,0 +1,206 @@
+// Package migrate copies ledger events from the Azure SQL Ledger to ImmuDB. Events are read
+// in commit order and imported with their original event IDs and timestamps; progress is
+// checkpointed after every batch so an interrupted migration resumes where it stopped.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"log"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+)
+
+// DefaultBatchSize is the number of events read per batch when Options.BatchSize is zero.
+const DefaultBatchSize = 500
+
+// Source is the ledger events are migrated from.
+type Source interface {
+	// EventsInCommitOrder returns up to limit events committed after the position, in commit order.
+	EventsInCommitOrder(ctx context.Context, after ledger.CommitPosition, limit int) ([]domain.LedgerEvent, error)
+}
+
+// Target is the ledger events are migrated to.
+type Target interface {
+	// ImportEvent writes an event, keeping its ID and timestamp. It reports false when an
+	// event with the same ID is already stored.
+	ImportEvent(ctx context.Context, event domain.LedgerEvent) (bool, error)
+	// GetEvent reads an event by ID, or returns ledger.ErrEventNotFound.
+	GetEvent(ctx context.Context, eventID string) (*domain.LedgerEvent, error)
+}
+
+// Options configures a migration.
+type Options struct {
+	BatchSize int // Events per batch; 0 means DefaultBatchSize
+	// Checkpoint is the path of the checkpoint file. When empty, the migration always
+	// starts from the beginning, which is safe because already imported events are skipped.
+	Checkpoint string
+}
+
+// Progress is the state saved in the checkpoint file.
+type Progress struct {
+	After    ledger.CommitPosition `json:"after"`    // Position of the last event handled
+	Read     int                   `json:"read"`     // Events read from the source
+	Imported int                   `json:"imported"` // Events written to the target
+	Skipped  int                   `json:"skipped"`  // Events the target already had
+}
+
+// Comparison is the result of checking every source event against the target.
+type Comparison struct {
+	SourceCount int      `json:"sourceCount"`
+	TargetCount int      `json:"targetCount"` // Source events found in the target
+	SourceHash  string   `json:"sourceHash"`
+	TargetHash  string   `json:"targetHash"`
+	Missing     []string `json:"missing,omitempty"`  // Event IDs not found in the target
+	Modified    []string `json:"modified,omitempty"` // Event IDs whose content differs in the target
+}
+
+// Complete reports whether the target holds every source event unchanged.
+func (c *Comparison) Complete() bool {
+	return c.SourceCount == c.TargetCount && c.SourceHash == c.TargetHash
+}
+
+// Migrator copies events from a source ledger to a target ledger.
+type Migrator struct {
+	source Source
+	target Target
+	opts   Options
+}
+
+// NewMigrator creates a migrator.
+func NewMigrator(source Source, target Target, opts Options) *Migrator {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	return &Migrator{source: source, target: target, opts: opts}
+}
+
+// Run imports every source event committed after the checkpoint, saving the checkpoint
+// after each batch, and returns the final progress.
+func (m *Migrator) Run(ctx context.Context) (*Progress, error) {
+	progress, err := loadCheckpoint(m.opts.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	if progress.Read > 0 {
+		log.Printf("Resuming ledger migration after transaction %d, sequence %d (%d events read)",
+			progress.After.TransactionID, progress.After.SequenceNumber, progress.Read)
+	}
+
+	for {
+		events, err := m.source.EventsInCommitOrder(ctx, progress.After, m.opts.BatchSize)
+		if err != nil {
+			return progress, fmt.Errorf("failed to read source events: %w", err)
+		}
+		if len(events) == 0 {
+			return progress, nil
+		}
+
+		for _, event := range events {
+			position, err := commitPosition(event)
+			if err != nil {
+				return progress, err
+			}
+			imported, err := m.target.ImportEvent(ctx, event)
+			if err != nil {
+				return progress, fmt.Errorf("failed to import event %s: %w", event.EventID, err)
+			}
+			if imported {
+				progress.Imported++
+			} else {
+				progress.Skipped++
+			}
+			progress.Read++
+			progress.After = position
+		}
+
+		if err := saveCheckpoint(m.opts.Checkpoint, progress); err != nil {
+			return progress, err
+		}
+		log.Printf("Ledger migration: %d events read, %d imported, %d already present", progress.Read, progress.Imported, progress.Skipped)
+	}
+}
+
+// Compare reads every source event in commit order, looks each one up in the target and
+// hashes both sides. The hashes cover event content but not backend metadata, so they
+// match exactly when the target holds every source event unchanged.
+func (m *Migrator) Compare(ctx context.Context) (*Comparison, error) {
+	comparison := &Comparison{}
+	sourceHash, targetHash := sha256.New(), sha256.New()
+	seen := make(map[string]bool)
+
+	var after ledger.CommitPosition
+	for {
+		events, err := m.source.EventsInCommitOrder(ctx, after, m.opts.BatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source events: %w", err)
+		}
+		if len(events) == 0 {
+			break
+		}
+
+		for _, event := range events {
+			if after, err = commitPosition(event); err != nil {
+				return nil, err
+			}
+			// A row the source returns twice was imported once
+			if seen[event.EventID] {
+				continue
+			}
+			seen[event.EventID] = true
+
+			comparison.SourceCount++
+			sourceContent, err := writeContent(sourceHash, event)
+			if err != nil {
+				return nil, err
+			}
+
+			migrated, err := m.target.GetEvent(ctx, event.EventID)
+			if errors.Is(err, ledger.ErrEventNotFound) {
+				comparison.Missing = append(comparison.Missing, event.EventID)
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read migrated event %s: %w", event.EventID, err)
+			}
+			comparison.TargetCount++
+			targetContent, err := writeContent(targetHash, *migrated)
+			if err != nil {
+				return nil, err
+			}
+			if sourceContent != targetContent {
+				comparison.Modified = append(comparison.Modified, event.EventID)
+			}
+		}
+	}
+
+	comparison.SourceHash = hex.EncodeToString(sourceHash.Sum(nil))
+	comparison.TargetHash = hex.EncodeToString(targetHash.Sum(nil))
+	return comparison, nil
+}
+
+// commitPosition returns the event's position in source commit order.
+func commitPosition(event domain.LedgerEvent) (ledger.CommitPosition, error) {
+	if event.Ledger.TransactionID == nil || event.Ledger.SequenceNumber == nil {
+		return ledger.CommitPosition{}, fmt.Errorf("source event %s has no ledger transaction", event.EventID)
+	}
+	return ledger.CommitPosition{TransactionID: *event.Ledger.TransactionID, SequenceNumber: *event.Ledger.SequenceNumber}, nil
+}
+
+// writeContent adds the event's backend-independent content to h and returns it.
+func writeContent(h hash.Hash, event domain.LedgerEvent) (string, error) {
+	event.Ledger = domain.LedgerTxMetadata{}
+	event.Timestamp = event.Timestamp.UTC()
+	content, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode event %s: %w", event.EventID, err)
+	}
+	h.Write(append(content, '\n'))
+	return string(content), nil
+}
@@ -0,0 +1,134 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+)
+
+// fakeSource serves events in the order given, which stands in for commit order.
+type fakeSource struct {
+	events []domain.LedgerEvent
+}
+
+func (s *fakeSource) EventsInCommitOrder(ctx context.Context, after ledger.CommitPosition, limit int) ([]domain.LedgerEvent, error) {
+	var page []domain.LedgerEvent
+	for _, event := range s.events {
+		tx, seq := *event.Ledger.TransactionID, *event.Ledger.SequenceNumber
+		if tx > after.TransactionID || (tx == after.TransactionID && seq > after.SequenceNumber) {
+			page = append(page, event)
+		}
+		if len(page) == limit {
+			break
+		}
+	}
+	return page, nil
+}
+
+// fakeTarget stores imported events by ID and can be told to fail after a number of imports.
+type fakeTarget struct {
+	events    map[string]domain.LedgerEvent
+	failAfter int // 0 means never fail
+	imports   int
+}
+
+func (t *fakeTarget) ImportEvent(ctx context.Context, event domain.LedgerEvent) (bool, error) {
+	if _, ok := t.events[event.EventID]; ok {
+		return false, nil
+	}
+	if t.failAfter > 0 && t.imports == t.failAfter {
+		return false, errors.New("connection reset")
+	}
+	t.imports++
+	event.Ledger = domain.LedgerTxMetadata{Backend: "immudb"}
+	t.events[event.EventID] = event
+	return true, nil
+}
+
+func (t *fakeTarget) GetEvent(ctx context.Context, eventID string) (*domain.LedgerEvent, error) {
+	event, ok := t.events[eventID]
+	if !ok {
+		return nil, ledger.ErrEventNotFound
+	}
+	return &event, nil
+}
+
+func sourceEvents(n int) []domain.LedgerEvent {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	events := make([]domain.LedgerEvent, n)
+	for i := range events {
+		// Two events per ledger transaction
+		tx, seq, item := int64(100+i/2), int64(i%2), uint64(i)
+		events[i] = domain.LedgerEvent{
+			EventID:      fmt.Sprintf("00000000-0000-0000-0000-%012d", i),
+			EventType:    domain.LedgerEventStatusChange,
+			Timestamp:    base.Add(time.Duration(i) * time.Minute),
+			ItemID:       &item,
+			Ledger:       domain.LedgerTxMetadata{Backend: "azure_sql", TransactionID: &tx, SequenceNumber: &seq},
+			StatusChange: &domain.StatusChangeDetails{PreviousStatus: "Operational", NewStatus: "Damaged"},
+		}
+	}
+	return events
+}
+
+func TestMigrator_ResumesFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	source := &fakeSource{events: sourceEvents(7)}
+	target := &fakeTarget{events: map[string]domain.LedgerEvent{}, failAfter: 5}
+	opts := Options{BatchSize: 3, Checkpoint: filepath.Join(t.TempDir(), "checkpoint.json")}
+
+	// The first run fails part way through the second batch
+	_, err := NewMigrator(source, target, opts).Run(ctx)
+	require.Error(t, err)
+	saved, err := loadCheckpoint(opts.Checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, 3, saved.Read, "only completed batches are checkpointed")
+	assert.Equal(t, ledger.CommitPosition{TransactionID: 101, SequenceNumber: 0}, saved.After)
+
+	// The rerun resumes after the checkpoint and skips the events written before the failure
+	target.failAfter = 0
+	progress, err := NewMigrator(source, target, opts).Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 7, progress.Read)
+	assert.Equal(t, 5, progress.Imported)
+	assert.Equal(t, 2, progress.Skipped)
+	assert.Len(t, target.events, 7)
+
+	comparison, err := NewMigrator(source, target, opts).Compare(ctx)
+	require.NoError(t, err)
+	assert.True(t, comparison.Complete())
+	assert.Equal(t, 7, comparison.SourceCount)
+	assert.Equal(t, comparison.SourceHash, comparison.TargetHash)
+}
+
+func TestMigrator_CompareReportsMissingAndModified(t *testing.T) {
+	ctx := context.Background()
+	source := &fakeSource{events: sourceEvents(4)}
+	target := &fakeTarget{events: map[string]domain.LedgerEvent{}}
+	migrator := NewMigrator(source, target, Options{})
+	_, err := migrator.Run(ctx)
+	require.NoError(t, err)
+
+	dropped, changed := source.events[1].EventID, source.events[3].EventID
+	delete(target.events, dropped)
+	event := target.events[changed]
+	event.StatusChange = &domain.StatusChangeDetails{PreviousStatus: "Operational", NewStatus: "Operational"}
+	target.events[changed] = event
+
+	comparison, err := migrator.Compare(ctx)
+	require.NoError(t, err)
+	assert.False(t, comparison.Complete())
+	assert.Equal(t, 4, comparison.SourceCount)
+	assert.Equal(t, 3, comparison.TargetCount)
+	assert.Equal(t, []string{dropped}, comparison.Missing)
+	assert.Equal(t, []string{changed}, comparison.Modified)
+	assert.NotEqual(t, comparison.SourceHash, comparison.TargetHash)
+}
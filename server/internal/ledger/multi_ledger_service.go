@@ -0,0 +1,291 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/receipt"
+)
+
+// WritePolicy decides when a write to a MultiLedgerService succeeds.
+type WritePolicy string
+
+const (
+	// WriteAll requires every backend to accept the write. A write that fails on any
+	// backend returns an error, even if others accepted it; retrying it with the same event
+	// ID (see WriteOptions) does not record it twice on those.
+	WriteAll WritePolicy = "all"
+	// WritePrimary requires the primary to accept the write; secondaries may fail.
+	WritePrimary WritePolicy = "primary"
+	// WriteAny requires at least one backend to accept the write.
+	WriteAny WritePolicy = "any"
+)
+
+// ParseWritePolicy converts a configured policy name, defaulting to WritePrimary when empty.
+func ParseWritePolicy(name string) (WritePolicy, error) {
+	switch policy := WritePolicy(name); policy {
+	case "":
+		return WritePrimary, nil
+	case WriteAll, WritePrimary, WriteAny:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown ledger write policy %q: must be %q, %q or %q", name, WriteAll, WritePrimary, WriteAny)
+	}
+}
+
+// Backend is one named ledger of a MultiLedgerService.
+type Backend struct {
+	Name    string
+	Service LedgerService
+}
+
+// DivergenceRecorder stores ledger divergences for later repair. repository.Repository implements it.
+type DivergenceRecorder interface {
+	CreateLedgerDivergence(divergence *domain.LedgerDivergence) error
+}
+
+// Ensure MultiLedgerService implements LedgerService interface at compile time
+var _ LedgerService = (*MultiLedgerService)(nil)
+
+// MultiLedgerService writes every event to several ledger backends, for running two ledgers
+// side by side during a migration or accreditation period. Reads, receipts and digests are
+// served by the primary. A write that some backends accept and another rejects is recorded
+// as a domain.LedgerDivergence.
+type MultiLedgerService struct {
+	backends []Backend // Primary first
+	policy   WritePolicy
+	recorder DivergenceRecorder
+}
+
+// NewMultiLedgerService creates a ledger that writes to primary and then to each secondary
+// in order. recorder may be nil, in which case divergences are only logged.
+func NewMultiLedgerService(primary Backend, secondaries []Backend, policy WritePolicy, recorder DivergenceRecorder) (*MultiLedgerService, error) {
+	if _, err := ParseWritePolicy(string(policy)); err != nil {
+		return nil, err
+	}
+
+	backends := append([]Backend{primary}, secondaries...)
+	names := make(map[string]bool, len(backends))
+	for _, b := range backends {
+		if b.Name == "" || b.Service == nil {
+			return nil, fmt.Errorf("ledger backend needs a name and a service")
+		}
+		if names[b.Name] {
+			return nil, fmt.Errorf("duplicate ledger backend %q", b.Name)
+		}
+		names[b.Name] = true
+	}
+	return &MultiLedgerService{backends: backends, policy: policy, recorder: recorder}, nil
+}
+
+// primary returns the backend reads are served from.
+func (s *MultiLedgerService) primary() LedgerService {
+	return s.backends[0].Service
+}
+
+// write calls a write method on every backend according to the write policy. args are the
+// method's arguments, stored with any divergence so the write can be replayed. Every backend
+// records the event under the same event ID and timestamp, those in opts if they are set, and the result is that of the first backend to
+// accept it: the primary, unless it failed under WriteAny.
+func (s *MultiLedgerService) write(ctx context.Context, operation string, args map[string]interface{}, opts WriteOptions, call func(context.Context, LedgerService, WriteOptions) (*domain.LedgerWriteResult, error)) (*domain.LedgerWriteResult, error) {
+	type failure struct {
+		backend string
+		err     error
+	}
+	var failures []failure
+	var accepted []string
+	var result *domain.LedgerWriteResult
+
+	eventID, _ := opts.eventID()
+	opts.EventID = eventID
+	opts.OccurredAt = opts.timestamp()
+	args["occurredAt"] = opts.OccurredAt
+	if opts.Signature != nil {
+		args["signature"] = opts.Signature
+	}
+	for i, b := range s.backends {
+		written, err := call(ctx, b.Service, opts)
+		if err != nil {
+			// Nothing has been written yet, so stopping here keeps the backends in step
+			if i == 0 && s.policy != WriteAny {
+				return nil, err
+			}
+			failures = append(failures, failure{backend: b.Name, err: err})
+			continue
+		}
+		accepted = append(accepted, b.Name)
+		if result == nil {
+			result = written
+		}
+	}
+
+	if len(failures) == 0 {
+		return result, nil
+	}
+	errs := make([]error, len(failures))
+	for i, f := range failures {
+		errs[i] = fmt.Errorf("%s: %w", f.backend, f.err)
+	}
+	if len(accepted) == 0 {
+		return nil, fmt.Errorf("%s failed on every ledger backend: %w", operation, errors.Join(errs...))
+	}
+
+	for _, f := range failures {
+		s.recordDivergence(operation, args, eventID, f.backend, f.err)
+	}
+	if s.policy == WriteAll {
+		return nil, fmt.Errorf("%s was written to %v but failed on: %w", operation, accepted, errors.Join(errs...))
+	}
+	return result, nil
+}
+
+// recordDivergence stores a write that backend missed. Failing to record it is logged
+// rather than returned, since the write itself was accepted.
+func (s *MultiLedgerService) recordDivergence(operation string, args map[string]interface{}, eventID string, backend string, cause error) {
+	log.Printf("Ledger divergence: %s failed on backend %s: %v", operation, backend, cause)
+	if s.recorder == nil {
+		return
+	}
+
+	raw, err := json.Marshal(args)
+	if err != nil {
+		log.Printf("Failed to encode arguments of divergent %s: %v", operation, err)
+		return
+	}
+	divergence := &domain.LedgerDivergence{
+		Backend:   backend,
+		Operation: operation,
+		EventID:   eventID,
+		Arguments: string(raw),
+		Error:     cause.Error(),
+		Status:    domain.DivergenceStatusOpen,
+	}
+	if err := s.recorder.CreateLedgerDivergence(divergence); err != nil {
+		log.Printf("Failed to record ledger divergence for %s on backend %s: %v", operation, backend, err)
+	}
+}
+
+// The arguments of the first three write methods use the same JSON field names as the
+// matching outbox payloads, so a divergence can be replayed the same way.
+
+func (s *MultiLedgerService) LogItemCreation(ctx context.Context, property domain.Property, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	args := map[string]interface{}{"property": property, "userId": userID}
+	return s.write(ctx, "LogItemCreation", args, opts, func(ctx context.Context, svc LedgerService, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+		return svc.LogItemCreation(ctx, property, userID, opts)
+	})
+}
+
+func (s *MultiLedgerService) LogTransferEvent(ctx context.Context, transfer domain.Transfer, serialNumber string, actorUserID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	args := map[string]interface{}{"transfer": transfer, "serialNumber": serialNumber, "actorUserId": actorUserID}
+	return s.write(ctx, "LogTransferEvent", args, opts, func(ctx context.Context, svc LedgerService, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+		return svc.LogTransferEvent(ctx, transfer, serialNumber, actorUserID, opts)
+	})
+}
+
+func (s *MultiLedgerService) LogStatusChange(ctx context.Context, itemID uint, serialNumber string, oldStatus string, newStatus string, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	args := map[string]interface{}{"itemId": itemID, "serialNumber": serialNumber, "oldStatus": oldStatus, "newStatus": newStatus, "userId": userID}
+	return s.write(ctx, "LogStatusChange", args, opts, func(ctx context.Context, svc LedgerService, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+		return svc.LogStatusChange(ctx, itemID, serialNumber, oldStatus, newStatus, userID, opts)
+	})
+}
+
+func (s *MultiLedgerService) LogVerificationEvent(ctx context.Context, itemID uint, serialNumber string, userID uint, verificationType string, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	args := map[string]interface{}{"itemId": itemID, "serialNumber": serialNumber, "userId": userID, "verificationType": verificationType}
+	return s.write(ctx, "LogVerificationEvent", args, opts, func(ctx context.Context, svc LedgerService, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+		return svc.LogVerificationEvent(ctx, itemID, serialNumber, userID, verificationType, opts)
+	})
+}
+
+func (s *MultiLedgerService) LogMaintenanceEvent(ctx context.Context, maintenanceRecordID string, itemID uint, initiatingUserID uint, performingUserID sql.NullInt64, eventType string, maintenanceType sql.NullString, description string, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	args := map[string]interface{}{
+		"maintenanceRecordId": maintenanceRecordID,
+		"itemId":              itemID,
+		"initiatingUserId":    initiatingUserID,
+		"performingUserId":    performingUserID,
+		"eventType":           eventType,
+		"maintenanceType":     maintenanceType,
+		"description":         description,
+	}
+	return s.write(ctx, "LogMaintenanceEvent", args, opts, func(ctx context.Context, svc LedgerService, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+		return svc.LogMaintenanceEvent(ctx, maintenanceRecordID, itemID, initiatingUserID, performingUserID, eventType, maintenanceType, description, opts)
+	})
+}
+
+func (s *MultiLedgerService) LogCorrectionEvent(ctx context.Context, originalEventID string, eventType string, reason string, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	args := map[string]interface{}{"originalEventId": originalEventID, "eventType": eventType, "reason": reason, "userId": userID}
+	return s.write(ctx, "LogCorrectionEvent", args, opts, func(ctx context.Context, svc LedgerService, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+		return svc.LogCorrectionEvent(ctx, originalEventID, eventType, reason, userID, opts)
+	})
+}
+
+func (s *MultiLedgerService) GetEvent(ctx context.Context, eventID string) (*domain.LedgerEvent, error) {
+	return s.primary().GetEvent(ctx, eventID)
+}
+
+func (s *MultiLedgerService) GetItemHistory(ctx context.Context, itemID uint) ([]domain.LedgerEvent, error) {
+	return s.primary().GetItemHistory(ctx, itemID)
+}
+
+func (s *MultiLedgerService) VerifyDocument(ctx context.Context, documentID string, tableName string) (bool, error) {
+	return s.primary().VerifyDocument(ctx, documentID, tableName)
+}
+
+func (s *MultiLedgerService) GetAllCorrectionEvents(ctx context.Context) ([]domain.CorrectionEvent, error) {
+	return s.primary().GetAllCorrectionEvents(ctx)
+}
+
+func (s *MultiLedgerService) GetCorrectionEventsByOriginalID(ctx context.Context, originalEventID string) ([]domain.CorrectionEvent, error) {
+	return s.primary().GetCorrectionEventsByOriginalID(ctx, originalEventID)
+}
+
+func (s *MultiLedgerService) GetCorrectionEventByID(ctx context.Context, eventID string) (*domain.CorrectionEvent, error) {
+	return s.primary().GetCorrectionEventByID(ctx, eventID)
+}
+
+func (s *MultiLedgerService) GetGeneralHistory(ctx context.Context, query HistoryQuery) (*HistoryPage, error) {
+	return s.primary().GetGeneralHistory(ctx, query)
+}
+
+func (s *MultiLedgerService) GetEventReceipt(ctx context.Context, eventID string) (*receipt.Receipt, error) {
+	return s.primary().GetEventReceipt(ctx, eventID)
+}
+
+// CaptureDigest captures a digest of the primary only; the secondaries are anchored, if at
+// all, by their own deployments.
+func (s *MultiLedgerService) CaptureDigest(ctx context.Context) (*Digest, error) {
+	return s.primary().CaptureDigest(ctx)
+}
+
+func (s *MultiLedgerService) VerifyDigest(ctx context.Context, digest Digest) error {
+	return s.primary().VerifyDigest(ctx, digest)
+}
+
+// Initialize initializes every backend. The primary must succeed; a secondary that fails
+// is only an error under WriteAll, since writes to it are otherwise recorded as divergences.
+func (s *MultiLedgerService) Initialize(ctx context.Context) error {
+	for i, b := range s.backends {
+		if err := b.Service.Initialize(ctx); err != nil {
+			if i == 0 || s.policy == WriteAll {
+				return fmt.Errorf("failed to initialize ledger backend %s: %w", b.Name, err)
+			}
+			log.Printf("Ledger backend %s failed to initialize, continuing under write policy %q: %v", b.Name, s.policy, err)
+		}
+	}
+	return nil
+}
+
+// Close closes every backend and returns their errors combined.
+func (s *MultiLedgerService) Close() error {
+	var errs []error
+	for _, b := range s.backends {
+		if err := b.Service.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
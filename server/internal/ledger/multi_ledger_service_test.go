@@ -0,0 +1,132 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+)
+
+// failingLedger rejects every status change; it implements no other calls.
+type failingLedger struct {
+	LedgerService
+}
+
+func (failingLedger) LogStatusChange(ctx context.Context, itemID uint, serialNumber string, oldStatus string, newStatus string, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	return nil, errors.New("ledger unavailable")
+}
+
+// divergenceLog records divergences in memory.
+type divergenceLog struct {
+	divergences []domain.LedgerDivergence
+}
+
+func (l *divergenceLog) CreateLedgerDivergence(divergence *domain.LedgerDivergence) error {
+	l.divergences = append(l.divergences, *divergence)
+	return nil
+}
+
+func TestMultiLedger_WritesToEveryBackend(t *testing.T) {
+	primary, _ := setupLocalLedger(t)
+	secondary, _ := setupLocalLedger(t)
+	recorder := &divergenceLog{}
+	svc, err := NewMultiLedgerService(Backend{Name: "local", Service: primary}, []Backend{{Name: "mirror", Service: secondary}}, WriteAll, recorder)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	result, err := svc.LogStatusChange(ctx, 7, "SN-7", "Operational", "Damaged", 1, WriteOptions{})
+	require.NoError(t, err)
+
+	for _, backend := range []LedgerService{primary, secondary} {
+		history, err := backend.GetItemHistory(ctx, 7)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, result.EventID, history[0].EventID, "every backend records the event under the same ID")
+	}
+	assert.Empty(t, recorder.divergences)
+}
+
+func TestMultiLedger_WritePolicies(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("primary tolerates a failing secondary and records the divergence", func(t *testing.T) {
+		primary, _ := setupLocalLedger(t)
+		recorder := &divergenceLog{}
+		svc, err := NewMultiLedgerService(Backend{Name: "local", Service: primary}, []Backend{{Name: "immudb", Service: failingLedger{}}}, WritePrimary, recorder)
+		require.NoError(t, err)
+
+		result, err := svc.LogStatusChange(ctx, 7, "SN-7", "Operational", "Damaged", 1, WriteOptions{})
+		require.NoError(t, err)
+
+		history, err := svc.GetItemHistory(ctx, 7)
+		require.NoError(t, err)
+		assert.Len(t, history, 1, "reads come from the primary")
+
+		require.Len(t, recorder.divergences, 1)
+		divergence := recorder.divergences[0]
+		assert.Equal(t, "immudb", divergence.Backend)
+		assert.Equal(t, "LogStatusChange", divergence.Operation)
+		assert.Equal(t, result.EventID, divergence.EventID)
+		assert.Equal(t, domain.DivergenceStatusOpen, divergence.Status)
+		assert.Contains(t, divergence.Error, "ledger unavailable")
+
+		var args map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(divergence.Arguments), &args))
+		assert.Equal(t, "Damaged", args["newStatus"])
+		assert.Equal(t, "SN-7", args["serialNumber"])
+	})
+
+	t.Run("all fails when a secondary fails", func(t *testing.T) {
+		primary, _ := setupLocalLedger(t)
+		recorder := &divergenceLog{}
+		svc, err := NewMultiLedgerService(Backend{Name: "local", Service: primary}, []Backend{{Name: "immudb", Service: failingLedger{}}}, WriteAll, recorder)
+		require.NoError(t, err)
+
+		_, err = svc.LogStatusChange(ctx, 7, "SN-7", "Operational", "Damaged", 1, WriteOptions{})
+		assert.Error(t, err)
+		assert.Len(t, recorder.divergences, 1)
+	})
+
+	t.Run("a failing primary stops the write", func(t *testing.T) {
+		secondary, _ := setupLocalLedger(t)
+		recorder := &divergenceLog{}
+		svc, err := NewMultiLedgerService(Backend{Name: "immudb", Service: failingLedger{}}, []Backend{{Name: "local", Service: secondary}}, WritePrimary, recorder)
+		require.NoError(t, err)
+
+		_, err = svc.LogStatusChange(ctx, 7, "SN-7", "Operational", "Damaged", 1, WriteOptions{})
+		assert.Error(t, err)
+		history, err := secondary.GetItemHistory(ctx, 7)
+		require.NoError(t, err)
+		assert.Empty(t, history)
+		assert.Empty(t, recorder.divergences)
+	})
+
+	t.Run("any accepts a write only the secondary took", func(t *testing.T) {
+		secondary, _ := setupLocalLedger(t)
+		recorder := &divergenceLog{}
+		svc, err := NewMultiLedgerService(Backend{Name: "immudb", Service: failingLedger{}}, []Backend{{Name: "local", Service: secondary}}, WriteAny, recorder)
+		require.NoError(t, err)
+
+		_, err = svc.LogStatusChange(ctx, 7, "SN-7", "Operational", "Damaged", 1, WriteOptions{})
+		require.NoError(t, err)
+		require.Len(t, recorder.divergences, 1)
+		assert.Equal(t, "immudb", recorder.divergences[0].Backend)
+	})
+}
+
+func TestNewMultiLedgerService_RejectsInvalidConfiguration(t *testing.T) {
+	primary, _ := setupLocalLedger(t)
+
+	_, err := NewMultiLedgerService(Backend{Name: "local", Service: primary}, nil, WritePolicy("most"), nil)
+	assert.Error(t, err)
+	_, err = NewMultiLedgerService(Backend{Name: "local", Service: primary}, []Backend{{Name: "local", Service: primary}}, WriteAll, nil)
+	assert.Error(t, err)
+
+	policy, err := ParseWritePolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, WritePrimary, policy)
+}
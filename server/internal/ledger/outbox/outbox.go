@@ -0,0 +1,183 @@
+// Package outbox records ledger events alongside database state changes and relays them
+// to the LedgerService, so ledger writes survive ledger outages and process restarts.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+)
+
+// Outbox event types, one per LedgerService write method.
+const (
+	EventItemCreation = "ItemCreation"
+	EventTransfer     = "TransferEvent"
+	EventStatusChange = "StatusChange"
+)
+
+// Every payload records when the action took place, so the ledger timestamps the event
+// with that time rather than the time it was delivered. Entries recorded before payloads
+// carried it fall back to the entry's CreatedAt. A payload also carries the acting user's
+// signature over the event, if they signed it, for the ledger to store with the event.
+
+// ItemCreationPayload holds the arguments of LedgerService.LogItemCreation.
+type ItemCreationPayload struct {
+	Property   domain.Property        `json:"property"`
+	UserID     uint                   `json:"userId"`
+	OccurredAt time.Time              `json:"occurredAt"`
+	Signature  *domain.EventSignature `json:"signature,omitempty"`
+}
+
+// TransferPayload holds the arguments of LedgerService.LogTransferEvent.
+type TransferPayload struct {
+	Transfer     domain.Transfer `json:"transfer"`
+	SerialNumber string          `json:"serialNumber"`
+	// ActorUserID is the user who performed this step of the transfer. Entries recorded
+	// before it was kept have none, and fall back to the transfer's sender.
+	ActorUserID uint                   `json:"actorUserId"`
+	OccurredAt  time.Time              `json:"occurredAt"`
+	Signature   *domain.EventSignature `json:"signature,omitempty"`
+}
+
+// StatusChangePayload holds the arguments of LedgerService.LogStatusChange.
+type StatusChangePayload struct {
+	ItemID       uint                   `json:"itemId"`
+	SerialNumber string                 `json:"serialNumber"`
+	OldStatus    string                 `json:"oldStatus"`
+	NewStatus    string                 `json:"newStatus"`
+	UserID       uint                   `json:"userId"`
+	OccurredAt   time.Time              `json:"occurredAt"`
+	Signature    *domain.EventSignature `json:"signature,omitempty"`
+}
+
+// The constructors take the options the event is to be written with: the event ID and
+// signature of an event the user signed, and when they acted. Without an event ID the
+// entry is given a new one; without a time, the current time.
+
+// NewItemCreationEntry builds the outbox entry for an item creation.
+func NewItemCreationEntry(property domain.Property, userID uint, opts ledger.WriteOptions) (*domain.LedgerOutboxEntry, error) {
+	now := when(opts)
+	return newEntry(EventItemCreation, property.ID, opts, ItemCreationPayload{
+		Property:   property,
+		UserID:     userID,
+		OccurredAt: now,
+		Signature:  opts.Signature,
+	})
+}
+
+// NewTransferEntry builds the outbox entry for a transfer creation or status update
+// performed by actorUserID.
+func NewTransferEntry(transfer domain.Transfer, serialNumber string, actorUserID uint, opts ledger.WriteOptions) (*domain.LedgerOutboxEntry, error) {
+	now := when(opts)
+	return newEntry(EventTransfer, transfer.PropertyID, opts, TransferPayload{
+		Transfer:     transfer,
+		SerialNumber: serialNumber,
+		ActorUserID:  actorUserID,
+		OccurredAt:   now,
+		Signature:    opts.Signature,
+	})
+}
+
+// NewStatusChangeEntry builds the outbox entry for an item status change.
+func NewStatusChangeEntry(itemID uint, serialNumber, oldStatus, newStatus string, userID uint, opts ledger.WriteOptions) (*domain.LedgerOutboxEntry, error) {
+	now := when(opts)
+	return newEntry(EventStatusChange, itemID, opts, StatusChangePayload{
+		ItemID:       itemID,
+		SerialNumber: serialNumber,
+		OldStatus:    oldStatus,
+		NewStatus:    newStatus,
+		UserID:       userID,
+		OccurredAt:   now,
+		Signature:    opts.Signature,
+	})
+}
+
+// when returns the time an entry's action took place: opts.OccurredAt, or now.
+func when(opts ledger.WriteOptions) time.Time {
+	if opts.OccurredAt.IsZero() {
+		return time.Now().UTC()
+	}
+	return opts.OccurredAt.UTC()
+}
+
+// newEntry marshals payload into a pending outbox entry for itemID that is due immediately.
+// The entry is given the ID its ledger event will be recorded under, so callers can report
+// it before the event is delivered.
+func newEntry(eventType string, itemID uint, opts ledger.WriteOptions, payload interface{}) (*domain.LedgerOutboxEntry, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s outbox payload: %w", eventType, err)
+	}
+	eventID := opts.EventID
+	if eventID == "" {
+		eventID = ledger.NewEventID()
+	}
+	return &domain.LedgerOutboxEntry{
+		EventType:     eventType,
+		EventID:       eventID,
+		ItemID:        &itemID,
+		Payload:       string(raw),
+		Status:        domain.OutboxStatusPending,
+		NextAttemptAt: time.Now().UTC(),
+	}, nil
+}
+
+// Dispatch writes an outbox entry to the ledger by calling the LedgerService method it records.
+// The event is recorded under the entry's event ID, so delivering an entry again does not
+// record it twice, timestamped with when it occurred and with the signature it was made with. Entries recorded before event IDs
+// were assigned get a new ID each time.
+func Dispatch(ctx context.Context, svc ledger.LedgerService, entry *domain.LedgerOutboxEntry) error {
+	opts := ledger.WriteOptions{EventID: entry.EventID}
+
+	var err error
+	switch entry.EventType {
+	case EventItemCreation:
+		var p ItemCreationPayload
+		if err := decode(entry, &p); err != nil {
+			return err
+		}
+		_, err = svc.LogItemCreation(ctx, p.Property, p.UserID, written(opts, entry, p.OccurredAt, p.Signature))
+	case EventTransfer:
+		var p TransferPayload
+		if err := decode(entry, &p); err != nil {
+			return err
+		}
+		actorUserID := p.ActorUserID
+		if actorUserID == 0 {
+			actorUserID = p.Transfer.FromUserID
+		}
+		_, err = svc.LogTransferEvent(ctx, p.Transfer, p.SerialNumber, actorUserID, written(opts, entry, p.OccurredAt, p.Signature))
+	case EventStatusChange:
+		var p StatusChangePayload
+		if err := decode(entry, &p); err != nil {
+			return err
+		}
+		_, err = svc.LogStatusChange(ctx, p.ItemID, p.SerialNumber, p.OldStatus, p.NewStatus, p.UserID, written(opts, entry, p.OccurredAt, p.Signature))
+	default:
+		return fmt.Errorf("unknown outbox event type %q", entry.EventType)
+	}
+	return err
+}
+
+// written sets the write's timestamp to when the entry's action took place, and its
+// signature to the one the payload carries.
+func written(opts ledger.WriteOptions, entry *domain.LedgerOutboxEntry, at time.Time, signature *domain.EventSignature) ledger.WriteOptions {
+	opts.Signature = signature
+	opts.OccurredAt = at
+	if at.IsZero() {
+		opts.OccurredAt = entry.CreatedAt
+	}
+	return opts
+}
+
+// decode unmarshals an entry's payload.
+func decode(entry *domain.LedgerOutboxEntry, payload interface{}) error {
+	if err := json.Unmarshal([]byte(entry.Payload), payload); err != nil {
+		return fmt.Errorf("failed to decode %s outbox entry %d: %w", entry.EventType, entry.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupLedger(t *testing.T) *ledger.LocalLedgerService {
+	svc, err := ledger.NewLocalLedgerService(filepath.Join(t.TempDir(), "ledger.jsonl"))
+	require.NoError(t, err)
+	require.NoError(t, svc.Initialize(context.Background()))
+	t.Cleanup(func() { svc.Close() })
+	return svc
+}
+
+func TestDispatch_ReplaysEntriesIntoLedger(t *testing.T) {
+	ctx := context.Background()
+	svc := setupLedger(t)
+
+	created, err := NewItemCreationEntry(domain.Property{ID: 4, SerialNumber: "SN-4", CurrentStatus: "Operational"}, 1, ledger.WriteOptions{})
+	require.NoError(t, err)
+	// The recipient accepts the transfer, signing the event on their device
+	actor := uint64(2)
+	signature := &domain.EventSignature{Algorithm: "Ed25519", Signer: domain.SignerUser, UserID: &actor, KeyID: "key-2", PublicKey: "cHVibGlj", Value: "c2lnbmF0dXJl", Payload: "cGF5bG9hZA=="}
+	signedID := ledger.NewEventID()
+	transferred, err := NewTransferEntry(domain.Transfer{ID: 2, PropertyID: 4, FromUserID: 1, ToUserID: 2, Status: "Approved"}, "SN-4", 2,
+		ledger.WriteOptions{EventID: signedID, Signature: signature})
+	require.NoError(t, err)
+	changed, err := NewStatusChangeEntry(4, "SN-4", "Operational", "Damaged", 2, ledger.WriteOptions{})
+	require.NoError(t, err)
+
+	for _, entry := range []*domain.LedgerOutboxEntry{created, transferred, changed} {
+		assert.Equal(t, domain.OutboxStatusPending, entry.Status)
+		require.NoError(t, Dispatch(ctx, svc, entry))
+	}
+
+	// A redelivered entry is recognised by its event ID and not recorded twice
+	require.NoError(t, Dispatch(ctx, svc, changed))
+
+	history, err := svc.GetItemHistory(ctx, 4)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, domain.LedgerEventTransfer, history[1].EventType)
+	assert.Equal(t, signedID, history[1].EventID, "events are recorded under the ID the user signed")
+	assert.Equal(t, actor, *history[1].ActorUserID, "transfer events name the user who acted")
+	assert.Equal(t, signature, history[1].Signature, "the user's signature is stored with the event")
+
+	// A transfer entry recorded before the actor was kept falls back to the sender
+	legacy := &domain.LedgerOutboxEntry{
+		EventType: EventTransfer,
+		EventID:   ledger.NewEventID(),
+		Payload:   `{"transfer":{"id":2,"propertyId":4,"fromUserId":1,"toUserId":2,"status":"Completed"},"serialNumber":"SN-4"}`,
+	}
+	require.NoError(t, Dispatch(ctx, svc, legacy))
+	completed, err := svc.GetEvent(ctx, legacy.EventID)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), *completed.ActorUserID)
+
+	assert.Error(t, Dispatch(ctx, svc, &domain.LedgerOutboxEntry{EventType: "Unknown", Payload: "{}"}))
+	assert.Error(t, Dispatch(ctx, svc, &domain.LedgerOutboxEntry{EventType: EventTransfer, Payload: "not json"}))
+}
+
+func TestRelay_BackoffIsExponentialAndCapped(t *testing.T) {
+	r := NewRelay(nil, nil, RelayConfig{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second}, nil)
+
+	assert.Equal(t, time.Second, r.cfg.backoff(1))
+	assert.Equal(t, 2*time.Second, r.cfg.backoff(2))
+	assert.Equal(t, 8*time.Second, r.cfg.backoff(4))
+	assert.Equal(t, 10*time.Second, r.cfg.backoff(5))
+	assert.Equal(t, 10*time.Second, r.cfg.backoff(50))
+	assert.Equal(t, DefaultRelayConfig().MaxAttempts, r.cfg.MaxAttempts)
+}
+
+func TestRelayConfig_MaxDeliveryDelay(t *testing.T) {
+	cfg := RelayConfig{MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: time.Minute, Lease: time.Minute}
+	// Three polls and leases, and backoffs of 1s and 2s between the attempts
+	assert.Equal(t, 3*(10*time.Second+time.Minute)+3*time.Second, cfg.MaxDeliveryDelay(10*time.Second))
+
+	assert.LessOrEqual(t, DefaultRelayConfig().MaxDeliveryDelay(10*time.Second), ledger.DefaultStreamLateLookback,
+		"ledger streams catch late deliveries under the default settings")
+}
+
+func TestDispatch_TimestampsEventsWhenTheyOccurred(t *testing.T) {
+	ctx := context.Background()
+	svc := setupLedger(t)
+
+	changed, err := NewStatusChangeEntry(4, "SN-4", "Operational", "Damaged", 2, ledger.WriteOptions{})
+	require.NoError(t, err)
+	var payload StatusChangePayload
+	require.NoError(t, json.Unmarshal([]byte(changed.Payload), &payload))
+	assert.Equal(t, uint(4), *changed.ItemID)
+
+	// An entry recorded before payloads carried the time falls back to when it was created
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	legacy := &domain.LedgerOutboxEntry{
+		EventType: EventStatusChange,
+		EventID:   ledger.NewEventID(),
+		Payload:   `{"itemId":4,"serialNumber":"SN-4","oldStatus":"Damaged","newStatus":"In Repair","userId":2}`,
+		CreatedAt: created,
+	}
+
+	time.Sleep(time.Millisecond) // Deliver strictly after the actions took place
+	require.NoError(t, Dispatch(ctx, svc, changed))
+	require.NoError(t, Dispatch(ctx, svc, legacy))
+
+	history, err := svc.GetItemHistory(ctx, 4)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.True(t, payload.OccurredAt.Equal(history[0].Timestamp), "timestamped when the entry was built, not delivered")
+	assert.True(t, created.Equal(history[1].Timestamp))
+}
+
+func TestRelay_LeasesEntriesAndDeliversOutsideTheClaim(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB, WithoutReturning: true}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	quiet := logrus.New()
+	quiet.SetOutput(io.Discard)
+	svc := setupLedger(t)
+	r := NewRelay(db, svc, RelayConfig{BatchSize: 10}, quiet)
+
+	entry, err := NewStatusChangeEntry(4, "SN-4", "Operational", "Damaged", 2, ledger.WriteOptions{})
+	require.NoError(t, err)
+
+	// Only the oldest undelivered entry of each item is claimed, and the claim commits
+	// before delivery
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "ledger_outbox" WHERE (status = $1 AND next_attempt_at <= $2) AND (locked_until IS NULL OR locked_until <= $3) AND (NOT EXISTS (SELECT 1 FROM ledger_outbox AS earlier`)+
+		`\s+`+regexp.QuoteMeta(`WHERE earlier.item_id = ledger_outbox.item_id AND earlier.id < ledger_outbox.id AND earlier.status <> $4)) ORDER BY id LIMIT $5 FOR UPDATE SKIP LOCKED`)).
+		WithArgs(domain.OutboxStatusPending, sqlmock.AnyArg(), sqlmock.AnyArg(), domain.OutboxStatusDelivered, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "event_id", "item_id", "payload", "status", "attempts", "next_attempt_at"}).
+			AddRow(7, entry.EventType, entry.EventID, 4, entry.Payload, domain.OutboxStatusPending, 0, entry.NextAttemptAt))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "ledger_outbox" SET "locked_until"=$1,"updated_at"=$2 WHERE id IN ($3)`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// The outcome is recorded only while the lease is still held
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "ledger_outbox" SET "attempts"=$1,"delivered_at"=$2,"last_error"=$3,"locked_until"=$4,"next_attempt_at"=$5,"status"=$6,"updated_at"=$7 WHERE id = $8 AND locked_until = $9`)).
+		WithArgs(1, sqlmock.AnyArg(), nil, nil, sqlmock.AnyArg(), domain.OutboxStatusDelivered, sqlmock.AnyArg(), 7, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result, err := r.ProcessBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, RelayResult{Delivered: 1}, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	history, err := svc.GetItemHistory(context.Background(), 4)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, entry.EventID, history[0].EventID)
+}
@@ -0,0 +1,266 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RelayConfig controls how the relay drains the outbox.
+type RelayConfig struct {
+	BatchSize   int           // Entries claimed per ProcessBatch call
+	MaxAttempts int           // Attempts before an entry is dead-lettered
+	BaseBackoff time.Duration // Delay after the first failure, doubled after each further failure
+	MaxBackoff  time.Duration // Upper bound on the delay between attempts
+	Lease       time.Duration // How long claimed entries are reserved; must exceed the time to deliver a batch
+}
+
+// DefaultRelayConfig returns the relay settings used when none are configured.
+func DefaultRelayConfig() RelayConfig {
+	return RelayConfig{
+		BatchSize:   100,
+		MaxAttempts: 10,
+		BaseBackoff: 5 * time.Second,
+		MaxBackoff:  15 * time.Minute,
+		Lease:       5 * time.Minute,
+	}
+}
+
+// RelayResult reports what one ProcessBatch call did.
+type RelayResult struct {
+	Delivered    int
+	Retried      int
+	DeadLettered int
+}
+
+// Relay delivers pending outbox entries to a LedgerService.
+//
+// Entries are claimed with SELECT ... FOR UPDATE SKIP LOCKED and leased by setting their
+// locked_until, in a transaction that commits before any entry is delivered, so several
+// workers can run concurrently without delivering the same entry twice and no row lock is
+// held across ledger calls. Delivery is at-least-once: if the ledger write succeeds but
+// recording that fails, or the lease runs out first, the entry is delivered again, and the
+// ledger returns the event already recorded under the entry's event ID.
+type Relay struct {
+	db     *gorm.DB
+	ledger ledger.LedgerService
+	cfg    RelayConfig
+	logger *logrus.Logger
+}
+
+// NewRelay creates a relay. Zero fields in cfg take their DefaultRelayConfig values.
+func NewRelay(db *gorm.DB, ledgerService ledger.LedgerService, cfg RelayConfig, logger *logrus.Logger) *Relay {
+	return &Relay{db: db, ledger: ledgerService, cfg: cfg.withDefaults(), logger: logger}
+}
+
+// withDefaults returns cfg with its zero fields set to their DefaultRelayConfig values.
+func (cfg RelayConfig) withDefaults() RelayConfig {
+	defaults := DefaultRelayConfig()
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaults.MaxAttempts
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = defaults.BaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaults.MaxBackoff
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = defaults.Lease
+	}
+	return cfg
+}
+
+// MaxDeliveryDelay bounds how long after it is recorded a relay polling every pollInterval
+// delivers an entry that is not dead-lettered: every attempt can wait a poll to be claimed
+// and a lease to be delivered, with the backoff between attempts. It assumes the relay keeps
+// up with the outbox, and does not cover an entry held back behind a dead-lettered one.
+// Followers of the ledger stream re-read this far back to catch late deliveries.
+func (cfg RelayConfig) MaxDeliveryDelay(pollInterval time.Duration) time.Duration {
+	cfg = cfg.withDefaults()
+	delay := time.Duration(cfg.MaxAttempts) * (pollInterval + cfg.Lease)
+	for attempts := 1; attempts < cfg.MaxAttempts; attempts++ {
+		delay += cfg.backoff(attempts)
+	}
+	return delay
+}
+
+// ProcessBatch claims up to BatchSize due entries, oldest first, and delivers them.
+// Entries for one item are delivered in the order they were recorded: an entry is not
+// claimed while an earlier entry for its item is undelivered, so an entry awaiting retry
+// or dead-lettered holds back the item's later events instead of being overtaken by them.
+// Failed entries are rescheduled with exponential backoff, or dead-lettered once they
+// reach MaxAttempts. A failed entry does not stop the rest of the batch.
+func (r *Relay) ProcessBatch(ctx context.Context) (RelayResult, error) {
+	var result RelayResult
+
+	entries, lease, err := r.claim(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	for i := range entries {
+		if !time.Now().Before(lease) {
+			break // The rest may already be claimed by another relay
+		}
+		entry := &entries[i]
+		r.deliver(ctx, entry, &result)
+		if err := r.record(ctx, entry, lease); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// claim leases up to BatchSize due entries, each the oldest undelivered entry for its item,
+// and returns them with the end of their lease.
+func (r *Relay) claim(ctx context.Context) ([]domain.LedgerOutboxEntry, time.Time, error) {
+	now := time.Now().UTC()
+	// Postgres keeps microseconds, and record matches the lease exactly
+	lease := now.Add(r.cfg.Lease).Truncate(time.Microsecond)
+
+	var entries []domain.LedgerOutboxEntry
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", domain.OutboxStatusPending, now).
+			Where("locked_until IS NULL OR locked_until <= ?", now).
+			Where(`NOT EXISTS (SELECT 1 FROM ledger_outbox AS earlier
+				WHERE earlier.item_id = ledger_outbox.item_id AND earlier.id < ledger_outbox.id AND earlier.status <> ?)`,
+				domain.OutboxStatusDelivered).
+			Order("id").
+			Limit(r.cfg.BatchSize).
+			Find(&entries).Error; err != nil {
+			return fmt.Errorf("failed to claim outbox entries: %w", err)
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(entries))
+		for i := range entries {
+			ids[i] = entries[i].ID
+			entries[i].LockedUntil = &lease
+		}
+		if err := tx.Model(&domain.LedgerOutboxEntry{}).Where("id IN ?", ids).Update("locked_until", lease).Error; err != nil {
+			return fmt.Errorf("failed to lease outbox entries: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return entries, lease, nil
+}
+
+// record saves the outcome of delivering entry and releases its lease. An entry whose lease
+// ran out and was claimed again is left to the relay now holding it.
+func (r *Relay) record(ctx context.Context, entry *domain.LedgerOutboxEntry, lease time.Time) error {
+	res := r.db.WithContext(ctx).Model(&domain.LedgerOutboxEntry{}).
+		Where("id = ? AND locked_until = ?", entry.ID, lease).
+		Updates(map[string]interface{}{
+			"status":          entry.Status,
+			"attempts":        entry.Attempts,
+			"last_error":      entry.LastError,
+			"next_attempt_at": entry.NextAttemptAt,
+			"delivered_at":    entry.DeliveredAt,
+			"locked_until":    nil,
+		})
+	if res.Error != nil {
+		return fmt.Errorf("failed to record outbox entry %d: %w", entry.ID, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		r.logger.WithFields(logrus.Fields{"outbox_id": entry.ID, "event_type": entry.EventType}).
+			Warn("Ledger outbox lease expired before delivery was recorded")
+	}
+	entry.LockedUntil = nil
+	return nil
+}
+
+// Drain processes batches until no due entry is left to deliver, a batch delivers nothing,
+// or ctx expires. Errors are logged rather than returned, so it can run as a scheduled job.
+func Drain(ctx context.Context, r *Relay, logger *logrus.Logger) {
+	for ctx.Err() == nil {
+		result, err := r.ProcessBatch(ctx)
+		if err != nil {
+			logger.WithError(err).Error("Ledger outbox relay failed")
+			return
+		}
+
+		if result.Delivered+result.Retried+result.DeadLettered > 0 {
+			logger.WithFields(logrus.Fields{
+				"delivered":     result.Delivered,
+				"retried":       result.Retried,
+				"dead_lettered": result.DeadLettered,
+			}).Info("Ledger outbox batch processed")
+		}
+		if result.Delivered == 0 {
+			return
+		}
+	}
+}
+
+// Run drains the outbox every interval until ctx is cancelled.
+func Run(ctx context.Context, r *Relay, interval time.Duration, logger *logrus.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		Drain(ctx, r, logger)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliver attempts one entry and updates its status, attempt count and schedule.
+func (r *Relay) deliver(ctx context.Context, entry *domain.LedgerOutboxEntry, result *RelayResult) {
+	entry.Attempts++
+	now := time.Now().UTC()
+
+	err := Dispatch(ctx, r.ledger, entry)
+	if err == nil {
+		entry.Status = domain.OutboxStatusDelivered
+		entry.DeliveredAt = &now
+		entry.LastError = nil
+		result.Delivered++
+		return
+	}
+
+	msg := err.Error()
+	entry.LastError = &msg
+	fields := logrus.Fields{"outbox_id": entry.ID, "event_type": entry.EventType, "attempts": entry.Attempts}
+
+	if entry.Attempts >= r.cfg.MaxAttempts {
+		entry.Status = domain.OutboxStatusDead
+		result.DeadLettered++
+		r.logger.WithError(err).WithFields(fields).Error("Ledger outbox entry dead-lettered")
+		return
+	}
+
+	entry.NextAttemptAt = now.Add(r.cfg.backoff(entry.Attempts))
+	result.Retried++
+	r.logger.WithError(err).WithFields(fields).Warn("Ledger outbox delivery failed, will retry")
+}
+
+// backoff returns the delay before the attempt following the given number of failures.
+func (cfg RelayConfig) backoff(attempts int) time.Duration {
+	delay := cfg.BaseBackoff
+	for i := 1; i < attempts && delay < cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > cfg.MaxBackoff {
+		delay = cfg.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,66 @@
+package receipt
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AzureSQLProof locates an event in the Azure SQL Database ledger and carries a database
+// digest generated after it was committed.
+type AzureSQLProof struct {
+	TransactionID  int64           `json:"transactionId"`  // ledger_transaction_id of the event row
+	SequenceNumber int64           `json:"sequenceNumber"` // ledger_sequence_number of the event row
+	BlockID        int64           `json:"blockId"`        // Ledger block holding the transaction
+	CommitTime     time.Time       `json:"commitTime"`
+	Digest         json.RawMessage `json:"digest"` // Output of sys.sp_generate_database_ledger_digest
+}
+
+// azureDigest is the part of an Azure SQL ledger digest the verifier reads.
+type azureDigest struct {
+	DatabaseName string `json:"database_name"`
+	BlockID      int64  `json:"block_id"`
+	Hash         string `json:"hash"`
+}
+
+// verifyAzureSQL checks that the event, transaction and digest in the proof agree. Azure SQL
+// does not publish inclusion proofs, so inclusion itself is confirmed by the database
+// verifying the digest; the result says so.
+func verifyAzureSQL(p *AzureSQLProof, eventID string, event []byte, result *Result) error {
+	var recorded struct {
+		EventID string `json:"eventId"`
+		Ledger  struct {
+			TransactionID  *int64 `json:"transactionId"`
+			SequenceNumber *int64 `json:"sequenceNumber"`
+		} `json:"ledger"`
+		// Receipts issued before events were typed carry the ledger IDs at the top level
+		LedgerTransactionID  *int64 `json:"ledgerTransactionId"`
+		LedgerSequenceNumber *int64 `json:"ledgerSequenceNumber"`
+	}
+	if err := json.Unmarshal(event, &recorded); err != nil {
+		return fmt.Errorf("%w: malformed event: %v", ErrInvalidReceipt, err)
+	}
+	if recorded.EventID != eventID {
+		return fmt.Errorf("%w: event ID %q does not match the receipt", ErrInvalidProof, recorded.EventID)
+	}
+	txID, seq := recorded.Ledger.TransactionID, recorded.Ledger.SequenceNumber
+	if txID == nil && seq == nil {
+		txID, seq = recorded.LedgerTransactionID, recorded.LedgerSequenceNumber
+	}
+	if txID == nil || *txID != p.TransactionID || seq == nil || *seq != p.SequenceNumber {
+		return fmt.Errorf("%w: event is not the row at transaction %d, sequence %d", ErrInvalidProof, p.TransactionID, p.SequenceNumber)
+	}
+
+	var digest azureDigest
+	if err := json.Unmarshal(p.Digest, &digest); err != nil || digest.Hash == "" {
+		return fmt.Errorf("%w: malformed ledger digest", ErrInvalidReceipt)
+	}
+	if digest.BlockID < p.BlockID {
+		return fmt.Errorf("%w: digest block %d predates the event's block %d", ErrInvalidProof, digest.BlockID, p.BlockID)
+	}
+
+	result.Notes = append(result.Notes, fmt.Sprintf(
+		"Azure SQL ledger digest for %s covers block %d; confirm inclusion by running sys.sp_verify_database_ledger against this digest",
+		digest.DatabaseName, digest.BlockID))
+	return nil
+}
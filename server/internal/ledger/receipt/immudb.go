@@ -0,0 +1,114 @@
+package receipt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// ImmuDBProof proves an immudb entry is included in a transaction, and that the transaction
+// is part of the history committed to by a database state.
+type ImmuDBProof struct {
+	Database string          `json:"database"`
+	Key      string          `json:"key"`   // Key the event is stored under
+	TxID     uint64          `json:"txId"`  // Transaction that wrote the event
+	Entry    json.RawMessage `json:"entry"` // schema.VerifiableEntry: the entry, its inclusion proof and a dual proof to State
+	State    json.RawMessage `json:"state"` // schema.ImmutableState the proof leads to, signed by immudb when server signing is enabled
+}
+
+// NewImmuDBProof packages a verifiable entry and the state it was proven against. The dual
+// proof must already carry its linear advance proof, so it verifies without the server.
+func NewImmuDBProof(key string, entry *schema.VerifiableEntry, state *schema.ImmutableState) (*ImmuDBProof, error) {
+	entryJSON, err := protojson.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode immudb entry proof: %w", err)
+	}
+	stateJSON, err := protojson.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode immudb state: %w", err)
+	}
+	return &ImmuDBProof{
+		Database: state.GetDb(),
+		Key:      key,
+		TxID:     entry.GetEntry().GetTx(),
+		Entry:    entryJSON,
+		State:    stateJSON,
+	}, nil
+}
+
+// VerifyImmuDBProof checks that event is the value stored in the proof's entry, that the
+// entry is included in its transaction, and that the transaction leads to the proof's state.
+// When serverKey is set, the state's signature must also verify against it.
+func VerifyImmuDBProof(p *ImmuDBProof, event []byte, serverKey *ecdsa.PublicKey) error {
+	return verifyImmuDB(p, event, serverKey, &Result{})
+}
+
+func verifyImmuDB(p *ImmuDBProof, event []byte, serverKey *ecdsa.PublicKey, result *Result) error {
+	var entry schema.VerifiableEntry
+	if err := protojson.Unmarshal(p.Entry, &entry); err != nil {
+		return fmt.Errorf("%w: malformed immudb entry: %v", ErrInvalidReceipt, err)
+	}
+	var state schema.ImmutableState
+	if err := protojson.Unmarshal(p.State, &state); err != nil {
+		return fmt.Errorf("%w: malformed immudb state: %v", ErrInvalidReceipt, err)
+	}
+
+	kv := entry.GetEntry()
+	vtx := entry.GetVerifiableTx()
+	if kv == nil || vtx == nil || vtx.GetDualProof() == nil || vtx.GetTx().GetHeader() == nil || entry.GetInclusionProof() == nil {
+		return fmt.Errorf("%w: incomplete immudb proof", ErrInvalidReceipt)
+	}
+	if kv.GetReferencedBy() != nil {
+		return fmt.Errorf("%w: entry is a reference", ErrInvalidReceipt)
+	}
+	if !bytes.Equal(kv.GetKey(), []byte(p.Key)) || kv.GetTx() != p.TxID {
+		return fmt.Errorf("%w: entry does not match the receipt's key and transaction", ErrInvalidProof)
+	}
+	if !bytes.Equal(canonical(kv.GetValue()), canonical(event)) {
+		return fmt.Errorf("%w: event payload differs from the ledger entry", ErrInvalidProof)
+	}
+	if state.GetTxId() < p.TxID {
+		return fmt.Errorf("%w: state %d predates transaction %d", ErrInvalidProof, state.GetTxId(), p.TxID)
+	}
+
+	dualProof := schema.DualProofFromProto(vtx.GetDualProof())
+	if dualProof.SourceTxHeader.ID != p.TxID || dualProof.TargetTxHeader.ID != state.GetTxId() {
+		return fmt.Errorf("%w: dual proof does not span transaction %d to state %d", ErrInvalidProof, p.TxID, state.GetTxId())
+	}
+
+	// The entry's digest must be a leaf of its transaction's entries tree...
+	entrySpecDigest, err := store.EntrySpecDigestFor(int(vtx.GetTx().GetHeader().GetVersion()))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReceipt, err)
+	}
+	spec := database.EncodeEntrySpec(kv.GetKey(), schema.KVMetadataFromProto(kv.GetMetadata()), kv.GetValue())
+	if !store.VerifyInclusion(schema.InclusionProofFromProto(entry.GetInclusionProof()), entrySpecDigest(spec), dualProof.SourceTxHeader.Eh) {
+		return fmt.Errorf("%w: entry is not included in transaction %d", ErrInvalidProof, p.TxID)
+	}
+
+	// ...and the transaction must be in the history that the state's hash commits to.
+	if !store.VerifyDualProof(dualProof, p.TxID, state.GetTxId(), dualProof.SourceTxHeader.Alh(), schema.DigestFromProto(state.GetTxHash())) {
+		return fmt.Errorf("%w: transaction %d is not consistent with state %d", ErrInvalidProof, p.TxID, state.GetTxId())
+	}
+	result.LedgerVerified = true
+
+	switch {
+	case state.GetSignature() == nil:
+		result.Notes = append(result.Notes, "immudb state is unsigned (server signing is not enabled)")
+	case serverKey != nil:
+		ok, err := state.CheckSignature(serverKey)
+		if err != nil || !ok {
+			return fmt.Errorf("%w: immudb state signature does not verify", ErrInvalidProof)
+		}
+		result.Notes = append(result.Notes, "immudb state signature verified against the trusted immudb server key")
+	default:
+		result.Notes = append(result.Notes, "immudb state signature not checked; supply the immudb server's public key to check it")
+	}
+	return nil
+}
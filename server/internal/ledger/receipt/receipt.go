@@ -0,0 +1,187 @@
+// Package receipt defines portable, signed proofs that a single event is recorded in the
+// ledger, and verifies them offline. It depends only on the proof formats of the ledger
+// backends, so the standalone verifier can use it without a server or database.
+package receipt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Version is the receipt format version written by this package.
+const Version = 1
+
+// Ledger backends a receipt can come from.
+const (
+	BackendImmuDB   = "immudb"
+	BackendAzureSQL = "azure_sql"
+	BackendLocal    = "local"
+)
+
+// SignatureAlgorithm is the algorithm of the server signature on a receipt.
+const SignatureAlgorithm = "Ed25519"
+
+var (
+	ErrInvalidReceipt   = errors.New("invalid receipt")
+	ErrInvalidSignature = errors.New("receipt signature does not verify")
+	ErrInvalidProof     = errors.New("receipt proof does not verify")
+)
+
+// Receipt is a self-contained proof that one ledger event was recorded. Exactly one of
+// ImmuDB, AzureSQL and Local is set, matching Backend.
+type Receipt struct {
+	Version   int             `json:"version"`
+	Backend   string          `json:"backend"`
+	EventID   string          `json:"eventId"`
+	EventType string          `json:"eventType"`
+	Event     json.RawMessage `json:"event"` // Event payload as stored in the ledger
+	IssuedAt  time.Time       `json:"issuedAt"`
+
+	ImmuDB   *ImmuDBProof   `json:"immudb,omitempty"`
+	AzureSQL *AzureSQLProof `json:"azureSql,omitempty"`
+	Local    *LocalProof    `json:"local,omitempty"`
+
+	Signature *Signature `json:"signature,omitempty"` // Server signature over every other field
+}
+
+// Signature is the issuing server's signature over a receipt.
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"keyId"`
+	PublicKey string `json:"publicKey"` // Base64 Ed25519 public key
+	Value     string `json:"value"`     // Base64 signature
+}
+
+// Signer signs receipts on behalf of the issuing server.
+type Signer interface {
+	KeyID() string
+	PublicKey() ed25519.PublicKey
+	Sign(payload []byte) []byte
+}
+
+// signingBytes returns the bytes a receipt signature covers: the receipt's JSON encoding
+// without its signature. RawMessage fields are compacted by encoding/json, so the bytes do
+// not depend on how the receipt file was formatted.
+func (r *Receipt) signingBytes() ([]byte, error) {
+	unsigned := *r
+	unsigned.Signature = nil
+	return json.Marshal(&unsigned)
+}
+
+// Sign signs the receipt with signer, replacing any existing signature.
+func (r *Receipt) Sign(signer Signer) error {
+	r.Signature = nil
+	payload, err := r.signingBytes()
+	if err != nil {
+		return fmt.Errorf("failed to encode receipt for signing: %w", err)
+	}
+	r.Signature = &Signature{
+		Algorithm: SignatureAlgorithm,
+		KeyID:     signer.KeyID(),
+		PublicKey: base64.StdEncoding.EncodeToString(signer.PublicKey()),
+		Value:     base64.StdEncoding.EncodeToString(signer.Sign(payload)),
+	}
+	return nil
+}
+
+// VerifyOptions supplies the keys a receipt is checked against. Without them, signatures
+// are checked against the keys embedded in the receipt, which proves integrity but not origin.
+type VerifyOptions struct {
+	ServerKey       ed25519.PublicKey // Trusted key of the issuing HandReceipt server
+	ImmuDBServerKey *ecdsa.PublicKey  // Trusted public key of the immudb server's state signing key
+}
+
+// Result describes what a successful verification established.
+type Result struct {
+	Backend        string   `json:"backend"`
+	EventID        string   `json:"eventId"`
+	ServerKeyID    string   `json:"serverKeyId"`
+	ServerTrusted  bool     `json:"serverTrusted"`  // Signature checked against a trusted server key
+	LedgerVerified bool     `json:"ledgerVerified"` // Inclusion in the ledger proven offline
+	Notes          []string `json:"notes,omitempty"`
+}
+
+// Verify checks the receipt's server signature and its backend proof.
+func Verify(r *Receipt, opts VerifyOptions) (*Result, error) {
+	if r.Version != Version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidReceipt, r.Version)
+	}
+	if r.EventID == "" || len(r.Event) == 0 {
+		return nil, fmt.Errorf("%w: missing event", ErrInvalidReceipt)
+	}
+
+	result := &Result{Backend: r.Backend, EventID: r.EventID}
+	if err := verifySignature(r, opts.ServerKey, result); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch r.Backend {
+	case BackendImmuDB:
+		if r.ImmuDB == nil {
+			return nil, fmt.Errorf("%w: missing immudb proof", ErrInvalidReceipt)
+		}
+		err = verifyImmuDB(r.ImmuDB, r.Event, opts.ImmuDBServerKey, result)
+	case BackendAzureSQL:
+		if r.AzureSQL == nil {
+			return nil, fmt.Errorf("%w: missing Azure SQL proof", ErrInvalidReceipt)
+		}
+		err = verifyAzureSQL(r.AzureSQL, r.EventID, r.Event, result)
+	case BackendLocal:
+		if r.Local == nil {
+			return nil, fmt.Errorf("%w: missing local ledger proof", ErrInvalidReceipt)
+		}
+		err = verifyLocal(r.Local, r.Event, result)
+	default:
+		return nil, fmt.Errorf("%w: unknown backend %q", ErrInvalidReceipt, r.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// verifySignature checks the server signature, against trusted when it is given.
+func verifySignature(r *Receipt, trusted ed25519.PublicKey, result *Result) error {
+	sig := r.Signature
+	if sig == nil {
+		return fmt.Errorf("%w: receipt is not signed", ErrInvalidSignature)
+	}
+	if sig.Algorithm != SignatureAlgorithm {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, sig.Algorithm)
+	}
+
+	embedded, err := base64.StdEncoding.DecodeString(sig.PublicKey)
+	if err != nil || len(embedded) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: malformed public key", ErrInvalidSignature)
+	}
+	value, err := base64.StdEncoding.DecodeString(sig.Value)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+
+	key := ed25519.PublicKey(embedded)
+	if trusted != nil {
+		if !key.Equal(trusted) {
+			return fmt.Errorf("%w: receipt was signed by key %s, not the trusted server key", ErrInvalidSignature, sig.KeyID)
+		}
+		result.ServerTrusted = true
+	} else {
+		result.Notes = append(result.Notes, "server signature checked against the key embedded in the receipt; supply the server's public key to confirm who issued it")
+	}
+
+	payload, err := r.signingBytes()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReceipt, err)
+	}
+	if !ed25519.Verify(key, payload, value) {
+		return ErrInvalidSignature
+	}
+	result.ServerKeyID = sig.KeyID
+	return nil
+}
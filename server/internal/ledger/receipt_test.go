@@ -0,0 +1,84 @@
+package ledger
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/receipt"
+	"github.com/toole-brendan/handreceipt-go/internal/services/signing"
+)
+
+func newTestSigner(t *testing.T) *signing.Signer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return signing.NewSigner(key)
+}
+
+// firstEventID returns the ID of the oldest event in the ledger.
+func firstEventID(t *testing.T, svc LedgerService) string {
+	page, err := svc.GetGeneralHistory(context.Background(), HistoryQuery{Order: SortAsc, Limit: 1})
+	require.NoError(t, err)
+	require.NotEmpty(t, page.Events)
+	return page.Events[0].EventID
+}
+
+func TestLocalLedger_EventReceipt(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := setupLocalLedger(t)
+	signer := newTestSigner(t)
+
+	_, err := svc.LogItemCreation(ctx, domain.Property{ID: 7, SerialNumber: "SN-7", Name: "M4 Carbine", CurrentStatus: "Operational"}, 1, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogStatusChange(ctx, 7, "SN-7", "Operational", "Damaged", 2, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogVerificationEvent(ctx, 7, "SN-7", 2, "Verified Present", WriteOptions{})
+	require.NoError(t, err)
+
+	eventID := firstEventID(t, svc)
+	rcpt, err := svc.GetEventReceipt(ctx, eventID)
+	require.NoError(t, err)
+	require.NoError(t, rcpt.Sign(signer))
+
+	// Round-trip through indented JSON, as a downloaded and re-saved receipt would be
+	data, err := json.MarshalIndent(rcpt, "", "  ")
+	require.NoError(t, err)
+	var downloaded receipt.Receipt
+	require.NoError(t, json.Unmarshal(data, &downloaded))
+
+	result, err := receipt.Verify(&downloaded, receipt.VerifyOptions{ServerKey: signer.PublicKey()})
+	require.NoError(t, err)
+	assert.Equal(t, eventID, result.EventID)
+	assert.Equal(t, receipt.BackendLocal, result.Backend)
+	assert.True(t, result.ServerTrusted)
+	assert.True(t, result.LedgerVerified)
+	assert.Len(t, downloaded.Local.SuccessorHashes, 2)
+	assert.NotContains(t, string(data), "Damaged", "later records are not disclosed")
+
+	t.Run("untrusted server key", func(t *testing.T) {
+		_, err := receipt.Verify(&downloaded, receipt.VerifyOptions{ServerKey: newTestSigner(t).PublicKey()})
+		assert.ErrorIs(t, err, receipt.ErrInvalidSignature)
+	})
+
+	t.Run("tampered event", func(t *testing.T) {
+		tampered := downloaded
+		tampered.Event = json.RawMessage(`{"tampered":true}`)
+		_, err := receipt.Verify(&tampered, receipt.VerifyOptions{ServerKey: signer.PublicKey()})
+		assert.ErrorIs(t, err, receipt.ErrInvalidSignature)
+
+		// Re-signing with another key cannot hide the change from the chain check
+		require.NoError(t, tampered.Sign(newTestSigner(t)))
+		_, err = receipt.Verify(&tampered, receipt.VerifyOptions{})
+		assert.ErrorIs(t, err, receipt.ErrInvalidProof)
+	})
+
+	t.Run("unknown event", func(t *testing.T) {
+		_, err := svc.GetEventReceipt(ctx, "missing")
+		assert.ErrorIs(t, err, ErrEventNotFound)
+	})
+}
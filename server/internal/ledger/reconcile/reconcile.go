@@ -0,0 +1,234 @@
+// Package reconcile replays the ledger and compares the state it describes with the
+// properties table, producing a drift report of every disagreement.
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+	"github.com/toole-brendan/handreceipt-go/internal/repository"
+)
+
+// Reconciler compares the ledger against Postgres and stores the resulting reports.
+type Reconciler struct {
+	repo   repository.Repository
+	ledger ledger.LedgerService
+	logger *logrus.Logger
+}
+
+// NewReconciler creates a reconciler.
+func NewReconciler(repo repository.Repository, ledgerService ledger.LedgerService, logger *logrus.Logger) *Reconciler {
+	return &Reconciler{repo: repo, ledger: ledgerService, logger: logger}
+}
+
+// Run snapshots the properties table, replays every ledger event recorded up to the
+// snapshot, and stores the drift report.
+//
+// Ledger events are written after the database commit (via the outbox), so changes that
+// were still undelivered at the snapshot show up as drift; the report records the pending
+// outbox count so such findings can be told apart from real divergence.
+func (r *Reconciler) Run(ctx context.Context) (*domain.ReconciliationReport, error) {
+	snapshot := time.Now().UTC()
+
+	outboxStats, err := r.repo.GetLedgerOutboxStats()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ledger outbox stats: %w", err)
+	}
+
+	properties, err := r.repo.ListProperties(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list properties: %w", err)
+	}
+
+	events, err := r.readLedger(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	findings := Compare(properties, events)
+	report := &domain.ReconciliationReport{
+		StartedAt:      snapshot,
+		CompletedAt:    time.Now().UTC(),
+		ItemsChecked:   len(properties),
+		EventsReplayed: len(events),
+		PendingOutbox:  outboxStats.Pending,
+		DriftCount:     len(findings),
+		Findings:       findings,
+	}
+	if err := r.repo.CreateReconciliationReport(report); err != nil {
+		return nil, fmt.Errorf("failed to store reconciliation report: %w", err)
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"report_id":       report.ID,
+		"items_checked":   report.ItemsChecked,
+		"events_replayed": report.EventsReplayed,
+		"drift_count":     report.DriftCount,
+		"pending_outbox":  report.PendingOutbox,
+	}).Info("Ledger reconciliation completed")
+	return report, nil
+}
+
+// readLedger pages through every ledger event up to and including until, oldest first.
+func (r *Reconciler) readLedger(ctx context.Context, until time.Time) ([]domain.LedgerEvent, error) {
+	var events []domain.LedgerEvent
+	query := ledger.HistoryQuery{To: &until, Limit: ledger.MaxHistoryLimit, Order: ledger.SortAsc}
+	for {
+		page, err := r.ledger.GetGeneralHistory(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ledger history: %w", err)
+		}
+		events = append(events, page.Events...)
+		if page.NextCursor == "" {
+			return events, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+// itemState is an item's state as derived from its ledger history. Fields stay unknown
+// until an event establishes them, since not every backend records every field.
+type itemState struct {
+	created     bool
+	status      *string
+	holderKnown bool
+	holder      *uint64
+}
+
+// Compare replays events, which must be ordered oldest first, and reports every property
+// whose ledger-derived status or holder differs from its row, every property without a
+// creation event, and every event for an item that has no properties row.
+func Compare(properties []domain.Property, events []domain.LedgerEvent) []domain.ReconciliationFinding {
+	rows := make(map[uint64]domain.Property, len(properties))
+	for _, property := range properties {
+		rows[uint64(property.ID)] = property
+	}
+
+	var findings []domain.ReconciliationFinding
+	states := make(map[uint64]*itemState)
+	for _, event := range events {
+		if event.ItemID == nil {
+			continue
+		}
+		itemID := *event.ItemID
+		if _, ok := rows[itemID]; !ok {
+			findings = append(findings, domain.ReconciliationFinding{
+				Kind:    domain.DriftOrphanEvent,
+				ItemID:  itemID,
+				EventID: event.EventID,
+				Message: fmt.Sprintf("%s event references item %d, which has no properties row", event.EventType, itemID),
+			})
+			continue
+		}
+
+		state, ok := states[itemID]
+		if !ok {
+			state = &itemState{}
+			states[itemID] = state
+		}
+		apply(state, event)
+	}
+
+	ids := make([]uint64, 0, len(rows))
+	for id := range rows {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var drift []domain.ReconciliationFinding
+	for _, id := range ids {
+		drift = append(drift, compareItem(rows[id], states[id])...)
+	}
+	return append(drift, findings...)
+}
+
+// apply advances state by one ledger event.
+func apply(state *itemState, event domain.LedgerEvent) {
+	switch {
+	case event.ItemCreation != nil:
+		if event.ItemCreation.Action == "Created" {
+			state.created = true
+			if event.ItemCreation.StateRecorded {
+				if status := event.ItemCreation.Status; status != "" {
+					state.status = &status
+				}
+				state.holderKnown = true
+				state.holder = event.ItemCreation.AssignedToUserID
+			}
+		}
+	case event.Transfer != nil:
+		if event.Transfer.Status == "Completed" {
+			to := event.Transfer.ToUserID
+			state.holderKnown = true
+			state.holder = &to
+		}
+	case event.StatusChange != nil:
+		if status := event.StatusChange.NewStatus; status != "" {
+			state.status = &status
+		}
+	}
+}
+
+// compareItem reports how a property row differs from its ledger-derived state.
+func compareItem(property domain.Property, state *itemState) []domain.ReconciliationFinding {
+	itemID := uint64(property.ID)
+	if state == nil || !state.created {
+		return []domain.ReconciliationFinding{{
+			Kind:         domain.DriftMissingEvent,
+			ItemID:       itemID,
+			SerialNumber: property.SerialNumber,
+			Message:      "no creation event recorded in the ledger",
+		}}
+	}
+
+	var findings []domain.ReconciliationFinding
+	if state.status != nil && *state.status != property.CurrentStatus {
+		actual := property.CurrentStatus
+		findings = append(findings, domain.ReconciliationFinding{
+			Kind:         domain.DriftStatusMismatch,
+			ItemID:       itemID,
+			SerialNumber: property.SerialNumber,
+			Expected:     state.status,
+			Actual:       &actual,
+			Message:      fmt.Sprintf("ledger says status %q, database has %q", *state.status, actual),
+		})
+	}
+
+	var assigned *uint64
+	if property.AssignedToUserID != nil {
+		id := uint64(*property.AssignedToUserID)
+		assigned = &id
+	}
+	if state.holderKnown && !sameID(state.holder, assigned) {
+		expected, actual := formatID(state.holder), formatID(assigned)
+		findings = append(findings, domain.ReconciliationFinding{
+			Kind:         domain.DriftHolderMismatch,
+			ItemID:       itemID,
+			SerialNumber: property.SerialNumber,
+			Expected:     &expected,
+			Actual:       &actual,
+			Message:      fmt.Sprintf("ledger says holder %s, database has %s", expected, actual),
+		})
+	}
+	return findings
+}
+
+func sameID(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func formatID(id *uint64) string {
+	if id == nil {
+		return "unassigned"
+	}
+	return strconv.FormatUint(*id, 10)
+}
@@ -0,0 +1,70 @@
+package reconcile
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger"
+)
+
+func uintPtr(v uint) *uint { return &v }
+
+func TestCompare_ReportsDrift(t *testing.T) {
+	ctx := context.Background()
+	svc, err := ledger.NewLocalLedgerService(filepath.Join(t.TempDir(), "ledger.jsonl"))
+	require.NoError(t, err)
+	require.NoError(t, svc.Initialize(ctx))
+	t.Cleanup(func() { svc.Close() })
+
+	// Item 1: created for user 1, transferred to user 2, then damaged
+	_, err = svc.LogItemCreation(ctx, domain.Property{ID: 1, SerialNumber: "SN-1", CurrentStatus: "Operational", AssignedToUserID: uintPtr(1)}, 1, ledger.WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogTransferEvent(ctx, domain.Transfer{ID: 5, PropertyID: 1, FromUserID: 1, ToUserID: 2, Status: "Requested"}, "SN-1", 1, ledger.WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogTransferEvent(ctx, domain.Transfer{ID: 5, PropertyID: 1, FromUserID: 1, ToUserID: 2, Status: "Completed"}, "SN-1", 1, ledger.WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogStatusChange(ctx, 1, "SN-1", "Operational", "Damaged", 2, ledger.WriteOptions{})
+	require.NoError(t, err)
+	// Item 2: created unassigned, in step with the database
+	_, err = svc.LogItemCreation(ctx, domain.Property{ID: 2, SerialNumber: "SN-2", CurrentStatus: "Operational"}, 1, ledger.WriteOptions{})
+	require.NoError(t, err)
+	// Item 9: has no properties row
+	_, err = svc.LogStatusChange(ctx, 9, "SN-9", "Operational", "Lost", 1, ledger.WriteOptions{})
+	require.NoError(t, err)
+
+	page, err := svc.GetGeneralHistory(ctx, ledger.HistoryQuery{Order: ledger.SortAsc, Limit: ledger.MaxHistoryLimit})
+	require.NoError(t, err)
+
+	properties := []domain.Property{
+		{ID: 3, SerialNumber: "SN-3", CurrentStatus: "Operational"},                               // never logged
+		{ID: 1, SerialNumber: "SN-1", CurrentStatus: "Operational", AssignedToUserID: uintPtr(1)}, // stale status and holder
+		{ID: 2, SerialNumber: "SN-2", CurrentStatus: "Operational"},
+	}
+	findings := Compare(properties, page.Events)
+	require.Len(t, findings, 4)
+
+	assert.Equal(t, domain.DriftStatusMismatch, findings[0].Kind)
+	assert.Equal(t, uint64(1), findings[0].ItemID)
+	assert.Equal(t, "Damaged", *findings[0].Expected)
+	assert.Equal(t, "Operational", *findings[0].Actual)
+
+	assert.Equal(t, domain.DriftHolderMismatch, findings[1].Kind)
+	assert.Equal(t, "2", *findings[1].Expected)
+	assert.Equal(t, "1", *findings[1].Actual)
+
+	assert.Equal(t, domain.DriftMissingEvent, findings[2].Kind)
+	assert.Equal(t, uint64(3), findings[2].ItemID)
+
+	assert.Equal(t, domain.DriftOrphanEvent, findings[3].Kind)
+	assert.Equal(t, uint64(9), findings[3].ItemID)
+	assert.NotEmpty(t, findings[3].EventID)
+
+	// Once the database catches up, only the missing and orphan findings remain
+	properties[1].CurrentStatus = "Damaged"
+	properties[1].AssignedToUserID = uintPtr(2)
+	assert.Len(t, Compare(properties, page.Events), 2)
+}
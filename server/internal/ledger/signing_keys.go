@@ -0,0 +1,99 @@
+package ledger
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/services/signing"
+)
+
+// ErrSigningKeyTaken is returned (wrapped) by EnrollKey when the key is enrolled by
+// someone else.
+var ErrSigningKeyTaken = errors.New("signing key is enrolled by another user")
+
+// EnrollKey records publicKey as a signing key of userID, or of the server when userID is
+// nil, valid from validFrom. Enrolling a key the same owner already enrolled returns the
+// existing key.
+func EnrollKey(keys KeyDirectory, userID *uint, publicKey ed25519.PublicKey, validFrom time.Time) (*domain.SigningKey, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: an Ed25519 public key is %d bytes, not %d", signing.ErrInvalidKey, ed25519.PublicKeySize, len(publicKey))
+	}
+	keyID := signing.KeyID(publicKey)
+	existing, err := keys.GetSigningKeyByKeyID(keyID)
+	if err != nil {
+		return nil, err
+	}
+	var owner *uint64
+	if userID != nil {
+		id := uint64(*userID)
+		owner = &id
+	}
+	if existing != nil {
+		if !existing.OwnedBy(owner) {
+			return nil, fmt.Errorf("%w: %s", ErrSigningKeyTaken, keyID)
+		}
+		return existing, nil
+	}
+
+	key := &domain.SigningKey{
+		UserID:    userID,
+		KeyID:     keyID,
+		PublicKey: base64.StdEncoding.EncodeToString(publicKey),
+		ValidFrom: validFrom.UTC(),
+	}
+	if err := keys.CreateSigningKey(key); err != nil {
+		return nil, fmt.Errorf("failed to enroll signing key %s: %w", keyID, err)
+	}
+	return key, nil
+}
+
+// ImportServerHeldKeys enrolls the user keys the server used to keep in dir as
+// user-<id>.pem, and used to sign users' events with, so the events they signed still
+// verify. The keys are enrolled revoked at retiredAt: users sign with keys of their own
+// from then on. It returns the number of keys newly enrolled. Once imported, the files
+// should be destroyed.
+func ImportServerHeldKeys(keys KeyDirectory, dir string, retiredAt time.Time) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "user-*.pem"))
+	if err != nil {
+		return 0, err
+	}
+
+	imported := 0
+	for _, path := range paths {
+		id, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "user-"), ".pem"), 10, 64)
+		if err != nil {
+			continue
+		}
+		signer, err := signing.LoadSigner(path)
+		if err != nil {
+			return imported, err
+		}
+		existing, err := keys.GetSigningKeyByKeyID(signer.KeyID())
+		if err != nil {
+			return imported, err
+		}
+		if existing != nil {
+			continue
+		}
+
+		userID := uint(id)
+		revokedAt := retiredAt.UTC()
+		if err := keys.CreateSigningKey(&domain.SigningKey{
+			UserID:    &userID,
+			KeyID:     signer.KeyID(),
+			PublicKey: base64.StdEncoding.EncodeToString(signer.PublicKey()),
+			RevokedAt: &revokedAt,
+		}); err != nil {
+			return imported, fmt.Errorf("failed to import signing key of user %d: %w", id, err)
+		}
+		imported++
+	}
+	return imported, nil
+}
@@ -0,0 +1,170 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+)
+
+const (
+	// DefaultStreamPollInterval is how often a Follower checks the ledger for new events.
+	DefaultStreamPollInterval = 2 * time.Second
+	// DefaultStreamLookback is how far before the newest event a Follower re-reads on every
+	// poll, to pick up events committed after others with later timestamps because another
+	// writer's clock is behind.
+	DefaultStreamLookback = 30 * time.Second
+	// DefaultStreamLateLookback is how far before the newest event a Follower re-reads every
+	// LateInterval, to pick up events the outbox delivered late. Events are timestamped when
+	// they occurred, so one queued during a ledger outage arrives behind newer events. It
+	// covers the longest delivery delay of the default outbox settings (see
+	// outbox.RelayConfig.MaxDeliveryDelay).
+	DefaultStreamLateLookback = 2 * time.Hour
+	// DefaultStreamLateInterval is how often a Follower re-reads the late lookback window.
+	DefaultStreamLateInterval = time.Minute
+)
+
+// StreamOptions tunes a Follower; zero values select the defaults.
+type StreamOptions struct {
+	PollInterval time.Duration
+	Lookback     time.Duration
+	LateLookback time.Duration // Raised to Lookback if shorter
+	LateInterval time.Duration
+}
+
+// Follower streams ledger events as they are committed. It polls GetGeneralHistory, so
+// every backend can be followed without backend-specific change feeds.
+type Follower struct {
+	svc   LedgerService
+	query HistoryQuery
+	opts  StreamOptions
+
+	start  *historyCursor       // Events at or before this position are not sent; nil sends everything from query.From
+	newest time.Time            // Latest timestamp seen
+	seen   map[string]time.Time // Event IDs seen within the late lookback window, by timestamp
+	primed bool                 // Whether the first poll has run
+}
+
+// NewFollower creates a follower for the events matching query. resumeFrom is the cursor of
+// the last event a client received (the SSE Last-Event-ID); when it is empty the stream
+// starts at query.From, or at the current time when no From is given. Cursor and Order
+// in query are ignored: events are always sent oldest first.
+func NewFollower(svc LedgerService, query HistoryQuery, resumeFrom string, opts StreamOptions) (*Follower, error) {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultStreamPollInterval
+	}
+	if opts.Lookback <= 0 {
+		opts.Lookback = DefaultStreamLookback
+	}
+	if opts.LateLookback <= 0 {
+		opts.LateLookback = DefaultStreamLateLookback
+	}
+	if opts.LateLookback < opts.Lookback {
+		opts.LateLookback = opts.Lookback
+	}
+	if opts.LateInterval <= 0 {
+		opts.LateInterval = DefaultStreamLateInterval
+	}
+
+	query.Cursor = resumeFrom
+	query.Order = SortAsc
+	query, start, err := query.normalize()
+	if err != nil {
+		return nil, err
+	}
+	query.Cursor = ""
+
+	f := &Follower{svc: svc, query: query, opts: opts, start: start, seen: make(map[string]time.Time)}
+	switch {
+	case start != nil:
+		f.newest = start.Timestamp
+	case query.From != nil:
+		f.newest = *query.From
+	default:
+		now := time.Now().UTC()
+		f.start = &historyCursor{Timestamp: now}
+		f.newest = now
+	}
+	return f, nil
+}
+
+// EventCursor returns the position just after event, for use as an SSE event ID.
+func EventCursor(event domain.LedgerEvent) string {
+	return encodeHistoryCursor(event)
+}
+
+// Run calls send for each new event until ctx ends or send or the ledger returns an error.
+// Events are sent in timestamp order, except that an event delivered late is sent when it
+// is found, after newer events. Events delivered later than the late lookback allows,
+// such as dead-lettered outbox entries that are requeued, are not sent. A cancelled
+// context ends the stream without an error.
+func (f *Follower) Run(ctx context.Context, send func(event domain.LedgerEvent, cursor string) error) error {
+	ticker := time.NewTicker(f.opts.PollInterval)
+	defer ticker.Stop()
+
+	var lastLateRead time.Time
+	for {
+		// The first poll reads the late window too, so that late events found later are
+		// told apart from those that were already in the ledger when the stream began
+		lookback := f.opts.Lookback
+		if time.Since(lastLateRead) >= f.opts.LateInterval {
+			lookback, lastLateRead = f.opts.LateLookback, time.Now()
+		}
+		if err := f.poll(ctx, lookback, send); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll sends the unseen events in the lookback window before the newest event seen.
+func (f *Follower) poll(ctx context.Context, lookback time.Duration, send func(event domain.LedgerEvent, cursor string) error) error {
+	query := f.query
+	from := f.newest.Add(-lookback)
+	if query.From == nil || from.After(*query.From) {
+		query.From = &from
+	}
+
+	for {
+		page, err := f.svc.GetGeneralHistory(ctx, query)
+		if err != nil {
+			return err
+		}
+		for _, event := range page.Events {
+			if _, ok := f.seen[event.EventID]; ok {
+				continue
+			}
+			f.seen[event.EventID] = event.Timestamp
+			if event.Timestamp.After(f.newest) {
+				f.newest = event.Timestamp
+			}
+			// Events the client had before reconnecting, or that predate the stream
+			if !f.primed && !f.start.after(event, SortAsc) {
+				continue
+			}
+			if err := send(event, EventCursor(event)); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	f.primed = true
+
+	horizon := f.newest.Add(-f.opts.LateLookback)
+	for id, ts := range f.seen {
+		if ts.Before(horizon) {
+			delete(f.seen, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+)
+
+type streamed struct {
+	event  domain.LedgerEvent
+	cursor string
+}
+
+// follow runs a follower in the background and returns the channel it sends events to.
+func follow(t *testing.T, svc LedgerService, query HistoryQuery, resumeFrom string) <-chan streamed {
+	return followWith(t, svc, query, resumeFrom, StreamOptions{PollInterval: 10 * time.Millisecond})
+}
+
+func followWith(t *testing.T, svc LedgerService, query HistoryQuery, resumeFrom string, opts StreamOptions) <-chan streamed {
+	follower, err := NewFollower(svc, query, resumeFrom, opts)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	events := make(chan streamed, 10)
+	go follower.Run(ctx, func(event domain.LedgerEvent, cursor string) error {
+		events <- streamed{event: event, cursor: cursor}
+		return nil
+	})
+	return events
+}
+
+func next(t *testing.T, events <-chan streamed) streamed {
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a streamed event")
+		return streamed{}
+	}
+}
+
+func TestFollower_StreamsNewEventsAndResumes(t *testing.T) {
+	svc, _ := setupLocalLedger(t)
+	ctx := context.Background()
+	_, err := svc.LogStatusChange(ctx, 7, "SN-7", "Operational", "Damaged", 1, WriteOptions{})
+	require.NoError(t, err)
+
+	events := follow(t, svc, HistoryQuery{ItemID: uint64Ptr(7)}, "")
+	time.Sleep(30 * time.Millisecond) // Let the first poll pass the existing event
+
+	_, err = svc.LogStatusChange(ctx, 8, "SN-8", "Operational", "Damaged", 1, WriteOptions{})
+	require.NoError(t, err)
+	_, err = svc.LogStatusChange(ctx, 7, "SN-7", "Damaged", "Operational", 1, WriteOptions{})
+	require.NoError(t, err)
+	first := next(t, events)
+	assert.Equal(t, "Operational", first.event.StatusChange.NewStatus, "only new events for the item are sent")
+
+	// A reconnecting client resumes after the last event it received
+	_, err = svc.LogStatusChange(ctx, 7, "SN-7", "Operational", "Lost", 1, WriteOptions{})
+	require.NoError(t, err)
+	resumed := follow(t, svc, HistoryQuery{ItemID: uint64Ptr(7)}, first.cursor)
+	assert.Equal(t, "Lost", next(t, resumed).event.StatusChange.NewStatus)
+	select {
+	case extra := <-resumed:
+		t.Fatalf("unexpected event after resume: %+v", extra.event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestFollower_StreamsLateDeliveries(t *testing.T) {
+	svc, _ := setupLocalLedger(t)
+	ctx := context.Background()
+	_, err := svc.LogStatusChange(ctx, 7, "SN-7", "Operational", "Damaged", 1, WriteOptions{OccurredAt: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+
+	events := followWith(t, svc, HistoryQuery{ItemID: uint64Ptr(7)}, "", StreamOptions{PollInterval: 10 * time.Millisecond, LateInterval: 50 * time.Millisecond})
+	time.Sleep(30 * time.Millisecond) // Let the first poll pass the existing event
+
+	_, err = svc.LogStatusChange(ctx, 7, "SN-7", "Damaged", "In Repair", 1, WriteOptions{})
+	require.NoError(t, err)
+	newest := next(t, events)
+	assert.Equal(t, "In Repair", newest.event.StatusChange.NewStatus)
+
+	// Delivered from the outbox after a ledger outage, timestamped when it occurred
+	_, err = svc.LogStatusChange(ctx, 7, "SN-7", "Damaged", "Lost", 1, WriteOptions{OccurredAt: newest.event.Timestamp.Add(-10 * time.Minute)})
+	require.NoError(t, err)
+	late := next(t, events)
+	assert.Equal(t, "Lost", late.event.StatusChange.NewStatus)
+	select {
+	case extra := <-events:
+		t.Fatalf("unexpected event: %+v", extra.event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNewFollower_RejectsMalformedLastEventID(t *testing.T) {
+	svc, _ := setupLocalLedger(t)
+	_, err := NewFollower(svc, HistoryQuery{}, "not-a-cursor", StreamOptions{})
+	assert.ErrorIs(t, err, ErrInvalidHistoryQuery)
+}
@@ -0,0 +1,158 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"github.com/toole-brendan/handreceipt-go/internal/ledger/receipt"
+)
+
+// Timeouts holds the per-operation deadlines applied by WithTimeouts.
+// A zero duration leaves that class of operation bounded only by the caller's context.
+type Timeouts struct {
+	Write      time.Duration // Log* methods
+	Read       time.Duration // history and correction queries, CaptureDigest
+	Verify     time.Duration // VerifyDocument, GetEventReceipt and each VerifyDigest call
+	Initialize time.Duration // Initialize
+}
+
+// DefaultTimeouts returns the deadlines used when none are configured.
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Write:      5 * time.Second,
+		Read:       10 * time.Second,
+		Verify:     60 * time.Second,
+		Initialize: 5 * time.Minute,
+	}
+}
+
+// Ensure timeoutLedgerService implements LedgerService interface at compile time
+var _ LedgerService = (*timeoutLedgerService)(nil)
+
+// timeoutLedgerService wraps a LedgerService and bounds every call with a deadline.
+type timeoutLedgerService struct {
+	next     LedgerService
+	timeouts Timeouts
+}
+
+// WithTimeouts wraps svc so that every call runs under the matching deadline from t.
+// The caller's context still applies, so whichever ends first cancels the call.
+func WithTimeouts(svc LedgerService, t Timeouts) LedgerService {
+	return &timeoutLedgerService{next: svc, timeouts: t}
+}
+
+// bound derives a context limited by d, or returns ctx unchanged when d is zero.
+func bound(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+func (s *timeoutLedgerService) LogItemCreation(ctx context.Context, property domain.Property, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Write)
+	defer cancel()
+	return s.next.LogItemCreation(ctx, property, userID, opts)
+}
+
+func (s *timeoutLedgerService) LogTransferEvent(ctx context.Context, transfer domain.Transfer, serialNumber string, actorUserID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Write)
+	defer cancel()
+	return s.next.LogTransferEvent(ctx, transfer, serialNumber, actorUserID, opts)
+}
+
+func (s *timeoutLedgerService) LogStatusChange(ctx context.Context, itemID uint, serialNumber string, oldStatus string, newStatus string, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Write)
+	defer cancel()
+	return s.next.LogStatusChange(ctx, itemID, serialNumber, oldStatus, newStatus, userID, opts)
+}
+
+func (s *timeoutLedgerService) LogVerificationEvent(ctx context.Context, itemID uint, serialNumber string, userID uint, verificationType string, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Write)
+	defer cancel()
+	return s.next.LogVerificationEvent(ctx, itemID, serialNumber, userID, verificationType, opts)
+}
+
+func (s *timeoutLedgerService) LogMaintenanceEvent(ctx context.Context, maintenanceRecordID string, itemID uint, initiatingUserID uint, performingUserID sql.NullInt64, eventType string, maintenanceType sql.NullString, description string, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Write)
+	defer cancel()
+	return s.next.LogMaintenanceEvent(ctx, maintenanceRecordID, itemID, initiatingUserID, performingUserID, eventType, maintenanceType, description, opts)
+}
+
+func (s *timeoutLedgerService) LogCorrectionEvent(ctx context.Context, originalEventID string, eventType string, reason string, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Write)
+	defer cancel()
+	return s.next.LogCorrectionEvent(ctx, originalEventID, eventType, reason, userID, opts)
+}
+
+func (s *timeoutLedgerService) GetEvent(ctx context.Context, eventID string) (*domain.LedgerEvent, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.next.GetEvent(ctx, eventID)
+}
+
+func (s *timeoutLedgerService) GetItemHistory(ctx context.Context, itemID uint) ([]domain.LedgerEvent, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.next.GetItemHistory(ctx, itemID)
+}
+
+func (s *timeoutLedgerService) VerifyDocument(ctx context.Context, documentID string, tableName string) (bool, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Verify)
+	defer cancel()
+	return s.next.VerifyDocument(ctx, documentID, tableName)
+}
+
+func (s *timeoutLedgerService) GetAllCorrectionEvents(ctx context.Context) ([]domain.CorrectionEvent, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.next.GetAllCorrectionEvents(ctx)
+}
+
+func (s *timeoutLedgerService) GetCorrectionEventsByOriginalID(ctx context.Context, originalEventID string) ([]domain.CorrectionEvent, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.next.GetCorrectionEventsByOriginalID(ctx, originalEventID)
+}
+
+func (s *timeoutLedgerService) GetCorrectionEventByID(ctx context.Context, eventID string) (*domain.CorrectionEvent, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.next.GetCorrectionEventByID(ctx, eventID)
+}
+
+func (s *timeoutLedgerService) GetGeneralHistory(ctx context.Context, query HistoryQuery) (*HistoryPage, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.next.GetGeneralHistory(ctx, query)
+}
+
+func (s *timeoutLedgerService) GetEventReceipt(ctx context.Context, eventID string) (*receipt.Receipt, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Verify)
+	defer cancel()
+	return s.next.GetEventReceipt(ctx, eventID)
+}
+
+func (s *timeoutLedgerService) CaptureDigest(ctx context.Context) (*Digest, error) {
+	ctx, cancel := bound(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.next.CaptureDigest(ctx)
+}
+
+func (s *timeoutLedgerService) VerifyDigest(ctx context.Context, digest Digest) error {
+	ctx, cancel := bound(ctx, s.timeouts.Verify)
+	defer cancel()
+	return s.next.VerifyDigest(ctx, digest)
+}
+
+func (s *timeoutLedgerService) Initialize(ctx context.Context) error {
+	ctx, cancel := bound(ctx, s.timeouts.Initialize)
+	defer cancel()
+	return s.next.Initialize(ctx)
+}
+
+func (s *timeoutLedgerService) Close() error {
+	return s.next.Close()
+}
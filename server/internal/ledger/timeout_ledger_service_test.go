@@ -0,0 +1,40 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+)
+
+// blockingLedger waits for its context to end on every call it overrides.
+type blockingLedger struct {
+	LedgerService
+}
+
+func (blockingLedger) LogItemCreation(ctx context.Context, property domain.Property, userID uint, opts WriteOptions) (*domain.LedgerWriteResult, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingLedger) GetGeneralHistory(ctx context.Context, query HistoryQuery) (*HistoryPage, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestWithTimeouts_AppliesPerOperationDeadline(t *testing.T) {
+	svc := WithTimeouts(blockingLedger{}, Timeouts{Write: 10 * time.Millisecond})
+
+	_, err := svc.LogItemCreation(context.Background(), domain.Property{ID: 1}, 1, WriteOptions{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Reads have no deadline configured, so only the caller's cancellation ends them
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = svc.GetGeneralHistory(ctx, HistoryQuery{})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,95 @@
+package ledger
+
+import (
+	"strconv"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+)
+
+// The events users sign. Each builder returns the event the matching LedgerService write
+// records, as the user asserts it: the client builds the same event, signs its
+// CanonicalEventBytes, and the handler checks the signature against the event it builds
+// from the request.
+
+// ItemCreationEvent is the event of userID creating property.
+func ItemCreationEvent(eventID string, property domain.Property, userID uint) domain.LedgerEvent {
+	details := &domain.ItemCreationDetails{
+		Action:        "Created",
+		Name:          property.Name,
+		StateRecorded: true,
+		Status:        property.CurrentStatus,
+	}
+	if property.Description != nil {
+		details.Description = *property.Description
+	}
+	if property.AssignedToUserID != nil {
+		details.AssignedToUserID = uint64Ptr(*property.AssignedToUserID)
+	}
+	return domain.LedgerEvent{
+		EventID:      eventID,
+		EventType:    domain.LedgerEventItemCreation,
+		ActorUserID:  uint64Ptr(userID),
+		ItemID:       uint64Ptr(property.ID),
+		SerialNumber: property.SerialNumber,
+		ItemCreation: details,
+	}
+}
+
+// TransferEvent is the event of actorUserID moving transfer to its current status.
+func TransferEvent(eventID string, transfer domain.Transfer, serialNumber string, actorUserID uint) domain.LedgerEvent {
+	details := &domain.TransferDetails{
+		TransferID: strconv.FormatUint(uint64(transfer.ID), 10),
+		Status:     transfer.Status,
+		FromUserID: uint64(transfer.FromUserID),
+		ToUserID:   uint64(transfer.ToUserID),
+	}
+	if transfer.Notes != nil {
+		details.Notes = *transfer.Notes
+	}
+	return domain.LedgerEvent{
+		EventID:      eventID,
+		EventType:    domain.LedgerEventTransfer,
+		ActorUserID:  uint64Ptr(actorUserID),
+		ItemID:       uint64Ptr(transfer.PropertyID),
+		SerialNumber: serialNumber,
+		Transfer:     details,
+	}
+}
+
+// StatusChangeEvent is the event of userID changing the status of an item.
+func StatusChangeEvent(eventID string, itemID uint, serialNumber, oldStatus, newStatus string, userID uint) domain.LedgerEvent {
+	return domain.LedgerEvent{
+		EventID:      eventID,
+		EventType:    domain.LedgerEventStatusChange,
+		ActorUserID:  uint64Ptr(userID),
+		ItemID:       uint64Ptr(itemID),
+		SerialNumber: serialNumber,
+		StatusChange: &domain.StatusChangeDetails{PreviousStatus: oldStatus, NewStatus: newStatus},
+	}
+}
+
+// VerificationEvent is the event of userID checking an item.
+func VerificationEvent(eventID string, itemID uint, serialNumber string, userID uint, verificationType string) domain.LedgerEvent {
+	return domain.LedgerEvent{
+		EventID:      eventID,
+		EventType:    domain.LedgerEventVerification,
+		ActorUserID:  uint64Ptr(userID),
+		ItemID:       uint64Ptr(itemID),
+		SerialNumber: serialNumber,
+		Verification: &domain.VerificationDetails{Result: verificationType},
+	}
+}
+
+// CorrectionEvent is the event of userID correcting an earlier event.
+func CorrectionEvent(eventID, originalEventID, eventType, reason string, userID uint) domain.LedgerEvent {
+	return domain.LedgerEvent{
+		EventID:     eventID,
+		EventType:   domain.LedgerEventCorrection,
+		ActorUserID: uint64Ptr(userID),
+		Correction: &domain.CorrectionDetails{
+			OriginalEventID:   originalEventID,
+			OriginalEventType: eventType,
+			Reason:            reason,
+		},
+	}
+}
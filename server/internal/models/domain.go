@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
 	"gorm.io/gorm"
 )
 
@@ -66,7 +67,7 @@ type Equipment struct {
 	UnitPrice       float64            `json:"unit_price"`
 	Quantity        int                `json:"quantity" gorm:"default:1"`
 	Location        string             `json:"location"`
-	Status          EquipmentStatus    `json:"status" gorm:"type:varchar(50);default:'available'"`
+	Status          EquipmentStatus    `json:"status" gorm:"type:varchar(50);default:'Operational'"`
 	Condition       EquipmentCondition `json:"condition" gorm:"type:varchar(50);default:'serviceable'"`
 	AssignedToID    *uint              `json:"assigned_to_id"`
 	AssignedTo      *User              `json:"assigned_to,omitempty" gorm:"foreignKey:AssignedToID"`
@@ -85,16 +86,18 @@ type Equipment struct {
 	AuditLogs          []AuditLog          `json:"audit_logs,omitempty" gorm:"foreignKey:EntityID;where:entity_type = 'equipment'"`
 }
 
+// EquipmentStatus is a property status from the canonical set in the domain package.
+// Whether an item is assigned or in transit is tracked by AssignedToID and transfers,
+// not by its status.
 type EquipmentStatus string
 
 const (
-	StatusAvailable   EquipmentStatus = "available"
-	StatusAssigned    EquipmentStatus = "assigned"
-	StatusInTransit   EquipmentStatus = "in_transit"
-	StatusMaintenance EquipmentStatus = "maintenance"
-	StatusRetired     EquipmentStatus = "retired"
-	StatusLost        EquipmentStatus = "lost"
-	StatusDamaged     EquipmentStatus = "damaged"
+	StatusOperational    EquipmentStatus = domain.PropertyStatusOperational
+	StatusNonOperational EquipmentStatus = domain.PropertyStatusNonOperational
+	StatusDamaged        EquipmentStatus = domain.PropertyStatusDamaged
+	StatusInRepair       EquipmentStatus = domain.PropertyStatusInRepair
+	StatusLost           EquipmentStatus = domain.PropertyStatusLost
+	StatusFound          EquipmentStatus = domain.PropertyStatusFound
 )
 
 type EquipmentCondition string
@@ -129,7 +129,7 @@ type UpdateEquipmentRequest struct {
 	UnitPrice       *float64            `json:"unit_price,omitempty" validate:"omitempty,min=0"`
 	Quantity        *int                `json:"quantity,omitempty" validate:"omitempty,min=1"`
 	Location        *string             `json:"location,omitempty" validate:"omitempty,max=255"`
-	Status          *EquipmentStatus    `json:"status,omitempty" validate:"omitempty,oneof=available assigned in_transit maintenance retired lost damaged"`
+	Status          *EquipmentStatus    `json:"status,omitempty" validate:"omitempty,oneof=Operational Non-Operational Damaged 'In Repair' Lost Found"`
 	Condition       *EquipmentCondition `json:"condition,omitempty" validate:"omitempty,oneof=serviceable unserviceable needs_repair beyond_repair new"`
 	AcquisitionDate *time.Time          `json:"acquisition_date,omitempty"`
 	WarrantyExpiry  *time.Time          `json:"warranty_expiry,omitempty"`
@@ -148,7 +148,7 @@ type EquipmentSearchRequest struct {
 	Query      string             `json:"query" validate:"max=255"`
 	NSN        string             `json:"nsn" validate:"omitempty,len=13"`
 	LIN        string             `json:"lin" validate:"omitempty,len=6"`
-	Status     EquipmentStatus    `json:"status" validate:"omitempty,oneof=available assigned in_transit maintenance retired lost damaged"`
+	Status     EquipmentStatus    `json:"status" validate:"omitempty,oneof=Operational Non-Operational Damaged 'In Repair' Lost Found"`
 	Condition  EquipmentCondition `json:"condition" validate:"omitempty,oneof=serviceable unserviceable needs_repair beyond_repair new"`
 	AssignedTo uint               `json:"assigned_to" validate:"omitempty,min=1"`
 	Location   string             `json:"location" validate:"max=255"`
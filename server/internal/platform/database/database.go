@@ -97,6 +97,10 @@ func Migrate(db *gorm.DB) error {
 		&domain.Property{},
 		&domain.Transfer{},
 		&domain.Activity{},
+		&domain.LedgerOutboxEntry{},
+		&domain.ReconciliationReport{},
+		&domain.LedgerDivergence{},
+		&domain.SigningKey{},
 	)
 }
 
@@ -58,6 +58,10 @@ func ConnectDB() (*gorm.DB, error) {
 		&domain.Property{},
 		&domain.Transfer{},
 		&domain.Activity{}, // Keep if still used, otherwise remove
+		&domain.LedgerOutboxEntry{},
+		&domain.ReconciliationReport{},
+		&domain.LedgerDivergence{},
+		&domain.SigningKey{},
 	)
 	if err != nil {
 		log.Printf("Auto-migration failed: %v\n", err)
@@ -0,0 +1,419 @@
+package immudb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/sirupsen/logrus"
+)
+
+// ImmuDB cannot delete entries, so old audit events are archived rather than removed: they
+// are exported into gzip-compressed bundles in object storage, and an index entry in ImmuDB
+// anchors each bundle's SHA-256 hash. Each bundle names the hash of the one before it, so
+// the bundles form a chain that cannot be reordered or have links dropped unnoticed.
+// Once events are archived, GetAuditTrail reads them from their bundles and scans ImmuDB
+// only for events newer than the archive, so the trail stays complete if the live
+// database is later rotated.
+
+const (
+	archiveBundleVersion = 1
+	archiveBundlePrefix  = "audit_archive:bundle:" // + zero-padded sequence -> ArchiveIndexEntry
+	archiveEntityPrefix  = "audit_archive:entity:" // + entity type:entity ID:sequence -> bundle key
+	auditScanPageSize    = 1000
+)
+
+// ErrArchiveIntegrity is returned (wrapped) when an archive bundle does not match the
+// index entry anchoring it, or the bundle chain is broken.
+var ErrArchiveIntegrity = errors.New("audit archive integrity check failed")
+
+// ArchiveStorage holds audit archive bundles. storage.MinIOService implements it.
+type ArchiveStorage interface {
+	UploadFile(ctx context.Context, objectName string, reader io.Reader, objectSize int64, contentType string) error
+	ReadFile(ctx context.Context, objectName string) ([]byte, error)
+}
+
+// ArchiveIndexEntry is the ImmuDB record anchoring one archive bundle.
+type ArchiveIndexEntry struct {
+	Sequence       uint64    `json:"sequence"`
+	ObjectName     string    `json:"object_name"`
+	SHA256         string    `json:"sha256"`          // Hex hash of the compressed bundle
+	PreviousHash   string    `json:"previous_hash"`   // SHA256 of the previous bundle; empty for the first
+	ArchivedFrom   time.Time `json:"archived_from"`   // Events at or after this time...
+	ArchivedBefore time.Time `json:"archived_before"` // ...and before this one are in the bundle
+	EventCount     int       `json:"event_count"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ArchiveBundle is the content of an archive object before compression.
+type ArchiveBundle struct {
+	Version        int          `json:"version"`
+	Sequence       uint64       `json:"sequence"`
+	PreviousHash   string       `json:"previous_hash"`
+	ArchivedFrom   time.Time    `json:"archived_from"`
+	ArchivedBefore time.Time    `json:"archived_before"`
+	Events         []AuditEvent `json:"events"` // Oldest first
+}
+
+// SetArchiveStorage enables archiving to storage, with bundle objects named under prefix
+// (e.g. "audit-archives/").
+func (r *AuditRepository) SetArchiveStorage(store ArchiveStorage, prefix string) {
+	r.archive = store
+	r.archivePrefix = prefix
+}
+
+// CompressOldLogs archives audit events recorded before olderThan that earlier runs have
+// not archived into a new bundle, and anchors it in ImmuDB. Runs that find nothing new to
+// archive do nothing.
+func (r *AuditRepository) CompressOldLogs(ctx context.Context, olderThan time.Time) error {
+	if !r.config.Enabled {
+		return nil
+	}
+	if r.archive == nil {
+		return fmt.Errorf("audit archive storage is not configured")
+	}
+
+	latest, err := r.latestArchive(ctx)
+	if err != nil {
+		return err
+	}
+	bundle := ArchiveBundle{Version: archiveBundleVersion, Sequence: 1, ArchivedBefore: olderThan.UTC()}
+	if latest != nil {
+		if !olderThan.After(latest.ArchivedBefore) {
+			r.logger.WithField("older_than", olderThan).Info("Audit events before this time are already archived")
+			return nil
+		}
+		bundle.Sequence = latest.Sequence + 1
+		bundle.PreviousHash = latest.SHA256
+		bundle.ArchivedFrom = latest.ArchivedBefore
+	}
+
+	bundle.Events, err = r.scanAuditEvents(ctx, func(event AuditEvent) bool {
+		return !event.Timestamp.Before(bundle.ArchivedFrom) && event.Timestamp.Before(bundle.ArchivedBefore)
+	})
+	if err != nil {
+		return err
+	}
+	if len(bundle.Events) == 0 {
+		r.logger.WithField("older_than", olderThan).Info("No audit events to archive")
+		return nil
+	}
+
+	data, hash, err := encodeArchiveBundle(bundle)
+	if err != nil {
+		return err
+	}
+	entry := ArchiveIndexEntry{
+		Sequence:       bundle.Sequence,
+		ObjectName:     fmt.Sprintf("%sbundle-%020d.json.gz", r.archivePrefix, bundle.Sequence),
+		SHA256:         hash,
+		PreviousHash:   bundle.PreviousHash,
+		ArchivedFrom:   bundle.ArchivedFrom,
+		ArchivedBefore: bundle.ArchivedBefore,
+		EventCount:     len(bundle.Events),
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := r.archive.UploadFile(ctx, entry.ObjectName, bytes.NewReader(data), int64(len(data)), "application/gzip"); err != nil {
+		return fmt.Errorf("failed to upload audit archive bundle: %w", err)
+	}
+	if err := r.writeArchiveIndex(ctx, entry, bundle.Events); err != nil {
+		return err
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"sequence": entry.Sequence,
+		"events":   entry.EventCount,
+		"object":   entry.ObjectName,
+		"sha256":   entry.SHA256,
+	}).Info("Audit events archived")
+	return nil
+}
+
+// VerifyArchiveIntegrity checks every archive bundle against its index entry and the
+// chain linking the bundles.
+func (r *AuditRepository) VerifyArchiveIntegrity(ctx context.Context) (bool, error) {
+	if !r.config.Enabled {
+		return true, nil
+	}
+
+	entries, err := r.scanPrefix(ctx, archiveBundlePrefix, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to scan audit archive index: %w", err)
+	}
+	index := make([]ArchiveIndexEntry, 0, len(entries))
+	for _, e := range entries {
+		entry, err := r.readArchiveEntry(ctx, string(e.Key))
+		if err != nil {
+			return false, err
+		}
+		index = append(index, *entry)
+	}
+	if err := verifyArchiveChain(index); err != nil {
+		return false, err
+	}
+	for _, entry := range index {
+		if _, err := r.loadArchiveBundle(ctx, entry); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// archivedAuditTrail returns the archived events for an entity and the time before which
+// every event is archived (zero when nothing is).
+func (r *AuditRepository) archivedAuditTrail(ctx context.Context, entityType, entityID string) ([]AuditEvent, time.Time, error) {
+	latest, err := r.latestArchive(ctx)
+	if err != nil || latest == nil {
+		return nil, time.Time{}, err
+	}
+	if r.archive == nil {
+		return nil, time.Time{}, fmt.Errorf("audit events are archived but archive storage is not configured")
+	}
+
+	refs, err := r.scanPrefix(ctx, fmt.Sprintf("%s%s:%s:", archiveEntityPrefix, entityType, entityID), nil)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to scan audit archive index: %w", err)
+	}
+	var events []AuditEvent
+	for _, ref := range refs {
+		entry, err := r.readArchiveEntry(ctx, string(ref.Value))
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		bundle, err := r.loadArchiveBundle(ctx, *entry)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		for _, event := range bundle.Events {
+			if event.EntityType == entityType && event.EntityID == entityID {
+				events = append(events, event)
+			}
+		}
+	}
+	return events, latest.ArchivedBefore, nil
+}
+
+// latestArchive returns the index entry of the most recent bundle, or nil if there is none.
+func (r *AuditRepository) latestArchive(ctx context.Context) (*ArchiveIndexEntry, error) {
+	entries, err := r.client.Scan(ctx, &schema.ScanRequest{Prefix: []byte(archiveBundlePrefix), Desc: true, Limit: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan audit archive index: %w", err)
+	}
+	if len(entries.Entries) == 0 {
+		return nil, nil
+	}
+	return r.readArchiveEntry(ctx, string(entries.Entries[0].Key))
+}
+
+// readArchiveEntry reads an index entry with VerifiedGet, so the anchored hash is proven
+// to be the one ImmuDB recorded.
+func (r *AuditRepository) readArchiveEntry(ctx context.Context, key string) (*ArchiveIndexEntry, error) {
+	stored, err := r.client.VerifiedGet(ctx, []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit archive index entry %s: %w", key, err)
+	}
+	var entry ArchiveIndexEntry
+	if err := json.Unmarshal(stored.Value, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode audit archive index entry %s: %w", key, err)
+	}
+	return &entry, nil
+}
+
+// loadArchiveBundle downloads a bundle and checks it against its index entry.
+func (r *AuditRepository) loadArchiveBundle(ctx context.Context, entry ArchiveIndexEntry) (*ArchiveBundle, error) {
+	data, err := r.archive.ReadFile(ctx, entry.ObjectName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download audit archive bundle %s: %w", entry.ObjectName, err)
+	}
+	return decodeArchiveBundle(data, entry)
+}
+
+// writeArchiveIndex records a bundle's index entry, and one reference to it per entity it
+// holds events for, in a single transaction. The write fails if another run has already
+// recorded a bundle with the same sequence.
+func (r *AuditRepository) writeArchiveIndex(ctx context.Context, entry ArchiveIndexEntry, events []AuditEvent) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit archive index entry: %w", err)
+	}
+	bundleKey := []byte(archiveBundleKey(entry.Sequence))
+	kvs := []*schema.KeyValue{{Key: bundleKey, Value: value}}
+
+	seen := make(map[string]bool)
+	for _, event := range events {
+		key := fmt.Sprintf("%s%s:%s:%020d", archiveEntityPrefix, event.EntityType, event.EntityID, entry.Sequence)
+		if !seen[key] {
+			seen[key] = true
+			kvs = append(kvs, &schema.KeyValue{Key: []byte(key), Value: bundleKey})
+		}
+	}
+
+	_, err = r.client.SetAll(ctx, &schema.SetRequest{
+		KVs:           kvs,
+		Preconditions: []*schema.Precondition{schema.PreconditionKeyMustNotExist(bundleKey)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record audit archive index entry: %w", err)
+	}
+	return nil
+}
+
+// scanAuditEvents pages through every audit event and returns the ones keep accepts, oldest first.
+func (r *AuditRepository) scanAuditEvents(ctx context.Context, keep func(AuditEvent) bool) ([]AuditEvent, error) {
+	var events []AuditEvent
+	_, err := r.scanPrefix(ctx, "audit:", func(entry *schema.Entry) {
+		var event AuditEvent
+		if err := json.Unmarshal(entry.Value, &event); err != nil {
+			r.logger.WithError(err).WithField("key", string(entry.Key)).Warn("Failed to unmarshal audit event")
+			return
+		}
+		if keep(event) {
+			events = append(events, event)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan audit entries: %w", err)
+	}
+	sortAuditEvents(events, false)
+	return events, nil
+}
+
+// scanPrefix pages through every key under prefix. With visit set, entries are passed to it
+// instead of being collected.
+func (r *AuditRepository) scanPrefix(ctx context.Context, prefix string, visit func(*schema.Entry)) ([]*schema.Entry, error) {
+	var collected []*schema.Entry
+	var seek []byte
+	for {
+		page, err := r.client.Scan(ctx, &schema.ScanRequest{Prefix: []byte(prefix), SeekKey: seek, Limit: auditScanPageSize})
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range page.Entries {
+			if visit != nil {
+				visit(entry)
+			} else {
+				collected = append(collected, entry)
+			}
+		}
+		if len(page.Entries) < auditScanPageSize {
+			return collected, nil
+		}
+		seek = page.Entries[len(page.Entries)-1].Key
+	}
+}
+
+func archiveBundleKey(sequence uint64) string {
+	return archiveBundlePrefix + fmt.Sprintf("%020d", sequence)
+}
+
+// auditKeyAt is the audit key an event for the entity recorded at ts would have.
+func auditKeyAt(entityType, entityID string, ts time.Time) string {
+	return fmt.Sprintf("audit:%s:%s:%s", entityType, entityID, strconv.FormatInt(ts.UnixNano(), 10))
+}
+
+// encodeArchiveBundle compresses a bundle and returns it with its hex SHA-256 hash.
+func encodeArchiveBundle(bundle ArchiveBundle) ([]byte, string, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(bundle); err != nil {
+		return nil, "", fmt.Errorf("failed to encode audit archive bundle: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to compress audit archive bundle: %w", err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return buf.Bytes(), hex.EncodeToString(sum[:]), nil
+}
+
+// decodeArchiveBundle checks a compressed bundle against the index entry anchoring it and
+// decodes it.
+func decodeArchiveBundle(data []byte, entry ArchiveIndexEntry) (*ArchiveBundle, error) {
+	sum := sha256.Sum256(data)
+	if hash := hex.EncodeToString(sum[:]); hash != entry.SHA256 {
+		return nil, fmt.Errorf("%w: bundle %s hashes to %s, index anchors %s", ErrArchiveIntegrity, entry.ObjectName, hash, entry.SHA256)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress audit archive bundle %s: %w", entry.ObjectName, err)
+	}
+	defer zr.Close()
+	var bundle ArchiveBundle
+	if err := json.NewDecoder(zr).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode audit archive bundle %s: %w", entry.ObjectName, err)
+	}
+
+	if bundle.Version != archiveBundleVersion {
+		return nil, fmt.Errorf("unsupported audit archive bundle version %d in %s", bundle.Version, entry.ObjectName)
+	}
+	if bundle.Sequence != entry.Sequence || bundle.PreviousHash != entry.PreviousHash || len(bundle.Events) != entry.EventCount {
+		return nil, fmt.Errorf("%w: bundle %s does not match its index entry", ErrArchiveIntegrity, entry.ObjectName)
+	}
+	return &bundle, nil
+}
+
+// verifyArchiveChain checks that index entries, oldest first, link each bundle to the one
+// before it and cover consecutive time ranges.
+func verifyArchiveChain(entries []ArchiveIndexEntry) error {
+	for i, entry := range entries {
+		if entry.Sequence != uint64(i+1) {
+			return fmt.Errorf("%w: expected bundle %d, found %d", ErrArchiveIntegrity, i+1, entry.Sequence)
+		}
+		if i == 0 {
+			if entry.PreviousHash != "" {
+				return fmt.Errorf("%w: first bundle links to %s", ErrArchiveIntegrity, entry.PreviousHash)
+			}
+			continue
+		}
+		previous := entries[i-1]
+		if entry.PreviousHash != previous.SHA256 {
+			return fmt.Errorf("%w: bundle %d does not link to bundle %d", ErrArchiveIntegrity, entry.Sequence, previous.Sequence)
+		}
+		if !entry.ArchivedFrom.Equal(previous.ArchivedBefore) {
+			return fmt.Errorf("%w: bundle %d does not start where bundle %d ends", ErrArchiveIntegrity, entry.Sequence, previous.Sequence)
+		}
+	}
+	return nil
+}
+
+// mergeAuditEvents combines archived and live events, dropping duplicates, newest first.
+func mergeAuditEvents(archived, live []AuditEvent) []AuditEvent {
+	seen := make(map[string]bool, len(archived)+len(live))
+	merged := make([]AuditEvent, 0, len(archived)+len(live))
+	for _, events := range [][]AuditEvent{archived, live} {
+		for _, event := range events {
+			if event.ID != "" && seen[event.ID] {
+				continue
+			}
+			seen[event.ID] = true
+			merged = append(merged, event)
+		}
+	}
+	sortAuditEvents(merged, true)
+	return merged
+}
+
+// sortAuditEvents orders events by timestamp, then ID so ties are stable.
+func sortAuditEvents(events []AuditEvent, newestFirst bool) {
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if newestFirst {
+			a, b = b, a
+		}
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp)
+		}
+		return strings.Compare(a.ID, b.ID) < 0
+	})
+}
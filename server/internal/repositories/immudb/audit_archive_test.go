@@ -0,0 +1,67 @@
+package immudb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/handreceipt-go/internal/models"
+)
+
+func TestArchiveBundle_RoundTripAndTamperDetection(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	bundle := ArchiveBundle{
+		Version:        archiveBundleVersion,
+		Sequence:       2,
+		PreviousHash:   "abc",
+		ArchivedFrom:   ts.Add(-time.Hour),
+		ArchivedBefore: ts.Add(time.Hour),
+		Events: []AuditEvent{
+			{ID: "a1", EntityType: "equipment", EntityID: "7", Action: models.AuditAction("update"), UserID: 1, Timestamp: ts},
+		},
+	}
+	data, hash, err := encodeArchiveBundle(bundle)
+	require.NoError(t, err)
+	entry := ArchiveIndexEntry{Sequence: 2, ObjectName: "bundle-2", SHA256: hash, PreviousHash: "abc", EventCount: 1}
+
+	decoded, err := decodeArchiveBundle(data, entry)
+	require.NoError(t, err)
+	assert.Equal(t, bundle, *decoded)
+
+	tampered := append([]byte(nil), data...)
+	tampered[len(tampered)-1] ^= 0xff
+	_, err = decodeArchiveBundle(tampered, entry)
+	assert.ErrorIs(t, err, ErrArchiveIntegrity)
+
+	entry.PreviousHash = "other"
+	_, err = decodeArchiveBundle(data, entry)
+	assert.ErrorIs(t, err, ErrArchiveIntegrity, "the bundle must match its index entry")
+}
+
+func TestVerifyArchiveChain(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []ArchiveIndexEntry{
+		{Sequence: 1, SHA256: "h1", ArchivedBefore: t0},
+		{Sequence: 2, SHA256: "h2", PreviousHash: "h1", ArchivedFrom: t0, ArchivedBefore: t0.Add(24 * time.Hour)},
+	}
+	assert.NoError(t, verifyArchiveChain(entries))
+
+	entries[1].PreviousHash = "forged"
+	assert.ErrorIs(t, verifyArchiveChain(entries), ErrArchiveIntegrity)
+
+	assert.ErrorIs(t, verifyArchiveChain(entries[1:]), ErrArchiveIntegrity, "a dropped bundle breaks the chain")
+}
+
+func TestMergeAuditEvents_NewestFirstWithoutDuplicates(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	archived := []AuditEvent{{ID: "a", Timestamp: t0}, {ID: "b", Timestamp: t0.Add(time.Minute)}}
+	live := []AuditEvent{{ID: "b", Timestamp: t0.Add(time.Minute)}, {ID: "c", Timestamp: t0.Add(time.Hour)}}
+
+	merged := mergeAuditEvents(archived, live)
+	ids := make([]string, len(merged))
+	for i, event := range merged {
+		ids[i] = event.ID
+	}
+	assert.Equal(t, []string{"c", "b", "a"}, ids)
+}
@@ -16,9 +16,11 @@ import (
 )
 
 type AuditRepository struct {
-	client immuclient.ImmuClient
-	logger *logrus.Logger
-	config *config.ImmuDBConfig
+	client        immuclient.ImmuClient
+	logger        *logrus.Logger
+	config        *config.ImmuDBConfig
+	archive       ArchiveStorage // Holds archive bundles; nil until SetArchiveStorage
+	archivePrefix string
 }
 
 type AuditEvent struct {
@@ -101,20 +103,35 @@ func (r *AuditRepository) LogEvent(ctx context.Context, event AuditEvent) error
 	return nil
 }
 
-// GetAuditTrail retrieves audit entries for a specific entity
+// GetAuditTrail retrieves audit entries for a specific entity, newest first. Events older
+// than the audit archive are read from their archive bundles, which are checked against
+// the hashes anchored in ImmuDB.
 func (r *AuditRepository) GetAuditTrail(ctx context.Context, entityType, entityID string) ([]AuditEvent, error) {
 	if !r.config.Enabled {
 		return []AuditEvent{}, nil
 	}
 
+	archived, archivedBefore, err := r.archivedAuditTrail(ctx, entityType, entityID)
+	if err != nil {
+		r.logger.WithError(err).WithFields(logrus.Fields{
+			"entity_type": entityType,
+			"entity_id":   entityID,
+		}).Error("Failed to read archived audit entries")
+		return nil, fmt.Errorf("failed to read archived audit entries: %w", err)
+	}
+
 	// Create prefix for scanning
 	prefix := fmt.Sprintf("audit:%s:%s:", entityType, entityID)
 
-	// Scan for entries with the prefix using the correct API
+	// Scan for entries with the prefix using the correct API, skipping archived entries
 	scanReq := &schema.ScanRequest{
 		Prefix: []byte(prefix),
 		Limit:  1000, // Limit to prevent memory issues
 	}
+	if !archivedBefore.IsZero() {
+		scanReq.SeekKey = []byte(auditKeyAt(entityType, entityID, archivedBefore))
+		scanReq.InclusiveSeek = true
+	}
 
 	entries, err := r.client.Scan(ctx, scanReq)
 	if err != nil {
@@ -135,16 +152,7 @@ func (r *AuditRepository) GetAuditTrail(ctx context.Context, entityType, entityI
 		events = append(events, event)
 	}
 
-	// Sort events by timestamp (newest first)
-	for i := 0; i < len(events)-1; i++ {
-		for j := i + 1; j < len(events); j++ {
-			if events[i].Timestamp.Before(events[j].Timestamp) {
-				events[i], events[j] = events[j], events[i]
-			}
-		}
-	}
-
-	return events, nil
+	return mergeAuditEvents(archived, events), nil
 }
 
 // SearchAuditEvents searches for audit events based on filters
@@ -320,18 +328,6 @@ func (r *AuditRepository) GetAuditStatistics(ctx context.Context, entityType str
 	return stats, nil
 }
 
-// CompressOldLogs compresses or archives old audit logs (placeholder for future implementation)
-func (r *AuditRepository) CompressOldLogs(ctx context.Context, olderThan time.Time) error {
-	if !r.config.Enabled {
-		return nil
-	}
-
-	// This is a placeholder for future implementation
-	// ImmuDB doesn't support deletion, but we could implement archiving strategies
-	r.logger.WithField("older_than", olderThan).Info("Audit log compression requested (not implemented)")
-	return nil
-}
-
 // generateAuditKey creates a unique key for audit entries
 func (r *AuditRepository) generateAuditKey(entityType, entityID string, timestamp time.Time) string {
 	// Use nanosecond timestamp to ensure uniqueness
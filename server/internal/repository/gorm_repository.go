@@ -56,6 +56,13 @@ func (r *gormRepository) GetAllUsers() ([]domain.User, error) {
 	return users, err
 }
 
+// ListUsersByUnit retrieves the users who belong to a unit.
+func (r *gormRepository) ListUsersByUnit(unit string) ([]domain.User, error) {
+	var users []domain.User
+	err := r.db.Where("unit = ?", unit).Order("id").Find(&users).Error
+	return users, err
+}
+
 // --- Property Operations ---
 
 func (r *gormRepository) CreateProperty(property *domain.Property) error {
@@ -88,9 +95,7 @@ func (r *gormRepository) GetPropertyBySerialNumber(serialNumber string) (*domain
 }
 
 func (r *gormRepository) UpdateProperty(property *domain.Property) error {
-	// Use Save to update all fields, including zero values
-	// Use Updates for partial updates if needed
-	return r.db.Save(property).Error
+	return updateVersioned(r.db, property, "property", property.ID, &property.Version)
 }
 
 func (r *gormRepository) ListProperties(assignedUserID *uint) ([]domain.Property, error) {
@@ -181,7 +186,7 @@ func (r *gormRepository) GetTransferByID(id uint) (*domain.Transfer, error) {
 }
 
 func (r *gormRepository) UpdateTransfer(transfer *domain.Transfer) error {
-	return r.db.Save(transfer).Error
+	return updateVersioned(r.db, transfer, "transfer", transfer.ID, &transfer.Version)
 }
 
 func (r *gormRepository) ListTransfers(userID uint, status *string) ([]domain.Transfer, error) {
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"regexp"
 	"testing"
@@ -405,15 +406,16 @@ func TestGormRepository_UpdateProperty(t *testing.T) {
 		SerialNumber:  "SN-UPDATE-ME",
 		CurrentStatus: "Under Maintenance",
 		UpdatedAt:     time.Now(), // GORM Save usually updates UpdatedAt
+		Version:       3,
 		// Assume other fields might be updated too
 		// We need to ensure all fields expected by GORM's Save are present in the SQL
 	}
 
-	// GORM's Save typically generates an UPDATE statement setting all fields
-	// including potentially unchanged ones, identified by the primary key.
-	expectedSQL := regexp.QuoteMeta(`UPDATE "properties" SET "property_model_id"=$1,"name"=$2,"serial_number"=$3,"description"=$4,"current_status"=$5,"assigned_to_user_id"=$6,"last_verified_at"=$7,"last_maintenance_at"=$8,"created_at"=$9,"updated_at"=$10 WHERE "id" = $11`)
+	// The update sets all fields, including potentially unchanged ones, and the next version,
+	// identified by the primary key and the version the caller read.
+	expectedSQL := regexp.QuoteMeta(`UPDATE "properties" SET "property_model_id"=$1,"name"=$2,"serial_number"=$3,"description"=$4,"current_status"=$5,"assigned_to_user_id"=$6,"last_verified_at"=$7,"last_maintenance_at"=$8,"created_at"=$9,"updated_at"=$10,"version"=$11 WHERE version = $12 AND "id" = $13`)
 
-	// Mock transaction flow for Update (Save)
+	// Mock transaction flow for Update
 	mock.ExpectBegin()
 	mock.ExpectExec(expectedSQL).
 		WithArgs(
@@ -427,6 +429,8 @@ func TestGormRepository_UpdateProperty(t *testing.T) {
 			updatedProperty.LastMaintenanceAt,
 			updatedProperty.CreatedAt, // Save typically includes CreatedAt
 			sqlmock.AnyArg(),          // Expect UpdatedAt to be updated
+			4,                         // Next version
+			3,                         // Version the update is based on
 			updatedProperty.ID,        // WHERE clause argument
 		).
 		WillReturnResult(sqlmock.NewResult(0, 1)) // Simulate 1 row affected
@@ -437,6 +441,19 @@ func TestGormRepository_UpdateProperty(t *testing.T) {
 
 	// Assertions
 	assert.NoError(t, err)
+	assert.Equal(t, uint(4), updatedProperty.Version)
+
+	// Someone else has updated the row since version 4 was read, so no row matches
+	mock.ExpectBegin()
+	mock.ExpectExec(expectedSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err = repo.UpdateProperty(updatedProperty)
+	var conflict *domain.VersionConflictError
+	if assert.ErrorAs(t, err, &conflict) {
+		assert.Equal(t, domain.VersionConflictError{Entity: "property", ID: 30, Version: 4}, *conflict)
+	}
+	assert.Equal(t, uint(4), updatedProperty.Version, "a failed update leaves the version unchanged")
 
 	// Verify that all expectations were met
 	err = mock.ExpectationsWereMet()
@@ -639,13 +656,14 @@ func TestGormRepository_UpdateTransfer(t *testing.T) {
 		RequestDate:  time.Now().Add(-2 * time.Hour),                     // Existing request date
 		ResolvedDate: func() *time.Time { t := time.Now(); return &t }(), // Set resolved date
 		CreatedAt:    time.Now().Add(-2 * time.Hour),                     // Existing created date
-		// GORM Save will update UpdatedAt
+		Version:      1,
+		// The update will set UpdatedAt
 	}
 
-	// Define the expected SQL UPDATE query from GORM Save
-	expectedSQL := regexp.QuoteMeta(`UPDATE "transfers" SET "property_id"=$1,"from_user_id"=$2,"to_user_id"=$3,"status"=$4,"request_date"=$5,"resolved_date"=$6,"notes"=$7,"created_at"=$8,"updated_at"=$9 WHERE "id" = $10`)
+	// Define the expected SQL UPDATE query, guarded by the version the caller read
+	expectedSQL := regexp.QuoteMeta(`UPDATE "transfers" SET "property_id"=$1,"from_user_id"=$2,"to_user_id"=$3,"status"=$4,"request_date"=$5,"resolved_date"=$6,"notes"=$7,"created_at"=$8,"updated_at"=$9,"version"=$10 WHERE version = $11 AND "id" = $12`)
 
-	// Mock transaction flow for Update (Save)
+	// Mock transaction flow for Update
 	mock.ExpectBegin()
 	mock.ExpectExec(expectedSQL).
 		WithArgs(
@@ -658,6 +676,8 @@ func TestGormRepository_UpdateTransfer(t *testing.T) {
 			updatedTransfer.Notes,
 			updatedTransfer.CreatedAt,
 			sqlmock.AnyArg(),   // updated_at
+			2,                  // Next version
+			1,                  // Version the update is based on
 			updatedTransfer.ID, // WHERE clause argument
 		).
 		WillReturnResult(sqlmock.NewResult(0, 1)) // Simulate 1 row affected
@@ -668,6 +688,7 @@ func TestGormRepository_UpdateTransfer(t *testing.T) {
 
 	// Assertions
 	assert.NoError(t, err)
+	assert.Equal(t, uint(2), updatedTransfer.Version)
 
 	// Verify that all expectations were met
 	err = mock.ExpectationsWereMet()
@@ -1010,3 +1031,137 @@ func TestGormRepository_GetAllUsers(t *testing.T) {
 }
 
 // TODO: Add tests for error scenarios (e.g., database connection errors - mock.ExpectQuery(...).WillReturnError(errors.New("DB error")))
+
+func TestGormRepository_SearchProperties(t *testing.T) {
+	_, mock, repo := setupMockDB(t)
+
+	typeID := uint(2)
+	query := domain.PropertyQuery{
+		Statuses:       []string{domain.PropertyStatusDamaged, domain.PropertyStatusInRepair},
+		PropertyTypeID: &typeID,
+		Unit:           "B Co",
+		Search:         "50%",
+		Sort:           domain.PropertySortName,
+		Order:          domain.SortDescending,
+		Page:           3,
+		Limit:          10,
+	}
+	filters := `WHERE current_status IN ($1,$2) AND property_model_id IN (SELECT id FROM property_models WHERE property_type_id = $3) ` +
+		`AND assigned_to_user_id IN (SELECT id FROM users WHERE unit = $4) AND ((name ILIKE $5 OR serial_number ILIKE $6 OR description ILIKE $7))`
+	args := []driver.Value{"Damaged", "In Repair", typeID, "B Co", `%50\%%`, `%50\%%`, `%50\%%`}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "properties" ` + filters)).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "properties" ` + filters + ` ORDER BY "name" DESC,"id" DESC LIMIT $8 OFFSET $9`)).
+		WithArgs(append(args, 10, 20)...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "serial_number", "current_status"}).AddRow(4, "Radio", "SN-4", "Damaged"))
+
+	page, err := repo.SearchProperties(query)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(21), page.Total)
+	assert.Equal(t, 3, page.Page)
+	if assert.Len(t, page.Items, 1) {
+		assert.Equal(t, "SN-4", page.Items[0].SerialNumber)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = repo.SearchProperties(domain.PropertyQuery{Sort: "password"})
+	assert.ErrorIs(t, err, domain.ErrInvalidPropertyQuery)
+}
+
+func TestGormRepository_WithTx(t *testing.T) {
+	_, mock, repo := setupMockDB(t)
+	insertUser := regexp.QuoteMeta(`INSERT INTO "users"`)
+
+	// Both writes commit together
+	mock.ExpectBegin()
+	mock.ExpectExec(insertUser).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(insertUser).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+	err := repo.WithTx(func(tx Repository) error {
+		if err := tx.CreateUser(&domain.User{Username: "a"}); err != nil {
+			return err
+		}
+		return tx.CreateUser(&domain.User{Username: "b"})
+	})
+	assert.NoError(t, err)
+
+	// A failed second write rolls back the first
+	mock.ExpectBegin()
+	mock.ExpectExec(insertUser).WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec(insertUser).WillReturnError(fmt.Errorf("duplicate key"))
+	mock.ExpectRollback()
+	err = repo.WithTx(func(tx Repository) error {
+		if err := tx.CreateUser(&domain.User{Username: "c"}); err != nil {
+			return err
+		}
+		return tx.CreateUser(&domain.User{Username: "c"})
+	})
+	assert.ErrorContains(t, err, "duplicate key")
+
+	// A panic rolls back and is re-raised
+	mock.ExpectBegin()
+	mock.ExpectExec(insertUser).WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectRollback()
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = repo.WithTx(func(tx Repository) error {
+			if err := tx.CreateUser(&domain.User{Username: "d"}); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGormRepository_TransferCustodyQueries(t *testing.T) {
+	_, mock, repo := setupMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "properties" WHERE "properties"."id" = $1 ORDER BY "properties"."id" LIMIT $2 FOR UPDATE`)).
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "serial_number"}).AddRow(5, "SN-5"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "transfers" WHERE property_id = $1 AND status IN ($2,$3) ORDER BY id,"transfers"."id" LIMIT $4`)).
+		WithArgs(5, domain.TransferStatusRequested, domain.TransferStatusApproved, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "property_id", "status"}))
+	mock.ExpectCommit()
+
+	err := repo.WithTx(func(tx Repository) error {
+		item, err := tx.GetPropertyByIDForUpdate(5)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, "SN-5", item.SerialNumber)
+
+		open, err := tx.GetOpenTransferByPropertyID(item.ID)
+		assert.Nil(t, open)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGormRepository_SigningKeys(t *testing.T) {
+	_, mock, repo := setupMockDB(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "signing_keys" WHERE key_id = $1 ORDER BY "signing_keys"."id" LIMIT $2`)).
+		WithArgs("missing", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "key_id"}))
+	key, err := repo.GetSigningKeyByKeyID("missing")
+	assert.NoError(t, err)
+	assert.Nil(t, key, "keys that are not enrolled are nil, not an error")
+
+	// Only the owner's active key is revoked
+	revokedAt := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "signing_keys" SET "revoked_at"=$1 WHERE user_id = $2 AND key_id = $3 AND revoked_at IS NULL`)).
+		WithArgs(revokedAt, 5, "key-5").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	revoked, err := repo.RevokeSigningKey(5, "key-5", revokedAt)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"gorm.io/gorm"
+)
+
+// listLedgerDivergences returns up to limit divergences with the given status, oldest first.
+func listLedgerDivergences(db *gorm.DB, status string, limit int) ([]domain.LedgerDivergence, error) {
+	var divergences []domain.LedgerDivergence
+	if err := db.Where("status = ?", status).Order("id ASC").Limit(limit).Find(&divergences).Error; err != nil {
+		return nil, err
+	}
+	return divergences, nil
+}
+
+// --- PostgresRepository ---
+
+func (r *PostgresRepository) CreateLedgerDivergence(divergence *domain.LedgerDivergence) error {
+	return r.db.Create(divergence).Error
+}
+
+func (r *PostgresRepository) ListLedgerDivergences(status string, limit int) ([]domain.LedgerDivergence, error) {
+	return listLedgerDivergences(r.db, status, limit)
+}
+
+// --- gormRepository ---
+
+func (r *gormRepository) CreateLedgerDivergence(divergence *domain.LedgerDivergence) error {
+	return r.db.Create(divergence).Error
+}
+
+func (r *gormRepository) ListLedgerDivergences(status string, limit int) ([]domain.LedgerDivergence, error) {
+	return listLedgerDivergences(r.db, status, limit)
+}
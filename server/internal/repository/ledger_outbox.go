@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"gorm.io/gorm"
+)
+
+// createLedgerOutboxEntry inserts entry. Call it through WithTx together with the state
+// change the entry records.
+func createLedgerOutboxEntry(db *gorm.DB, entry *domain.LedgerOutboxEntry) error {
+	if err := db.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to write ledger outbox entry: %w", err)
+	}
+	return nil
+}
+
+// ledgerOutboxStats counts outbox entries by status and finds the oldest pending one.
+func ledgerOutboxStats(db *gorm.DB) (*domain.LedgerOutboxStats, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := db.Model(&domain.LedgerOutboxEntry{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	stats := &domain.LedgerOutboxStats{}
+	for _, row := range rows {
+		switch row.Status {
+		case domain.OutboxStatusPending:
+			stats.Pending = row.Count
+		case domain.OutboxStatusDelivered:
+			stats.Delivered = row.Count
+		case domain.OutboxStatusDead:
+			stats.Dead = row.Count
+		}
+	}
+
+	if stats.Pending > 0 {
+		var oldest domain.LedgerOutboxEntry
+		if err := db.Where("status = ?", domain.OutboxStatusPending).Order("id").First(&oldest).Error; err != nil {
+			return nil, err
+		}
+		stats.OldestPendingAt = &oldest.CreatedAt
+	}
+	return stats, nil
+}
+
+// listLedgerOutboxEntries returns up to limit entries with the given status, oldest first.
+func listLedgerOutboxEntries(db *gorm.DB, status string, limit int) ([]domain.LedgerOutboxEntry, error) {
+	var entries []domain.LedgerOutboxEntry
+	if err := db.Where("status = ?", status).Order("id").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// --- PostgresRepository ---
+
+func (r *PostgresRepository) CreateLedgerOutboxEntry(entry *domain.LedgerOutboxEntry) error {
+	return createLedgerOutboxEntry(r.db, entry)
+}
+
+func (r *PostgresRepository) GetLedgerOutboxStats() (*domain.LedgerOutboxStats, error) {
+	return ledgerOutboxStats(r.db)
+}
+
+func (r *PostgresRepository) ListLedgerOutboxEntries(status string, limit int) ([]domain.LedgerOutboxEntry, error) {
+	return listLedgerOutboxEntries(r.db, status, limit)
+}
+
+// --- gormRepository ---
+
+func (r *gormRepository) CreateLedgerOutboxEntry(entry *domain.LedgerOutboxEntry) error {
+	return createLedgerOutboxEntry(r.db, entry)
+}
+
+func (r *gormRepository) GetLedgerOutboxStats() (*domain.LedgerOutboxStats, error) {
+	return ledgerOutboxStats(r.db)
+}
+
+func (r *gormRepository) ListLedgerOutboxEntries(status string, limit int) ([]domain.LedgerOutboxEntry, error) {
+	return listLedgerOutboxEntries(r.db, status, limit)
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"gorm.io/gorm"
+)
+
+// updateVersioned writes every column of model, whose primary key is id and whose Version
+// field is version, provided the stored row still has that version, and increments it. If
+// the row has changed or gone since it was read, nothing is written, version is left as it
+// was and a *domain.VersionConflictError is returned.
+func updateVersioned(db *gorm.DB, model interface{}, entity string, id uint, version *uint) error {
+	expected := *version
+	*version = expected + 1
+	result := db.Model(model).Where("version = ?", expected).Select("*").Updates(model)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = &domain.VersionConflictError{Entity: entity, ID: id, Version: expected}
+	}
+	if result.Error != nil {
+		*version = expected
+		return result.Error
+	}
+	return nil
+}
@@ -53,6 +53,14 @@ func (r *PostgresRepository) GetAllUsers() ([]domain.User, error) {
 	return users, nil
 }
 
+func (r *PostgresRepository) ListUsersByUnit(unit string) ([]domain.User, error) {
+	var users []domain.User
+	if err := r.db.Where("unit = ?", unit).Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // Property operations
 func (r *PostgresRepository) CreateProperty(property *domain.Property) error {
 	return r.db.Create(property).Error
@@ -81,9 +89,7 @@ func (r *PostgresRepository) GetPropertyBySerialNumber(serialNumber string) (*do
 }
 
 func (r *PostgresRepository) UpdateProperty(property *domain.Property) error {
-	// Use Select("*") to update all fields, including zero values.
-	// Or specify fields to update if needed.
-	return r.db.Save(property).Error
+	return updateVersioned(r.db, property, "property", property.ID, &property.Version)
 }
 
 func (r *PostgresRepository) ListProperties(assignedUserID *uint) ([]domain.Property, error) {
@@ -171,7 +177,7 @@ func (r *PostgresRepository) GetTransferByID(id uint) (*domain.Transfer, error)
 }
 
 func (r *PostgresRepository) UpdateTransfer(transfer *domain.Transfer) error {
-	return r.db.Save(transfer).Error
+	return updateVersioned(r.db, transfer, "transfer", transfer.ID, &transfer.Version)
 }
 
 func (r *PostgresRepository) ListTransfers(userID uint, status *string) ([]domain.Transfer, error) {
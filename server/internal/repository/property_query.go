@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// propertySortColumns maps PropertyQuery sort fields to properties columns.
+var propertySortColumns = map[string]string{
+	domain.PropertySortID:             "id",
+	domain.PropertySortName:           "name",
+	domain.PropertySortSerialNumber:   "serial_number",
+	domain.PropertySortStatus:         "current_status",
+	domain.PropertySortLastVerifiedAt: "last_verified_at",
+	domain.PropertySortCreatedAt:      "created_at",
+	domain.PropertySortUpdatedAt:      "updated_at",
+}
+
+// likeEscaper escapes the LIKE wildcards in user input.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// propertyFilters returns a scope applying the filters of a normalized query.
+func propertyFilters(query domain.PropertyQuery) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(query.Statuses) > 0 {
+			db = db.Where("current_status IN ?", query.Statuses)
+		}
+		if query.PropertyModelID != nil {
+			db = db.Where("property_model_id = ?", *query.PropertyModelID)
+		}
+		if query.PropertyTypeID != nil {
+			db = db.Where("property_model_id IN (SELECT id FROM property_models WHERE property_type_id = ?)", *query.PropertyTypeID)
+		}
+		if query.AssignedToUserID != nil {
+			db = db.Where("assigned_to_user_id = ?", *query.AssignedToUserID)
+		}
+		if query.Unit != "" {
+			db = db.Where("assigned_to_user_id IN (SELECT id FROM users WHERE unit = ?)", query.Unit)
+		}
+		if query.VerifiedBefore != nil {
+			db = db.Where("(last_verified_at IS NULL OR last_verified_at < ?)", *query.VerifiedBefore)
+		}
+		if query.Search != "" {
+			pattern := "%" + likeEscaper.Replace(query.Search) + "%"
+			db = db.Where("(name ILIKE ? OR serial_number ILIKE ? OR description ILIKE ?)", pattern, pattern, pattern)
+		}
+		return db
+	}
+}
+
+// searchProperties returns one page of the properties matching query and how many match in total.
+func searchProperties(db *gorm.DB, query domain.PropertyQuery) (*domain.PropertyPage, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.PropertyPage{Items: []domain.Property{}, Page: query.Page, Limit: query.Limit}
+	if err := db.Model(&domain.Property{}).Scopes(propertyFilters(query)).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	if page.Total == 0 {
+		return page, nil
+	}
+
+	desc := query.Order == domain.SortDescending
+	order := clause.OrderBy{Columns: []clause.OrderByColumn{
+		{Column: clause.Column{Name: propertySortColumns[query.Sort]}, Desc: desc},
+	}}
+	if query.Sort != domain.PropertySortID {
+		order.Columns = append(order.Columns, clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: desc})
+	}
+	err = db.Scopes(propertyFilters(query)).
+		Clauses(order).
+		Limit(query.Limit).
+		Offset((query.Page - 1) * query.Limit).
+		Find(&page.Items).Error
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// --- PostgresRepository ---
+
+func (r *PostgresRepository) SearchProperties(query domain.PropertyQuery) (*domain.PropertyPage, error) {
+	return searchProperties(r.db, query)
+}
+
+// --- gormRepository ---
+
+func (r *gormRepository) SearchProperties(query domain.PropertyQuery) (*domain.PropertyPage, error) {
+	return searchProperties(r.db, query)
+}
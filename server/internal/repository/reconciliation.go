@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"gorm.io/gorm"
+)
+
+// listReconciliationReports returns up to limit reports, newest first, without their findings.
+func listReconciliationReports(db *gorm.DB, limit int) ([]domain.ReconciliationReport, error) {
+	var reports []domain.ReconciliationReport
+	if err := db.Omit("findings").Order("id DESC").Limit(limit).Find(&reports).Error; err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// --- PostgresRepository ---
+
+func (r *PostgresRepository) CreateReconciliationReport(report *domain.ReconciliationReport) error {
+	return r.db.Create(report).Error
+}
+
+func (r *PostgresRepository) GetReconciliationReportByID(id uint) (*domain.ReconciliationReport, error) {
+	var report domain.ReconciliationReport
+	if err := r.db.First(&report, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *PostgresRepository) GetLatestReconciliationReport() (*domain.ReconciliationReport, error) {
+	var report domain.ReconciliationReport
+	if err := r.db.Order("id DESC").First(&report).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *PostgresRepository) ListReconciliationReports(limit int) ([]domain.ReconciliationReport, error) {
+	return listReconciliationReports(r.db, limit)
+}
+
+// --- gormRepository ---
+
+func (r *gormRepository) CreateReconciliationReport(report *domain.ReconciliationReport) error {
+	return r.db.Create(report).Error
+}
+
+func (r *gormRepository) GetReconciliationReportByID(id uint) (*domain.ReconciliationReport, error) {
+	var report domain.ReconciliationReport
+	if err := r.db.First(&report, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("reconciliation report with ID %d not found", id)
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *gormRepository) GetLatestReconciliationReport() (*domain.ReconciliationReport, error) {
+	var report domain.ReconciliationReport
+	if err := r.db.Order("id DESC").First(&report).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("no reconciliation report found")
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *gormRepository) ListReconciliationReports(limit int) ([]domain.ReconciliationReport, error) {
+	return listReconciliationReports(r.db, limit)
+}
@@ -1,24 +1,42 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/toole-brendan/handreceipt-go/internal/domain"
 )
 
 // Repository defines the interface for data access operations.
 type Repository interface {
+	// WithTx runs fn in a database transaction, passing it a Repository bound to that
+	// transaction. The transaction commits if fn returns nil and rolls back if fn returns an
+	// error or panics; the panic is re-raised after the rollback. Calling WithTx on a
+	// transactional Repository nests the work in a savepoint.
+	WithTx(fn func(tx Repository) error) error
+
 	// User operations
 	CreateUser(user *domain.User) error
 	GetUserByID(id uint) (*domain.User, error)
 	GetUserByUsername(username string) (*domain.User, error)
 	GetAllUsers() ([]domain.User, error)
+	ListUsersByUnit(unit string) ([]domain.User, error)
 	// Add other user methods as needed (Update, Delete, List)
 
 	// Property operations
 	CreateProperty(property *domain.Property) error
 	GetPropertyByID(id uint) (*domain.Property, error)
 	GetPropertyBySerialNumber(serialNumber string) (*domain.Property, error)
+	// GetPropertyByIDForUpdate is GetPropertyByID, but inside WithTx it also locks the row
+	// until the transaction ends. Transfers and status changes of an item lock it first, so they
+	// run one at a time.
+	GetPropertyByIDForUpdate(id uint) (*domain.Property, error)
+	// UpdateProperty saves property if the stored row still has property.Version, and
+	// increments the version. Otherwise it returns a *domain.VersionConflictError.
 	UpdateProperty(property *domain.Property) error
 	ListProperties(assignedUserID *uint) ([]domain.Property, error) // List all or by assigned user
+	// SearchProperties returns one page of the properties matching query, with the total
+	// number of matches. Invalid queries return an error wrapping domain.ErrInvalidPropertyQuery.
+	SearchProperties(query domain.PropertyQuery) (*domain.PropertyPage, error)
 	// Add DeleteProperty if needed
 
 	// PropertyType operations
@@ -33,8 +51,40 @@ type Repository interface {
 	// Transfer operations
 	CreateTransfer(transfer *domain.Transfer) error
 	GetTransferByID(id uint) (*domain.Transfer, error)
+	// GetTransferByIDForUpdate is GetTransferByID, but inside WithTx it also locks the row
+	// until the transaction ends.
+	GetTransferByIDForUpdate(id uint) (*domain.Transfer, error)
+	// GetOpenTransferByPropertyID returns the Requested or Approved transfer of a property,
+	// or nil if it has none.
+	GetOpenTransferByPropertyID(propertyID uint) (*domain.Transfer, error)
+	// UpdateTransfer saves transfer if the stored row still has transfer.Version, and
+	// increments the version. Otherwise it returns a *domain.VersionConflictError.
 	UpdateTransfer(transfer *domain.Transfer) error
 	ListTransfers(userID uint, status *string) ([]domain.Transfer, error) // List transfers involving a user (from/to), optionally filter by status
 
+	// Ledger outbox operations. Create an entry inside WithTx, in the same transaction as the
+	// state change it records, so that either both are saved or neither is.
+	CreateLedgerOutboxEntry(entry *domain.LedgerOutboxEntry) error
+	GetLedgerOutboxStats() (*domain.LedgerOutboxStats, error)
+	ListLedgerOutboxEntries(status string, limit int) ([]domain.LedgerOutboxEntry, error) // Oldest first
+
+	// Reconciliation report operations
+	CreateReconciliationReport(report *domain.ReconciliationReport) error
+	GetReconciliationReportByID(id uint) (*domain.ReconciliationReport, error)
+	GetLatestReconciliationReport() (*domain.ReconciliationReport, error)
+	ListReconciliationReports(limit int) ([]domain.ReconciliationReport, error) // Newest first, without findings
+
+	// Ledger divergence operations
+	CreateLedgerDivergence(divergence *domain.LedgerDivergence) error
+	ListLedgerDivergences(status string, limit int) ([]domain.LedgerDivergence, error) // Oldest first
+
+	// Signing key operations. Keys are never deleted, so events they signed stay verifiable.
+	CreateSigningKey(key *domain.SigningKey) error
+	GetSigningKeyByKeyID(keyID string) (*domain.SigningKey, error) // nil if not enrolled
+	ListSigningKeys(userID uint) ([]domain.SigningKey, error)      // Oldest first
+	// RevokeSigningKey ends the validity of a user's key at at. It reports false if the user
+	// has no such key or it was already revoked.
+	RevokeSigningKey(userID uint, keyID string, at time.Time) (bool, error)
+
 	// Add other data access methods as required
 }
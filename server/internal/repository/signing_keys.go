@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"gorm.io/gorm"
+)
+
+// signingKeyByKeyID returns the signing key with keyID, or nil if none is enrolled.
+func signingKeyByKeyID(db *gorm.DB, keyID string) (*domain.SigningKey, error) {
+	var key domain.SigningKey
+	if err := db.Where("key_id = ?", keyID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// listSigningKeys returns the keys of a user, oldest first.
+func listSigningKeys(db *gorm.DB, userID uint) ([]domain.SigningKey, error) {
+	var keys []domain.SigningKey
+	if err := db.Where("user_id = ?", userID).Order("id ASC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// revokeSigningKey ends the validity of a user's key at at, unless it has already ended.
+func revokeSigningKey(db *gorm.DB, userID uint, keyID string, at time.Time) (bool, error) {
+	result := db.Model(&domain.SigningKey{}).
+		Where("user_id = ? AND key_id = ? AND revoked_at IS NULL", userID, keyID).
+		Update("revoked_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// --- PostgresRepository ---
+
+func (r *PostgresRepository) CreateSigningKey(key *domain.SigningKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *PostgresRepository) GetSigningKeyByKeyID(keyID string) (*domain.SigningKey, error) {
+	return signingKeyByKeyID(r.db, keyID)
+}
+
+func (r *PostgresRepository) ListSigningKeys(userID uint) ([]domain.SigningKey, error) {
+	return listSigningKeys(r.db, userID)
+}
+
+func (r *PostgresRepository) RevokeSigningKey(userID uint, keyID string, at time.Time) (bool, error) {
+	return revokeSigningKey(r.db, userID, keyID, at)
+}
+
+// --- gormRepository ---
+
+func (r *gormRepository) CreateSigningKey(key *domain.SigningKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *gormRepository) GetSigningKeyByKeyID(keyID string) (*domain.SigningKey, error) {
+	return signingKeyByKeyID(r.db, keyID)
+}
+
+func (r *gormRepository) ListSigningKeys(userID uint) ([]domain.SigningKey, error) {
+	return listSigningKeys(r.db, userID)
+}
+
+func (r *gormRepository) RevokeSigningKey(userID uint, keyID string, at time.Time) (bool, error) {
+	return revokeSigningKey(r.db, userID, keyID, at)
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/toole-brendan/handreceipt-go/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// forUpdate locks the rows a query reads until the surrounding transaction ends.
+var forUpdate = clause.Locking{Strength: "UPDATE"}
+
+// openTransferByPropertyID returns the open transfer of a property, or nil if it has none.
+func openTransferByPropertyID(db *gorm.DB, propertyID uint) (*domain.Transfer, error) {
+	var transfer domain.Transfer
+	err := db.Where("property_id = ? AND status IN ?", propertyID,
+		[]string{domain.TransferStatusRequested, domain.TransferStatusApproved}).
+		Order("id").First(&transfer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// --- PostgresRepository ---
+
+func (r *PostgresRepository) GetPropertyByIDForUpdate(id uint) (*domain.Property, error) {
+	return (&PostgresRepository{db: r.db.Clauses(forUpdate)}).GetPropertyByID(id)
+}
+
+func (r *PostgresRepository) GetTransferByIDForUpdate(id uint) (*domain.Transfer, error) {
+	return (&PostgresRepository{db: r.db.Clauses(forUpdate)}).GetTransferByID(id)
+}
+
+func (r *PostgresRepository) GetOpenTransferByPropertyID(propertyID uint) (*domain.Transfer, error) {
+	return openTransferByPropertyID(r.db, propertyID)
+}
+
+// --- gormRepository ---
+
+func (r *gormRepository) GetPropertyByIDForUpdate(id uint) (*domain.Property, error) {
+	return (&gormRepository{db: r.db.Clauses(forUpdate)}).GetPropertyByID(id)
+}
+
+func (r *gormRepository) GetTransferByIDForUpdate(id uint) (*domain.Transfer, error) {
+	return (&gormRepository{db: r.db.Clauses(forUpdate)}).GetTransferByID(id)
+}
+
+func (r *gormRepository) GetOpenTransferByPropertyID(propertyID uint) (*domain.Transfer, error) {
+	return openTransferByPropertyID(r.db, propertyID)
+}
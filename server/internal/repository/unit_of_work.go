@@ -0,0 +1,23 @@
+package repository
+
+import "gorm.io/gorm"
+
+// withTx runs fn in a transaction on db. gorm rolls the transaction back when fn returns an
+// error or panics, and uses a savepoint when db is already a transaction.
+func withTx(db *gorm.DB, bind func(tx *gorm.DB) Repository, fn func(tx Repository) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return fn(bind(tx))
+	})
+}
+
+// --- PostgresRepository ---
+
+func (r *PostgresRepository) WithTx(fn func(tx Repository) error) error {
+	return withTx(r.db, func(tx *gorm.DB) Repository { return &PostgresRepository{db: tx} }, fn)
+}
+
+// --- gormRepository ---
+
+func (r *gormRepository) WithTx(fn func(tx Repository) error) error {
+	return withTx(r.db, func(tx *gorm.DB) Repository { return &gormRepository{db: tx} }, fn)
+}
@@ -0,0 +1,136 @@
+// Package signing holds the server's Ed25519 key, used to sign documents it issues
+// (such as ledger event receipts) so they can be checked offline.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	ErrInvalidKey  = errors.New("invalid Ed25519 key")
+	ErrKeyNotFound = errors.New("signing key not found")
+)
+
+// Signer signs payloads with the server's Ed25519 private key.
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+// NewSigner creates a signer for an existing private key.
+func NewSigner(key ed25519.PrivateKey) *Signer {
+	return &Signer{key: key, keyID: KeyID(key.Public().(ed25519.PublicKey))}
+}
+
+// LoadOrCreateSigner reads a PEM-encoded (PKCS#8) Ed25519 private key from path,
+// generating and saving a new key there if the file does not exist.
+func LoadOrCreateSigner(path string) (*Signer, error) {
+	signer, err := LoadSigner(path)
+	if errors.Is(err, ErrKeyNotFound) {
+		return createSigner(path)
+	}
+	return signer, err
+}
+
+// LoadSigner reads a PEM-encoded (PKCS#8) Ed25519 private key from path. It returns an
+// error wrapping ErrKeyNotFound if the file does not exist.
+func LoadSigner(path string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("%w: %s does not contain a PEM private key", ErrInvalidKey, path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s holds a %T", ErrInvalidKey, path, parsed)
+	}
+	return NewSigner(key), nil
+}
+
+// createSigner generates a key and writes it to path, readable only by the owner.
+func createSigner(path string) (*Signer, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signing key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create signing key directory: %w", err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write signing key: %w", err)
+	}
+	return NewSigner(key), nil
+}
+
+// KeyID returns the ID of the signer's key.
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// PublicKey returns the signer's public key.
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// PublicKeyPEM returns the signer's public key as a PEM-encoded PKIX block.
+func (s *Signer) PublicKeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(s.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// Sign signs payload.
+func (s *Signer) Sign(payload []byte) []byte {
+	return ed25519.Sign(s.key, payload)
+}
+
+// KeyID derives a short, stable identifier for a public key: the first 16 hex
+// characters of its SHA-256 hash.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParsePublicKeyPEM parses a PEM-encoded PKIX Ed25519 public key.
+func ParsePublicKeyPEM(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("%w: no PEM public key found", ErrInvalidKey)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	pub, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: found a %T", ErrInvalidKey, parsed)
+	}
+	return pub, nil
+}
@@ -134,3 +134,19 @@ func (s *MinIOService) GetFileInfo(ctx context.Context, objectName string) (mini
 
 	return info, nil
 }
+
+// ReadFile downloads a whole file from MinIO storage into memory
+func (s *MinIOService) ReadFile(ctx context.Context, objectName string) ([]byte, error) {
+	object, err := s.DownloadFile(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", objectName, err)
+	}
+
+	return data, nil
+}
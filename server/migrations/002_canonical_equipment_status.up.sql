@@ -0,0 +1,27 @@
+-- Replace the original equipment statuses with the canonical property statuses used by the
+-- API and every ledger backend (see internal/domain/property_status.go).
+--
+-- Whether an item is assigned or on its way to someone is recorded by assigned_to_id and
+-- transfers, not by its status, so those items are Operational. Retired items are kept
+-- as Non-Operational.
+
+CREATE TYPE property_status AS ENUM ('Operational', 'Non-Operational', 'Damaged', 'In Repair', 'Lost', 'Found');
+
+ALTER TABLE equipment ALTER COLUMN status DROP DEFAULT;
+
+ALTER TABLE equipment
+    ALTER COLUMN status TYPE property_status USING (
+        CASE status
+            WHEN 'available' THEN 'Operational'
+            WHEN 'assigned' THEN 'Operational'
+            WHEN 'in_transit' THEN 'Operational'
+            WHEN 'maintenance' THEN 'In Repair'
+            WHEN 'retired' THEN 'Non-Operational'
+            WHEN 'lost' THEN 'Lost'
+            WHEN 'damaged' THEN 'Damaged'
+        END
+    )::property_status;
+
+ALTER TABLE equipment ALTER COLUMN status SET DEFAULT 'Operational';
+
+DROP TYPE equipment_status;
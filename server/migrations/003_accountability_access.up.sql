@@ -0,0 +1,8 @@
+-- Accountability reports may be viewed by the user they concern, the users above them in
+-- their chain of command, and investigators (see internal/ledger/accountability).
+
+ALTER TYPE user_role ADD VALUE IF NOT EXISTS 'investigator';
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS supervisor_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_supervisor_id ON users(supervisor_id);
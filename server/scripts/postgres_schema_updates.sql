@@ -22,6 +22,10 @@ CREATE TABLE IF NOT EXISTS public.users (
     updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
 );
 
+-- Unit membership, used to reconstruct a unit's hand receipt from the ledger
+ALTER TABLE public.users
+ADD COLUMN IF NOT EXISTS unit VARCHAR(100);
+
 -- 1. Modify the main Property table
 --    Assuming a table named 'property' already exists.
 --    If it doesn't exist, you'd use CREATE TABLE instead.